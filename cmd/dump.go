@@ -6,34 +6,41 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
 
-	"github.com/unknwon/cae/zip"
 	"github.com/unknwon/com"
 	"github.com/urfave/cli"
+	"xorm.io/builder"
 )
 
 // CmdDump represents the available dump sub-command.
 var CmdDump = cli.Command{
 	Name:  "dump",
 	Usage: "Dump Gitea files and database",
-	Description: `Dump compresses all related files and database into zip file.
+	Description: `Dump compresses all related files and database into an archive.
 It can be used for backup and capture Gitea server image to send to maintainer`,
 	Action: runDump,
 	Flags: []cli.Flag{
 		cli.StringFlag{
 			Name:  "file, f",
-			Value: fmt.Sprintf("gitea-dump-%d.zip", time.Now().Unix()),
-			Usage: "Name of the dump file which will be created.",
+			Usage: `Name of the dump file which will be created, "-" writes to stdout. (default: gitea-dump-[timestamp].[type])`,
+		},
+		cli.StringFlag{
+			Name:  "type",
+			Value: "zip",
+			Usage: fmt.Sprintf("Dump output format: %s", strings.Join(dumpTypes, ", ")),
 		},
 		cli.BoolFlag{
 			Name:  "verbose, V",
@@ -52,16 +59,75 @@ It can be used for backup and capture Gitea server image to send to maintainer`,
 			Name:  "skip-repository, R",
 			Usage: "Skip the repository dumping",
 		},
+		cli.StringFlag{
+			Name:  "manifest",
+			Usage: "Also write the manifest of the dump to this file, it can be passed to --incremental by the next dump",
+		},
+		cli.StringFlag{
+			Name:  "incremental",
+			Usage: "Manifest of a previous dump, only the repositories, attachments and LFS objects changed since then are dumped",
+		},
 	},
 }
 
+// dumpManifest records the state of the instance at the time of a dump.
+// An incremental dump compares it to the current state.
+type dumpManifest struct {
+	GiteaVersion string    `json:"gitea_version"`
+	Created      time.Time `json:"created"`
+	// Incremental is the creation time of the manifest an incremental dump is based on
+	Incremental *time.Time `json:"incremental,omitempty"`
+	// Repositories maps the ids of all repositories to their UpdatedUnix
+	Repositories        map[int64]timeutil.TimeStamp `json:"repositories"`
+	MaxAttachmentID     int64                        `json:"max_attachment_id"`
+	MaxLFSMetaObjectID  int64                        `json:"max_lfs_meta_object_id"`
+	SkippedRepositories bool                         `json:"skipped_repositories,omitempty"`
+}
+
+func readDumpManifest(fileName string) (*dumpManifest, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &dumpManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
 func fatal(format string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, format+"\n", args...)
 	log.Fatal(format, args...)
 }
 
 func runDump(ctx *cli.Context) error {
+	outType := ctx.String("type")
+	if !isDumpType(outType) {
+		fatal("Unknown dump type %s, supported are %s", outType, strings.Join(dumpTypes, ", "))
+	}
+	fileName := ctx.String("file")
+	if fileName == "" {
+		fileName = fmt.Sprintf("gitea-dump-%d.%s", time.Now().Unix(), outType)
+	}
+	toStdout := fileName == "-"
+	if toStdout {
+		// the console logger writes to stdout
+		if err := log.DelLogger("console"); err != nil {
+			fatal("Deleting default logger failed. Can not write to stdout: %v", err)
+		}
+	}
+
 	setting.NewContext()
+	if toStdout {
+		// log to stderr, no matter what the configuration tells
+		if _, err := setting.Cfg.Section("log").NewKey("MODE", "console"); err != nil {
+			fatal("Setting logging mode to console failed: %v", err)
+		}
+		if _, err := setting.Cfg.Section("log.console").NewKey("STDERR", "true"); err != nil {
+			fatal("Setting console logger to stderr failed: %v", err)
+		}
+	}
 	setting.NewServices() // cannot access session settings otherwise
 
 	err := models.SetEngine()
@@ -69,6 +135,14 @@ func runDump(ctx *cli.Context) error {
 		return err
 	}
 
+	var previous *dumpManifest
+	if manifestFile := ctx.String("incremental"); manifestFile != "" {
+		if previous, err = readDumpManifest(manifestFile); err != nil {
+			fatal("Failed to read the manifest %s: %v", manifestFile, err)
+		}
+		log.Info("Dumping the changes since %s", previous.Created.Format(time.RFC3339))
+	}
+
 	tmpDir := ctx.String("tempdir")
 	if _, err := os.Stat(tmpDir); os.IsNotExist(err) {
 		fatal("Path does not exist: %s", tmpDir)
@@ -84,30 +158,15 @@ func runDump(ctx *cli.Context) error {
 		os.Setenv("TMPDIR", tmpWorkDir)
 	}
 
-	dbDump := path.Join(tmpWorkDir, "gitea-db.sql")
-
-	fileName := ctx.String("file")
-	log.Info("Packing dump files...")
-	z, err := zip.Create(fileName)
+	// The state of the instance is recorded before anything is dumped, what
+	// changes while dumping is dumped again by the next incremental dump.
+	manifest, repos, err := newDumpManifest(previous)
 	if err != nil {
-		fatal("Failed to create %s: %v", fileName, err)
-	}
-
-	zip.Verbose = ctx.Bool("verbose")
-
-	if ctx.IsSet("skip-repository") {
-		log.Info("Skip dumping local repositories")
-	} else {
-		log.Info("Dumping local repositories...%s", setting.RepoRootPath)
-		reposDump := path.Join(tmpWorkDir, "gitea-repo.zip")
-		if err := zip.PackTo(setting.RepoRootPath, reposDump, true); err != nil {
-			fatal("Failed to dump local repositories: %v", err)
-		}
-		if err := z.AddFile("gitea-repo.zip", reposDump); err != nil {
-			fatal("Failed to include gitea-repo.zip: %v", err)
-		}
+		fatal("Failed to create the manifest: %v", err)
 	}
+	manifest.SkippedRepositories = ctx.IsSet("skip-repository")
 
+	dbDump := path.Join(tmpWorkDir, "gitea-db.sql")
 	targetDBType := ctx.String("database")
 	if len(targetDBType) > 0 && targetDBType != setting.Database.Type {
 		log.Info("Dumping database %s => %s...", setting.Database.Type, targetDBType)
@@ -119,21 +178,52 @@ func runDump(ctx *cli.Context) error {
 		fatal("Failed to dump database: %v", err)
 	}
 
-	if err := z.AddFile("gitea-db.sql", dbDump); err != nil {
-		fatal("Failed to include gitea-db.sql: %v", err)
+	var out *os.File
+	if toStdout {
+		out = os.Stdout
+	} else {
+		out, err = os.OpenFile(fileName, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		if err != nil {
+			fatal("Failed to create %s: %v", fileName, err)
+		}
+	}
+	fail := func(format string, args ...interface{}) {
+		if !toStdout {
+			out.Close()
+			_ = os.Remove(fileName)
+		}
+		fatal(format, args...)
+	}
+
+	log.Info("Packing dump files...")
+	w, err := newDumpWriter(out, outType, ctx.Bool("verbose"))
+	if err != nil {
+		fail("Failed to create %s: %v", fileName, err)
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		fail("Failed to encode the manifest: %v", err)
+	}
+	if err := w.addData("manifest.json", manifestData); err != nil {
+		fail("Failed to include manifest.json: %v", err)
+	}
+
+	if err := w.addFile("gitea-db.sql", dbDump); err != nil {
+		fail("Failed to include gitea-db.sql: %v", err)
 	}
 
 	if len(setting.CustomConf) > 0 {
 		log.Info("Adding custom configuration file from %s", setting.CustomConf)
-		if err := z.AddFile("app.ini", setting.CustomConf); err != nil {
-			fatal("Failed to include specified app.ini: %v", err)
+		if err := w.addFile("app.ini", setting.CustomConf); err != nil {
+			fail("Failed to include specified app.ini: %v", err)
 		}
 	}
 
 	customDir, err := os.Stat(setting.CustomPath)
 	if err == nil && customDir.IsDir() {
-		if err := z.AddDir("custom", setting.CustomPath); err != nil {
-			fatal("Failed to include custom: %v", err)
+		if err := w.addDir("custom", setting.CustomPath); err != nil {
+			fail("Failed to include custom: %v", err)
 		}
 	} else {
 		log.Info("Custom dir %s doesn't exist, skipped", setting.CustomPath)
@@ -142,28 +232,61 @@ func runDump(ctx *cli.Context) error {
 	if com.IsExist(setting.AppDataPath) {
 		log.Info("Packing data directory...%s", setting.AppDataPath)
 
-		var sessionAbsPath string
+		var excludes []string
 		if setting.SessionConfig.Provider == "file" {
-			sessionAbsPath = setting.SessionConfig.ProviderConfig
+			excludes = append(excludes, setting.SessionConfig.ProviderConfig)
+		}
+		if previous != nil {
+			// only the new attachments and LFS objects are added below
+			excludes = append(excludes, setting.AttachmentStorage.Path, setting.LFSStorage.Path)
+		}
+		if err := w.addDir("data", setting.AppDataPath, excludes...); err != nil {
+			fail("Failed to include data directory: %v", err)
+		}
+
+		if previous != nil {
+			if err := dumpNewAttachments(w, previous.MaxAttachmentID, manifest.MaxAttachmentID); err != nil {
+				fail("Failed to include attachments: %v", err)
+			}
+			if err := dumpNewLFSObjects(w, previous.MaxLFSMetaObjectID, manifest.MaxLFSMetaObjectID); err != nil {
+				fail("Failed to include LFS objects: %v", err)
+			}
+		}
+	}
+
+	if manifest.SkippedRepositories {
+		log.Info("Skip dumping local repositories")
+	} else if previous == nil {
+		log.Info("Dumping local repositories...%s", setting.RepoRootPath)
+		if err := w.addDir("repos", setting.RepoRootPath); err != nil {
+			fail("Failed to dump local repositories: %v", err)
 		}
-		if err := zipAddDirectoryExclude(z, "data", setting.AppDataPath, sessionAbsPath); err != nil {
-			fatal("Failed to include data directory: %v", err)
+	} else {
+		log.Info("Dumping the changed local repositories...%s", setting.RepoRootPath)
+		if err := dumpChangedRepositories(w, repos, previous); err != nil {
+			fail("Failed to dump local repositories: %v", err)
 		}
 	}
 
 	if com.IsExist(setting.LogRootPath) {
-		if err := z.AddDir("log", setting.LogRootPath); err != nil {
-			fatal("Failed to include log: %v", err)
+		if err := w.addDir("log", setting.LogRootPath); err != nil {
+			fail("Failed to include log: %v", err)
 		}
 	}
 
-	if err = z.Close(); err != nil {
-		_ = os.Remove(fileName)
-		fatal("Failed to save %s: %v", fileName, err)
+	if err := w.Close(); err != nil {
+		fail("Failed to save %s: %v", fileName, err)
+	}
+	if !toStdout {
+		if err := out.Close(); err != nil {
+			fail("Failed to save %s: %v", fileName, err)
+		}
 	}
 
-	if err := os.Chmod(fileName, 0600); err != nil {
-		log.Info("Can't change file access permissions mask to 0600: %v", err)
+	if manifestFile := ctx.String("manifest"); manifestFile != "" {
+		if err := ioutil.WriteFile(manifestFile, manifestData, 0600); err != nil {
+			fatal("Failed to write the manifest %s: %v", manifestFile, err)
+		}
 	}
 
 	log.Info("Removing tmp work dir: %s", tmpWorkDir)
@@ -176,39 +299,130 @@ func runDump(ctx *cli.Context) error {
 	return nil
 }
 
-// zipAddDirectoryExclude zips absPath to specified zipPath inside z excluding excludeAbsPath
-func zipAddDirectoryExclude(zip *zip.ZipArchive, zipPath, absPath string, excludeAbsPath string) error {
-	absPath, err := filepath.Abs(absPath)
-	if err != nil {
-		return err
+// newDumpManifest records the current state of the instance and returns it together with the repositories
+func newDumpManifest(previous *dumpManifest) (*dumpManifest, []*models.Repository, error) {
+	manifest := &dumpManifest{
+		GiteaVersion: setting.AppVer,
+		Created:      time.Now().UTC(),
+		Repositories: make(map[int64]timeutil.TimeStamp),
+	}
+	if previous != nil {
+		manifest.Incremental = &previous.Created
 	}
-	dir, err := os.Open(absPath)
+
+	var repos []*models.Repository
+	err := models.Iterate(
+		models.DefaultDBContext(),
+		new(models.Repository),
+		builder.Gt{"id": 0},
+		func(idx int, bean interface{}) error {
+			repo := bean.(*models.Repository)
+			manifest.Repositories[repo.ID] = repo.UpdatedUnix
+			repos = append(repos, repo)
+			return nil
+		},
+	)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	defer dir.Close()
 
-	zip.AddEmptyDir(zipPath)
+	if manifest.MaxAttachmentID, err = models.GetMaxID("attachment"); err != nil {
+		return nil, nil, err
+	}
+	if manifest.MaxLFSMetaObjectID, err = models.GetMaxID("lfs_meta_object"); err != nil {
+		return nil, nil, err
+	}
+	return manifest, repos, nil
+}
 
-	files, err := dir.Readdir(0)
-	if err != nil {
-		return err
+// dumpChangedRepositories adds the repositories and wikis whose UpdatedUnix
+// differs from the previous manifest, or all of them if the previous dump skipped them
+func dumpChangedRepositories(w *dumpWriter, repos []*models.Repository, previous *dumpManifest) error {
+	if previous.SkippedRepositories {
+		log.Info("The previous dump skipped the repositories, dumping all of them")
+		return w.addDir("repos", setting.RepoRootPath)
 	}
-	for _, file := range files {
-		currentAbsPath := path.Join(absPath, file.Name())
-		currentZipPath := path.Join(zipPath, file.Name())
-		if file.IsDir() {
-			if currentAbsPath != excludeAbsPath {
-				if err = zipAddDirectoryExclude(zip, currentZipPath, currentAbsPath, excludeAbsPath); err != nil {
-					return err
-				}
+	for _, repo := range repos {
+		if updated, ok := previous.Repositories[repo.ID]; ok && updated == repo.UpdatedUnix {
+			continue
+		}
+		for _, p := range []string{repo.RepoPath(), repo.WikiPath()} {
+			if !com.IsExist(p) {
+				continue
 			}
-
-		} else {
-			if err = zip.AddFile(currentZipPath, currentAbsPath); err != nil {
+			rel, err := filepath.Rel(setting.RepoRootPath, p)
+			if err != nil {
+				return err
+			}
+			if err := w.addDir(path.Join("repos", filepath.ToSlash(rel)), p); err != nil {
 				return err
 			}
 		}
 	}
 	return nil
 }
+
+// dataStorageDumpPath returns the path of a local storage in the data
+// directory of the dump, the storage is not dumped if it is elsewhere.
+func dataStorageDumpPath(name string, cfg setting.Storage) (string, bool) {
+	if !cfg.IsLocal() {
+		log.Warn("The %s are not stored on the local file system, they are not dumped", name)
+		return "", false
+	}
+	rel, err := filepath.Rel(setting.AppDataPath, cfg.Path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		log.Warn("The %s are not stored in the data directory, they are not dumped", name)
+		return "", false
+	}
+	return path.Join("data", filepath.ToSlash(rel)), true
+}
+
+// dumpNewAttachments adds the attachments with an id in (fromID, toID]
+func dumpNewAttachments(w *dumpWriter, fromID, toID int64) error {
+	dumpPath, ok := dataStorageDumpPath("attachments", setting.AttachmentStorage)
+	if !ok {
+		return nil
+	}
+	return models.Iterate(
+		models.DefaultDBContext(),
+		new(models.Attachment),
+		builder.Gt{"id": fromID}.And(builder.Lte{"id": toID}),
+		func(idx int, bean interface{}) error {
+			attach := bean.(*models.Attachment)
+			p := filepath.Join(setting.AttachmentStorage.Path, filepath.FromSlash(attach.RelativePath()))
+			if !com.IsExist(p) {
+				log.Warn("Attachment %s does not exist, skipped", attach.UUID)
+				return nil
+			}
+			return w.addFile(path.Join(dumpPath, attach.RelativePath()), p)
+		},
+	)
+}
+
+// dumpNewLFSObjects adds the LFS objects of the meta objects with an id in (fromID, toID]
+func dumpNewLFSObjects(w *dumpWriter, fromID, toID int64) error {
+	dumpPath, ok := dataStorageDumpPath("LFS objects", setting.LFSStorage)
+	if !ok {
+		return nil
+	}
+	// the same object may belong to several repositories
+	added := make(map[string]bool)
+	return models.Iterate(
+		models.DefaultDBContext(),
+		new(models.LFSMetaObject),
+		builder.Gt{"id": fromID}.And(builder.Lte{"id": toID}),
+		func(idx int, bean interface{}) error {
+			meta := bean.(*models.LFSMetaObject)
+			if added[meta.Oid] {
+				return nil
+			}
+			added[meta.Oid] = true
+			p := filepath.Join(setting.LFSStorage.Path, filepath.FromSlash(meta.RelativePath()))
+			if !com.IsExist(p) {
+				log.Warn("LFS object %s does not exist, skipped", meta.Oid)
+				return nil
+			}
+			return w.addFile(path.Join(dumpPath, meta.RelativePath()), p)
+		},
+	)
+}
@@ -0,0 +1,209 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var dumpTypes = []string{"zip", "tar", "tar.gz", "tar.zst"}
+
+func isDumpType(t string) bool {
+	for _, dumpType := range dumpTypes {
+		if t == dumpType {
+			return true
+		}
+	}
+	return false
+}
+
+// dumpWriter streams the entries of a dump into a zip or a (compressed) tar archive
+type dumpWriter struct {
+	zip        *zip.Writer
+	tar        *tar.Writer
+	compressor io.WriteCloser
+	verbose    bool
+}
+
+func newDumpWriter(w io.Writer, dumpType string, verbose bool) (*dumpWriter, error) {
+	d := &dumpWriter{verbose: verbose}
+	switch dumpType {
+	case "zip":
+		d.zip = zip.NewWriter(w)
+		return d, nil
+	case "tar":
+	case "tar.gz":
+		d.compressor = gzip.NewWriter(w)
+	case "tar.zst":
+		enc, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		d.compressor = enc
+	default:
+		return nil, fmt.Errorf("unknown dump type: %s", dumpType)
+	}
+	if d.compressor != nil {
+		w = d.compressor
+	}
+	d.tar = tar.NewWriter(w)
+	return d, nil
+}
+
+// add adds an entry, r is the content of a regular file and link the target of a symlink
+func (d *dumpWriter) add(name string, info os.FileInfo, r io.Reader, link string) error {
+	if d.verbose {
+		log.Info("Adding %s", name)
+	}
+	if info.IsDir() {
+		name += "/"
+	}
+
+	if d.zip != nil {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.Mode().IsRegular() {
+			header.Method = zip.Deflate
+		}
+		w, err := d.zip.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			_, err = io.WriteString(w, link)
+			return err
+		}
+		if r != nil {
+			_, err = io.Copy(w, r)
+		}
+		return err
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	// the owner of the files on this system means nothing on the restoring one
+	header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+	if err := d.tar.WriteHeader(header); err != nil {
+		return err
+	}
+	if r != nil && header.Typeflag == tar.TypeReg {
+		// the size is in the header already, a file growing meanwhile is cut
+		if _, err := io.CopyN(d.tar, r, header.Size); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// addData adds a regular file with the given content
+func (d *dumpWriter) addData(name string, data []byte) error {
+	return d.add(name, &dataFileInfo{name: path.Base(name), size: int64(len(data)), modTime: time.Now()}, bytes.NewReader(data), "")
+}
+
+// addFile adds the file, directory or symlink at absPath, directories are added without their content
+func (d *dumpWriter) addFile(name, absPath string) error {
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(absPath)
+		if err != nil {
+			return err
+		}
+		return d.add(name, info, nil, link)
+	case info.Mode().IsRegular():
+		f, err := os.Open(absPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return d.add(name, info, f, "")
+	case info.IsDir():
+		return d.add(name, info, nil, "")
+	default:
+		log.Warn("Skipping %s, it is neither a regular file, a directory nor a symlink", absPath)
+		return nil
+	}
+}
+
+// addDir adds the directory at absPath recursively, except the paths in excludeAbsPaths
+func (d *dumpWriter) addDir(name, absPath string, excludeAbsPaths ...string) error {
+	absPath, err := filepath.Abs(absPath)
+	if err != nil {
+		return err
+	}
+	excludes := make(map[string]bool, len(excludeAbsPaths))
+	for _, p := range excludeAbsPaths {
+		if p, err := filepath.Abs(p); err == nil {
+			excludes[p] = true
+		}
+	}
+
+	return filepath.Walk(absPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if excludes[p] {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(absPath, p)
+		if err != nil {
+			return err
+		}
+		return d.addFile(path.Join(name, filepath.ToSlash(rel)), p)
+	})
+}
+
+// Close finishes the archive, the underlying writer is not closed
+func (d *dumpWriter) Close() error {
+	if d.zip != nil {
+		return d.zip.Close()
+	}
+	if err := d.tar.Close(); err != nil {
+		return err
+	}
+	if d.compressor != nil {
+		return d.compressor.Close()
+	}
+	return nil
+}
+
+// dataFileInfo describes a file which is created from memory
+type dataFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (fi *dataFileInfo) Name() string       { return fi.name }
+func (fi *dataFileInfo) Size() int64        { return fi.size }
+func (fi *dataFileInfo) Mode() os.FileMode  { return 0600 }
+func (fi *dataFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *dataFileInfo) IsDir() bool        { return false }
+func (fi *dataFileInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,82 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpWriter(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "dump")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "data", "sessions"), 0700))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "data", "file"), []byte("content"), 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "data", "sessions", "session"), []byte("secret"), 0600))
+	assert.NoError(t, os.Symlink("file", filepath.Join(tmpDir, "data", "link")))
+
+	expected := map[string]string{
+		"manifest.json": "{}",
+		"data/":         "",
+		"data/file":     "content",
+		"data/link":     "file",
+	}
+
+	for _, dumpType := range dumpTypes {
+		var buf bytes.Buffer
+		w, err := newDumpWriter(&buf, dumpType, false)
+		assert.NoError(t, err)
+		assert.NoError(t, w.addData("manifest.json", []byte("{}")))
+		assert.NoError(t, w.addDir("data", filepath.Join(tmpDir, "data"), filepath.Join(tmpDir, "data", "sessions")))
+		assert.NoError(t, w.Close())
+
+		entries := make(map[string]string)
+		if dumpType == "zip" {
+			z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			assert.NoError(t, err)
+			for _, f := range z.File {
+				r, err := f.Open()
+				assert.NoError(t, err)
+				content, err := ioutil.ReadAll(r)
+				assert.NoError(t, err)
+				entries[f.Name] = string(content)
+			}
+		} else {
+			var r io.Reader = &buf
+			switch dumpType {
+			case "tar.gz":
+				r, err = gzip.NewReader(r)
+				assert.NoError(t, err)
+			case "tar.zst":
+				r, err = zstd.NewReader(r)
+				assert.NoError(t, err)
+			}
+			tr := tar.NewReader(r)
+			for {
+				header, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+				assert.NoError(t, err)
+				content, err := ioutil.ReadAll(tr)
+				assert.NoError(t, err)
+				entries[header.Name] = string(content) + header.Linkname
+			}
+		}
+		assert.Equal(t, expected, entries, dumpType)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+
+	"github.com/urfave/cli"
+)
+
+// CmdMigrateStorage represents the available migrate storage sub-command.
+var CmdMigrateStorage = cli.Command{
+	Name:  "migrate-storage",
+	Usage: "Migrate the storage",
+	Description: `Copies the attachments, LFS objects or avatars from the storage configured in app.ini to another storage.
+The configured storage is left untouched, change app.ini to the new storage once the migration succeeded.`,
+	Action: runMigrateStorage,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "type, t",
+			Value: "",
+			Usage: "Kinds of files to migrate, currently only 'attachments', 'lfs', 'avatars' and 'repo-avatars' are supported",
+		},
+		cli.StringFlag{
+			Name:  "storage, s",
+			Value: setting.LocalStorageType,
+			Usage: "New storage type, 'local' or 'minio'",
+		},
+		cli.StringFlag{
+			Name:  "path, p",
+			Value: "",
+			Usage: "New storage placement if store is local (leave blank for default)",
+		},
+		cli.StringFlag{
+			Name:  "minio-endpoint",
+			Value: "localhost:9000",
+			Usage: "Minio storage endpoint",
+		},
+		cli.StringFlag{
+			Name:  "minio-access-key-id",
+			Value: "",
+			Usage: "Minio storage accessKeyID",
+		},
+		cli.StringFlag{
+			Name:  "minio-secret-access-key",
+			Value: "",
+			Usage: "Minio storage secretAccessKey",
+		},
+		cli.StringFlag{
+			Name:  "minio-bucket",
+			Value: "gitea",
+			Usage: "Minio storage bucket",
+		},
+		cli.StringFlag{
+			Name:  "minio-location",
+			Value: "us-east-1",
+			Usage: "Minio storage location to create bucket",
+		},
+		cli.StringFlag{
+			Name:  "minio-base-path",
+			Value: "",
+			Usage: "Minio storage basepath on the bucket (leave blank for default)",
+		},
+		cli.BoolFlag{
+			Name:  "minio-use-ssl",
+			Usage: "Enable SSL for minio",
+		},
+	},
+}
+
+func runMigrateStorage(ctx *cli.Context) error {
+	setting.NewContext()
+
+	var srcSetting setting.Storage
+	var defaultPath, defaultBasePath string
+	switch tp := strings.ToLower(ctx.String("type")); tp {
+	case "attachments":
+		srcSetting = setting.AttachmentStorage
+		defaultPath, defaultBasePath = filepath.Join(setting.AppDataPath, "attachments"), "attachments/"
+	case "lfs":
+		srcSetting = setting.LFSStorage
+		defaultPath, defaultBasePath = filepath.Join(setting.AppDataPath, "lfs"), "lfs/"
+	case "avatars":
+		srcSetting = setting.AvatarStorage
+		defaultPath, defaultBasePath = filepath.Join(setting.AppDataPath, "avatars"), "avatars/"
+	case "repo-avatars":
+		srcSetting = setting.RepoAvatarStorage
+		defaultPath, defaultBasePath = filepath.Join(setting.AppDataPath, "repo-avatars"), "repo-avatars/"
+	default:
+		return fmt.Errorf("Unsupported type: %s", tp)
+	}
+
+	var dstSetting setting.Storage
+	dstSetting.Type = strings.ToLower(ctx.String("storage"))
+	switch dstSetting.Type {
+	case setting.LocalStorageType:
+		dstSetting.Path = ctx.String("path")
+		if dstSetting.Path == "" {
+			dstSetting.Path = defaultPath
+		}
+		if !filepath.IsAbs(dstSetting.Path) {
+			dstSetting.Path = filepath.Join(setting.AppWorkPath, dstSetting.Path)
+		}
+		if srcSetting.IsLocal() && filepath.Clean(srcSetting.Path) == filepath.Clean(dstSetting.Path) {
+			return fmt.Errorf("The files are already stored in %s", dstSetting.Path)
+		}
+	case setting.MinioStorageType:
+		dstSetting.Minio.Endpoint = ctx.String("minio-endpoint")
+		dstSetting.Minio.AccessKeyID = ctx.String("minio-access-key-id")
+		dstSetting.Minio.SecretAccessKey = ctx.String("minio-secret-access-key")
+		dstSetting.Minio.Bucket = ctx.String("minio-bucket")
+		dstSetting.Minio.Location = ctx.String("minio-location")
+		dstSetting.Minio.BasePath = ctx.String("minio-base-path")
+		if dstSetting.Minio.BasePath == "" {
+			dstSetting.Minio.BasePath = defaultBasePath
+		}
+		dstSetting.Minio.UseSSL = ctx.Bool("minio-use-ssl")
+	default:
+		return fmt.Errorf("Unsupported storage: %s", dstSetting.Type)
+	}
+
+	src, err := storage.NewStorage(srcSetting)
+	if err != nil {
+		return fmt.Errorf("Failed to open the configured storage: %v", err)
+	}
+	dst, err := storage.NewStorage(dstSetting)
+	if err != nil {
+		return fmt.Errorf("Failed to open the new storage: %v", err)
+	}
+
+	var count int
+	if err := src.IterateObjects(func(path string, obj storage.Object) error {
+		if _, err := dst.Save(path, obj); err != nil {
+			return fmt.Errorf("Failed to migrate %s: %v", path, err)
+		}
+		count++
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	log.Info("Migrated %d files of %s to the %s storage", count, ctx.String("type"), dstSetting.Type)
+	fmt.Printf("Migrated %d files to the %s storage\n", count, dstSetting.Type)
+	return nil
+}
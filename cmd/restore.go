@@ -0,0 +1,538 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/migrations"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+
+	"github.com/urfave/cli"
+	ini "gopkg.in/ini.v1"
+)
+
+// CmdRestore represents the available restore sub-command.
+var CmdRestore = cli.Command{
+	Name:  "restore",
+	Usage: "Restore Gitea files and database from a dump",
+	Description: `Restore validates a dump created by "gitea dump" and restores the database, the repositories,
+the data and the custom directory into the locations configured in app.ini.
+
+The database must be empty, the SQL of the dump is imported into it even if it is of another type
+than the dumped database. The paths of the instance that created the dump are read from the app.ini
+in the dump, so that files are restored into the paths configured for this instance. Existing files
+are never overwritten, the restore fails before writing anything instead.
+
+Dumps of all types can be restored, tar dumps are unpacked into the temporary directory first.
+Incremental dumps are applied in the given order on top of the full dump they are based on.
+Symbolic links in the dump must point inside the directory they are restored into.`,
+	Action: runRestore,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "file, f",
+			Usage: `Name of the full dump file to restore, "-" reads a tar dump from stdin`,
+		},
+		cli.StringSliceFlag{
+			Name:  "incremental",
+			Usage: "Name of an incremental dump to apply on top of the full dump, can be given several times in the order of the dumps",
+		},
+		cli.StringFlag{
+			Name:  "tempdir, t",
+			Value: os.TempDir(),
+			Usage: "Temporary dir path",
+		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Only validate the dump and list what would be written",
+		},
+	},
+}
+
+// restoreFile is a file or directory of the dump and where it is restored to
+type restoreFile struct {
+	entry dumpEntry
+	// either the local path or the path in the storage
+	target string
+	// the local directory the file is restored into, symbolic links must not leave it
+	root        string
+	storage     storage.ObjectStorage
+	storageName string
+}
+
+func (f *restoreFile) String() string {
+	if f.storage != nil {
+		return fmt.Sprintf("%s in the %s storage", f.target, f.storageName)
+	}
+	return f.target
+}
+
+// dumpPaths are the paths the instance that created a dump was configured with,
+// they are read from the app.ini of the dump.
+type dumpPaths struct {
+	dataPath string
+	dbPath   string
+	storages map[string]string
+}
+
+func readDumpPaths(cfg *ini.File) *dumpPaths {
+	resolve := func(p string) string {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(setting.AppWorkPath, p)
+		}
+		return filepath.Clean(p)
+	}
+
+	paths := &dumpPaths{
+		dataPath: resolve(cfg.Section("server").Key("APP_DATA_PATH").MustString(filepath.Join(setting.AppWorkPath, "data"))),
+	}
+	if cfg.Section("database").Key("DB_TYPE").String() == "sqlite3" {
+		paths.dbPath = resolve(cfg.Section("database").Key("PATH").MustString(filepath.Join(paths.dataPath, "gitea.db")))
+	}
+	paths.storages = map[string]string{
+		"attachments":  resolve(cfg.Section("attachment").Key("PATH").MustString(filepath.Join(paths.dataPath, "attachments"))),
+		"lfs":          resolve(cfg.Section("server").Key("LFS_CONTENT_PATH").MustString(filepath.Join(paths.dataPath, "lfs"))),
+		"avatars":      resolve(cfg.Section("picture").Key("AVATAR_UPLOAD_PATH").MustString(filepath.Join(paths.dataPath, "avatars"))),
+		"repo-avatars": resolve(cfg.Section("picture").Key("REPOSITORY_AVATAR_UPLOAD_PATH").MustString(filepath.Join(paths.dataPath, "repo-avatars"))),
+	}
+	return paths
+}
+
+// isSafeDumpPath returns whether the name of a zip entry stays inside the directory it is extracted to
+func isSafeDumpPath(name string) bool {
+	if name == "" || strings.HasPrefix(name, "/") || strings.Contains(name, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func validateDumpEntries(entries []dumpEntry) error {
+	for _, e := range entries {
+		if !isSafeDumpPath(e.Name()) {
+			return fmt.Errorf("invalid file name in dump: %s", e.Name())
+		}
+	}
+	return nil
+}
+
+// loadDumpConfig loads the app.ini of the dump, or an empty configuration if there is none
+func loadDumpConfig(d *dumpArchive) (*ini.File, error) {
+	e := d.entry("app.ini")
+	if e == nil {
+		log.Warn("The dump %s does not contain app.ini, assuming the default data paths", d.name)
+		return ini.Empty(), nil
+	}
+	data, err := readDumpEntry(e)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read app.ini of %s: %v", d.name, err)
+	}
+	cfg, err := ini.Load(data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse app.ini of %s: %v", d.name, err)
+	}
+	return cfg, nil
+}
+
+// incrementalDataPrefixes returns the prefixes of the storages in the data directory
+// of which incremental dumps only contain the new files
+func incrementalDataPrefixes(paths *dumpPaths) []string {
+	var prefixes []string
+	for _, name := range []string{"attachments", "lfs"} {
+		rel, err := filepath.Rel(paths.dataPath, paths.storages[name])
+		if err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			prefixes = append(prefixes, "data/"+filepath.ToSlash(rel)+"/")
+		}
+	}
+	return prefixes
+}
+
+// readDumpSymlink returns the target of a symbolic link of the dump, which must
+// resolve to a path inside the directory the link is restored into.
+func readDumpSymlink(f *restoreFile) (string, error) {
+	if f.storage != nil {
+		return "", fmt.Errorf("symbolic links can not be restored into the %s storage", f.storageName)
+	}
+
+	r, err := f.entry.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	link := string(data)
+	resolved := link
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(filepath.Dir(f.target), resolved)
+	}
+	rel, err := filepath.Rel(f.root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("symbolic link to %s leaves %s", link, f.root)
+	}
+	return link, nil
+}
+
+func extractDumpEntry(f dumpEntry, target string) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func runRestore(ctx *cli.Context) error {
+	if err := argsSet(ctx, "file"); err != nil {
+		return err
+	}
+	if err := initDB(); err != nil {
+		return err
+	}
+	dryRun := ctx.Bool("dry-run")
+
+	tmpDir := ctx.String("tempdir")
+	if _, err := os.Stat(tmpDir); os.IsNotExist(err) {
+		return fmt.Errorf("Path does not exist: %s", tmpDir)
+	}
+	tmpWorkDir, err := ioutil.TempDir(tmpDir, "gitea-restore-")
+	if err != nil {
+		return fmt.Errorf("Failed to create tmp work directory: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpWorkDir); err != nil {
+			log.Error("Failed to remove %s: %v", tmpWorkDir, err)
+		}
+	}()
+
+	fileName := ctx.String("file")
+	d, err := openDumpArchive(fileName, filepath.Join(tmpWorkDir, "dump-0"))
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	manifest, err := d.manifest()
+	if err != nil {
+		return err
+	}
+	if manifest != nil && manifest.Incremental != nil {
+		return fmt.Errorf("%s is an incremental dump, pass it with --incremental together with the full dump it is based on", fileName)
+	}
+	dumpEntries := d.entries
+	dumpCfg, err := loadDumpConfig(d)
+	if err != nil {
+		return err
+	}
+
+	for i, incrementalName := range ctx.StringSlice("incremental") {
+		incremental, err := openDumpArchive(incrementalName, filepath.Join(tmpWorkDir, fmt.Sprintf("dump-%d", i+1)))
+		if err != nil {
+			return err
+		}
+		defer incremental.Close()
+		incrementalManifest, err := incremental.manifest()
+		if err != nil {
+			return err
+		}
+		if incrementalManifest == nil || incrementalManifest.Incremental == nil {
+			return fmt.Errorf("%s is not an incremental dump", incrementalName)
+		}
+		if manifest == nil || !incrementalManifest.Incremental.Equal(manifest.Created) {
+			return fmt.Errorf("%s is not based on the dump before it", incrementalName)
+		}
+		if dumpCfg, err = loadDumpConfig(incremental); err != nil {
+			return err
+		}
+		log.Info("Applying the incremental dump %s created at %s", incrementalName, incrementalManifest.Created.Format(time.RFC3339))
+		dumpEntries = applyIncrementalDump(dumpEntries, incremental.entries, incrementalDataPrefixes(readDumpPaths(dumpCfg)))
+		manifest = incrementalManifest
+	}
+
+	entries := make(map[string]dumpEntry, len(dumpEntries))
+	for _, e := range dumpEntries {
+		entries[e.Name()] = e
+	}
+
+	// The database
+	if entries["gitea-db.sql"] == nil {
+		return fmt.Errorf("%s does not contain gitea-db.sql, it was not created by gitea dump", fileName)
+	}
+	dbDump := filepath.Join(tmpWorkDir, "gitea-db.sql")
+	if err := extractDumpEntry(entries["gitea-db.sql"], dbDump); err != nil {
+		return fmt.Errorf("Failed to extract gitea-db.sql: %v", err)
+	}
+	dump, err := models.ReadDatabaseDump(dbDump)
+	if err != nil {
+		return fmt.Errorf("Invalid gitea-db.sql: %v", err)
+	}
+	useDumpSchema := dump.DBType == setting.Database.Type
+	if !useDumpSchema {
+		version := dump.Version
+		if version < 0 {
+			log.Warn("The dump does not record its database version, assuming it was created by this version of Gitea")
+			version = migrations.ExpectedVersion()
+		}
+		if version != migrations.ExpectedVersion() {
+			return fmt.Errorf("The database of the dump has version %d, it can only be imported into a %s database by Gitea of the same version (database version %d)",
+				version, setting.Database.Type, migrations.ExpectedVersion())
+		}
+	}
+	if empty, err := models.IsDatabaseEmpty(); err != nil {
+		return err
+	} else if !empty {
+		return fmt.Errorf("The %s database is not empty", setting.Database.Type)
+	}
+
+	// The paths of the dumped instance
+	paths := readDumpPaths(dumpCfg)
+
+	files, err := planRestoreFiles(dumpEntries, paths)
+	if err != nil {
+		return err
+	}
+
+	// Older dumps pack the repositories into a zip of their own
+	if f := entries["gitea-repo.zip"]; f != nil {
+		reposDump := filepath.Join(tmpWorkDir, "gitea-repo.zip")
+		if err := extractDumpEntry(f, reposDump); err != nil {
+			return fmt.Errorf("Failed to extract gitea-repo.zip: %v", err)
+		}
+		repos, err := zip.OpenReader(reposDump)
+		if err != nil {
+			return fmt.Errorf("Invalid gitea-repo.zip: %v", err)
+		}
+		defer repos.Close()
+		repoEntries := zipDumpEntries(repos.File)
+		if err := validateDumpEntries(repoEntries); err != nil {
+			return err
+		}
+		for _, f := range repoEntries {
+			// the entries start with the name of the repository root of the dumped instance
+			parts := strings.SplitN(f.Name(), "/", 2)
+			if len(parts) < 2 || parts[1] == "" {
+				continue
+			}
+			files = append(files, &restoreFile{entry: f, target: filepath.Join(setting.RepoRootPath, filepath.FromSlash(parts[1])), root: setting.RepoRootPath})
+		}
+	} else if entries["repos/"] == nil {
+		log.Warn("The dump does not contain repositories, no repositories are restored")
+	}
+
+	var conflicts []string
+	for _, f := range files {
+		if f.entry.Mode().IsDir() {
+			continue
+		}
+		if f.entry.Mode()&os.ModeSymlink != 0 {
+			if _, err := readDumpSymlink(f); err != nil {
+				return fmt.Errorf("Invalid file in dump %s: %v", f.entry.Name(), err)
+			}
+		}
+		if f.storage != nil {
+			if _, err := f.storage.Stat(f.target); err == nil {
+				conflicts = append(conflicts, f.String())
+			}
+		} else if _, err := os.Lstat(f.target); err == nil {
+			conflicts = append(conflicts, f.String())
+		}
+	}
+
+	if dryRun {
+		fmt.Printf("Would import the %s dump into the %s database:\n", dump.DBType, setting.Database.Type)
+		for _, table := range dump.Tables {
+			fmt.Printf("  %s: %d rows\n", table, dump.Rows[table])
+		}
+		fmt.Printf("Would write %d files and directories:\n", len(files))
+		for _, f := range files {
+			fmt.Printf("  %s\n", f)
+		}
+		for _, conflict := range conflicts {
+			fmt.Printf("Would fail, the file already exists: %s\n", conflict)
+		}
+		return nil
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%d files already exist, the first is %s", len(conflicts), conflicts[0])
+	}
+
+	log.Info("Restoring the %s dump into the %s database...", dump.DBType, setting.Database.Type)
+	if err := models.RestoreDatabase(dump, useDumpSchema); err != nil {
+		return fmt.Errorf("Failed to restore the database: %v", err)
+	}
+	if err := models.NewEngine(context.Background(), migrations.Migrate); err != nil {
+		return fmt.Errorf("Failed to migrate the restored database: %v", err)
+	}
+
+	log.Info("Restoring %d files...", len(files))
+	for _, f := range files {
+		if err := restoreDumpFile(f); err != nil {
+			return fmt.Errorf("Failed to restore %s: %v", f.entry.Name(), err)
+		}
+	}
+
+	// hooks and authorized_keys contain the paths of the dumped instance
+	log.Info("Rewriting repository hooks and authorized_keys...")
+	if err := models.SyncRepositoryHooks(); err != nil {
+		return fmt.Errorf("Failed to rewrite repository hooks: %v", err)
+	}
+	if err := models.RewriteAllPublicKeys(); err != nil {
+		return fmt.Errorf("Failed to rewrite authorized_keys: %v", err)
+	}
+
+	log.Info("Finished restoring %s", fileName)
+	return nil
+}
+
+// planRestoreFiles maps the custom, data and repository directories of the dump to the paths of this instance
+func planRestoreFiles(entries []dumpEntry, paths *dumpPaths) ([]*restoreFile, error) {
+	// the storages are matched by their path relative to the data directory of the dumped instance
+	type dataStorage struct {
+		name    string
+		prefix  string
+		storage storage.ObjectStorage
+	}
+	var storages []dataStorage
+	for name, cfg := range map[string]setting.Storage{
+		"attachments":  setting.AttachmentStorage,
+		"lfs":          setting.LFSStorage,
+		"avatars":      setting.AvatarStorage,
+		"repo-avatars": setting.RepoAvatarStorage,
+	} {
+		rel, err := filepath.Rel(paths.dataPath, paths.storages[name])
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			log.Warn("The %s of the dump were not stored in the data directory, they are not restored", name)
+			continue
+		}
+		s, err := storage.NewStorage(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open the %s storage: %v", name, err)
+		}
+		storages = append(storages, dataStorage{name: name, prefix: filepath.ToSlash(rel) + "/", storage: s})
+	}
+	sort.Slice(storages, func(i, j int) bool {
+		return len(storages[i].prefix) > len(storages[j].prefix)
+	})
+
+	var dbPath string
+	if paths.dbPath != "" {
+		if rel, err := filepath.Rel(paths.dataPath, paths.dbPath); err == nil {
+			dbPath = filepath.ToSlash(rel)
+		}
+	}
+
+	var files []*restoreFile
+	for _, f := range entries {
+		name := strings.TrimSuffix(f.Name(), "/")
+		switch {
+		case strings.HasPrefix(name, "custom/"):
+			rel := strings.TrimPrefix(name, "custom/")
+			target := filepath.Join(setting.CustomPath, filepath.FromSlash(rel))
+			if rel == "conf/app.ini" || target == filepath.Clean(setting.CustomConf) {
+				log.Info("Skipping %s, the configuration of this instance is kept", f.Name)
+				continue
+			}
+			files = append(files, &restoreFile{entry: f, target: target, root: setting.CustomPath})
+		case strings.HasPrefix(name, "data/"):
+			rel := strings.TrimPrefix(name, "data/")
+			if rel == dbPath {
+				// the database is restored from gitea-db.sql
+				continue
+			}
+			file := &restoreFile{entry: f, target: filepath.Join(setting.AppDataPath, filepath.FromSlash(rel)), root: setting.AppDataPath}
+			for _, s := range storages {
+				if strings.HasPrefix(rel, s.prefix) {
+					file.storage, file.storageName, file.target = s.storage, s.name, strings.TrimPrefix(rel, s.prefix)
+					break
+				} else if rel+"/" == s.prefix {
+					file = nil
+					break
+				}
+			}
+			if file != nil {
+				files = append(files, file)
+			}
+		case strings.HasPrefix(name, "repos/"):
+			rel := strings.TrimPrefix(name, "repos/")
+			files = append(files, &restoreFile{entry: f, target: filepath.Join(setting.RepoRootPath, filepath.FromSlash(rel)), root: setting.RepoRootPath})
+		}
+	}
+	return files, nil
+}
+
+func restoreDumpFile(f *restoreFile) error {
+	if f.entry.Mode().IsDir() {
+		if f.storage != nil {
+			return nil
+		}
+		return os.MkdirAll(f.target, os.ModePerm)
+	}
+
+	mode := f.entry.Mode()
+	if mode&os.ModeSymlink != 0 {
+		link, err := readDumpSymlink(f)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(f.target), os.ModePerm); err != nil {
+			return err
+		}
+		return os.Symlink(link, f.target)
+	}
+
+	r, err := f.entry.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if f.storage != nil {
+		_, err = f.storage.Save(f.target, r)
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.target), os.ModePerm); err != nil {
+		return err
+	}
+	if mode.Perm() == 0 {
+		mode = 0644
+	}
+	w, err := os.OpenFile(f.target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
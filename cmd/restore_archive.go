@@ -0,0 +1,295 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// dumpEntry is a file, directory or symbolic link of a dump, the names of
+// directories end with a slash and the content of a symbolic link is its target
+type dumpEntry interface {
+	Name() string
+	Mode() os.FileMode
+	Open() (io.ReadCloser, error)
+}
+
+// zipDumpEntry is an entry of a zip dump, it is read from the archive
+type zipDumpEntry struct {
+	file *zip.File
+}
+
+func (e *zipDumpEntry) Name() string                 { return e.file.Name }
+func (e *zipDumpEntry) Mode() os.FileMode            { return e.file.Mode() }
+func (e *zipDumpEntry) Open() (io.ReadCloser, error) { return e.file.Open() }
+
+func zipDumpEntries(files []*zip.File) []dumpEntry {
+	entries := make([]dumpEntry, 0, len(files))
+	for _, f := range files {
+		entries = append(entries, &zipDumpEntry{file: f})
+	}
+	return entries
+}
+
+// stagedDumpEntry is an entry of a tar dump, regular files are unpacked into the
+// temporary directory as tar archives can only be read sequentially
+type stagedDumpEntry struct {
+	name string
+	mode os.FileMode
+	path string
+	link string
+}
+
+func (e *stagedDumpEntry) Name() string      { return e.name }
+func (e *stagedDumpEntry) Mode() os.FileMode { return e.mode }
+func (e *stagedDumpEntry) Open() (io.ReadCloser, error) {
+	switch {
+	case e.mode&os.ModeSymlink != 0:
+		return ioutil.NopCloser(strings.NewReader(e.link)), nil
+	case e.mode.IsDir():
+		return nil, fmt.Errorf("%s is a directory", e.name)
+	}
+	return os.Open(e.path)
+}
+
+// dumpArchive is an opened dump of any type
+type dumpArchive struct {
+	name    string
+	entries []dumpEntry
+	zip     *zip.ReadCloser
+}
+
+// Close closes the archive, the unpacked files of tar dumps are left to the caller
+func (d *dumpArchive) Close() error {
+	if d.zip != nil {
+		return d.zip.Close()
+	}
+	return nil
+}
+
+// entry returns the entry with the given name, or nil
+func (d *dumpArchive) entry(name string) dumpEntry {
+	for _, e := range d.entries {
+		if e.Name() == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// manifest returns the manifest of the dump, or nil for dumps created before manifests existed
+func (d *dumpArchive) manifest() (*dumpManifest, error) {
+	e := d.entry("manifest.json")
+	if e == nil {
+		return nil, nil
+	}
+	data, err := readDumpEntry(e)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &dumpManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("Invalid manifest.json of %s: %v", d.name, err)
+	}
+	return manifest, nil
+}
+
+func readDumpEntry(e dumpEntry) ([]byte, error) {
+	r, err := e.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// detectDumpType returns the type of a dump from its first bytes
+func detectDumpType(header []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")), bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		return "zip", nil
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return "tar.gz", nil
+	case bytes.HasPrefix(header, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return "tar.zst", nil
+	case len(header) > 262 && bytes.Equal(header[257:262], []byte("ustar")):
+		return "tar", nil
+	}
+	return "", fmt.Errorf("not a dump created by gitea dump")
+}
+
+// openDumpArchive opens a dump of any type, "-" reads it from stdin. Zip dumps are read
+// in place, tar dumps are unpacked into stagingDir.
+func openDumpArchive(fileName, stagingDir string) (*dumpArchive, error) {
+	var in io.Reader = os.Stdin
+	if fileName != "-" {
+		f, err := os.Open(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open %s: %v", fileName, err)
+		}
+		defer f.Close()
+		in = f
+	}
+	br := bufio.NewReader(in)
+	header, err := br.Peek(512)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("Failed to read %s: %v", fileName, err)
+	}
+	dumpType, err := detectDumpType(header)
+	if err != nil {
+		return nil, fmt.Errorf("%s is %v", fileName, err)
+	}
+
+	var r io.Reader = br
+	switch dumpType {
+	case "zip":
+		if fileName == "-" {
+			return nil, fmt.Errorf("zip dumps can not be read from stdin")
+		}
+		z, err := zip.OpenReader(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open %s: %v", fileName, err)
+		}
+		d := &dumpArchive{name: fileName, entries: zipDumpEntries(z.File), zip: z}
+		if err := validateDumpEntries(d.entries); err != nil {
+			z.Close()
+			return nil, err
+		}
+		return d, nil
+	case "tar.gz":
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read %s: %v", fileName, err)
+		}
+		defer gz.Close()
+		r = gz
+	case "tar.zst":
+		dec, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read %s: %v", fileName, err)
+		}
+		defer dec.Close()
+		r = dec
+	}
+
+	log.Info("Unpacking %s into %s...", fileName, stagingDir)
+	entries, err := unpackTarDump(r, stagingDir)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unpack %s: %v", fileName, err)
+	}
+	return &dumpArchive{name: fileName, entries: entries}, nil
+}
+
+// unpackTarDump reads the entries of a tar dump, the regular files are written to stagingDir
+func unpackTarDump(r io.Reader, stagingDir string) ([]dumpEntry, error) {
+	if err := os.MkdirAll(stagingDir, 0700); err != nil {
+		return nil, err
+	}
+
+	var entries []dumpEntry
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if !isSafeDumpPath(header.Name) {
+			return nil, fmt.Errorf("invalid file name in dump: %s", header.Name)
+		}
+
+		mode := header.FileInfo().Mode()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			name := header.Name
+			if !strings.HasSuffix(name, "/") {
+				name += "/"
+			}
+			entries = append(entries, &stagedDumpEntry{name: name, mode: mode})
+		case tar.TypeSymlink:
+			entries = append(entries, &stagedDumpEntry{name: header.Name, mode: mode, link: header.Linkname})
+		case tar.TypeReg:
+			// the files are numbered, their names are only used to restore them
+			p := filepath.Join(stagingDir, strconv.Itoa(len(entries)))
+			w, err := os.OpenFile(p, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+			if err != nil {
+				return nil, err
+			}
+			if _, err = io.Copy(w, tr); err != nil {
+				w.Close()
+				return nil, err
+			}
+			if err = w.Close(); err != nil {
+				return nil, err
+			}
+			entries = append(entries, &stagedDumpEntry{name: header.Name, mode: mode, path: p})
+		default:
+			log.Warn("Skipping %s, it is neither a regular file, a directory nor a symlink", header.Name)
+		}
+	}
+}
+
+// applyIncrementalDump returns the entries of a dump updated by the incremental dump based on it.
+// The repositories contained in the incremental dump replace the dumped ones, all of them if it
+// contains the whole repository root because the dump before skipped them. The same applies to
+// the custom directory and the data directory apart from the attachments and LFS objects which
+// are only added by incremental dumps. These prefixes of the data directory are given in keptData.
+func applyIncrementalDump(base, incremental []dumpEntry, keptData []string) []dumpEntry {
+	replaced := make(map[string]bool, len(incremental))
+	var replacedPrefixes []string
+	for _, e := range incremental {
+		name := e.Name()
+		replaced[name] = true
+		switch {
+		case name == "custom/", name == "data/", name == "repos/":
+			replacedPrefixes = append(replacedPrefixes, name)
+		case strings.HasPrefix(name, "repos/") && strings.HasSuffix(name, "/") && strings.Count(name, "/") == 3:
+			// repos/<owner>/<name>.git/ or repos/<owner>/<name>.wiki.git/
+			replacedPrefixes = append(replacedPrefixes, name)
+		}
+	}
+
+	isReplaced := func(name string) bool {
+		if replaced[name] {
+			return true
+		}
+		for _, prefix := range keptData {
+			if strings.HasPrefix(name, prefix) {
+				return false
+			}
+		}
+		for _, prefix := range replacedPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	entries := make([]dumpEntry, 0, len(base)+len(incremental))
+	for _, e := range base {
+		if !isReplaced(e.Name()) {
+			entries = append(entries, e)
+		}
+	}
+	return append(entries, incremental...)
+}
@@ -0,0 +1,177 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenDumpArchive(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "restore")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	repoDir := filepath.Join(tmpDir, "repo.git")
+	assert.NoError(t, os.MkdirAll(filepath.Join(repoDir, "refs"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(repoDir, "HEAD"), []byte("ref: refs/heads/master\n"), 0644))
+	assert.NoError(t, os.Symlink("HEAD", filepath.Join(repoDir, "LINK")))
+
+	for _, dumpType := range dumpTypes {
+		fileName := filepath.Join(tmpDir, "dump."+dumpType)
+		f, err := os.Create(fileName)
+		assert.NoError(t, err)
+		w, err := newDumpWriter(f, dumpType, false)
+		assert.NoError(t, err)
+		assert.NoError(t, w.addData("manifest.json", []byte(`{"gitea_version":"1.13"}`)))
+		assert.NoError(t, w.addDir("repos/user/repo.git", repoDir))
+		assert.NoError(t, w.Close())
+		assert.NoError(t, f.Close())
+
+		d, err := openDumpArchive(fileName, filepath.Join(tmpDir, "staging-"+dumpType))
+		if !assert.NoError(t, err, dumpType) {
+			continue
+		}
+		manifest, err := d.manifest()
+		assert.NoError(t, err)
+		assert.Equal(t, "1.13", manifest.GiteaVersion)
+
+		assert.True(t, d.entry("repos/user/repo.git/").Mode().IsDir(), dumpType)
+		assert.True(t, d.entry("repos/user/repo.git/refs/").Mode().IsDir(), dumpType)
+		data, err := readDumpEntry(d.entry("repos/user/repo.git/HEAD"))
+		assert.NoError(t, err)
+		assert.Equal(t, "ref: refs/heads/master\n", string(data))
+		link := d.entry("repos/user/repo.git/LINK")
+		assert.NotZero(t, link.Mode()&os.ModeSymlink, dumpType)
+		data, err = readDumpEntry(link)
+		assert.NoError(t, err)
+		assert.Equal(t, "HEAD", string(data))
+		assert.NoError(t, d.Close())
+	}
+
+	fileName := filepath.Join(tmpDir, "gitea-db.sql")
+	assert.NoError(t, ioutil.WriteFile(fileName, []byte("INSERT INTO"), 0600))
+	_, err = openDumpArchive(fileName, filepath.Join(tmpDir, "staging-sql"))
+	assert.Error(t, err)
+}
+
+func TestApplyIncrementalDump(t *testing.T) {
+	entries := func(names ...string) []dumpEntry {
+		result := make([]dumpEntry, 0, len(names))
+		for _, name := range names {
+			mode := os.FileMode(0644)
+			if name[len(name)-1] == '/' {
+				mode = os.ModeDir | 0755
+			}
+			result = append(result, &stagedDumpEntry{name: name, mode: mode})
+		}
+		return result
+	}
+	names := func(entries []dumpEntry) []string {
+		result := make([]string, 0, len(entries))
+		for _, e := range entries {
+			result = append(result, e.Name())
+		}
+		return result
+	}
+
+	base := entries(
+		"manifest.json",
+		"gitea-db.sql",
+		"data/",
+		"data/indexers/issues.bleve",
+		"data/attachments/",
+		"data/attachments/a/b/ab",
+		"repos/",
+		"repos/user/",
+		"repos/user/changed.git/",
+		"repos/user/changed.git/packed-refs",
+		"repos/user/unchanged.git/",
+		"repos/user/unchanged.git/HEAD",
+	)
+	incremental := entries(
+		"manifest.json",
+		"gitea-db.sql",
+		"data/",
+		"data/attachments/c/d/cd",
+		"repos/user/changed.git/",
+		"repos/user/changed.git/HEAD",
+	)
+	assert.Equal(t, []string{
+		"data/attachments/",
+		"data/attachments/a/b/ab",
+		"repos/",
+		"repos/user/",
+		"repos/user/unchanged.git/",
+		"repos/user/unchanged.git/HEAD",
+		"manifest.json",
+		"gitea-db.sql",
+		"data/",
+		"data/attachments/c/d/cd",
+		"repos/user/changed.git/",
+		"repos/user/changed.git/HEAD",
+	}, names(applyIncrementalDump(base, incremental, []string{"data/attachments/"})))
+
+	// an incremental dump after one which skipped the repositories contains all of them
+	incremental = entries(
+		"repos/",
+		"repos/user/",
+		"repos/user/changed.git/",
+		"repos/user/changed.git/HEAD",
+	)
+	assert.Equal(t, []string{
+		"manifest.json",
+		"gitea-db.sql",
+		"data/",
+		"data/indexers/issues.bleve",
+		"data/attachments/",
+		"data/attachments/a/b/ab",
+		"repos/",
+		"repos/user/",
+		"repos/user/changed.git/",
+		"repos/user/changed.git/HEAD",
+	}, names(applyIncrementalDump(base, incremental, nil)))
+}
+
+func TestReadDumpSymlink(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	links := map[string]string{
+		"repos/user/repo.git/inside":  "../other.git/objects",
+		"repos/user/repo.git/outside": "../../../etc/passwd",
+		"repos/user/repo.git/abs":     "/etc/passwd",
+		"repos/user/repo.git/root":    "../..",
+	}
+	for name, link := range links {
+		header := &zip.FileHeader{Name: name}
+		header.SetMode(os.ModeSymlink | 0777)
+		w, err := zw.CreateHeader(header)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(link))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	root := filepath.Join(os.TempDir(), "repositories")
+	for _, entry := range z.File {
+		f := &restoreFile{entry: &zipDumpEntry{file: entry}, target: filepath.Join(root, filepath.FromSlash(entry.Name[len("repos/"):])), root: root}
+		link, err := readDumpSymlink(f)
+		switch entry.Name {
+		case "repos/user/repo.git/inside", "repos/user/repo.git/root":
+			assert.NoError(t, err, entry.Name)
+			assert.Equal(t, links[entry.Name], link)
+		default:
+			assert.Error(t, err, entry.Name)
+		}
+	}
+}
@@ -45,3 +45,132 @@ func TestAPIGetBranch(t *testing.T) {
 		testAPIGetBranch(t, test.BranchName, test.Exists)
 	}
 }
+
+func testAPIGetBranchProtection(t *testing.T, branchName string, expectedHTTPStatus int) {
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	req := NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/branch_protections/%s?token=%s", branchName, token)
+	resp := session.MakeRequest(t, req, expectedHTTPStatus)
+
+	if resp.Code == http.StatusOK {
+		var branchProtection api.BranchProtection
+		DecodeJSON(t, resp, &branchProtection)
+		assert.EqualValues(t, branchName, branchProtection.BranchName)
+	}
+}
+
+func testAPICreateBranchProtection(t *testing.T, branchName string, expectedHTTPStatus int) {
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	req := NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/branch_protections?token="+token, &api.CreateBranchProtectionOption{
+		BranchName: branchName,
+	})
+	resp := session.MakeRequest(t, req, expectedHTTPStatus)
+
+	if resp.Code == http.StatusCreated {
+		var branchProtection api.BranchProtection
+		DecodeJSON(t, resp, &branchProtection)
+		assert.EqualValues(t, branchName, branchProtection.BranchName)
+	}
+}
+
+func testAPIEditBranchProtection(t *testing.T, branchName string, body *api.EditBranchProtectionOption, expectedHTTPStatus int) *api.BranchProtection {
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	req := NewRequestWithJSON(t, "PATCH", "/api/v1/repos/user2/repo1/branch_protections/"+branchName+"?token="+token, body)
+	resp := session.MakeRequest(t, req, expectedHTTPStatus)
+
+	if expectedHTTPStatus != http.StatusOK {
+		return nil
+	}
+	// the callers check the returned protection, they must not continue without one
+	if !assert.EqualValues(t, http.StatusOK, resp.Code) {
+		t.FailNow()
+	}
+	var branchProtection api.BranchProtection
+	DecodeJSON(t, resp, &branchProtection)
+	if !assert.EqualValues(t, branchName, branchProtection.BranchName) {
+		t.FailNow()
+	}
+	return &branchProtection
+}
+
+func testAPIDeleteBranchProtection(t *testing.T, branchName string, expectedHTTPStatus int) {
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	req := NewRequestf(t, "DELETE", "/api/v1/repos/user2/repo1/branch_protections/%s?token=%s", branchName, token)
+	session.MakeRequest(t, req, expectedHTTPStatus)
+}
+
+func TestAPIBranchProtection(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	// Branch protection only on branch that exist
+	testAPICreateBranchProtection(t, "master/doesnotexist", http.StatusNotFound)
+	// Get branch protection on branch that exist but not branch protection
+	testAPIGetBranchProtection(t, "master", http.StatusNotFound)
+
+	testAPICreateBranchProtection(t, "master", http.StatusCreated)
+	// Can only create once
+	testAPICreateBranchProtection(t, "master", http.StatusForbidden)
+
+	testAPIGetBranchProtection(t, "master", http.StatusOK)
+
+	// Whitelists accept user names and keep their value when not given
+	enable := true
+	bp := testAPIEditBranchProtection(t, "master", &api.EditBranchProtectionOption{
+		EnablePush:             &enable,
+		EnablePushWhitelist:    &enable,
+		PushWhitelistUsernames: []string{"user2"},
+	}, http.StatusOK)
+	assert.True(t, bp.EnablePush)
+	assert.True(t, bp.EnablePushWhitelist)
+	assert.EqualValues(t, []string{"user2"}, bp.PushWhitelistUsernames)
+
+	var approvals int64 = 1
+	bp = testAPIEditBranchProtection(t, "master", &api.EditBranchProtectionOption{
+		RequiredApprovals: &approvals,
+	}, http.StatusOK)
+	assert.EqualValues(t, 1, bp.RequiredApprovals)
+	assert.EqualValues(t, []string{"user2"}, bp.PushWhitelistUsernames)
+
+	bp = testAPIEditBranchProtection(t, "master", &api.EditBranchProtectionOption{
+		RequireSignedCommits:  &enable,
+		ProtectedFilePatterns: []string{".gitea/CODEOWNERS", "migrations/**"},
+	}, http.StatusOK)
+	assert.True(t, bp.RequireSignedCommits)
+	assert.EqualValues(t, []string{".gitea/CODEOWNERS", "migrations/**"}, bp.ProtectedFilePatterns)
+	testAPIEditBranchProtection(t, "master", &api.EditBranchProtectionOption{
+		ProtectedFilePatterns: []string{"["},
+	}, http.StatusUnprocessableEntity)
+
+	// Unknown user names are rejected
+	testAPIEditBranchProtection(t, "master", &api.EditBranchProtectionOption{
+		PushWhitelistUsernames: []string{"doesnotexist"},
+	}, http.StatusUnprocessableEntity)
+
+	testAPIDeleteBranchProtection(t, "master", http.StatusNoContent)
+	testAPIGetBranchProtection(t, "master", http.StatusNotFound)
+	testAPIDeleteBranchProtection(t, "master", http.StatusNotFound)
+}
+
+func TestAPIBranchProtectionPattern(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	// patterns can be created before any branch matches them
+	testAPICreateBranchProtection(t, "feature/*", http.StatusCreated)
+	testAPICreateBranchProtection(t, "release/[", http.StatusUnprocessableEntity)
+	testAPIGetBranchProtection(t, "feature/*", http.StatusOK)
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	for branchName, protected := range map[string]bool{"feature/1": true, "master": false} {
+		req := NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/branches/%s?token=%s", branchName, token)
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		var branch api.Branch
+		DecodeJSON(t, resp, &branch)
+		assert.Equal(t, protected, branch.Protected, branchName)
+	}
+
+	testAPIDeleteBranchProtection(t, "feature/*", http.StatusNoContent)
+}
@@ -0,0 +1,146 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIPullReview(t *testing.T) {
+	defer prepareTestEnv(t)()
+	pullIssue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 3}).(*models.Issue)
+	assert.NoError(t, pullIssue.LoadAttributes())
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: pullIssue.RepoID}).(*models.Repository)
+	owner := models.AssertExistsAndLoadBean(t, &models.User{ID: repo.OwnerID}).(*models.User)
+
+	// the merge base of the fixture is not a commit, line comments need the real one
+	pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{IssueID: pullIssue.ID}).(*models.PullRequest)
+	mergeBase, err := git.NewCommand("merge-base", pr.BaseBranch, pr.GetGitRefName()).RunInDir(repo.RepoPath())
+	assert.NoError(t, err)
+	pr.MergeBase = strings.TrimSpace(mergeBase)
+	assert.NoError(t, pr.UpdateCols("merge_base"))
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	urlPrefix := fmt.Sprintf("/api/v1/repos/%s/%s/pulls/%d/reviews", owner.Name, repo.Name, pullIssue.Index)
+
+	// ListPullReviews, the pending review of user2 is included
+	req := NewRequestf(t, http.MethodGet, "%s?token=%s", urlPrefix, token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var reviews []*api.PullReview
+	DecodeJSON(t, resp, &reviews)
+	if !assert.Len(t, reviews, 6) {
+		return
+	}
+	for _, r := range reviews {
+		assert.EqualValues(t, pullIssue.HTMLURL(), r.HTMLPullURL)
+		if r.ID == 10 {
+			// the reviewer was deleted
+			assert.EqualValues(t, "Ghost", r.Reviewer.UserName)
+		}
+	}
+
+	// GetPullReview
+	req = NewRequestf(t, http.MethodGet, "%s/8?token=%s", urlPrefix, token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var review api.PullReview
+	DecodeJSON(t, resp, &review)
+	assert.EqualValues(t, 8, review.ID)
+	assert.EqualValues(t, api.ReviewStateApproved, review.State)
+	assert.EqualValues(t, "user4", review.Reviewer.UserName)
+	assert.EqualValues(t, pullIssue.HTMLURL(), review.HTMLPullURL)
+
+	// GetPullReviewComments
+	req = NewRequestf(t, http.MethodGet, "%s/10/comments?token=%s", urlPrefix, token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var reviewComments []*api.PullReviewComment
+	DecodeJSON(t, resp, &reviewComments)
+	if assert.Len(t, reviewComments, 1) {
+		assert.EqualValues(t, "a review from a deleted user", reviewComments[0].Body)
+		assert.EqualValues(t, pullIssue.HTMLURL(), reviewComments[0].HTMLPullURL)
+	}
+
+	// CreatePullReview as pending, the comments and the body are added to the pending review of user2
+	req = NewRequestWithJSON(t, http.MethodPost, fmt.Sprintf("%s?token=%s", urlPrefix, token), &api.CreatePullReviewOptions{
+		Body:  "body1",
+		Event: api.ReviewStatePending,
+		Comments: []api.CreatePullReviewComment{{
+			Path:       "README.md",
+			Body:       "first new line",
+			NewLineNum: 1,
+		}, {
+			Path:       "README.md",
+			Body:       "second comment on the first line",
+			NewLineNum: 1,
+		}},
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &review)
+	assert.EqualValues(t, 6, review.ID)
+	assert.EqualValues(t, api.ReviewStatePending, review.State)
+	assert.EqualValues(t, "body1", review.Body)
+	assert.EqualValues(t, 2, review.CodeCommentsCount)
+
+	// CreatePullReviewComment on the pending review
+	req = NewRequestWithJSON(t, http.MethodPost, fmt.Sprintf("%s/%d/comments?token=%s", urlPrefix, review.ID, token), &api.CreatePullReviewComment{
+		Path:       "README.md",
+		Body:       "another comment",
+		NewLineNum: 1,
+	})
+	resp = session.MakeRequest(t, req, http.StatusCreated)
+	DecodeJSON(t, resp, &reviewComments)
+	assert.Len(t, reviewComments, 3)
+
+	// SubmitPullReview
+	req = NewRequestWithJSON(t, http.MethodPost, fmt.Sprintf("%s/%d?token=%s", urlPrefix, review.ID, token), &api.SubmitPullReviewOptions{
+		Event: api.ReviewStateApproved,
+		Body:  "looks good",
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &review)
+	assert.EqualValues(t, 6, review.ID)
+	assert.EqualValues(t, api.ReviewStateApproved, review.State)
+	assert.EqualValues(t, "looks good", review.Body)
+	assert.EqualValues(t, 3, review.CodeCommentsCount)
+
+	// comments can only be added to pending reviews
+	req = NewRequestWithJSON(t, http.MethodPost, fmt.Sprintf("%s/%d/comments?token=%s", urlPrefix, review.ID, token), &api.CreatePullReviewComment{
+		Path:       "README.md",
+		Body:       "too late",
+		NewLineNum: 1,
+	})
+	session.MakeRequest(t, req, http.StatusForbidden)
+
+	// CreatePullReview submitted directly
+	req = NewRequestWithJSON(t, http.MethodPost, fmt.Sprintf("%s?token=%s", urlPrefix, token), &api.CreatePullReviewOptions{
+		Body:  "just a comment",
+		Event: api.ReviewStateComment,
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &review)
+	assert.EqualValues(t, api.ReviewStateComment, review.State)
+	assert.EqualValues(t, "just a comment", review.Body)
+	assert.EqualValues(t, 0, review.CodeCommentsCount)
+	assert.EqualValues(t, pullIssue.HTMLURL(), review.HTMLPullURL)
+
+	// DeletePullReview
+	req = NewRequestf(t, http.MethodDelete, "%s/%d?token=%s", urlPrefix, review.ID, token)
+	session.MakeRequest(t, req, http.StatusNoContent)
+	req = NewRequestf(t, http.MethodGet, "%s/%d?token=%s", urlPrefix, review.ID, token)
+	session.MakeRequest(t, req, http.StatusNotFound)
+
+	// only the reviewer can delete a review
+	req = NewRequestf(t, http.MethodDelete, "%s/8?token=%s", urlPrefix, token)
+	session.MakeRequest(t, req, http.StatusForbidden)
+}
@@ -93,3 +93,60 @@ func TestAPICreatePullSuccess2(t *testing.T) {
 
 	session.MakeRequest(t, req, 201)
 }
+
+func TestAPIPullFiles(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	req := NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/pulls/3/files?token=%s", token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+
+	var files []*api.ChangedFile
+	DecodeJSON(t, resp, &files)
+	if assert.Len(t, files, 1) {
+		assert.EqualValues(t, "3", files[0].Filename)
+		assert.EqualValues(t, "added", files[0].Status)
+		assert.EqualValues(t, 1, files[0].Additions)
+		assert.EqualValues(t, 0, files[0].Deletions)
+		assert.Contains(t, files[0].Patch, "@@ ")
+	}
+	assert.EqualValues(t, "1", resp.Header().Get("X-Total-Count"))
+
+	req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/pulls/3/files?page=2&token=%s", token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &files)
+	assert.Len(t, files, 0)
+
+	for _, path := range []string{"999/files", "999.diff", "999.patch"} {
+		req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/pulls/%s?token=%s", path, token)
+		session.MakeRequest(t, req, http.StatusNotFound)
+	}
+}
+
+func TestAPICompare(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	req := NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/compare/master...refs/pull/3/head?token=%s", token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+
+	var compare api.Compare
+	DecodeJSON(t, resp, &compare)
+	assert.EqualValues(t, "65f1bf27bc3bf70f64657658635e66094edbcb4d", compare.BaseCommitID)
+	assert.EqualValues(t, compare.BaseCommitID, compare.MergeBaseCommitID)
+	assert.EqualValues(t, 1, compare.TotalCommits)
+	if assert.Len(t, compare.Commits, 1) {
+		assert.EqualValues(t, compare.HeadCommitID, compare.Commits[0].SHA)
+	}
+	assert.EqualValues(t, 1, compare.TotalFiles)
+	assert.Len(t, compare.Files, 1)
+
+	for _, basehead := range []string{"master", "master...", "--output=x...master"} {
+		req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/compare/%s?token=%s", basehead, token)
+		session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	}
+	req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/compare/master...doesnotexist?token=%s", token)
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
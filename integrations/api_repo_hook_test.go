@@ -0,0 +1,55 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/notification"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPICreateHookPullRequestEvents(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	owner := models.AssertExistsAndLoadBean(t, &models.User{ID: repo.OwnerID}).(*models.User)
+
+	session := loginUser(t, owner.Name)
+	token := getTokenForLoggedInUser(t, session)
+	req := NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/%s/%s/hooks?token=%s", owner.Name, repo.Name, token), &api.CreateHookOption{
+		Type: "gitea",
+		Config: api.CreateHookOptionConfig{
+			"url":          "http://example.com/hook",
+			"content_type": "json",
+		},
+		Events: []string{"pull_request"},
+		Active: true,
+	})
+	resp := session.MakeRequest(t, req, http.StatusCreated)
+	var apiHook api.Hook
+	DecodeJSON(t, resp, &apiHook)
+	// the pull_request event includes the granular events of pull requests
+	assert.ElementsMatch(t, []string{"pull_request", "pull_request_assign", "pull_request_label", "pull_request_milestone", "pull_request_sync", "pull_request_review_request"}, apiHook.Events)
+
+	// an edit with the same events keeps them
+	req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("/api/v1/repos/%s/%s/hooks/%d?token=%s", owner.Name, repo.Name, apiHook.ID, token), &api.EditHookOption{
+		Events: []string{"pull_request"},
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &apiHook)
+	assert.Contains(t, apiHook.Events, "pull_request_sync")
+
+	// a synchronized pull request is delivered to the hook
+	pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: 2}).(*models.PullRequest)
+	assert.EqualValues(t, repo.ID, pr.BaseRepoID)
+	notification.NotifyPullRequestSynchronized(owner, pr)
+	models.AssertExistsAndLoadBean(t, &models.HookTask{HookID: apiHook.ID, EventType: models.HookEventPullRequestSync})
+}
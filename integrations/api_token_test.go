@@ -7,9 +7,12 @@ package integrations
 import (
 	"net/http"
 	"testing"
+	"time"
 
 	"code.gitea.io/gitea/models"
 	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
 )
 
 // TestAPICreateAndDeleteToken tests that token that was just created can be deleted
@@ -48,3 +51,170 @@ func TestAPIDeleteMissingToken(t *testing.T) {
 	req = AddBasicAuthHeader(req, user.Name)
 	MakeRequest(t, req, http.StatusNotFound)
 }
+
+// fullAccessTokens are the plaintext fixture tokens with the "all" scope of the users
+var fullAccessTokens = map[string]string{
+	"user1": "d2c6c1ba3890b309189a8e618c72a162e4efbf36",
+	"user2": "90a18faa671dc43924b795806ffe4fd169d28c91",
+}
+
+func createAPIAccessToken(t *testing.T, userName string, opts *api.CreateAccessTokenOption, expectedStatus int) string {
+	req := NewRequestWithJSON(t, "POST", "/api/v1/users/"+userName+"/tokens", opts)
+	req.SetBasicAuth(userName, fullAccessTokens[userName])
+	resp := MakeRequest(t, req, expectedStatus)
+	if expectedStatus != http.StatusCreated {
+		return ""
+	}
+
+	var token api.AccessToken
+	DecodeJSON(t, resp, &token)
+	assert.EqualValues(t, opts.Scopes, token.Scopes)
+	return token.Token
+}
+
+// TestAPITokenScopes tests that the scopes of a token limit what it can be used for
+func TestAPITokenScopes(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	// user1 is a site admin
+	token := createAPIAccessToken(t, "user1", &api.CreateAccessTokenOption{
+		Name:   "read-repository",
+		Scopes: []string{"read:repository"},
+	}, http.StatusCreated)
+	MakeRequest(t, NewRequestf(t, "GET", "/api/v1/repos/user2/repo1?token=%s", token), http.StatusOK)
+	MakeRequest(t, NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/issues?token=%s", token), http.StatusForbidden)
+	req := NewRequestWithJSON(t, "PATCH", "/api/v1/repos/user2/repo1?token="+token, &api.EditRepoOption{})
+	MakeRequest(t, req, http.StatusForbidden)
+	// site admin privileges require the admin scope
+	MakeRequest(t, NewRequestf(t, "GET", "/api/v1/admin/orgs?token=%s", token), http.StatusForbidden)
+	MakeRequest(t, NewRequestf(t, "GET", "/api/v1/repos/user2/repo2?token=%s", token), http.StatusNotFound)
+
+	// a token with a limited scope can't create tokens
+	req = NewRequestWithJSON(t, "POST", "/api/v1/users/user1/tokens", &api.CreateAccessTokenOption{Name: "all"})
+	req.SetBasicAuth("user1", token)
+	MakeRequest(t, req, http.StatusForbidden)
+
+	token = createAPIAccessToken(t, "user1", &api.CreateAccessTokenOption{
+		Name:   "read-admin",
+		Scopes: []string{"read:admin", "read:repository"},
+	}, http.StatusCreated)
+	MakeRequest(t, NewRequestf(t, "GET", "/api/v1/admin/orgs?token=%s", token), http.StatusOK)
+	MakeRequest(t, NewRequestf(t, "GET", "/api/v1/repos/user2/repo2?token=%s", token), http.StatusOK)
+	req = NewRequestWithJSON(t, "POST", "/api/v1/admin/users/user2/orgs?token="+token, &api.CreateOrgOption{UserName: "scoped-org"})
+	MakeRequest(t, req, http.StatusForbidden)
+
+	createAPIAccessToken(t, "user1", &api.CreateAccessTokenOption{
+		Name:   "invalid",
+		Scopes: []string{"read:everything"},
+	}, http.StatusUnprocessableEntity)
+}
+
+// TestAPITokenRestrictions tests tokens restricted to some repositories and expiring tokens
+func TestAPITokenRestrictions(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	token := createAPIAccessToken(t, "user2", &api.CreateAccessTokenOption{
+		Name:         "repo1-only",
+		Scopes:       []string{"write:repository"},
+		Repositories: []string{"user2/repo1"},
+	}, http.StatusCreated)
+	MakeRequest(t, NewRequestf(t, "GET", "/api/v1/repos/user2/repo1?token=%s", token), http.StatusOK)
+	MakeRequest(t, NewRequestf(t, "GET", "/api/v1/repos/user2/repo2?token=%s", token), http.StatusNotFound)
+	MakeRequest(t, NewRequestf(t, "GET", "/api/v1/repositories/2?token=%s", token), http.StatusNotFound)
+	req := NewRequestWithJSON(t, "POST", "/api/v1/user/repos?token="+token, &api.CreateRepoOption{Name: "scoped-repo"})
+	MakeRequest(t, req, http.StatusForbidden)
+
+	createAPIAccessToken(t, "user2", &api.CreateAccessTokenOption{
+		Name:         "not-visible",
+		Repositories: []string{"user10/repo6"},
+	}, http.StatusUnprocessableEntity)
+
+	expiresAt := time.Now().Add(-time.Hour)
+	createAPIAccessToken(t, "user2", &api.CreateAccessTokenOption{
+		Name:      "expired",
+		ExpiresAt: &expiresAt,
+	}, http.StatusUnprocessableEntity)
+}
+
+// TestAPITokenRestrictedListings tests that searches and listings only return what a token may access
+func TestAPITokenRestrictedListings(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	token := createAPIAccessToken(t, "user2", &api.CreateAccessTokenOption{
+		Name:         "repo1-only",
+		Scopes:       []string{"read:issue", "read:repository"},
+		Repositories: []string{"user2/repo1"},
+	}, http.StatusCreated)
+
+	var results api.SearchResults
+	resp := MakeRequest(t, NewRequestf(t, "GET", "/api/v1/repos/search?token=%s", token), http.StatusOK)
+	DecodeJSON(t, resp, &results)
+	if assert.Len(t, results.Data, 1) {
+		assert.Equal(t, "user2/repo1", results.Data[0].FullName)
+	}
+
+	var repos []*api.Repository
+	resp = MakeRequest(t, NewRequestf(t, "GET", "/api/v1/user/repos?token=%s", token), http.StatusOK)
+	DecodeJSON(t, resp, &repos)
+	if assert.Len(t, repos, 1) {
+		assert.Equal(t, "user2/repo1", repos[0].FullName)
+	}
+	resp = MakeRequest(t, NewRequestf(t, "GET", "/api/v1/users/user2/repos?token=%s", token), http.StatusOK)
+	DecodeJSON(t, resp, &repos)
+	assert.Len(t, repos, 1)
+
+	var issues []*api.Issue
+	resp = MakeRequest(t, NewRequestf(t, "GET", "/api/v1/repos/issues/search?state=all&token=%s", token), http.StatusOK)
+	DecodeJSON(t, resp, &issues)
+	assert.NotEmpty(t, issues)
+	for _, issue := range issues {
+		assert.Equal(t, "user2/repo1", issue.Repo.FullName)
+	}
+
+	// the search routes require the scope of what they search
+	MakeRequest(t, NewRequestf(t, "GET", "/api/v1/users/search?token=%s", token), http.StatusForbidden)
+	token = createAPIAccessToken(t, "user2", &api.CreateAccessTokenOption{
+		Name:   "read-user",
+		Scopes: []string{"read:user"},
+	}, http.StatusCreated)
+	MakeRequest(t, NewRequestf(t, "GET", "/api/v1/users/search?token=%s", token), http.StatusOK)
+	MakeRequest(t, NewRequestf(t, "GET", "/api/v1/repos/search?token=%s", token), http.StatusForbidden)
+	MakeRequest(t, NewRequestf(t, "GET", "/api/v1/repos/issues/search?token=%s", token), http.StatusForbidden)
+	MakeRequest(t, NewRequestf(t, "GET", "/api/v1/topics/search?q=topic&token=%s", token), http.StatusForbidden)
+	req := NewRequestWithJSON(t, "POST", "/api/v1/markdown?token="+token, &api.MarkdownOption{Text: "text"})
+	MakeRequest(t, req, http.StatusForbidden)
+}
+
+// TestTokenScopesOutsideAPI tests that only tokens with full access sign in to the web routes
+func TestTokenScopesOutsideAPI(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	req := NewRequest(t, "GET", "/user/settings")
+	req.SetBasicAuth("user2", fullAccessTokens["user2"])
+	MakeRequest(t, req, http.StatusOK)
+
+	token := createAPIAccessToken(t, "user2", &api.CreateAccessTokenOption{
+		Name:   "read-repository",
+		Scopes: []string{"read:repository"},
+	}, http.StatusCreated)
+	req = NewRequest(t, "GET", "/user/settings")
+	req.SetBasicAuth("user2", token)
+	MakeRequest(t, req, http.StatusFound)
+
+	token = createAPIAccessToken(t, "user2", &api.CreateAccessTokenOption{
+		Name:         "repo1-only",
+		Scopes:       []string{"all"},
+		Repositories: []string{"user2/repo1"},
+	}, http.StatusCreated)
+	req = NewRequest(t, "GET", "/user/settings")
+	req.SetBasicAuth("user2", token)
+	MakeRequest(t, req, http.StatusFound)
+
+	// git smart HTTP checks the scope and the restrictions of tokens itself
+	req = NewRequest(t, "GET", "/user2/repo1.git/info/refs?service=git-upload-pack")
+	req.SetBasicAuth("user2", token)
+	MakeRequest(t, req, http.StatusOK)
+	req = NewRequest(t, "GET", "/user2/repo2.git/info/refs?service=git-upload-pack")
+	req.SetBasicAuth("user2", token)
+	MakeRequest(t, req, http.StatusForbidden)
+}
@@ -62,10 +62,12 @@ arguments - which can alternatively be run by running the subcommand web.`
 		cmd.CmdServ,
 		cmd.CmdHook,
 		cmd.CmdDump,
+		cmd.CmdRestore,
 		cmd.CmdCert,
 		cmd.CmdAdmin,
 		cmd.CmdGenerate,
 		cmd.CmdMigrate,
+		cmd.CmdMigrateStorage,
 		cmd.CmdKeys,
 		cmd.CmdConvert,
 		cmd.CmdDoctor,
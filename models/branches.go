@@ -7,14 +7,17 @@ package models
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/util"
 
+	"github.com/gobwas/glob"
 	"github.com/unknwon/com"
 )
 
@@ -25,6 +28,19 @@ const (
 	ProtectedBranchPRID = "GITEA_PR_ID"
 )
 
+// IsBranchProtectionPattern returns true if the name of a branch protection
+// rule is a glob pattern matching several branches rather than a branch name.
+// Git refuses these characters in branch names, except for '{'.
+func IsBranchProtectionPattern(name string) bool {
+	return strings.ContainsAny(name, "*?[{")
+}
+
+// IsValidBranchProtectionPattern returns true if the pattern can be compiled.
+func IsValidBranchProtectionPattern(pattern string) bool {
+	_, err := glob.Compile(pattern, '/')
+	return err == nil
+}
+
 // ProtectedBranch struct
 type ProtectedBranch struct {
 	ID                        int64  `xorm:"pk autoincr"`
@@ -45,6 +61,9 @@ type ProtectedBranch struct {
 	ApprovalsWhitelistTeamIDs []int64            `xorm:"JSON TEXT"`
 	RequiredApprovals         int64              `xorm:"NOT NULL DEFAULT 0"`
 	BlockOnRejectedReviews    bool               `xorm:"NOT NULL DEFAULT false"`
+	RequireCodeOwnerApproval  bool               `xorm:"NOT NULL DEFAULT false"`
+	RequireSignedCommits      bool               `xorm:"NOT NULL DEFAULT false"`
+	ProtectedFilePatterns     []string           `xorm:"JSON TEXT"`
 	CreatedUnix               timeutil.TimeStamp `xorm:"created"`
 	UpdatedUnix               timeutil.TimeStamp `xorm:"updated"`
 }
@@ -54,6 +73,54 @@ func (protectBranch *ProtectedBranch) IsProtected() bool {
 	return protectBranch.ID > 0
 }
 
+// IsPattern returns if the name of this rule is a glob pattern
+func (protectBranch *ProtectedBranch) IsPattern() bool {
+	return IsBranchProtectionPattern(protectBranch.BranchName)
+}
+
+// Match returns if the rule applies to the given branch. Patterns are matched
+// with '/' as separator, so "release/*" matches "release/v1.0" but not
+// "release/v1.0/fix", which needs "release/**".
+func (protectBranch *ProtectedBranch) Match(branchName string) bool {
+	if protectBranch.BranchName == branchName {
+		return true
+	}
+	if !protectBranch.IsPattern() {
+		return false
+	}
+
+	g, err := glob.Compile(protectBranch.BranchName, '/')
+	if err != nil {
+		log.Warn("Invalid branch protection pattern %q of repo %d: %v", protectBranch.BranchName, protectBranch.RepoID, err)
+		return false
+	}
+	return g.Match(branchName)
+}
+
+// MatchProtectedFile returns the first of the paths matching one of the
+// protected file patterns, or an empty string if no protected file is touched.
+// Like rule names the patterns use '/' as separator.
+func (protectBranch *ProtectedBranch) MatchProtectedFile(paths []string) string {
+	patterns := make([]glob.Glob, 0, len(protectBranch.ProtectedFilePatterns))
+	for _, pattern := range protectBranch.ProtectedFilePatterns {
+		g, err := glob.Compile(pattern, '/')
+		if err != nil {
+			log.Warn("Invalid protected file pattern %q of repo %d: %v", pattern, protectBranch.RepoID, err)
+			continue
+		}
+		patterns = append(patterns, g)
+	}
+
+	for _, path := range paths {
+		for _, g := range patterns {
+			if g.Match(path) {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
 // CanUserPush returns if some user could push to this protected branch
 func (protectBranch *ProtectedBranch) CanUserPush(userID int64) bool {
 	if !protectBranch.CanPush {
@@ -180,6 +247,7 @@ func (protectBranch *ProtectedBranch) GetGrantedApprovalsCount(pr *PullRequest)
 	approvals, err := x.Where("issue_id = ?", pr.IssueID).
 		And("type = ?", ReviewTypeApprove).
 		And("official = ?", true).
+		And("dismissed = ?", false).
 		Count(new(Review))
 	if err != nil {
 		log.Error("GetGrantedApprovalsCount: %v", err)
@@ -197,6 +265,7 @@ func (protectBranch *ProtectedBranch) MergeBlockedByRejectedReview(pr *PullReque
 	rejectExist, err := x.Where("issue_id = ?", pr.IssueID).
 		And("type = ?", ReviewTypeReject).
 		And("official = ?", true).
+		And("dismissed = ?", false).
 		Exist(new(Review))
 	if err != nil {
 		log.Error("MergeBlockedByRejectedReview: %v", err)
@@ -206,17 +275,88 @@ func (protectBranch *ProtectedBranch) MergeBlockedByRejectedReview(pr *PullReque
 	return rejectExist
 }
 
+// MergeBlockedByCodeOwners returns true if merge is blocked because a path owned by code owners
+// has not been approved by one of its owners
+func (protectBranch *ProtectedBranch) MergeBlockedByCodeOwners(pr *PullRequest) bool {
+	if !protectBranch.RequireCodeOwnerApproval {
+		return false
+	}
+	approved, err := hasCodeOwnerApprovals(x, pr.IssueID)
+	if err != nil {
+		log.Error("MergeBlockedByCodeOwners: %v", err)
+		return true
+	}
+
+	return !approved
+}
+
+// MergeBlockedByUnsignedCommits returns true if merge is blocked because the branch
+// requires signed commits and either a commit of the pull request is not verified or
+// the merge commit of the doer would not be signed
+func (protectBranch *ProtectedBranch) MergeBlockedByUnsignedCommits(pr *PullRequest, doer *User) bool {
+	if !protectBranch.RequireSignedCommits {
+		return false
+	}
+	if err := pr.GetBaseRepo(); err != nil {
+		log.Error("MergeBlockedByUnsignedCommits: %v", err)
+		return true
+	}
+	baseRef := git.BranchPrefix + pr.BaseBranch
+	commitID, err := FindUnverifiedCommit(pr.BaseRepo.RepoPath(), []string{pr.GetGitRefName(), "--not", baseRef}, nil)
+	if err != nil {
+		log.Error("MergeBlockedByUnsignedCommits: %v", err)
+		return true
+	} else if commitID != "" || doer == nil {
+		return true
+	}
+	sign, _ := pr.SignMerge(doer, pr.BaseRepo.RepoPath(), baseRef, pr.GetGitRefName())
+	return !sign
+}
+
+// IsMergeStyleAllowed returns false for the merge styles which rewrite the commits of
+// pull requests without signing them if the branch requires signed commits
+func (protectBranch *ProtectedBranch) IsMergeStyleAllowed(mergeStyle MergeStyle) bool {
+	return !protectBranch.RequireSignedCommits ||
+		(mergeStyle != MergeStyleRebase && mergeStyle != MergeStyleRebaseMerge)
+}
+
 // GetProtectedBranchByRepoID getting protected branch by repo ID
 func GetProtectedBranchByRepoID(repoID int64) ([]*ProtectedBranch, error) {
 	protectedBranches := make([]*ProtectedBranch, 0)
 	return protectedBranches, x.Where("repo_id = ?", repoID).Desc("updated_unix").Find(&protectedBranches)
 }
 
-// GetProtectedBranchBy getting protected branch by ID/Name
+// GetProtectedBranchBy getting protected branch rule by its exact name, use
+// GetMatchingProtectedBranch to find the rule which protects a branch
 func GetProtectedBranchBy(repoID int64, branchName string) (*ProtectedBranch, error) {
 	return getProtectedBranchBy(x, repoID, branchName)
 }
 
+// GetMatchingProtectedBranch returns the rule which protects the branch, or nil
+// if the branch is not protected. A rule named exactly like the branch takes
+// precedence over patterns, of several matching patterns the oldest one applies.
+func GetMatchingProtectedBranch(repoID int64, branchName string) (*ProtectedBranch, error) {
+	return getMatchingProtectedBranch(x, repoID, branchName)
+}
+
+func getMatchingProtectedBranch(e Engine, repoID int64, branchName string) (*ProtectedBranch, error) {
+	rules := make([]*ProtectedBranch, 0, 5)
+	if err := e.Where("repo_id = ?", repoID).Asc("id").Find(&rules); err != nil {
+		return nil, err
+	}
+
+	var matched *ProtectedBranch
+	for _, rule := range rules {
+		if rule.BranchName == branchName {
+			return rule, nil
+		}
+		if matched == nil && rule.Match(branchName) {
+			matched = rule
+		}
+	}
+	return matched, nil
+}
+
 func getProtectedBranchBy(e Engine, repoID int64, branchName string) (*ProtectedBranch, error) {
 	rel := &ProtectedBranch{RepoID: repoID, BranchName: branchName}
 	has, err := e.Get(rel)
@@ -323,7 +463,7 @@ func (repo *Repository) GetProtectedBranches() ([]*ProtectedBranch, error) {
 
 // GetBranchProtection get the branch protection of a branch
 func (repo *Repository) GetBranchProtection(branchName string) (*ProtectedBranch, error) {
-	return GetProtectedBranchBy(repo.ID, branchName)
+	return GetMatchingProtectedBranch(repo.ID, branchName)
 }
 
 // IsProtectedBranch checks if branch is protected
@@ -332,16 +472,11 @@ func (repo *Repository) IsProtectedBranch(branchName string, doer *User) (bool,
 		return true, nil
 	}
 
-	protectedBranch := &ProtectedBranch{
-		RepoID:     repo.ID,
-		BranchName: branchName,
-	}
-
-	has, err := x.Exist(protectedBranch)
+	protectedBranch, err := GetMatchingProtectedBranch(repo.ID, branchName)
 	if err != nil {
 		return true, err
 	}
-	return has, nil
+	return protectedBranch != nil, nil
 }
 
 // IsProtectedBranchForPush checks if branch is protected for push
@@ -350,15 +485,10 @@ func (repo *Repository) IsProtectedBranchForPush(branchName string, doer *User)
 		return true, nil
 	}
 
-	protectedBranch := &ProtectedBranch{
-		RepoID:     repo.ID,
-		BranchName: branchName,
-	}
-
-	has, err := x.Get(protectedBranch)
+	protectedBranch, err := GetMatchingProtectedBranch(repo.ID, branchName)
 	if err != nil {
 		return true, err
-	} else if has {
+	} else if protectedBranch != nil {
 		return !protectedBranch.CanUserPush(doer.ID), nil
 	}
 
@@ -371,16 +501,11 @@ func (repo *Repository) IsProtectedBranchForMerging(pr *PullRequest, branchName
 		return true, nil
 	}
 
-	protectedBranch := &ProtectedBranch{
-		RepoID:     repo.ID,
-		BranchName: branchName,
-	}
-
-	has, err := x.Get(protectedBranch)
+	protectedBranch, err := GetMatchingProtectedBranch(repo.ID, branchName)
 	if err != nil {
 		return true, err
-	} else if has {
-		return !protectedBranch.CanUserMerge(doer.ID) || !protectedBranch.HasEnoughApprovals(pr) || protectedBranch.MergeBlockedByRejectedReview(pr), nil
+	} else if protectedBranch != nil {
+		return !protectedBranch.CanUserMerge(doer.ID) || !protectedBranch.HasEnoughApprovals(pr) || protectedBranch.MergeBlockedByRejectedReview(pr) || protectedBranch.MergeBlockedByCodeOwners(pr) || protectedBranch.MergeBlockedByUnsignedCommits(pr, doer), nil
 	}
 
 	return false, nil
@@ -78,3 +78,96 @@ func getDeletedBranch(t *testing.T, branch *DeletedBranch) *DeletedBranch {
 
 	return deletedBranch
 }
+
+func TestProtectedBranchMatch(t *testing.T) {
+	for _, test := range []struct {
+		Rule    string
+		Branch  string
+		Matches bool
+	}{
+		{"master", "master", true},
+		{"master", "master2", false},
+		{"release/*", "release/v1.0", true},
+		{"release/*", "release/v1.0/fix", false},
+		{"release/**", "release/v1.0/fix", true},
+		{"release/*", "release", false},
+		{"{release,hotfix}/*", "hotfix/42", true},
+		{"v1.?", "v1.2", true},
+		{"v1.?", "v1.10", false},
+	} {
+		protectBranch := &ProtectedBranch{BranchName: test.Rule}
+		assert.Equal(t, test.Matches, protectBranch.Match(test.Branch), "rule %q on branch %q", test.Rule, test.Branch)
+	}
+}
+
+func TestGetMatchingProtectedBranch(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	for _, name := range []string{"release/*", "release/**", "release/v1.0"} {
+		protectBranch := &ProtectedBranch{RepoID: repo.ID, BranchName: name}
+		assert.NoError(t, UpdateProtectBranch(repo, protectBranch, WhitelistOptions{}))
+	}
+
+	for branch, rule := range map[string]string{
+		// the exact name takes precedence over older patterns
+		"release/v1.0": "release/v1.0",
+		// of several patterns the oldest one applies
+		"release/v1.1":     "release/*",
+		"release/v1.1/fix": "release/**",
+	} {
+		protectBranch, err := GetMatchingProtectedBranch(repo.ID, branch)
+		assert.NoError(t, err)
+		if assert.NotNil(t, protectBranch, branch) {
+			assert.Equal(t, rule, protectBranch.BranchName)
+		}
+	}
+
+	protectBranch, err := GetMatchingProtectedBranch(repo.ID, "master")
+	assert.NoError(t, err)
+	assert.Nil(t, protectBranch)
+
+	isProtected, err := repo.IsProtectedBranch("release/v2.0", &User{ID: 2})
+	assert.NoError(t, err)
+	assert.True(t, isProtected)
+}
+
+func TestProtectedBranchMatchProtectedFile(t *testing.T) {
+	protectBranch := &ProtectedBranch{ProtectedFilePatterns: []string{".gitea/CODEOWNERS", "migrations/**", "["}}
+
+	assert.Equal(t, "", protectBranch.MatchProtectedFile([]string{"README.md", ".gitea/issue_template.md", "migrations"}))
+	assert.Equal(t, ".gitea/CODEOWNERS", protectBranch.MatchProtectedFile([]string{"README.md", ".gitea/CODEOWNERS"}))
+	assert.Equal(t, "migrations/v1/up.sql", protectBranch.MatchProtectedFile([]string{"migrations/v1/up.sql"}))
+}
+
+func TestProtectedBranch_MergeBlockedByUnsignedCommits(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	pr := AssertExistsAndLoadBean(t, &PullRequest{ID: 2}).(*PullRequest)
+	doer := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+
+	protectBranch := &ProtectedBranch{RepoID: pr.BaseRepoID, BranchName: pr.BaseBranch}
+	assert.False(t, protectBranch.MergeBlockedByUnsignedCommits(pr, doer))
+
+	// the commits of the fixture repositories are not signed
+	protectBranch.RequireSignedCommits = true
+	assert.True(t, protectBranch.MergeBlockedByUnsignedCommits(pr, doer))
+
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: pr.BaseRepoID}).(*Repository)
+	commitID, err := FindUnverifiedCommit(repo.RepoPath(), []string{"master", "--not", "master"}, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, commitID)
+}
+
+func TestProtectedBranch_IsMergeStyleAllowed(t *testing.T) {
+	protectBranch := &ProtectedBranch{}
+	for _, mergeStyle := range []MergeStyle{MergeStyleMerge, MergeStyleRebase, MergeStyleRebaseMerge, MergeStyleSquash} {
+		assert.True(t, protectBranch.IsMergeStyleAllowed(mergeStyle))
+	}
+
+	// the rebased commits are not signed
+	protectBranch.RequireSignedCommits = true
+	assert.True(t, protectBranch.IsMergeStyleAllowed(MergeStyleMerge))
+	assert.False(t, protectBranch.IsMergeStyleAllowed(MergeStyleRebase))
+	assert.False(t, protectBranch.IsMergeStyleAllowed(MergeStyleRebaseMerge))
+	assert.True(t, protectBranch.IsMergeStyleAllowed(MergeStyleSquash))
+}
@@ -0,0 +1,300 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+
+	"xorm.io/builder"
+)
+
+// CodeOwnersFiles are the paths, relative to the repository root, a CODEOWNERS file is looked up at
+var CodeOwnersFiles = []string{"CODEOWNERS", "docs/CODEOWNERS", ".gitea/CODEOWNERS"}
+
+// CodeOwnerRule represents a rule of a CODEOWNERS file
+type CodeOwnerRule struct {
+	Pattern string
+	Rule    *regexp.Regexp
+	Users   []*User
+	Teams   []*Team
+}
+
+// Match returns true if the rule applies to the given path
+func (rule *CodeOwnerRule) Match(path string) bool {
+	return rule.Rule.MatchString(strings.TrimPrefix(path, "/"))
+}
+
+// codeOwnerPatternToRegexp converts a gitignore style pattern of a CODEOWNERS file to a regular expression
+func codeOwnerPatternToRegexp(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	// a pattern containing a slash is relative to the repository root,
+	// otherwise it matches at any depth
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if len(pattern) == 0 {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	var expr strings.Builder
+	if anchored {
+		expr.WriteString("^")
+	} else {
+		expr.WriteString("^(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				if i+2 < len(pattern) && pattern[i+2] == '/' {
+					// "**/" matches any number of directories
+					expr.WriteString("(?:.*/)?")
+					i += 2
+				} else {
+					expr.WriteString(".*")
+					i++
+				}
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	// a pattern matching a directory owns everything below it
+	if dirOnly {
+		expr.WriteString("/.*$")
+	} else {
+		expr.WriteString("(?:/.*)?$")
+	}
+
+	return regexp.Compile(expr.String())
+}
+
+// ParseCodeOwners parses the content of a CODEOWNERS file of the repository.
+// Owners are given as @username, @org/team-name or email address, owners which
+// can not be resolved are skipped.
+func ParseCodeOwners(repo *Repository, content string) ([]*CodeOwnerRule, error) {
+	if err := repo.GetOwner(); err != nil {
+		return nil, err
+	}
+
+	users := make(map[string]*User)
+	teams := make(map[string]*Team)
+	rules := make([]*CodeOwnerRule, 0, 10)
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		re, err := codeOwnerPatternToRegexp(fields[0])
+		if err != nil {
+			log.Trace("Skipping invalid CODEOWNERS pattern %q in %-v: %v", fields[0], repo, err)
+			continue
+		}
+		rule := &CodeOwnerRule{
+			Pattern: fields[0],
+			Rule:    re,
+		}
+
+		for _, owner := range fields[1:] {
+			switch {
+			case strings.HasPrefix(owner, "@") && strings.Contains(owner, "/"):
+				team, ok := teams[owner]
+				if !ok {
+					parts := strings.SplitN(owner[1:], "/", 2)
+					if repo.Owner.IsOrganization() && strings.EqualFold(parts[0], repo.Owner.Name) {
+						if team, err = GetTeam(repo.OwnerID, parts[1]); err != nil && !IsErrTeamNotExist(err) {
+							return nil, err
+						}
+					}
+					teams[owner] = team
+				}
+				if team != nil {
+					rule.Teams = append(rule.Teams, team)
+				}
+			default:
+				user, ok := users[owner]
+				if !ok {
+					if strings.HasPrefix(owner, "@") {
+						user, err = GetUserByName(owner[1:])
+					} else {
+						user, err = GetUserByEmail(owner)
+					}
+					if err != nil && !IsErrUserNotExist(err) {
+						return nil, err
+					}
+					if user != nil && user.IsOrganization() {
+						user = nil
+					}
+					users[owner] = user
+				}
+				if user != nil {
+					rule.Users = append(rule.Users, user)
+				}
+			}
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, scanner.Err()
+}
+
+// PullRequestCodeOwner represents the code owners of the paths changed by a pull request
+// which are owned by the same CODEOWNERS rule
+type PullRequestCodeOwner struct {
+	ID      int64    `xorm:"pk autoincr"`
+	IssueID int64    `xorm:"INDEX"`
+	Pattern string   `xorm:"TEXT"`
+	Paths   []string `xorm:"JSON TEXT"`
+	UserIDs []int64  `xorm:"JSON TEXT"`
+	TeamIDs []int64  `xorm:"JSON TEXT"`
+}
+
+// GetCodeOwnersOfFiles returns the code owners of the given files grouped by the rule owning them,
+// like in git the last matching rule of a file takes precedence
+func GetCodeOwnersOfFiles(rules []*CodeOwnerRule, files []string) []*PullRequestCodeOwner {
+	owners := make([]*PullRequestCodeOwner, 0, len(rules))
+	byRule := make(map[int]*PullRequestCodeOwner)
+	for _, file := range files {
+		if len(file) == 0 {
+			continue
+		}
+		for i := len(rules) - 1; i >= 0; i-- {
+			rule := rules[i]
+			if !rule.Match(file) {
+				continue
+			}
+			// a rule without owners makes a path unowned
+			if len(rule.Users) == 0 && len(rule.Teams) == 0 {
+				break
+			}
+
+			owner, ok := byRule[i]
+			if !ok {
+				owner = &PullRequestCodeOwner{
+					Pattern: rule.Pattern,
+					UserIDs: make([]int64, 0, len(rule.Users)),
+					TeamIDs: make([]int64, 0, len(rule.Teams)),
+				}
+				for _, user := range rule.Users {
+					owner.UserIDs = append(owner.UserIDs, user.ID)
+				}
+				for _, team := range rule.Teams {
+					owner.TeamIDs = append(owner.TeamIDs, team.ID)
+				}
+				byRule[i] = owner
+				owners = append(owners, owner)
+			}
+			owner.Paths = append(owner.Paths, file)
+			break
+		}
+	}
+	return owners
+}
+
+func getPullRequestCodeOwners(e Engine, issueID int64) ([]*PullRequestCodeOwner, error) {
+	owners := make([]*PullRequestCodeOwner, 0, 5)
+	return owners, e.Where("issue_id = ?", issueID).Asc("id").Find(&owners)
+}
+
+// GetPullRequestCodeOwners returns the code owners of the paths changed by the pull request of the issue
+func GetPullRequestCodeOwners(issueID int64) ([]*PullRequestCodeOwner, error) {
+	return getPullRequestCodeOwners(x, issueID)
+}
+
+// UpdatePullRequestCodeOwners replaces the code owners of the paths changed by the pull request of the issue
+func UpdatePullRequestCodeOwners(issueID int64, owners []*PullRequestCodeOwner) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Where("issue_id = ?", issueID).Delete(new(PullRequestCodeOwner)); err != nil {
+		return err
+	}
+	for _, owner := range owners {
+		owner.ID = 0
+		owner.IssueID = issueID
+	}
+	if len(owners) > 0 {
+		if _, err := sess.Insert(&owners); err != nil {
+			return err
+		}
+	}
+
+	return sess.Commit()
+}
+
+// hasCodeOwnerApprovals returns true if the paths owned by each rule are approved by one of its owners.
+// Only approvals of reviewers other than the poster count, even for the paths the poster owns.
+func hasCodeOwnerApprovals(e Engine, issueID int64) (bool, error) {
+	owners, err := getPullRequestCodeOwners(e, issueID)
+	if err != nil {
+		return false, err
+	} else if len(owners) == 0 {
+		return true, nil
+	}
+
+	issue, err := getIssueByID(e, issueID)
+	if err != nil {
+		return false, err
+	}
+
+	// only the latest review of each reviewer counts
+	approverIDs := make([]int64, 0, 5)
+	if err = e.Table("review").
+		In("id", builder.Select("max(id)").From("review").
+			Where(builder.Eq{"issue_id": issueID, "dismissed": false}.
+				And(builder.In("type", ReviewTypeApprove, ReviewTypeReject))).
+			GroupBy("reviewer_id")).
+		And("type = ?", ReviewTypeApprove).
+		And("reviewer_id <> ?", issue.PosterID).
+		Cols("reviewer_id").
+		Find(&approverIDs); err != nil {
+		return false, err
+	}
+
+	approvers := make(map[int64]bool, len(approverIDs))
+	for _, id := range approverIDs {
+		approvers[id] = true
+	}
+
+OWNERS:
+	for _, owner := range owners {
+		for _, userID := range owner.UserIDs {
+			if approvers[userID] {
+				continue OWNERS
+			}
+		}
+		if len(owner.TeamIDs) > 0 {
+			approved, err := e.In("team_id", owner.TeamIDs).In("uid", approverIDs).Exist(new(TeamUser))
+			if err != nil {
+				return false, err
+			} else if approved {
+				continue
+			}
+		}
+		return false, nil
+	}
+
+	return true, nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeOwnerRule_Match(t *testing.T) {
+	kases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"*", "README.md", true},
+		{"*", "docs/index.md", true},
+		{"*.go", "main.go", true},
+		{"*.go", "models/models.go", true},
+		{"*.go", "main.golang", false},
+		{"/docs/", "docs/index.md", true},
+		{"/docs/", "src/docs/index.md", false},
+		{"docs/", "docs", false},
+		{"docs", "src/docs/index.md", true},
+		{"/build/logs", "build/logs/error.log", true},
+		{"/build/logs", "build/logs.txt", false},
+		{"apps/*.js", "apps/index.js", true},
+		{"apps/*.js", "apps/lib/index.js", false},
+		{"**/vendor", "a/b/vendor/c.go", true},
+		{"docs/**/*.md", "docs/a/b/c.md", true},
+		{"docs/**/*.md", "docs/c.md", true},
+		{"file?.txt", "file1.txt", true},
+	}
+	for _, kase := range kases {
+		re, err := codeOwnerPatternToRegexp(kase.pattern)
+		assert.NoError(t, err)
+		rule := &CodeOwnerRule{Pattern: kase.pattern, Rule: re}
+		assert.Equal(t, kase.match, rule.Match(kase.path), "pattern %q on %q", kase.pattern, kase.path)
+	}
+}
+
+func TestParseCodeOwners(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 3}).(*Repository)
+	rules, err := ParseCodeOwners(repo, `# comment
+*       @user2 user4@example.com
+/docs/  @user3/owners @unknown # trailing comment
+
+*.md    @user5 @user3/unknown
+vendor/
+`)
+	assert.NoError(t, err)
+	if assert.Len(t, rules, 4) {
+		assert.Equal(t, "*", rules[0].Pattern)
+		if assert.Len(t, rules[0].Users, 2) {
+			assert.EqualValues(t, 2, rules[0].Users[0].ID)
+			assert.EqualValues(t, 4, rules[0].Users[1].ID)
+		}
+		assert.Len(t, rules[1].Users, 0)
+		if assert.Len(t, rules[1].Teams, 1) {
+			assert.EqualValues(t, 1, rules[1].Teams[0].ID)
+		}
+		assert.Len(t, rules[2].Users, 1)
+		assert.Len(t, rules[2].Teams, 0)
+		assert.Len(t, rules[3].Users, 0)
+	}
+
+	owners := GetCodeOwnersOfFiles(rules, []string{"main.go", "docs/index.go", "docs/index.md", "vendor/lib.go", ""})
+	if assert.Len(t, owners, 3) {
+		assert.Equal(t, "*", owners[0].Pattern)
+		assert.Equal(t, []string{"main.go"}, owners[0].Paths)
+		assert.Equal(t, []int64{2, 4}, owners[0].UserIDs)
+		assert.Equal(t, "/docs/", owners[1].Pattern)
+		assert.Equal(t, []string{"docs/index.go"}, owners[1].Paths)
+		assert.Equal(t, []int64{1}, owners[1].TeamIDs)
+		assert.Equal(t, "*.md", owners[2].Pattern)
+		assert.Equal(t, []string{"docs/index.md"}, owners[2].Paths)
+	}
+}
+
+func TestProtectedBranch_MergeBlockedByCodeOwners(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	pr := AssertExistsAndLoadBean(t, &PullRequest{IssueID: 2}).(*PullRequest)
+	protectBranch := &ProtectedBranch{RequireCodeOwnerApproval: true}
+
+	// no owned paths
+	assert.False(t, protectBranch.MergeBlockedByCodeOwners(pr))
+
+	assert.NoError(t, UpdatePullRequestCodeOwners(pr.IssueID, []*PullRequestCodeOwner{
+		{Pattern: "*", Paths: []string{"README.md"}, UserIDs: []int64{1, 2}},
+		{Pattern: "/docs/", Paths: []string{"docs/index.md"}, TeamIDs: []int64{1}},
+	}))
+	owners, err := GetPullRequestCodeOwners(pr.IssueID)
+	assert.NoError(t, err)
+	assert.Len(t, owners, 2)
+
+	// only user 1 approved, who is the poster
+	assert.True(t, protectBranch.MergeBlockedByCodeOwners(pr))
+
+	// user 2 owns the first rule and is a member of team 1
+
+	_, err = CreateReview(CreateReviewOptions{
+		Type:     ReviewTypeApprove,
+		Issue:    AssertExistsAndLoadBean(t, &Issue{ID: pr.IssueID}).(*Issue),
+		Reviewer: AssertExistsAndLoadBean(t, &User{ID: 2}).(*User),
+	})
+	assert.NoError(t, err)
+	assert.False(t, protectBranch.MergeBlockedByCodeOwners(pr))
+
+	protectBranch.RequireCodeOwnerApproval = false
+	assert.NoError(t, UpdatePullRequestCodeOwners(pr.IssueID, []*PullRequestCodeOwner{
+		{Pattern: "*", Paths: []string{"README.md"}, UserIDs: []int64{4}},
+	}))
+	assert.False(t, protectBranch.MergeBlockedByCodeOwners(pr))
+}
+
+func TestProtectedBranch_MergeBlockedByCodeOwners_Poster(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	pr := AssertExistsAndLoadBean(t, &PullRequest{IssueID: 3}).(*PullRequest)
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: pr.IssueID}).(*Issue)
+	protectBranch := &ProtectedBranch{RequireCodeOwnerApproval: true}
+
+	// the poster owning the changed paths does not approve them
+	assert.NoError(t, UpdatePullRequestCodeOwners(pr.IssueID, []*PullRequestCodeOwner{
+		{Pattern: "*", Paths: []string{"README.md"}, UserIDs: []int64{issue.PosterID, 5}},
+	}))
+	assert.True(t, protectBranch.MergeBlockedByCodeOwners(pr))
+
+	// neither does an approval of the poster themselves
+	_, err := CreateReview(CreateReviewOptions{
+		Type:     ReviewTypeApprove,
+		Issue:    issue,
+		Reviewer: AssertExistsAndLoadBean(t, &User{ID: issue.PosterID}).(*User),
+	})
+	assert.NoError(t, err)
+	assert.True(t, protectBranch.MergeBlockedByCodeOwners(pr))
+
+	// the approval of another owner does
+	_, err = CreateReview(CreateReviewOptions{
+		Type:     ReviewTypeApprove,
+		Issue:    issue,
+		Reviewer: AssertExistsAndLoadBean(t, &User{ID: 5}).(*User),
+	})
+	assert.NoError(t, err)
+	assert.False(t, protectBranch.MergeBlockedByCodeOwners(pr))
+}
@@ -85,6 +85,7 @@ func (repo *Repository) checkForConsistency(t *testing.T) {
 	assert.Equal(t, repo.LowerName, strings.ToLower(repo.Name), "repo: %+v", repo)
 	assertCount(t, &Star{RepoID: repo.ID}, repo.NumStars)
 	assertCount(t, &Milestone{RepoID: repo.ID}, repo.NumMilestones)
+	assertCount(t, &Project{RepoID: repo.ID}, repo.NumProjects)
 	assertCount(t, &Repository{ForkID: repo.ID}, repo.NumForks)
 	if repo.IsFork {
 		AssertExistsAndLoadBean(t, &Repository{ID: repo.ForkID})
@@ -113,6 +114,10 @@ func (repo *Repository) checkForConsistency(t *testing.T) {
 	actual = getCount(t, x.Where("is_closed=?", true), &Milestone{RepoID: repo.ID})
 	assert.EqualValues(t, repo.NumClosedMilestones, actual,
 		"Unexpected number of closed milestones for repo %+v", repo)
+
+	actual = getCount(t, x.Where("is_closed=?", true), &Project{RepoID: repo.ID})
+	assert.EqualValues(t, repo.NumClosedProjects, actual,
+		"Unexpected number of closed projects for repo %+v", repo)
 }
 
 func (issue *Issue) checkForConsistency(t *testing.T) {
@@ -706,6 +706,53 @@ func ParseCommitWithSignature(c *git.Commit) *CommitVerification {
 	}
 }
 
+// FindUnverifiedCommit returns a commit of revRange whose signature can not be verified,
+// or an empty string if all of them are verified. A single git log finds the unsigned
+// commits, only the signed ones are read to verify them against the keys of the users.
+func FindUnverifiedCommit(repoPath string, revRange []string, env []string) (string, error) {
+	stdout, err := git.NewCommand(append([]string{"log", "--format=%H %G?"}, revRange...)...).RunInDirWithEnv(repoPath, env)
+	if err != nil {
+		return "", err
+	}
+
+	var signed []string
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if fields[1] == "N" {
+			return fields[0], nil
+		}
+		signed = append(signed, fields[0])
+	}
+	if len(signed) == 0 {
+		return "", nil
+	}
+
+	gitRepo, err := git.OpenRepository(repoPath)
+	if err != nil {
+		return "", err
+	}
+	defer gitRepo.Close()
+
+	for _, commitID := range signed {
+		// the commits may only exist in the quarantine of a push, they are read with its environment
+		data, err := git.NewCommand("cat-file", "commit", commitID).RunInDirWithEnv(repoPath, env)
+		if err != nil {
+			return "", err
+		}
+		commit, err := git.CommitFromReader(gitRepo, git.MustIDFromString(commitID), strings.NewReader(data))
+		if err != nil {
+			return "", err
+		}
+		if verification := ParseCommitWithSignature(commit); !verification.Verified {
+			return commitID, nil
+		}
+	}
+	return "", nil
+}
+
 func verifyWithGPGSettings(gpgSettings *git.GPGSettings, sig *packet.Signature, payload string, committer *User, keyID string) *CommitVerification {
 	// First try to find the key in the db
 	if commitVerification := hashAndVerifyForKeyID(sig, payload, committer, gpgSettings.KeyID, gpgSettings.Name, gpgSettings.Email); commitVerification != nil {
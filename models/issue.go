@@ -42,6 +42,7 @@ type Issue struct {
 	Labels           []*Label   `xorm:"-"`
 	MilestoneID      int64      `xorm:"INDEX"`
 	Milestone        *Milestone `xorm:"-"`
+	Project          *Project   `xorm:"-"`
 	Priority         int
 	AssigneeID       int64        `xorm:"-"`
 	Assignee         *User        `xorm:"-"`
@@ -267,6 +268,10 @@ func (issue *Issue) loadAttributes(e Engine) (err error) {
 		return
 	}
 
+	if err = issue.loadProject(e); err != nil {
+		return
+	}
+
 	if err = issue.loadAssignees(e); err != nil {
 		return
 	}
@@ -941,7 +946,7 @@ func newIssue(e *xorm.Session, doer *User, opts NewIssueOptions) (err error) {
 
 		for _, label := range labels {
 			// Silently drop invalid labels.
-			if label.RepoID != opts.Repo.ID {
+			if label.RepoID != opts.Repo.ID && label.OrgID != opts.Repo.OwnerID {
 				continue
 			}
 
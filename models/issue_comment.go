@@ -86,6 +86,10 @@ const (
 	CommentTypeChangeTargetBranch
 	// Delete time manual for time tracking
 	CommentTypeDeleteTimeManual
+	// add or remove Request from one
+	CommentTypeReviewRequest
+	// Assign or unassign a project
+	CommentTypeProject
 )
 
 // CommentTag defines comment tag type
@@ -115,9 +119,15 @@ type Comment struct {
 	MilestoneID      int64
 	OldMilestone     *Milestone `xorm:"-"`
 	Milestone        *Milestone `xorm:"-"`
+	OldProjectID     int64
+	ProjectID        int64
+	OldProject       *Project `xorm:"-"`
+	Project          *Project `xorm:"-"`
 	AssigneeID       int64
 	RemovedAssignee  bool
 	Assignee         *User `xorm:"-"`
+	AssigneeTeamID   int64 `xorm:"NOT NULL DEFAULT 0"`
+	AssigneeTeam     *Team `xorm:"-"`
 	OldTitle         string
 	NewTitle         string
 	OldRef           string
@@ -344,6 +354,30 @@ func (c *Comment) LoadMilestone() error {
 	return nil
 }
 
+// LoadProject if comment.Type is CommentTypeProject, then load project
+func (c *Comment) LoadProject() error {
+	if c.OldProjectID > 0 {
+		var oldProject Project
+		has, err := x.ID(c.OldProjectID).Get(&oldProject)
+		if err != nil {
+			return err
+		} else if has {
+			c.OldProject = &oldProject
+		}
+	}
+
+	if c.ProjectID > 0 {
+		var project Project
+		has, err := x.ID(c.ProjectID).Get(&project)
+		if err != nil {
+			return err
+		} else if has {
+			c.Project = &project
+		}
+	}
+	return nil
+}
+
 // LoadPoster loads comment poster
 func (c *Comment) LoadPoster() error {
 	return c.loadPoster(x)
@@ -400,6 +434,22 @@ func (c *Comment) LoadAssigneeUser() error {
 	return nil
 }
 
+// LoadAssigneeTeam if comment.Type is CommentTypeReviewRequest, then load the requested team
+func (c *Comment) LoadAssigneeTeam() error {
+	var err error
+
+	if c.AssigneeTeamID > 0 && c.AssigneeTeam == nil {
+		c.AssigneeTeam, err = getTeamByID(x, c.AssigneeTeamID)
+		if err != nil {
+			if !IsErrTeamNotExist(err) {
+				return err
+			}
+			c.AssigneeTeam = &Team{ID: -1, Name: "Ghost"}
+		}
+	}
+	return nil
+}
+
 // LoadDepIssueDetails loads Dependent Issue Details
 func (c *Comment) LoadDepIssueDetails() (err error) {
 	if c.DependentIssueID <= 0 || c.DependentIssue != nil {
@@ -515,8 +565,11 @@ func createComment(e *xorm.Session, opts *CreateCommentOptions) (_ *Comment, err
 		LabelID:          LabelID,
 		OldMilestoneID:   opts.OldMilestoneID,
 		MilestoneID:      opts.MilestoneID,
+		OldProjectID:     opts.OldProjectID,
+		ProjectID:        opts.ProjectID,
 		RemovedAssignee:  opts.RemovedAssignee,
 		AssigneeID:       opts.AssigneeID,
+		AssigneeTeamID:   opts.AssigneeTeamID,
 		CommitID:         opts.CommitID,
 		CommitSHA:        opts.CommitSHA,
 		Line:             opts.LineNum,
@@ -677,7 +730,10 @@ type CreateCommentOptions struct {
 	DependentIssueID int64
 	OldMilestoneID   int64
 	MilestoneID      int64
+	OldProjectID     int64
+	ProjectID        int64
 	AssigneeID       int64
+	AssigneeTeamID   int64
 	RemovedAssignee  bool
 	OldTitle         string
 	NewTitle         string
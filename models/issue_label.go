@@ -59,10 +59,11 @@ func GetLabelTemplateFile(name string) ([][3]string, error) {
 	return list, nil
 }
 
-// Label represents a label of repository for issues.
+// Label represents a label of repository or organization for issues.
 type Label struct {
 	ID              int64 `xorm:"pk autoincr"`
 	RepoID          int64 `xorm:"INDEX"`
+	OrgID           int64 `xorm:"INDEX"`
 	Name            string
 	Description     string
 	Color           string `xorm:"VARCHAR(7)"`
@@ -85,6 +86,16 @@ func (label *Label) APIFormat() *api.Label {
 	}
 }
 
+// BelongsToOrg returns true if the label belongs to an organization
+func (label *Label) BelongsToOrg() bool {
+	return label.OrgID > 0
+}
+
+// BelongsToRepo returns true if the label belongs to a repository
+func (label *Label) BelongsToRepo() bool {
+	return label.RepoID > 0
+}
+
 // CalOpenIssues calculates the open issues of label.
 func (label *Label) CalOpenIssues() {
 	label.NumOpenIssues = label.NumIssues - label.NumClosedIssues
@@ -151,7 +162,7 @@ func LoadLabelsFormatted(labelTemplate string) (string, error) {
 	return strings.Join(labels, ", "), err
 }
 
-func initalizeLabels(e Engine, repoID int64, labelTemplate string) error {
+func initalizeLabels(e Engine, id int64, labelTemplate string, isOrg bool) error {
 	list, err := GetLabelTemplateFile(labelTemplate)
 	if err != nil {
 		return ErrIssueLabelTemplateLoad{labelTemplate, err}
@@ -160,11 +171,15 @@ func initalizeLabels(e Engine, repoID int64, labelTemplate string) error {
 	labels := make([]*Label, len(list))
 	for i := 0; i < len(list); i++ {
 		labels[i] = &Label{
-			RepoID:      repoID,
 			Name:        list[i][0],
 			Description: list[i][2],
 			Color:       list[i][1],
 		}
+		if isOrg {
+			labels[i].OrgID = id
+		} else {
+			labels[i].RepoID = id
+		}
 	}
 	for _, label := range labels {
 		if err = newLabel(e, label); err != nil {
@@ -174,9 +189,9 @@ func initalizeLabels(e Engine, repoID int64, labelTemplate string) error {
 	return nil
 }
 
-// InitalizeLabels adds a label set to a repository using a template
-func InitalizeLabels(repoID int64, labelTemplate string) error {
-	return initalizeLabels(x, repoID, labelTemplate)
+// InitalizeLabels adds a label set to a repository or an organization using a template
+func InitalizeLabels(id int64, labelTemplate string, isOrg bool) error {
+	return initalizeLabels(x, id, labelTemplate, isOrg)
 }
 
 func newLabel(e Engine, label *Label) error {
@@ -184,7 +199,7 @@ func newLabel(e Engine, label *Label) error {
 	return err
 }
 
-// NewLabel creates a new label for a repository
+// NewLabel creates a new label for a repository or an organization
 func NewLabel(label *Label) error {
 	return newLabel(x, label)
 }
@@ -257,25 +272,20 @@ func GetLabelInRepoByName(repoID int64, labelName string) (*Label, error) {
 }
 
 // GetLabelIDsInRepoByNames returns a list of labelIDs by names in a given
-// repository.
+// repository, including the labels of the organization owning it.
 // it silently ignores label names that do not belong to the repository.
 func GetLabelIDsInRepoByNames(repoID int64, labelNames []string) ([]int64, error) {
-	labelIDs := make([]int64, 0, len(labelNames))
-	return labelIDs, x.Table("label").
-		Where("repo_id = ?", repoID).
-		In("name", labelNames).
-		Asc("name").
-		Cols("id").
-		Find(&labelIDs)
+	return GetLabelIDsInReposByNames([]int64{repoID}, labelNames)
 }
 
 // GetLabelIDsInReposByNames returns a list of labelIDs by names in one of the given
-// repositories.
+// repositories, including the labels of the organizations owning them.
 // it silently ignores label names that do not belong to the repository.
 func GetLabelIDsInReposByNames(repoIDs []int64, labelNames []string) ([]int64, error) {
 	labelIDs := make([]int64, 0, len(labelNames))
 	return labelIDs, x.Table("label").
-		In("repo_id", repoIDs).
+		Where(builder.In("repo_id", repoIDs).
+			Or(builder.In("org_id", builder.Select("owner_id").From("repository").Where(builder.In("id", repoIDs))))).
 		In("name", labelNames).
 		Asc("name").
 		Cols("id").
@@ -329,6 +339,91 @@ func getLabelsByIssueID(e Engine, issueID int64) ([]*Label, error) {
 		Find(&labels)
 }
 
+// getLabelInOrgByName returns a label by name in given organization.
+func getLabelInOrgByName(e Engine, orgID int64, labelName string) (*Label, error) {
+	if len(labelName) == 0 || orgID <= 0 {
+		return nil, ErrOrgLabelNotExist{0, orgID}
+	}
+
+	l := &Label{
+		Name:  labelName,
+		OrgID: orgID,
+	}
+	has, err := e.Get(l)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrOrgLabelNotExist{0, l.OrgID}
+	}
+	return l, nil
+}
+
+// getLabelInOrgByID returns a label by ID in given organization.
+func getLabelInOrgByID(e Engine, orgID, labelID int64) (*Label, error) {
+	if labelID <= 0 || orgID <= 0 {
+		return nil, ErrOrgLabelNotExist{labelID, orgID}
+	}
+
+	l := &Label{
+		ID:    labelID,
+		OrgID: orgID,
+	}
+	has, err := e.Get(l)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrOrgLabelNotExist{l.ID, l.OrgID}
+	}
+	return l, nil
+}
+
+// GetLabelInOrgByName returns a label by name in given organization.
+func GetLabelInOrgByName(orgID int64, labelName string) (*Label, error) {
+	return getLabelInOrgByName(x, orgID, labelName)
+}
+
+// GetLabelInOrgByID returns a label by ID in given organization.
+func GetLabelInOrgByID(orgID, labelID int64) (*Label, error) {
+	return getLabelInOrgByID(x, orgID, labelID)
+}
+
+// GetLabelsInOrgByIDs returns a list of labels by IDs in given organization,
+// it silently ignores label IDs that do not belong to the organization.
+func GetLabelsInOrgByIDs(orgID int64, labelIDs []int64) ([]*Label, error) {
+	labels := make([]*Label, 0, len(labelIDs))
+	return labels, x.
+		Where("org_id = ?", orgID).
+		In("id", labelIDs).
+		Asc("name").
+		Find(&labels)
+}
+
+func getLabelsByOrgID(e Engine, orgID int64, sortType string) ([]*Label, error) {
+	if orgID <= 0 {
+		return nil, ErrOrgLabelNotExist{0, orgID}
+	}
+	labels := make([]*Label, 0, 10)
+	sess := e.Where("org_id = ?", orgID)
+
+	switch sortType {
+	case "reversealphabetically":
+		sess.Desc("name")
+	case "leastissues":
+		sess.Asc("num_issues")
+	case "mostissues":
+		sess.Desc("num_issues")
+	default:
+		sess.Asc("name")
+	}
+
+	return labels, sess.Find(&labels)
+}
+
+// GetLabelsByOrgID returns all labels that belong to given organization by ID.
+func GetLabelsByOrgID(orgID int64, sortType string) ([]*Label, error) {
+	return getLabelsByOrgID(x, orgID, sortType)
+}
+
 // GetLabelsByIssueID returns all labels that belong to given issue by ID.
 func GetLabelsByIssueID(issueID int64) ([]*Label, error) {
 	return getLabelsByIssueID(x, issueID)
@@ -367,6 +462,23 @@ func DeleteLabel(repoID, labelID int64) error {
 		return err
 	}
 
+	return deleteLabel(labelID)
+}
+
+// DeleteOrgLabel delete a label of given organization.
+func DeleteOrgLabel(orgID, labelID int64) error {
+	_, err := GetLabelInOrgByID(orgID, labelID)
+	if err != nil {
+		if IsErrOrgLabelNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	return deleteLabel(labelID)
+}
+
+func deleteLabel(labelID int64) (err error) {
 	sess := x.NewSession()
 	defer sess.Close()
 	if err = sess.Begin(); err != nil {
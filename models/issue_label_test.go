@@ -144,6 +144,81 @@ func TestGetLabelsByRepoID(t *testing.T) {
 	testSuccess(1, "default", []int64{1, 2})
 }
 
+func TestGetLabelIDsInRepoByNamesIncludesOrgLabels(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	// repo 3 is owned by org 3
+	labelIDs, err := GetLabelIDsInRepoByNames(3, []string{"orglabel3", "label1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3}, labelIDs)
+
+	labelIDs, err = GetLabelIDsInReposByNames([]int64{1, 3}, []string{"orglabel3", "label1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 3}, labelIDs)
+}
+
+func TestGetLabelInOrgByName(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	label, err := GetLabelInOrgByName(3, "orglabel3")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, label.ID)
+	assert.Equal(t, "orglabel3", label.Name)
+
+	_, err = GetLabelInOrgByName(3, "")
+	assert.True(t, IsErrOrgLabelNotExist(err))
+
+	_, err = GetLabelInOrgByName(0, "orglabel3")
+	assert.True(t, IsErrOrgLabelNotExist(err))
+
+	_, err = GetLabelInOrgByName(NonexistentID, "nonexistent")
+	assert.True(t, IsErrOrgLabelNotExist(err))
+}
+
+func TestGetLabelInOrgByID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	label, err := GetLabelInOrgByID(3, 3)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, label.ID)
+	assert.True(t, label.BelongsToOrg())
+	assert.False(t, label.BelongsToRepo())
+
+	_, err = GetLabelInOrgByID(3, -1)
+	assert.True(t, IsErrOrgLabelNotExist(err))
+
+	// repository label
+	_, err = GetLabelInOrgByID(3, 1)
+	assert.True(t, IsErrOrgLabelNotExist(err))
+
+	_, err = GetLabelInOrgByID(NonexistentID, NonexistentID)
+	assert.True(t, IsErrOrgLabelNotExist(err))
+}
+
+func TestGetLabelsInOrgByIDs(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	labels, err := GetLabelsInOrgByIDs(3, []int64{1, 3, 4, NonexistentID})
+	assert.NoError(t, err)
+	if assert.Len(t, labels, 2) {
+		assert.EqualValues(t, 3, labels[0].ID)
+		assert.EqualValues(t, 4, labels[1].ID)
+	}
+}
+
+func TestGetLabelsByOrgID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	testSuccess := func(orgID int64, sortType string, expectedIssueIDs []int64) {
+		labels, err := GetLabelsByOrgID(orgID, sortType)
+		assert.NoError(t, err)
+		assert.Len(t, labels, len(expectedIssueIDs))
+		for i, label := range labels {
+			assert.EqualValues(t, expectedIssueIDs[i], label.ID)
+		}
+	}
+	testSuccess(3, "reversealphabetically", []int64{4, 3})
+	testSuccess(3, "default", []int64{3, 4})
+
+	_, err := GetLabelsByOrgID(0, "default")
+	assert.True(t, IsErrOrgLabelNotExist(err))
+}
+
 func TestGetLabelsByIssueID(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	labels, err := GetLabelsByIssueID(1)
@@ -181,6 +256,21 @@ func TestDeleteLabel(t *testing.T) {
 	CheckConsistencyFor(t, &Label{}, &Repository{})
 }
 
+func TestDeleteOrgLabel(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	label := AssertExistsAndLoadBean(t, &Label{ID: 3}).(*Label)
+
+	// a repository label can not be deleted as an organization label
+	assert.NoError(t, DeleteOrgLabel(label.OrgID, 1))
+	AssertExistsAndLoadBean(t, &Label{ID: 1})
+
+	assert.NoError(t, DeleteOrgLabel(label.OrgID, label.ID))
+	AssertNotExistsBean(t, &Label{ID: label.ID, OrgID: label.OrgID})
+
+	assert.NoError(t, DeleteOrgLabel(NonexistentID, NonexistentID))
+	CheckConsistencyFor(t, &Label{}, &Repository{})
+}
+
 func TestHasIssueLabel(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	assert.True(t, HasIssueLabel(1, 1))
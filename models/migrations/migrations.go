@@ -290,6 +290,36 @@ var migrations = []Migration{
 	NewMigration("Extend TrackedTimes", extendTrackedTimes),
 	// v117 -> v118
 	NewMigration("Add block on rejected reviews branch protection", addBlockOnRejectedReviews),
+	// v118 -> v119
+	NewMigration("Add push mirror table", addPushMirrorTable),
+	// v119 -> v120
+	NewMigration("Add commit id and dismissed to review", addCommitIDAndDismissedToReview),
+	// v120 -> v121
+	NewMigration("Add review requests of teams to review and comment", addReviewerTeamIDToReviewAndComment),
+	// v121 -> v122
+	NewMigration("Add code owners of pull requests and code owner approval branch protection", addPullRequestCodeOwners),
+	// v122 -> v123
+	NewMigration("Add org_id to label", addOrgIDLabelColumn),
+	// v123 -> v124
+	NewMigration("Add projects and project boards", addProjectsInfo),
+	// v124 -> v125
+	NewMigration("Add delivery attempts to hook_task", addHookTaskAttempts),
+	// v125 -> v126
+	NewMigration("Add granular issue and pull request webhook events", addGranularWebhookEvents),
+	// v126 -> v127
+	NewMigration("Add system webhook column", addSystemWebhookColumn),
+	// v127 -> v128
+	NewMigration("Add protected file patterns and signed commit requirement to protected branches", addProtectedFilesAndSignedCommitsToProtectedBranch),
+	// v128 -> v129
+	NewMigration("Add scopes, restrictions and expiry to access tokens", addScopesToAccessToken),
+	// v129 -> v130
+	NewMigration("Add scope to OAuth2 grants and nonce to authorization codes", addScopeToOAuth2Grant),
+	// v130 -> v131
+	NewMigration("Add WebAuthn credentials and convert U2F registrations", addWebAuthnCredential),
+	// v131 -> v132
+	NewMigration("Add require_two_factor_auth to user", addRequireTwoFactorAuthToUser),
+	// v132 -> v133
+	NewMigration("Leave the units of existing repositories unchanged", addProjectsUnitToRepositories),
 }
 
 // GetCurrentDBVersion returns the current db version
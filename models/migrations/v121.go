@@ -0,0 +1,32 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+)
+
+func addPullRequestCodeOwners(x *xorm.Engine) error {
+	type PullRequestCodeOwner struct {
+		ID      int64    `xorm:"pk autoincr"`
+		IssueID int64    `xorm:"INDEX"`
+		Pattern string   `xorm:"TEXT"`
+		Paths   []string `xorm:"JSON TEXT"`
+		UserIDs []int64  `xorm:"JSON TEXT"`
+		TeamIDs []int64  `xorm:"JSON TEXT"`
+	}
+
+	type ProtectedBranch struct {
+		RequireCodeOwnerApproval bool `xorm:"NOT NULL DEFAULT false"`
+	}
+
+	if err := x.Sync2(new(PullRequestCodeOwner)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	return x.Sync2(new(ProtectedBranch))
+}
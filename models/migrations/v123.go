@@ -0,0 +1,57 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addProjectsInfo(x *xorm.Engine) error {
+	type Project struct {
+		ID          int64  `xorm:"pk autoincr"`
+		Title       string `xorm:"INDEX NOT NULL"`
+		Description string `xorm:"TEXT"`
+		RepoID      int64  `xorm:"INDEX NOT NULL"`
+		CreatorID   int64  `xorm:"NOT NULL"`
+		IsClosed    bool   `xorm:"INDEX"`
+		BoardType   uint8
+
+		CreatedUnix    timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix    timeutil.TimeStamp `xorm:"INDEX updated"`
+		ClosedDateUnix timeutil.TimeStamp
+	}
+
+	type ProjectBoard struct {
+		ID        int64  `xorm:"pk autoincr"`
+		Title     string `xorm:"NOT NULL"`
+		Sorting   int    `xorm:"NOT NULL DEFAULT 0"`
+		ProjectID int64  `xorm:"INDEX NOT NULL"`
+		CreatorID int64  `xorm:"NOT NULL"`
+
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	type ProjectIssue struct {
+		ID             int64 `xorm:"pk autoincr"`
+		IssueID        int64 `xorm:"INDEX"`
+		ProjectID      int64 `xorm:"INDEX"`
+		ProjectBoardID int64 `xorm:"INDEX"`
+	}
+
+	type Repository struct {
+		NumProjects       int `xorm:"NOT NULL DEFAULT 0"`
+		NumClosedProjects int `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	type Comment struct {
+		OldProjectID int64
+		ProjectID    int64
+	}
+
+	return x.Sync2(new(Project), new(ProjectBoard), new(ProjectIssue), new(Repository), new(Comment))
+}
@@ -0,0 +1,26 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addHookTaskAttempts(x *xorm.Engine) error {
+	type HookTask struct {
+		Attempts        int                `xorm:"NOT NULL DEFAULT 0"`
+		NextAttemptUnix timeutil.TimeStamp `xorm:"INDEX NOT NULL DEFAULT 0"`
+	}
+
+	if err := x.Sync2(new(HookTask)); err != nil {
+		return err
+	}
+
+	// tasks delivered before have been attempted exactly once
+	_, err := x.Exec("UPDATE hook_task SET attempts = 1 WHERE is_delivered = ?", true)
+	return err
+}
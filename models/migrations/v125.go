@@ -0,0 +1,72 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"encoding/json"
+
+	"xorm.io/xorm"
+)
+
+func addGranularWebhookEvents(x *xorm.Engine) error {
+	type Webhook struct {
+		ID     int64  `xorm:"pk autoincr"`
+		Events string `xorm:"TEXT"`
+	}
+
+	type HookEvent struct {
+		PushOnly       bool            `json:"push_only"`
+		SendEverything bool            `json:"send_everything"`
+		ChooseEvents   bool            `json:"choose_events"`
+		BranchFilter   string          `json:"branch_filter"`
+		Events         map[string]bool `json:"events"`
+	}
+
+	const batchSize = 100
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	for start := 0; ; start += batchSize {
+		hooks := make([]*Webhook, 0, batchSize)
+		if err := sess.OrderBy("id").Limit(batchSize, start).Find(&hooks); err != nil {
+			return err
+		}
+		if len(hooks) == 0 {
+			break
+		}
+
+		for _, hook := range hooks {
+			var event HookEvent
+			if err := json.Unmarshal([]byte(hook.Events), &event); err != nil || !event.ChooseEvents {
+				continue
+			}
+			if event.Events == nil {
+				event.Events = make(map[string]bool)
+			}
+
+			// the changes have been sent as issues and pull_request events before
+			for _, name := range []string{"issue_assign", "issue_label", "issue_milestone"} {
+				event.Events[name] = event.Events["issues"]
+			}
+			for _, name := range []string{"pull_request_assign", "pull_request_label", "pull_request_milestone", "pull_request_sync"} {
+				event.Events[name] = event.Events["pull_request"]
+			}
+
+			data, err := json.Marshal(&event)
+			if err != nil {
+				return err
+			}
+			hook.Events = string(data)
+			if _, err := sess.ID(hook.ID).Cols("events").Update(hook); err != nil {
+				return err
+			}
+		}
+	}
+
+	return sess.Commit()
+}
@@ -0,0 +1,30 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addScopesToAccessToken(x *xorm.Engine) error {
+	type AccessToken struct {
+		Scope       string
+		RepoIDs     []int64            `xorm:"JSON TEXT"`
+		OrgIDs      []int64            `xorm:"JSON TEXT"`
+		ExpiresUnix timeutil.TimeStamp `xorm:"INDEX"`
+	}
+
+	if err := x.Sync2(new(AccessToken)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	// existing tokens keep the full access they had before
+	_, err := x.Exec("UPDATE access_token SET scope = ? WHERE scope IS NULL OR scope = ?", "all", "")
+	return err
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+)
+
+// OAuth2Grant here is a snapshot of the scope column added to models.OAuth2Grant
+type OAuth2Grant struct {
+	ID    int64  `xorm:"pk autoincr"`
+	Scope string `xorm:"TEXT"`
+}
+
+// TableName sets the table name to `oauth2_grant`, the generated name would be `o_auth2_grant`
+func (grant *OAuth2Grant) TableName() string {
+	return "oauth2_grant"
+}
+
+// OAuth2AuthorizationCode here is a snapshot of the nonce column added to models.OAuth2AuthorizationCode
+type OAuth2AuthorizationCode struct {
+	ID    int64  `xorm:"pk autoincr"`
+	Nonce string `xorm:"TEXT"`
+}
+
+// TableName sets the table name to `oauth2_authorization_code`, the generated name would be `o_auth2_authorization_code`
+func (code *OAuth2AuthorizationCode) TableName() string {
+	return "oauth2_authorization_code"
+}
+
+func addScopeToOAuth2Grant(x *xorm.Engine) error {
+	if err := x.Sync2(new(OAuth2Grant), new(OAuth2AuthorizationCode)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	// existing grants keep the full access they had before
+	_, err := x.Exec("UPDATE oauth2_grant SET scope = ? WHERE scope IS NULL OR scope = ?", "all", "")
+	return err
+}
@@ -0,0 +1,152 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"crypto/elliptic"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/duo-labs/webauthn/protocol/webauthncose"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/tstranex/u2f"
+	"xorm.io/xorm"
+)
+
+// WebAuthnCredential here is a snapshot of models.WebAuthnCredential
+type WebAuthnCredential struct {
+	ID              int64 `xorm:"pk autoincr"`
+	Name            string
+	LowerName       string `xorm:"unique(s)"`
+	UserID          int64  `xorm:"INDEX unique(s)"`
+	CredentialID    string `xorm:"INDEX VARCHAR(410)"`
+	PublicKey       []byte
+	AttestationType string
+	AAGUID          []byte
+	SignCount       uint32 `xorm:"BIGINT"`
+	CloneWarning    bool
+	CreatedUnix     timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix     timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// TableName sets the table name to `webauthn_credential`, the generated name would be `web_authn_credential`
+func (cred *WebAuthnCredential) TableName() string {
+	return "webauthn_credential"
+}
+
+// U2FRegistration here is a snapshot of the removed models.U2FRegistration
+type U2FRegistration struct {
+	ID          int64 `xorm:"pk autoincr"`
+	Name        string
+	UserID      int64 `xorm:"INDEX"`
+	Raw         []byte
+	Counter     uint32             `xorm:"BIGINT"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// TableName sets the table name to `u2f_registration`, the generated name would be `u2_f_registration`
+func (reg *U2FRegistration) TableName() string {
+	return "u2f_registration"
+}
+
+// u2fPublicKeyToCOSE encodes the P-256 public key of a U2F registration as COSE key,
+// which is the format WebAuthn uses for credential public keys.
+func u2fPublicKeyToCOSE(reg *u2f.Registration) ([]byte, error) {
+	size := (elliptic.P256().Params().BitSize + 7) / 8
+	pad := func(b []byte) []byte {
+		padded := make([]byte, size)
+		copy(padded[size-len(b):], b)
+		return padded
+	}
+	return cbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1, // P-256
+		XCoord: pad(reg.PubKey.X.Bytes()),
+		YCoord: pad(reg.PubKey.Y.Bytes()),
+	})
+}
+
+func addWebAuthnCredential(x *xorm.Engine) error {
+	if err := x.Sync2(new(WebAuthnCredential)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	exist, err := x.IsTableExist(new(U2FRegistration))
+	if err != nil {
+		return err
+	} else if !exist {
+		return nil
+	}
+
+	// The registrations are converted to credentials which keep working with
+	// the appid extension, the u2f_registration table itself is left untouched.
+	const batchSize = 50
+	for start := 0; ; start += batchSize {
+		regs := make([]*U2FRegistration, 0, batchSize)
+		if err := x.OrderBy("id").Limit(batchSize, start).Find(&regs); err != nil {
+			return err
+		}
+
+		sess := x.NewSession()
+		if err := sess.Begin(); err != nil {
+			sess.Close()
+			return err
+		}
+		for _, reg := range regs {
+			parsed := new(u2f.Registration)
+			if err := parsed.UnmarshalBinary(reg.Raw); err != nil {
+				log.Warn("Unable to parse U2F registration %d of user %d, skipping: %v", reg.ID, reg.UserID, err)
+				continue
+			}
+			publicKey, err := u2fPublicKeyToCOSE(parsed)
+			if err != nil {
+				sess.Close()
+				return err
+			}
+
+			name := reg.Name
+			if has, err := sess.Exist(&WebAuthnCredential{UserID: reg.UserID, LowerName: strings.ToLower(name)}); err != nil {
+				sess.Close()
+				return err
+			} else if has {
+				name = fmt.Sprintf("%s (%d)", reg.Name, reg.ID)
+			}
+
+			cred := &WebAuthnCredential{
+				Name:            name,
+				LowerName:       strings.ToLower(name),
+				UserID:          reg.UserID,
+				CredentialID:    base64.RawURLEncoding.EncodeToString(parsed.KeyHandle),
+				PublicKey:       publicKey,
+				AttestationType: "fido-u2f",
+				AAGUID:          []byte{},
+				SignCount:       reg.Counter,
+				CreatedUnix:     reg.CreatedUnix,
+				UpdatedUnix:     reg.UpdatedUnix,
+			}
+			if _, err := sess.NoAutoTime().Insert(cred); err != nil {
+				sess.Close()
+				return err
+			}
+		}
+		if err := sess.Commit(); err != nil {
+			sess.Close()
+			return err
+		}
+		sess.Close()
+
+		if len(regs) < batchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,112 @@
+//go:build sqlite
+// +build sqlite
+
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/duo-labs/webauthn/protocol/webauthncose"
+	"github.com/stretchr/testify/assert"
+	"xorm.io/core"
+	"xorm.io/xorm"
+)
+
+// u2fRegistrationData builds the raw registration data a U2F token sends, which is
+// what the u2f_registration table stored.
+func u2fRegistrationData(t *testing.T, key *ecdsa.PrivateKey, keyHandle []byte) []byte {
+	certKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "U2F attestation"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &certKey.PublicKey, certKey)
+	assert.NoError(t, err)
+
+	data := []byte{0x05}
+	data = append(data, elliptic.Marshal(elliptic.P256(), key.X, key.Y)...)
+	data = append(data, byte(len(keyHandle)))
+	data = append(data, keyHandle...)
+	data = append(data, cert...)
+	// the signature of the registration is not checked by the conversion
+	return append(data, 0x30, 0x00)
+}
+
+func TestAddWebAuthnCredential(t *testing.T) {
+	x, err := xorm.NewEngine("sqlite3", "file::memory:?cache=shared")
+	assert.NoError(t, err)
+	defer x.Close()
+	x.SetMapper(core.GonicMapper{})
+
+	assert.NoError(t, x.Sync2(new(U2FRegistration)))
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	keyHandle := []byte("key handle of the token")
+	_, err = x.Insert(&U2FRegistration{
+		Name:    "Token",
+		UserID:  1,
+		Raw:     u2fRegistrationData(t, key, keyHandle),
+		Counter: 7,
+	}, &U2FRegistration{
+		Name:    "token",
+		UserID:  1,
+		Raw:     u2fRegistrationData(t, key, []byte("another key handle")),
+		Counter: 1,
+	}, &U2FRegistration{
+		Name:   "broken",
+		UserID: 2,
+		Raw:    []byte("not a registration"),
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, addWebAuthnCredential(x))
+
+	var creds []*WebAuthnCredential
+	assert.NoError(t, x.OrderBy("id").Find(&creds))
+	if !assert.Len(t, creds, 2) {
+		return
+	}
+	cred := creds[0]
+	assert.Equal(t, "Token", cred.Name)
+	assert.EqualValues(t, 1, cred.UserID)
+	assert.EqualValues(t, 7, cred.SignCount)
+	assert.Equal(t, "fido-u2f", cred.AttestationType)
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(keyHandle), cred.CredentialID)
+	// the names are unique per user regardless of case
+	assert.Equal(t, "token (2)", creds[1].Name)
+
+	// signatures of the token can be verified with the converted public key
+	parsed, err := webauthncose.ParsePublicKey(cred.PublicKey)
+	assert.NoError(t, err)
+	publicKey, ok := parsed.(webauthncose.EC2PublicKeyData)
+	if !assert.True(t, ok) {
+		return
+	}
+	data := []byte("signed data")
+	hash := sha256.Sum256(data)
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	assert.NoError(t, err)
+	sig, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	assert.NoError(t, err)
+	valid, err := publicKey.Verify(data, sig)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
@@ -0,0 +1,16 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"xorm.io/xorm"
+)
+
+func addProjectsUnitToRepositories(x *xorm.Engine) error {
+	// Only new repositories get the projects unit by default, the units of existing
+	// repositories are up to their owners. This migration is kept as a no-op so that
+	// the version of the databases it has already been applied to stays valid.
+	return nil
+}
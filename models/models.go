@@ -113,13 +113,18 @@ func init() {
 		new(LFSLock),
 		new(Reaction),
 		new(IssueAssignees),
-		new(U2FRegistration),
+		new(WebAuthnCredential),
 		new(TeamUnit),
 		new(Review),
 		new(OAuth2Application),
 		new(OAuth2AuthorizationCode),
 		new(OAuth2Grant),
 		new(Task),
+		new(PushMirror),
+		new(PullRequestCodeOwner),
+		new(Project),
+		new(ProjectBoard),
+		new(ProjectIssue),
 	)
 
 	gonicNames := []string{"SSL", "UID"}
@@ -247,6 +252,19 @@ func DumpDatabase(filePath string, dbType string) error {
 		t.Table.Name = t.Name
 		tbs = append(tbs, t.Table)
 	}
+
+	// The version table is created by the migrations, it is dumped as well
+	// so that a restored database is migrated from the version of the dump.
+	metas, err := x.DBMetas()
+	if err != nil {
+		return err
+	}
+	for _, t := range metas {
+		if t.Name == "version" {
+			tbs = append(tbs, t)
+		}
+	}
+
 	if len(dbType) > 0 {
 		return x.DumpTablesToFile(tbs, filePath, core.DbType(dbType))
 	}
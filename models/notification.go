@@ -6,9 +6,15 @@ package models
 
 import (
 	"fmt"
+	"path"
 
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
 )
 
 type (
@@ -59,39 +65,120 @@ type Notification struct {
 	UpdatedUnix timeutil.TimeStamp `xorm:"updated INDEX NOT NULL"`
 }
 
+// FindNotificationOptions represent the filters for notifications. If an ID is 0 it will be ignored.
+type FindNotificationOptions struct {
+	UserID            int64
+	RepoID            int64
+	IssueID           int64
+	Status            []NotificationStatus
+	Source            []NotificationSource
+	UpdatedAfterUnix  int64
+	UpdatedBeforeUnix int64
+	Page              int
+	PageSize          int
+}
+
+// ToCond will convert each condition into a xorm-Cond
+func (opts *FindNotificationOptions) ToCond() builder.Cond {
+	cond := builder.NewCond()
+	if opts.UserID != 0 {
+		cond = cond.And(builder.Eq{"notification.user_id": opts.UserID})
+	}
+	if opts.RepoID != 0 {
+		cond = cond.And(builder.Eq{"notification.repo_id": opts.RepoID})
+	}
+	if opts.IssueID != 0 {
+		cond = cond.And(builder.Eq{"notification.issue_id": opts.IssueID})
+	}
+	if len(opts.Status) > 0 {
+		cond = cond.And(builder.In("notification.status", opts.Status))
+	}
+	if len(opts.Source) > 0 {
+		cond = cond.And(builder.In("notification.source", opts.Source))
+	}
+	if opts.UpdatedAfterUnix != 0 {
+		cond = cond.And(builder.Gte{"notification.updated_unix": opts.UpdatedAfterUnix})
+	}
+	if opts.UpdatedBeforeUnix != 0 {
+		cond = cond.And(builder.Lte{"notification.updated_unix": opts.UpdatedBeforeUnix})
+	}
+	return cond
+}
+
+// ToSession will convert the given options to a xorm Session by using the conditions from ToCond and applying the pagination
+func (opts *FindNotificationOptions) ToSession(e Engine) *xorm.Session {
+	sess := e.Where(opts.ToCond())
+	if opts.Page > 0 && opts.PageSize > 0 {
+		sess.Limit(opts.PageSize, (opts.Page-1)*opts.PageSize)
+	}
+	return sess
+}
+
+func getNotifications(e Engine, opts FindNotificationOptions) (nl NotificationList, err error) {
+	err = opts.ToSession(e).OrderBy("notification.updated_unix DESC").Find(&nl)
+	return
+}
+
+// GetNotifications returns all notifications that fit to the given options.
+func GetNotifications(opts FindNotificationOptions) (NotificationList, error) {
+	return getNotifications(x, opts)
+}
+
+// UpdateNotificationStatusesByOptions sets the status of all the notifications that fit to the given options.
+// The pagination of the options is ignored.
+func UpdateNotificationStatusesByOptions(opts FindNotificationOptions, status NotificationStatus) error {
+	n := &Notification{Status: status, UpdatedBy: opts.UserID}
+	_, err := x.
+		Where(opts.ToCond()).
+		Cols("status", "updated_by", "updated_unix").
+		Update(n)
+	return err
+}
+
 // CreateOrUpdateIssueNotifications creates an issue notification
-// for each watcher, or updates it if already exists
-func CreateOrUpdateIssueNotifications(issueID, commentID int64, notificationAuthorID int64) error {
+// for each watcher, or updates it if already exists.
+// If receiverID is set only the receiver is notified.
+func CreateOrUpdateIssueNotifications(issueID, commentID, notificationAuthorID, receiverID int64) error {
 	sess := x.NewSession()
 	defer sess.Close()
 	if err := sess.Begin(); err != nil {
 		return err
 	}
 
-	if err := createOrUpdateIssueNotifications(sess, issueID, commentID, notificationAuthorID); err != nil {
+	if err := createOrUpdateIssueNotifications(sess, issueID, commentID, notificationAuthorID, receiverID); err != nil {
 		return err
 	}
 
 	return sess.Commit()
 }
 
-func createOrUpdateIssueNotifications(e Engine, issueID, commentID int64, notificationAuthorID int64) error {
-	issueWatches, err := getIssueWatchers(e, issueID)
+func createOrUpdateIssueNotifications(e Engine, issueID, commentID, notificationAuthorID, receiverID int64) error {
+	issue, err := getIssueByID(e, issueID)
 	if err != nil {
 		return err
 	}
 
-	issue, err := getIssueByID(e, issueID)
+	notifications, err := getNotificationsByIssueID(e, issueID)
 	if err != nil {
 		return err
 	}
 
-	watches, err := getWatchers(e, issue.RepoID)
+	if receiverID > 0 {
+		if receiverID == notificationAuthorID {
+			return nil
+		}
+		if notificationExists(notifications, issue.ID, receiverID) {
+			return updateIssueNotification(e, receiverID, issue.ID, commentID, notificationAuthorID)
+		}
+		return createIssueNotification(e, receiverID, issue, commentID, notificationAuthorID)
+	}
+
+	issueWatches, err := getIssueWatchers(e, issueID)
 	if err != nil {
 		return err
 	}
 
-	notifications, err := getNotificationsByIssueID(e, issueID)
+	watches, err := getWatchers(e, issue.RepoID)
 	if err != nil {
 		return err
 	}
@@ -257,6 +344,35 @@ func (n *Notification) GetIssue() (*Issue, error) {
 	return n.Issue, err
 }
 
+// LoadAttributes loads the repository, the issue and the comment of the notification
+func (n *Notification) LoadAttributes() error {
+	return n.loadAttributes(x)
+}
+
+func (n *Notification) loadAttributes(e Engine) (err error) {
+	if n.Repository == nil {
+		if n.Repository, err = getRepositoryByID(e, n.RepoID); err != nil {
+			return fmt.Errorf("getRepositoryByID [%d]: %v", n.RepoID, err)
+		}
+	}
+	if err = n.Repository.getOwner(e); err != nil {
+		return fmt.Errorf("getOwner [%d]: %v", n.Repository.OwnerID, err)
+	}
+	if n.Issue == nil && n.IssueID != 0 {
+		if n.Issue, err = getIssueByID(e, n.IssueID); err != nil {
+			return fmt.Errorf("getIssueByID [%d]: %v", n.IssueID, err)
+		}
+		n.Issue.Repo = n.Repository
+	}
+	if n.Comment == nil && n.CommentID != 0 {
+		if n.Comment, err = getCommentByID(e, n.CommentID); err != nil {
+			return fmt.Errorf("getCommentByID [%d]: %v", n.CommentID, err)
+		}
+		n.Comment.Issue = n.Issue
+	}
+	return nil
+}
+
 // HTMLURL formats a URL-string to the notification
 func (n *Notification) HTMLURL() string {
 	if n.Comment != nil {
@@ -265,9 +381,94 @@ func (n *Notification) HTMLURL() string {
 	return n.Issue.HTMLURL()
 }
 
+// APIURL formats a URL-string to the notification thread in the API
+func (n *Notification) APIURL() string {
+	return setting.AppURL + path.Join("api/v1/notifications/threads", fmt.Sprint(n.ID))
+}
+
+// APIFormat converts a Notification to api.NotificationThread,
+// the repository, issue and comment have to be loaded before
+func (n *Notification) APIFormat() *api.NotificationThread {
+	result := &api.NotificationThread{
+		ID:        n.ID,
+		Unread:    n.Status == NotificationStatusUnread,
+		Pinned:    n.Status == NotificationStatusPinned,
+		UpdatedAt: n.UpdatedUnix.AsTime(),
+		URL:       n.APIURL(),
+	}
+
+	// the user only gets notifications of the repositories they can read
+	if n.Repository != nil {
+		result.Repository = n.Repository.APIFormat(AccessModeRead)
+	}
+
+	switch n.Source {
+	case NotificationSourceIssue, NotificationSourcePullRequest:
+		result.Subject = &api.NotificationSubject{Type: "Issue"}
+		if n.Source == NotificationSourcePullRequest {
+			result.Subject.Type = "Pull"
+		}
+		if n.Issue != nil && n.Issue.Repo != nil {
+			result.Subject.Title = n.Issue.Title
+			if n.Issue.IsPull {
+				result.Subject.URL = n.Issue.Repo.APIURL() + "/" + path.Join("pulls", fmt.Sprint(n.Issue.Index))
+			} else {
+				result.Subject.URL = n.Issue.APIURL()
+			}
+			if n.Comment != nil {
+				result.Subject.LatestCommentURL = n.Issue.Repo.APIURL() + "/" + path.Join("issues/comments", fmt.Sprint(n.Comment.ID))
+			}
+		}
+	case NotificationSourceCommit:
+		result.Subject = &api.NotificationSubject{
+			Type:  "Commit",
+			Title: n.CommitID,
+		}
+		if n.Repository != nil {
+			result.Subject.URL = n.Repository.APIURL() + "/" + path.Join("git/commits", n.CommitID)
+		}
+	}
+
+	return result
+}
+
 // NotificationList contains a list of notifications
 type NotificationList []*Notification
 
+// LoadAttributes loads the repositories, issues and comments of the notifications,
+// the notifications which miss one of them are removed from the returned list
+func (nl NotificationList) LoadAttributes() (NotificationList, error) {
+	repos, failures, err := nl.LoadRepos()
+	if err != nil {
+		return nil, err
+	}
+	nl = nl.Without(failures)
+	if err := repos.LoadAttributes(); err != nil {
+		return nil, err
+	}
+
+	failures, err = nl.LoadIssues()
+	if err != nil {
+		return nil, err
+	}
+	nl = nl.Without(failures)
+
+	failures, err = nl.LoadComments()
+	if err != nil {
+		return nil, err
+	}
+	return nl.Without(failures), nil
+}
+
+// APIFormat converts a NotificationList to api.NotificationThread list
+func (nl NotificationList) APIFormat() []*api.NotificationThread {
+	result := make([]*api.NotificationThread, 0, len(nl))
+	for _, n := range nl {
+		result = append(result, n.APIFormat())
+	}
+	return result
+}
+
 func (nl NotificationList) getPendingRepoIDs() []int64 {
 	var ids = make(map[int64]struct{}, len(nl))
 	for _, notification := range nl {
@@ -524,25 +725,30 @@ func setNotificationStatusReadIfUnread(e Engine, userID, issueID int64) error {
 }
 
 // SetNotificationStatus change the notification status
-func SetNotificationStatus(notificationID int64, user *User, status NotificationStatus) error {
-	notification, err := getNotificationByID(notificationID)
+func SetNotificationStatus(notificationID int64, user *User, status NotificationStatus) (*Notification, error) {
+	notification, err := getNotificationByID(x, notificationID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if notification.UserID != user.ID {
-		return fmt.Errorf("Can't change notification of another user: %d, %d", notification.UserID, user.ID)
+		return nil, fmt.Errorf("Can't change notification of another user: %d, %d", notification.UserID, user.ID)
 	}
 
 	notification.Status = status
 
 	_, err = x.ID(notificationID).Update(notification)
-	return err
+	return notification, err
+}
+
+// GetNotificationByID return notification by ID
+func GetNotificationByID(notificationID int64) (*Notification, error) {
+	return getNotificationByID(x, notificationID)
 }
 
-func getNotificationByID(notificationID int64) (*Notification, error) {
+func getNotificationByID(e Engine, notificationID int64) (*Notification, error) {
 	notification := new(Notification)
-	ok, err := x.
+	ok, err := e.
 		Where("id = ?", notificationID).
 		Get(notification)
 
@@ -551,7 +757,7 @@ func getNotificationByID(notificationID int64) (*Notification, error) {
 	}
 
 	if !ok {
-		return nil, fmt.Errorf("Notification %d does not exists", notificationID)
+		return nil, ErrNotExist{ID: notificationID}
 	}
 
 	return notification, nil
@@ -7,6 +7,8 @@ package models
 import (
 	"testing"
 
+	"code.gitea.io/gitea/modules/setting"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -14,7 +16,7 @@ func TestCreateOrUpdateIssueNotifications(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	issue := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
 
-	assert.NoError(t, CreateOrUpdateIssueNotifications(issue.ID, 0, 2))
+	assert.NoError(t, CreateOrUpdateIssueNotifications(issue.ID, 0, 2, 0))
 
 	// User 9 is inactive, thus notifications for user 1 and 4 are created
 	notf := AssertExistsAndLoadBean(t, &Notification{UserID: 1, IssueID: issue.ID}).(*Notification)
@@ -74,12 +76,81 @@ func TestSetNotificationStatus(t *testing.T) {
 	user := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
 	notf := AssertExistsAndLoadBean(t,
 		&Notification{UserID: user.ID, Status: NotificationStatusRead}).(*Notification)
-	assert.NoError(t, SetNotificationStatus(notf.ID, user, NotificationStatusPinned))
+	n, err := SetNotificationStatus(notf.ID, user, NotificationStatusPinned)
+	assert.NoError(t, err)
+	assert.Equal(t, NotificationStatusPinned, n.Status)
 	AssertExistsAndLoadBean(t,
 		&Notification{ID: notf.ID, Status: NotificationStatusPinned})
 
-	assert.Error(t, SetNotificationStatus(1, user, NotificationStatusRead))
-	assert.Error(t, SetNotificationStatus(NonexistentID, user, NotificationStatusRead))
+	_, err = SetNotificationStatus(1, user, NotificationStatusRead)
+	assert.Error(t, err)
+	_, err = SetNotificationStatus(NonexistentID, user, NotificationStatusRead)
+	assert.True(t, IsErrNotExist(err))
+}
+
+func TestGetNotifications(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	nl, err := GetNotifications(FindNotificationOptions{UserID: 2})
+	assert.NoError(t, err)
+	assert.Len(t, nl, 3)
+
+	nl, err = GetNotifications(FindNotificationOptions{
+		UserID: 2,
+		Status: []NotificationStatus{NotificationStatusUnread, NotificationStatusPinned},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, nl, 2) {
+		for _, n := range nl {
+			assert.NotEqual(t, NotificationStatusRead, n.Status)
+		}
+	}
+
+	nl, err = GetNotifications(FindNotificationOptions{UserID: 2, Source: []NotificationSource{NotificationSourcePullRequest}})
+	assert.NoError(t, err)
+	assert.Len(t, nl, 0)
+
+	nl, err = GetNotifications(FindNotificationOptions{UserID: 2, UpdatedAfterUnix: 946684801})
+	assert.NoError(t, err)
+	assert.Len(t, nl, 0)
+
+	nl, err = GetNotifications(FindNotificationOptions{UserID: 2, Page: 2, PageSize: 2})
+	assert.NoError(t, err)
+	assert.Len(t, nl, 1)
+}
+
+func TestNotification_APIFormat(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	n := AssertExistsAndLoadBean(t, &Notification{ID: 4}).(*Notification)
+	assert.NoError(t, n.LoadAttributes())
+
+	thread := n.APIFormat()
+	assert.EqualValues(t, 4, thread.ID)
+	assert.True(t, thread.Unread)
+	assert.False(t, thread.Pinned)
+	assert.Equal(t, setting.AppURL+"api/v1/notifications/threads/4", thread.URL)
+	if assert.NotNil(t, thread.Repository) {
+		assert.Equal(t, "user2/repo1", thread.Repository.FullName)
+	}
+	if assert.NotNil(t, thread.Subject) {
+		assert.Equal(t, "Issue", thread.Subject.Type)
+		assert.Equal(t, n.Issue.Title, thread.Subject.Title)
+		assert.Equal(t, setting.AppURL+"api/v1/repos/user2/repo1/pulls/2", thread.Subject.URL)
+	}
+}
+
+func TestUpdateNotificationStatusesByOptions(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, UpdateNotificationStatusesByOptions(FindNotificationOptions{
+		UserID: 2,
+		Status: []NotificationStatus{NotificationStatusUnread},
+	}, NotificationStatusRead))
+	AssertExistsAndLoadBean(t, &Notification{ID: 4, Status: NotificationStatusRead})
+	AssertExistsAndLoadBean(t, &Notification{ID: 3, Status: NotificationStatusPinned})
+	// the notifications of other users are not changed
+	AssertExistsAndLoadBean(t, &Notification{ID: 1, Status: NotificationStatusUnread})
 }
 
 func TestUpdateNotificationStatuses(t *testing.T) {
@@ -9,8 +9,11 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net/url"
+	"sort"
+	"strings"
 	"time"
 
+	"code.gitea.io/gitea/modules/auth/oauth2"
 	"code.gitea.io/gitea/modules/secret"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
@@ -102,15 +105,16 @@ func (app *OAuth2Application) getGrantByUserID(e Engine, userID int64) (grant *O
 	return grant, nil
 }
 
-// CreateGrant generates a grant for an user
-func (app *OAuth2Application) CreateGrant(userID int64) (*OAuth2Grant, error) {
-	return app.createGrant(x, userID)
+// CreateGrant generates a grant for an user with the given normalized scope
+func (app *OAuth2Application) CreateGrant(userID int64, scope string) (*OAuth2Grant, error) {
+	return app.createGrant(x, userID, scope)
 }
 
-func (app *OAuth2Application) createGrant(e Engine, userID int64) (*OAuth2Grant, error) {
+func (app *OAuth2Application) createGrant(e Engine, userID int64, scope string) (*OAuth2Grant, error) {
 	grant := &OAuth2Grant{
 		ApplicationID: app.ID,
 		UserID:        userID,
+		Scope:         scope,
 	}
 	_, err := e.Insert(grant)
 	if err != nil {
@@ -263,6 +267,7 @@ type OAuth2AuthorizationCode struct {
 	CodeChallenge       string
 	CodeChallengeMethod string
 	RedirectURI         string
+	Nonce               string             `xorm:"TEXT"`
 	ValidUntil          timeutil.TimeStamp `xorm:"index"`
 }
 
@@ -347,6 +352,7 @@ type OAuth2Grant struct {
 	Application   *OAuth2Application `xorm:"-"`
 	ApplicationID int64              `xorm:"INDEX unique(user_application)"`
 	Counter       int64              `xorm:"NOT NULL DEFAULT 1"`
+	Scope         string             `xorm:"TEXT"`
 	CreatedUnix   timeutil.TimeStamp `xorm:"created"`
 	UpdatedUnix   timeutil.TimeStamp `xorm:"updated"`
 }
@@ -357,11 +363,11 @@ func (grant *OAuth2Grant) TableName() string {
 }
 
 // GenerateNewAuthorizationCode generates a new authorization code for a grant and saves it to the databse
-func (grant *OAuth2Grant) GenerateNewAuthorizationCode(redirectURI, codeChallenge, codeChallengeMethod string) (*OAuth2AuthorizationCode, error) {
-	return grant.generateNewAuthorizationCode(x, redirectURI, codeChallenge, codeChallengeMethod)
+func (grant *OAuth2Grant) GenerateNewAuthorizationCode(redirectURI, codeChallenge, codeChallengeMethod, nonce string) (*OAuth2AuthorizationCode, error) {
+	return grant.generateNewAuthorizationCode(x, redirectURI, codeChallenge, codeChallengeMethod, nonce)
 }
 
-func (grant *OAuth2Grant) generateNewAuthorizationCode(e Engine, redirectURI, codeChallenge, codeChallengeMethod string) (code *OAuth2AuthorizationCode, err error) {
+func (grant *OAuth2Grant) generateNewAuthorizationCode(e Engine, redirectURI, codeChallenge, codeChallengeMethod, nonce string) (code *OAuth2AuthorizationCode, err error) {
 	var codeSecret string
 	if codeSecret, err = secret.New(); err != nil {
 		return &OAuth2AuthorizationCode{}, err
@@ -373,6 +379,7 @@ func (grant *OAuth2Grant) generateNewAuthorizationCode(e Engine, redirectURI, co
 		Code:                codeSecret,
 		CodeChallenge:       codeChallenge,
 		CodeChallengeMethod: codeChallengeMethod,
+		Nonce:               nonce,
 	}
 	if _, err := e.Insert(code); err != nil {
 		return nil, err
@@ -398,6 +405,72 @@ func (grant *OAuth2Grant) increaseCount(e Engine) error {
 	return nil
 }
 
+// Scopes returns the list of OAuth2 scopes of the grant
+func (grant *OAuth2Grant) Scopes() []string {
+	return strings.Fields(grant.Scope)
+}
+
+// ScopeContains returns true if the given OAuth2 scope was explicitly granted
+func (grant *OAuth2Grant) ScopeContains(scope string) bool {
+	for _, s := range grant.Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope returns true if all scopes of the given normalized scope are covered by the grant
+func (grant *OAuth2Grant) HasScope(scope string) bool {
+	apiScope := grant.AccessTokenScope()
+	for _, s := range strings.Fields(scope) {
+		if grant.ScopeContains(s) {
+			continue
+		}
+		if category, level, ok := parseScope(s); ok && apiScope.HasScope(category, level) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// AccessTokenScope returns the API permissions of the grant. The scope is empty
+// if the grant only contains OpenID Connect scopes.
+func (grant *OAuth2Grant) AccessTokenScope() AccessTokenScope {
+	scopes := make([]string, 0, 2)
+	for _, s := range grant.Scopes() {
+		if !isOIDCScope(s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return AccessTokenScopeFromList(scopes)
+}
+
+// ToAccessToken returns an unsaved access token of the user of the grant with the
+// API permissions of the grant, so the checks of access tokens apply to OAuth2 tokens as well.
+func (grant *OAuth2Grant) ToAccessToken() *AccessToken {
+	return &AccessToken{
+		UID:   grant.UserID,
+		Scope: grant.AccessTokenScope(),
+	}
+}
+
+// AddScope adds the scopes of the given normalized scope to the grant
+func (grant *OAuth2Grant) AddScope(scope string) error {
+	return grant.addScope(x, scope)
+}
+
+func (grant *OAuth2Grant) addScope(e Engine, scope string) error {
+	newScope, err := NormalizeOAuth2Scope(grant.Scope + " " + scope)
+	if err != nil {
+		return err
+	}
+	grant.Scope = newScope
+	_, err = e.ID(grant.ID).Cols("scope").Update(grant)
+	return err
+}
+
 // GetOAuth2GrantByID returns the grant with the given ID
 func GetOAuth2GrantByID(id int64) (*OAuth2Grant, error) {
 	return getOAuth2GrantByID(x, id)
@@ -445,18 +518,70 @@ func getOAuth2GrantsByUserID(e Engine, uid int64) ([]*OAuth2Grant, error) {
 	return grants, nil
 }
 
-// RevokeOAuth2Grant deletes the grant with grantID and userID
+// RevokeOAuth2Grant deletes the grant with grantID and userID and the authorization codes issued for it
 func RevokeOAuth2Grant(grantID, userID int64) error {
-	return revokeOAuth2Grant(x, grantID, userID)
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+	if err := revokeOAuth2Grant(sess, grantID, userID); err != nil {
+		return err
+	}
+	return sess.Commit()
 }
 
 func revokeOAuth2Grant(e Engine, grantID, userID int64) error {
-	_, err := e.Delete(&OAuth2Grant{ID: grantID, UserID: userID})
+	deleted, err := e.Delete(&OAuth2Grant{ID: grantID, UserID: userID})
+	if err != nil || deleted == 0 {
+		return err
+	}
+	_, err = e.Where("grant_id = ?", grantID).Delete(new(OAuth2AuthorizationCode))
 	return err
 }
 
 //////////////////////////////////////////////////////////////
 
+// OAuth2 scopes defined by OpenID Connect
+const (
+	OAuth2ScopeOpenID  = "openid"
+	OAuth2ScopeProfile = "profile"
+	OAuth2ScopeEmail   = "email"
+)
+
+func isOIDCScope(scope string) bool {
+	return scope == OAuth2ScopeOpenID || scope == OAuth2ScopeProfile || scope == OAuth2ScopeEmail
+}
+
+// NormalizeOAuth2Scope validates a space separated list of OAuth2 scopes and returns it
+// sorted and without duplicates. Besides the OpenID Connect scopes, a scope is either
+// "all" or of the form "<level>:<category>" like the scopes of access tokens.
+// An empty scope grants full access for compatibility with clients not requesting scopes.
+func NormalizeOAuth2Scope(scope string) (string, error) {
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		return string(AccessTokenScopeAll), nil
+	}
+
+	seen := make(map[string]bool, len(scopes))
+	normalized := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		if !isOIDCScope(s) && AccessTokenScope(s) != AccessTokenScopeAll {
+			if _, _, ok := parseScope(s); !ok {
+				return "", ErrInvalidAccessTokenScope{s}
+			}
+		}
+		if !seen[s] {
+			seen[s] = true
+			normalized = append(normalized, s)
+		}
+	}
+	sort.Strings(normalized)
+	return strings.Join(normalized, " "), nil
+}
+
+//////////////////////////////////////////////////////////////
+
 // OAuth2TokenType represents the type of token for an oauth application
 type OAuth2TokenType int
 
@@ -500,3 +625,28 @@ func (token *OAuth2Token) SignToken() (string, error) {
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS512, token)
 	return jwtToken.SignedString(setting.OAuth2.JWTSecretBytes)
 }
+
+// OIDCToken represents an OpenID Connect ID token
+type OIDCToken struct {
+	jwt.StandardClaims
+	Nonce string `json:"nonce,omitempty"`
+
+	// Scope profile
+	Name              string             `json:"name,omitempty"`
+	PreferredUsername string             `json:"preferred_username,omitempty"`
+	Profile           string             `json:"profile,omitempty"`
+	Picture           string             `json:"picture,omitempty"`
+	Website           string             `json:"website,omitempty"`
+	Locale            string             `json:"locale,omitempty"`
+	UpdatedAt         timeutil.TimeStamp `json:"updated_at,omitempty"`
+
+	// Scope email
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified,omitempty"`
+}
+
+// SignToken signs an ID token with the given signing key
+func (token *OIDCToken) SignToken(signingKey *oauth2.JWTSigningKey) (string, error) {
+	token.IssuedAt = time.Now().Unix()
+	return signingKey.SignToken(token)
+}
@@ -94,11 +94,12 @@ func TestOAuth2Application_GetGrantByUserID(t *testing.T) {
 func TestOAuth2Application_CreateGrant(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	app := AssertExistsAndLoadBean(t, &OAuth2Application{ID: 1}).(*OAuth2Application)
-	grant, err := app.CreateGrant(2)
+	grant, err := app.CreateGrant(2, "openid read:repository")
 	assert.NoError(t, err)
 	assert.NotNil(t, grant)
 	assert.Equal(t, int64(2), grant.UserID)
 	assert.Equal(t, int64(1), grant.ApplicationID)
+	assert.Equal(t, "openid read:repository", grant.Scope)
 }
 
 //////////////////// Grant
@@ -125,10 +126,53 @@ func TestOAuth2Grant_IncreaseCounter(t *testing.T) {
 func TestOAuth2Grant_GenerateNewAuthorizationCode(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	grant := AssertExistsAndLoadBean(t, &OAuth2Grant{ID: 1}).(*OAuth2Grant)
-	code, err := grant.GenerateNewAuthorizationCode("https://example2.com/callback", "CjvyTLSdR47G5zYenDA-eDWW4lRrO8yvjcWwbD_deOg", "S256")
+	code, err := grant.GenerateNewAuthorizationCode("https://example2.com/callback", "CjvyTLSdR47G5zYenDA-eDWW4lRrO8yvjcWwbD_deOg", "S256", "thenonce")
 	assert.NoError(t, err)
 	assert.NotNil(t, code)
 	assert.True(t, len(code.Code) > 32) // secret length > 32
+	AssertExistsAndLoadBean(t, &OAuth2AuthorizationCode{Code: code.Code, Nonce: "thenonce"})
+}
+
+func TestOAuth2Grant_HasScope(t *testing.T) {
+	grant := &OAuth2Grant{Scope: "email openid write:repository"}
+	assert.True(t, grant.HasScope("openid"))
+	assert.True(t, grant.HasScope("email openid read:repository"))
+	assert.True(t, grant.HasScope("write:repository"))
+	assert.False(t, grant.HasScope("profile"))
+	assert.False(t, grant.HasScope("read:issue"))
+	assert.False(t, grant.HasScope("all"))
+	assert.Equal(t, AccessTokenScope("write:repository"), grant.AccessTokenScope())
+
+	grant = &OAuth2Grant{Scope: "all"}
+	assert.True(t, grant.HasScope("write:admin read:issue"))
+	assert.False(t, grant.HasScope("openid"))
+
+	// OpenID Connect only grants do not give access to the API
+	grant = &OAuth2Grant{UserID: 2, Scope: "openid profile"}
+	token := grant.ToAccessToken()
+	assert.EqualValues(t, 2, token.UID)
+	assert.False(t, token.Scope.HasScope(AccessTokenScopeCategoryUser, AccessTokenScopeLevelRead))
+}
+
+func TestOAuth2Grant_AddScope(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	grant := AssertExistsAndLoadBean(t, &OAuth2Grant{ID: 1}).(*OAuth2Grant)
+	assert.NoError(t, grant.AddScope("read:repository openid"))
+	assert.Equal(t, "all email openid profile read:repository", grant.Scope)
+	AssertExistsAndLoadBean(t, &OAuth2Grant{ID: 1, Scope: "all email openid profile read:repository"})
+}
+
+func TestNormalizeOAuth2Scope(t *testing.T) {
+	scope, err := NormalizeOAuth2Scope("")
+	assert.NoError(t, err)
+	assert.Equal(t, "all", scope)
+
+	scope, err = NormalizeOAuth2Scope("  profile openid\twrite:issue openid ")
+	assert.NoError(t, err)
+	assert.Equal(t, "openid profile write:issue", scope)
+
+	_, err = NormalizeOAuth2Scope("openid repo")
+	assert.True(t, IsErrInvalidAccessTokenScope(err))
 }
 
 func TestOAuth2Grant_TableName(t *testing.T) {
@@ -152,6 +196,7 @@ func TestRevokeOAuth2Grant(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	assert.NoError(t, RevokeOAuth2Grant(1, 1))
 	AssertNotExistsBean(t, &OAuth2Grant{ID: 1, UserID: 1})
+	AssertNotExistsBean(t, &OAuth2AuthorizationCode{GrantID: 1})
 }
 
 //////////////////// Authorization Code
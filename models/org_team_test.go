@@ -183,6 +183,22 @@ func TestGetTeam(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestGetTeamIDsByNames(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	ids, err := GetTeamIDsByNames(3, []string{"Owners", "team1", "nonexistent"}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, ids)
+
+	ids, err = GetTeamIDsByNames(3, []string{"team1", "nonexistent"}, false)
+	assert.True(t, IsErrTeamNotExist(err))
+	assert.Nil(t, ids)
+
+	names, err := GetTeamNamesByID([]int64{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"owners", "team1"}, names)
+}
+
 func TestGetTeamByID(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 
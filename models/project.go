@@ -0,0 +1,301 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// ProjectBoardType is used to represent a project board type
+type ProjectBoardType uint8
+
+const (
+	// ProjectBoardTypeNone is a project board type that has no predefined columns
+	ProjectBoardTypeNone ProjectBoardType = iota
+
+	// ProjectBoardTypeBasicKanban is a project board type that has basic predefined columns
+	ProjectBoardTypeBasicKanban
+
+	// ProjectBoardTypeBugTriage is a project board type that has predefined columns suited to hunting down bugs
+	ProjectBoardTypeBugTriage
+)
+
+// ProjectBoardConfig is used to identify the type of board that is being created
+type ProjectBoardConfig struct {
+	BoardType   ProjectBoardType
+	Name        string
+	Translation string
+}
+
+// ProjectBoardConfigs contains the board types a project can be created with
+var ProjectBoardConfigs = []ProjectBoardConfig{
+	{ProjectBoardTypeNone, "none", "repo.projects.type.none"},
+	{ProjectBoardTypeBasicKanban, "basic_kanban", "repo.projects.type.basic_kanban"},
+	{ProjectBoardTypeBugTriage, "bug_triage", "repo.projects.type.bug_triage"},
+}
+
+// IsProjectBoardTypeValid checks if the project board type is valid
+func IsProjectBoardTypeValid(p ProjectBoardType) bool {
+	return int(p) < len(ProjectBoardConfigs)
+}
+
+// ProjectBoardTypeFromName returns the project board type of the given name
+func ProjectBoardTypeFromName(name string) (ProjectBoardType, bool) {
+	if len(name) == 0 {
+		return ProjectBoardTypeNone, true
+	}
+	for _, config := range ProjectBoardConfigs {
+		if config.Name == name {
+			return config.BoardType, true
+		}
+	}
+	return ProjectBoardTypeNone, false
+}
+
+// Project represents a project board of a repository
+type Project struct {
+	ID              int64  `xorm:"pk autoincr"`
+	Title           string `xorm:"INDEX NOT NULL"`
+	Description     string `xorm:"TEXT"`
+	RenderedContent string `xorm:"-"`
+	RepoID          int64  `xorm:"INDEX NOT NULL"`
+	CreatorID       int64  `xorm:"NOT NULL"`
+	IsClosed        bool   `xorm:"INDEX"`
+	BoardType       ProjectBoardType
+
+	CreatedUnix    timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix    timeutil.TimeStamp `xorm:"INDEX updated"`
+	ClosedDateUnix timeutil.TimeStamp
+}
+
+// State returns string representation of project status.
+func (p *Project) State() api.StateType {
+	if p.IsClosed {
+		return api.StateClosed
+	}
+	return api.StateOpen
+}
+
+// APIFormat returns this Project in API format.
+func (p *Project) APIFormat() *api.Project {
+	apiProject := &api.Project{
+		ID:           p.ID,
+		Title:        p.Title,
+		Description:  p.Description,
+		BoardType:    ProjectBoardConfigs[p.BoardType].Name,
+		State:        p.State(),
+		OpenIssues:   p.NumOpenIssues(),
+		ClosedIssues: p.NumClosedIssues(),
+		Created:      p.CreatedUnix.AsTime(),
+		Updated:      p.UpdatedUnix.AsTime(),
+	}
+	if p.IsClosed {
+		apiProject.Closed = p.ClosedDateUnix.AsTimePtr()
+	}
+	return apiProject
+}
+
+// NewProject creates a new project of a repository with the columns of its board type.
+func NewProject(p *Project) (err error) {
+	if !IsProjectBoardTypeValid(p.BoardType) {
+		p.BoardType = ProjectBoardTypeNone
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	p.Title = strings.TrimSpace(p.Title)
+
+	if _, err = sess.Insert(p); err != nil {
+		return err
+	}
+
+	if err = createBoardsForProjectsType(sess, p); err != nil {
+		return err
+	}
+
+	if _, err = sess.Exec("UPDATE `repository` SET num_projects = num_projects + 1 WHERE id = ?", p.RepoID); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+func getProjectByRepoID(e Engine, repoID, id int64) (*Project, error) {
+	p := &Project{
+		ID:     id,
+		RepoID: repoID,
+	}
+	has, err := e.Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectNotExist{id, repoID}
+	}
+	return p, nil
+}
+
+// GetProjectByRepoID returns the project in a repository.
+func GetProjectByRepoID(repoID, id int64) (*Project, error) {
+	return getProjectByRepoID(x, repoID, id)
+}
+
+func getProjectByID(e Engine, id int64) (*Project, error) {
+	p := new(Project)
+	has, err := e.ID(id).Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectNotExist{id, 0}
+	}
+	return p, nil
+}
+
+// GetProjectByID returns the project via id.
+func GetProjectByID(id int64) (*Project, error) {
+	return getProjectByID(x, id)
+}
+
+// GetProjectsByRepoID returns all projects of a repository in the given state.
+func GetProjectsByRepoID(repoID int64, state api.StateType) ([]*Project, error) {
+	sess := x.Where("repo_id = ?", repoID)
+
+	switch state {
+	case api.StateClosed:
+		sess = sess.And("is_closed = ?", true)
+
+	case api.StateAll:
+		break
+
+	case api.StateOpen:
+		fallthrough
+
+	default:
+		sess = sess.And("is_closed = ?", false)
+	}
+
+	projects := make([]*Project, 0, 10)
+	return projects, sess.Desc("created_unix").Desc("id").Find(&projects)
+}
+
+// GetProjects returns a list of projects of given repository and status.
+func GetProjects(repoID int64, page int, isClosed bool, sortType string) ([]*Project, error) {
+	projects := make([]*Project, 0, setting.UI.IssuePagingNum)
+	sess := x.Where("repo_id = ? AND is_closed = ?", repoID, isClosed)
+	if page > 0 {
+		sess = sess.Limit(setting.UI.IssuePagingNum, (page-1)*setting.UI.IssuePagingNum)
+	}
+
+	switch sortType {
+	case "oldest":
+		sess.Asc("created_unix")
+	case "recentupdate":
+		sess.Desc("updated_unix")
+	case "leastupdate":
+		sess.Asc("updated_unix")
+	default:
+		sess.Desc("created_unix")
+	}
+	return projects, sess.Find(&projects)
+}
+
+// UpdateProject updates the title and description of given project.
+func UpdateProject(p *Project) error {
+	p.Title = strings.TrimSpace(p.Title)
+	_, err := x.ID(p.ID).Cols("title", "description").Update(p)
+	return err
+}
+
+func updateRepoProjectNum(e Engine, repoID int64) error {
+	_, err := e.Exec("UPDATE `repository` SET num_projects=(SELECT count(*) FROM project WHERE repo_id=?),num_closed_projects=(SELECT count(*) FROM project WHERE repo_id=? AND is_closed=?) WHERE id=?",
+		repoID,
+		repoID,
+		true,
+		repoID,
+	)
+	return err
+}
+
+// ChangeProjectStatus changes the project open/closed status.
+func ChangeProjectStatus(p *Project, isClosed bool) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	p.IsClosed = isClosed
+	if isClosed {
+		p.ClosedDateUnix = timeutil.TimeStampNow()
+	}
+
+	if _, err = sess.ID(p.ID).Cols("is_closed", "closed_date_unix").Update(p); err != nil {
+		return err
+	}
+
+	if err = updateRepoProjectNum(sess, p.RepoID); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+func deleteProjectByID(e Engine, id int64) error {
+	if _, err := e.Where("project_id = ?", id).Delete(new(ProjectIssue)); err != nil {
+		return err
+	}
+	if _, err := e.Where("project_id = ?", id).Delete(new(ProjectBoard)); err != nil {
+		return err
+	}
+	_, err := e.ID(id).Delete(new(Project))
+	return err
+}
+
+// DeleteProjectByRepoID deletes a project and its columns from a repository.
+func DeleteProjectByRepoID(repoID, id int64) error {
+	p, err := GetProjectByRepoID(repoID, id)
+	if err != nil {
+		if IsErrProjectNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if err = deleteProjectByID(sess, p.ID); err != nil {
+		return err
+	}
+
+	if err = updateRepoProjectNum(sess, p.RepoID); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+func deleteProjectsByRepoID(e Engine, repoID int64) error {
+	projects := make([]*Project, 0, 10)
+	if err := e.Where("repo_id = ?", repoID).Find(&projects); err != nil {
+		return err
+	}
+	for _, p := range projects {
+		if err := deleteProjectByID(e, p.ID); err != nil {
+			return fmt.Errorf("deleteProjectByID[%d]: %v", p.ID, err)
+		}
+	}
+	return nil
+}
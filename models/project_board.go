@@ -0,0 +1,179 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"strings"
+
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+// ProjectBoard is a column of a project which holds issues
+type ProjectBoard struct {
+	ID        int64  `xorm:"pk autoincr"`
+	Title     string `xorm:"NOT NULL"`
+	Sorting   int    `xorm:"NOT NULL DEFAULT 0"`
+	ProjectID int64  `xorm:"INDEX NOT NULL"`
+	CreatorID int64  `xorm:"NOT NULL"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+
+	Issues []*Issue `xorm:"-"`
+}
+
+// ProjectBoardList is a list of project boards
+type ProjectBoardList []*ProjectBoard
+
+// IsUncategorized returns true if the board is the pseudo column holding the issues
+// which have not been put into any column of the project
+func (b *ProjectBoard) IsUncategorized() bool {
+	return b.ID == 0
+}
+
+// APIFormat returns this ProjectBoard in API format.
+func (b *ProjectBoard) APIFormat() *api.ProjectColumn {
+	return &api.ProjectColumn{
+		ID:        b.ID,
+		Title:     b.Title,
+		Sorting:   b.Sorting,
+		ProjectID: b.ProjectID,
+		Created:   b.CreatedUnix.AsTime(),
+		Updated:   b.UpdatedUnix.AsTime(),
+	}
+}
+
+func createBoardsForProjectsType(e *xorm.Session, project *Project) error {
+	var titles []string
+	switch project.BoardType {
+	case ProjectBoardTypeBasicKanban:
+		titles = []string{"To Do", "In Progress", "Done"}
+	case ProjectBoardTypeBugTriage:
+		titles = []string{"Needs Triage", "High Priority", "Low Priority", "Closed"}
+	default:
+		return nil
+	}
+
+	boards := make([]ProjectBoard, 0, len(titles))
+	for i, title := range titles {
+		boards = append(boards, ProjectBoard{
+			Title:     title,
+			Sorting:   i,
+			ProjectID: project.ID,
+			CreatorID: project.CreatorID,
+		})
+	}
+	_, err := e.Insert(boards)
+	return err
+}
+
+// NewProjectBoard adds a new column to a project.
+func NewProjectBoard(board *ProjectBoard) error {
+	board.Title = strings.TrimSpace(board.Title)
+	_, err := x.Insert(board)
+	return err
+}
+
+func getProjectBoard(e Engine, projectID, boardID int64) (*ProjectBoard, error) {
+	board := &ProjectBoard{
+		ID:        boardID,
+		ProjectID: projectID,
+	}
+	has, err := e.Get(board)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectBoardNotExist{boardID, projectID}
+	}
+	return board, nil
+}
+
+// GetProjectBoard returns the column of a project.
+func GetProjectBoard(projectID, boardID int64) (*ProjectBoard, error) {
+	return getProjectBoard(x, projectID, boardID)
+}
+
+// UpdateProjectBoard updates the title and sorting of a project column.
+func UpdateProjectBoard(board *ProjectBoard) error {
+	board.Title = strings.TrimSpace(board.Title)
+	_, err := x.ID(board.ID).Cols("title", "sorting").Update(board)
+	return err
+}
+
+// DeleteProjectBoard deletes a column of a project, the issues it holds become uncategorized.
+func DeleteProjectBoard(projectID, boardID int64) error {
+	board, err := GetProjectBoard(projectID, boardID)
+	if err != nil {
+		if IsErrProjectBoardNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.Exec("UPDATE `project_issue` SET project_board_id = 0 WHERE project_board_id = ?", board.ID); err != nil {
+		return err
+	}
+
+	if _, err = sess.ID(board.ID).Delete(new(ProjectBoard)); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+// GetProjectBoards returns the columns of a project in their display order.
+func GetProjectBoards(projectID int64) (ProjectBoardList, error) {
+	boards := make([]*ProjectBoard, 0, 5)
+	return boards, x.Where("project_id = ?", projectID).Asc("sorting").Asc("id").Find(&boards)
+}
+
+// GetUncategorizedBoard returns the pseudo column holding the issues of the project
+// which have not been put into any column.
+func (p *Project) GetUncategorizedBoard() *ProjectBoard {
+	return &ProjectBoard{
+		ProjectID: p.ID,
+	}
+}
+
+func (b *ProjectBoard) loadIssues(e Engine) error {
+	issues := make([]*Issue, 0, 10)
+	if err := e.Join("INNER", "project_issue", "issue.id = project_issue.issue_id").
+		Where("project_issue.project_id = ? AND project_issue.project_board_id = ?", b.ProjectID, b.ID).
+		Desc("issue.priority").
+		Desc("issue.created_unix").
+		Find(&issues); err != nil {
+		return err
+	}
+
+	if err := IssueList(issues).loadAttributes(e); err != nil {
+		return err
+	}
+	b.Issues = issues
+	return nil
+}
+
+// LoadIssues loads the issues and pull requests held by the column
+func (b *ProjectBoard) LoadIssues() error {
+	return b.loadIssues(x)
+}
+
+// LoadIssues loads the issues and pull requests held by every column of the list
+func (bs ProjectBoardList) LoadIssues() error {
+	for _, b := range bs {
+		if err := b.loadIssues(x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+)
+
+// ProjectIssue saves relation from issue to a project
+type ProjectIssue struct {
+	ID        int64 `xorm:"pk autoincr"`
+	IssueID   int64 `xorm:"INDEX"`
+	ProjectID int64 `xorm:"INDEX"`
+
+	// If 0, then it has not been added to a specific board in the project
+	ProjectBoardID int64 `xorm:"INDEX"`
+}
+
+func (issue *Issue) loadProject(e Engine) (err error) {
+	var p Project
+	has, err := e.Table("project").
+		Join("INNER", "project_issue", "project.id = project_issue.project_id").
+		Where("project_issue.issue_id = ?", issue.ID).
+		Get(&p)
+	if err != nil {
+		return err
+	} else if !has {
+		issue.Project = nil
+		return nil
+	}
+	issue.Project = &p
+	return nil
+}
+
+// LoadProject loads the project the issue is assigned to
+func (issue *Issue) LoadProject() error {
+	return issue.loadProject(x)
+}
+
+// ProjectID returns the id of the project the issue is assigned to, 0 if none
+func (issue *Issue) ProjectID() int64 {
+	if issue.Project == nil {
+		return 0
+	}
+	return issue.Project.ID
+}
+
+func getProjectIssue(e Engine, issueID int64) (*ProjectIssue, error) {
+	pi := new(ProjectIssue)
+	has, err := e.Where("issue_id = ?", issueID).Get(pi)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, nil
+	}
+	return pi, nil
+}
+
+// GetProjectBoardIDOfIssue returns the id of the project column the issue is in, 0 if it is uncategorized
+func GetProjectBoardIDOfIssue(issueID int64) (int64, error) {
+	pi, err := getProjectIssue(x, issueID)
+	if err != nil || pi == nil {
+		return 0, err
+	}
+	return pi.ProjectBoardID, nil
+}
+
+// NumIssues returns the number of issues and pull requests assigned to the project
+func (p *Project) NumIssues() int {
+	c, err := x.Table("project_issue").
+		Where("project_id = ?", p.ID).
+		Count(new(ProjectIssue))
+	if err != nil {
+		return 0
+	}
+	return int(c)
+}
+
+// NumClosedIssues returns the number of closed issues and pull requests assigned to the project
+func (p *Project) NumClosedIssues() int {
+	c, err := x.Table("project_issue").
+		Join("INNER", "issue", "project_issue.issue_id = issue.id").
+		Where("project_issue.project_id = ? AND issue.is_closed = ?", p.ID, true).
+		Count(new(ProjectIssue))
+	if err != nil {
+		return 0
+	}
+	return int(c)
+}
+
+// NumOpenIssues returns the number of open issues and pull requests assigned to the project
+func (p *Project) NumOpenIssues() int {
+	return p.NumIssues() - p.NumClosedIssues()
+}
+
+func changeProjectAssign(e *xorm.Session, doer *User, issue *Issue, newProjectID int64) error {
+	oldProjectID := issue.ProjectID()
+	if oldProjectID == newProjectID {
+		return nil
+	}
+
+	if _, err := e.Where("issue_id = ?", issue.ID).Delete(new(ProjectIssue)); err != nil {
+		return err
+	}
+
+	issue.Project = nil
+	if newProjectID > 0 {
+		p, err := getProjectByRepoID(e, issue.RepoID, newProjectID)
+		if err != nil {
+			return err
+		}
+		if _, err = e.Insert(&ProjectIssue{
+			IssueID:   issue.ID,
+			ProjectID: p.ID,
+		}); err != nil {
+			return err
+		}
+		issue.Project = p
+	}
+
+	if err := issue.loadRepo(e); err != nil {
+		return err
+	}
+
+	_, err := createComment(e, &CreateCommentOptions{
+		Type:         CommentTypeProject,
+		Doer:         doer,
+		Repo:         issue.Repo,
+		Issue:        issue,
+		OldProjectID: oldProjectID,
+		ProjectID:    newProjectID,
+	})
+	return err
+}
+
+// ChangeProjectAssign changes the project the issue is assigned to, 0 removes it from its project.
+func ChangeProjectAssign(issue *Issue, doer *User, newProjectID int64) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if err = issue.loadProject(sess); err != nil {
+		return err
+	}
+
+	if err = changeProjectAssign(sess, doer, issue, newProjectID); err != nil {
+		return err
+	}
+
+	if err = sess.Commit(); err != nil {
+		return fmt.Errorf("Commit: %v", err)
+	}
+	return nil
+}
+
+// MoveIssueAcrossProjectBoards moves an issue to another column of the project it is assigned to.
+// If the issue is not assigned to the project of the column yet, it is assigned to it.
+func MoveIssueAcrossProjectBoards(issue *Issue, doer *User, board *ProjectBoard) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if err = issue.loadProject(sess); err != nil {
+		return err
+	}
+
+	if issue.ProjectID() != board.ProjectID {
+		if err = changeProjectAssign(sess, doer, issue, board.ProjectID); err != nil {
+			return err
+		}
+	}
+
+	if _, err = sess.Exec("UPDATE `project_issue` SET project_board_id = ? WHERE issue_id = ? AND project_id = ?",
+		board.ID, issue.ID, board.ProjectID); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
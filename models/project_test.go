@@ -0,0 +1,181 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectBoardTypeFromName(t *testing.T) {
+	tp, ok := ProjectBoardTypeFromName("")
+	assert.True(t, ok)
+	assert.Equal(t, ProjectBoardTypeNone, tp)
+
+	tp, ok = ProjectBoardTypeFromName("bug_triage")
+	assert.True(t, ok)
+	assert.Equal(t, ProjectBoardTypeBugTriage, tp)
+
+	_, ok = ProjectBoardTypeFromName("unknown")
+	assert.False(t, ok)
+
+	assert.True(t, IsProjectBoardTypeValid(ProjectBoardTypeBasicKanban))
+	assert.False(t, IsProjectBoardTypeValid(ProjectBoardType(10)))
+}
+
+func TestNewProject(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	project := &Project{
+		Title:       "projectTitle",
+		Description: "projectDescription",
+		RepoID:      1,
+		CreatorID:   2,
+		BoardType:   ProjectBoardTypeBasicKanban,
+	}
+
+	assert.NoError(t, NewProject(project))
+	AssertExistsAndLoadBean(t, project)
+	CheckConsistencyFor(t, &Repository{ID: project.RepoID})
+
+	boards, err := GetProjectBoards(project.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, boards, 3) {
+		assert.Equal(t, "To Do", boards[0].Title)
+		assert.Equal(t, "Done", boards[2].Title)
+	}
+}
+
+func TestGetProjects(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	projects, err := GetProjectsByRepoID(1, api.StateOpen)
+	assert.NoError(t, err)
+	if assert.Len(t, projects, 1) {
+		assert.EqualValues(t, 1, projects[0].ID)
+		assert.Equal(t, 2, projects[0].NumIssues())
+		assert.Equal(t, 0, projects[0].NumClosedIssues())
+	}
+
+	projects, err = GetProjectsByRepoID(1, api.StateAll)
+	assert.NoError(t, err)
+	assert.Len(t, projects, 2)
+
+	projects, err = GetProjects(1, 1, true, "")
+	assert.NoError(t, err)
+	if assert.Len(t, projects, 1) {
+		assert.EqualValues(t, 2, projects[0].ID)
+	}
+
+	_, err = GetProjectByRepoID(2, 1)
+	assert.True(t, IsErrProjectNotExist(err))
+}
+
+func TestChangeProjectStatus(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	project := AssertExistsAndLoadBean(t, &Project{ID: 1}).(*Project)
+	assert.NoError(t, ChangeProjectStatus(project, true))
+	AssertExistsAndLoadBean(t, &Project{ID: 1}, "is_closed=1")
+	CheckConsistencyFor(t, &Repository{ID: project.RepoID})
+
+	assert.NoError(t, ChangeProjectStatus(project, false))
+	AssertExistsAndLoadBean(t, &Project{ID: 1}, "is_closed=0")
+	CheckConsistencyFor(t, &Repository{ID: project.RepoID})
+}
+
+func TestDeleteProjectByRepoID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, DeleteProjectByRepoID(1, 1))
+	AssertNotExistsBean(t, &Project{ID: 1})
+	AssertNotExistsBean(t, &ProjectBoard{ProjectID: 1})
+	AssertNotExistsBean(t, &ProjectIssue{ProjectID: 1})
+	CheckConsistencyFor(t, &Repository{ID: 1})
+
+	assert.NoError(t, DeleteProjectByRepoID(NonexistentID, NonexistentID))
+}
+
+func TestProjectBoards(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	project := AssertExistsAndLoadBean(t, &Project{ID: 1}).(*Project)
+	boards, err := GetProjectBoards(project.ID)
+	assert.NoError(t, err)
+	assert.Len(t, boards, 3)
+	assert.NoError(t, boards.LoadIssues())
+	if assert.Len(t, boards[0].Issues, 1) {
+		assert.EqualValues(t, 1, boards[0].Issues[0].ID)
+	}
+	assert.Len(t, boards[1].Issues, 0)
+
+	uncategorized := project.GetUncategorizedBoard()
+	assert.True(t, uncategorized.IsUncategorized())
+	assert.NoError(t, uncategorized.LoadIssues())
+	if assert.Len(t, uncategorized.Issues, 1) {
+		assert.EqualValues(t, 2, uncategorized.Issues[0].ID)
+	}
+
+	board := &ProjectBoard{Title: " Review ", ProjectID: project.ID, CreatorID: 2, Sorting: 5}
+	assert.NoError(t, NewProjectBoard(board))
+	AssertExistsAndLoadBean(t, &ProjectBoard{ID: board.ID, Title: "Review"})
+
+	board.Title = "Reviewing"
+	assert.NoError(t, UpdateProjectBoard(board))
+	AssertExistsAndLoadBean(t, &ProjectBoard{ID: board.ID, Title: "Reviewing"})
+
+	_, err = GetProjectBoard(2, board.ID)
+	assert.True(t, IsErrProjectBoardNotExist(err))
+
+	// the issues of a deleted column become uncategorized
+	assert.NoError(t, DeleteProjectBoard(project.ID, 1))
+	AssertNotExistsBean(t, &ProjectBoard{ID: 1})
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 1, ProjectID: 1}, "project_board_id = 0")
+}
+
+func TestChangeProjectAssign(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+
+	assert.NoError(t, ChangeProjectAssign(issue, doer, 2))
+	assert.EqualValues(t, 2, issue.ProjectID())
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 1, ProjectID: 2})
+	AssertNotExistsBean(t, &ProjectIssue{IssueID: 1, ProjectID: 1})
+	AssertExistsAndLoadBean(t, &Comment{
+		IssueID:      issue.ID,
+		Type:         CommentTypeProject,
+		OldProjectID: 1,
+		ProjectID:    2,
+	})
+
+	assert.NoError(t, ChangeProjectAssign(issue, doer, 0))
+	assert.EqualValues(t, 0, issue.ProjectID())
+	AssertNotExistsBean(t, &ProjectIssue{IssueID: 1})
+
+	// a project of another repository can not be assigned
+	assert.Error(t, ChangeProjectAssign(issue, doer, NonexistentID))
+}
+
+func TestMoveIssueAcrossProjectBoards(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 2}).(*Issue)
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	board := AssertExistsAndLoadBean(t, &ProjectBoard{ID: 3}).(*ProjectBoard)
+
+	assert.NoError(t, MoveIssueAcrossProjectBoards(issue, doer, board))
+	boardID, err := GetProjectBoardIDOfIssue(issue.ID)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, boardID)
+
+	// an issue which is not assigned to the project yet is added to it
+	issue = AssertExistsAndLoadBean(t, &Issue{ID: 5}).(*Issue)
+	assert.NoError(t, MoveIssueAcrossProjectBoards(issue, doer, board))
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 5, ProjectID: 1, ProjectBoardID: 3})
+}
@@ -166,7 +166,7 @@ func (pr *PullRequest) loadProtectedBranch(e Engine) (err error) {
 			return
 		}
 	}
-	pr.ProtectedBranch, err = getProtectedBranchBy(e, pr.BaseRepo.ID, pr.BaseBranch)
+	pr.ProtectedBranch, err = getMatchingProtectedBranch(e, pr.BaseRepo.ID, pr.BaseBranch)
 	return
 }
 
@@ -44,7 +44,7 @@ func (pr *PullRequest) SignMerge(u *User, tmpBasePath, baseCommit, headCommit st
 				return false, ""
 			}
 		case approved:
-			protectedBranch, err := GetProtectedBranchBy(repo.ID, pr.BaseBranch)
+			protectedBranch, err := GetMatchingProtectedBranch(repo.ID, pr.BaseBranch)
 			if err != nil || protectedBranch == nil {
 				return false, ""
 			}
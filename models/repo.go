@@ -15,7 +15,6 @@ import (
 
 	// Needed for jpeg support
 	_ "image/jpeg"
-	"image/png"
 	"io/ioutil"
 	"net/url"
 	"os"
@@ -32,6 +31,7 @@ import (
 	"code.gitea.io/gitea/modules/markup"
 	"code.gitea.io/gitea/modules/options"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/structs"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/sync"
@@ -50,6 +50,9 @@ var (
 	// ErrMirrorNotExist mirror does not exist error
 	ErrMirrorNotExist = errors.New("Mirror does not exist")
 
+	// ErrPushMirrorNotExist push mirror does not exist error
+	ErrPushMirrorNotExist = errors.New("PushMirror does not exist")
+
 	// ErrNameEmpty name is empty error
 	ErrNameEmpty = errors.New("Name is empty")
 )
@@ -173,6 +176,9 @@ type Repository struct {
 	NumMilestones       int `xorm:"NOT NULL DEFAULT 0"`
 	NumClosedMilestones int `xorm:"NOT NULL DEFAULT 0"`
 	NumOpenMilestones   int `xorm:"-"`
+	NumProjects         int `xorm:"NOT NULL DEFAULT 0"`
+	NumClosedProjects   int `xorm:"NOT NULL DEFAULT 0"`
+	NumOpenProjects     int `xorm:"-"`
 
 	IsPrivate  bool `xorm:"INDEX"`
 	IsEmpty    bool `xorm:"INDEX"`
@@ -249,6 +255,7 @@ func (repo *Repository) AfterLoad() {
 	repo.NumOpenIssues = repo.NumIssues - repo.NumClosedIssues
 	repo.NumOpenPulls = repo.NumPulls - repo.NumClosedPulls
 	repo.NumOpenMilestones = repo.NumMilestones - repo.NumClosedMilestones
+	repo.NumOpenProjects = repo.NumProjects - repo.NumClosedProjects
 }
 
 // MustOwner always returns a valid *User object to avoid
@@ -667,6 +674,32 @@ func (repo *Repository) GetAssignees() (_ []*User, err error) {
 	return repo.getAssignees(x)
 }
 
+func (repo *Repository) getReviewers(e Engine, doerID, posterID int64) ([]*User, error) {
+	if err := repo.getOwner(e); err != nil {
+		return nil, err
+	}
+
+	cond := builder.And(builder.Neq{"`user`.id": posterID}, builder.Neq{"`user`.id": doerID})
+	if repo.Owner.IsOrganization() {
+		cond = cond.And(builder.In("`user`.id",
+			builder.Select("user_id").From("access").Where(builder.Eq{"repo_id": repo.ID}.
+				And(builder.Gte{"mode": AccessModeRead}))))
+	} else {
+		cond = cond.And(builder.Eq{"`user`.id": repo.OwnerID}.Or(builder.In("`user`.id",
+			builder.Select("user_id").From("access").Where(builder.Eq{"repo_id": repo.ID}.
+				And(builder.Gte{"mode": AccessModeRead})))))
+	}
+
+	users := make([]*User, 0, 8)
+	return users, e.Where(cond).OrderBy("name").Find(&users)
+}
+
+// GetReviewers returns all users that have read access to the repository
+// and can be requested to review its pull requests, except the doer and the poster
+func (repo *Repository) GetReviewers(doerID, posterID int64) ([]*User, error) {
+	return repo.getReviewers(x, doerID, posterID)
+}
+
 // GetMilestoneByID returns the milestone belongs to repository by given ID.
 func (repo *Repository) GetMilestoneByID(milestoneID int64) (*Milestone, error) {
 	return GetMilestoneByRepoID(repo.ID, milestoneID)
@@ -1488,7 +1521,7 @@ func CreateRepository(doer, u *User, opts CreateRepoOptions) (_ *Repository, err
 
 		// Initialize Issue Labels if selected
 		if len(opts.IssueLabels) > 0 {
-			if err = initalizeLabels(sess, repo.ID, opts.IssueLabels); err != nil {
+			if err = initalizeLabels(sess, repo.ID, opts.IssueLabels, false); err != nil {
 				return nil, fmt.Errorf("initalizeLabels: %v", err)
 			}
 		}
@@ -1607,6 +1640,24 @@ func TransferOwnership(doer *User, newOwnerName string, repo *Repository) error
 		}
 	}
 
+	// Remove labels of the old organization from the issues of the repository.
+	if oldOwner.IsOrganization() {
+		if _, err = sess.In("issue_id", builder.Select("id").From("issue").Where(builder.Eq{"repo_id": repo.ID})).
+			In("label_id", builder.Select("id").From("label").Where(builder.Eq{"org_id": oldOwner.ID})).
+			Delete(new(IssueLabel)); err != nil {
+			return fmt.Errorf("remove organization labels: %v", err)
+		}
+		orgLabels, err := getLabelsByOrgID(sess, oldOwner.ID, "")
+		if err != nil {
+			return fmt.Errorf("getLabelsByOrgID: %v", err)
+		}
+		for _, label := range orgLabels {
+			if err = updateLabel(sess, label); err != nil {
+				return fmt.Errorf("updateLabel: %v", err)
+			}
+		}
+	}
+
 	if newOwner.IsOrganization() {
 		if err := newOwner.getTeams(sess); err != nil {
 			return fmt.Errorf("GetTeams: %v", err)
@@ -1903,7 +1954,7 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 	}
 	releaseAttachments := make([]string, 0, len(attachments))
 	for i := 0; i < len(attachments); i++ {
-		releaseAttachments = append(releaseAttachments, attachments[i].LocalPath())
+		releaseAttachments = append(releaseAttachments, attachments[i].RelativePath())
 	}
 
 	if err = deleteBeans(sess,
@@ -1912,6 +1963,7 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		&Watch{RepoID: repoID},
 		&Star{RepoID: repoID},
 		&Mirror{RepoID: repoID},
+		&PushMirror{RepoID: repoID},
 		&Milestone{RepoID: repoID},
 		&Release{RepoID: repoID},
 		&Collaboration{RepoID: repoID},
@@ -1929,6 +1981,10 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	if err = deleteProjectsByRepoID(sess, repoID); err != nil {
+		return fmt.Errorf("deleteProjectsByRepoID: %v", err)
+	}
+
 	deleteCond := builder.Select("id").From("issue").Where(builder.Eq{"repo_id": repoID})
 	// Delete comments and attachments
 	if _, err = sess.In("issue_id", deleteCond).
@@ -1968,6 +2024,11 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		return err
 	}
 
+	if _, err = sess.In("issue_id", deleteCond).
+		Delete(&PullRequestCodeOwner{}); err != nil {
+		return err
+	}
+
 	if _, err = sess.In("issue_id", deleteCond).
 		Delete(&TrackedTime{}); err != nil {
 		return err
@@ -1981,7 +2042,7 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 	}
 	attachmentPaths := make([]string, 0, len(attachments))
 	for j := range attachments {
-		attachmentPaths = append(attachmentPaths, attachments[j].LocalPath())
+		attachmentPaths = append(attachmentPaths, attachments[j].RelativePath())
 	}
 
 	if _, err = sess.In("issue_id", deleteCond).
@@ -2037,8 +2098,7 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 			continue
 		}
 
-		oidPath := filepath.Join(setting.LFS.ContentPath, v.Oid[0:2], v.Oid[2:4], v.Oid[4:len(v.Oid)])
-		removeAllWithNotice(sess, "Delete orphaned LFS file", oidPath)
+		removeStorageWithNotice(sess, storage.LFS, "Delete orphaned LFS file", v.RelativePath())
 	}
 
 	if _, err := sess.Delete(&LFSMetaObject{RepositoryID: repoID}); err != nil {
@@ -2068,20 +2128,17 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 
 	// Remove issue attachment files.
 	for i := range attachmentPaths {
-		removeAllWithNotice(x, "Delete issue attachment", attachmentPaths[i])
+		removeStorageWithNotice(x, storage.Attachments, "Delete issue attachment", attachmentPaths[i])
 	}
 
 	// Remove release attachment files.
 	for i := range releaseAttachments {
-		removeAllWithNotice(x, "Delete release attachment", releaseAttachments[i])
+		removeStorageWithNotice(x, storage.Attachments, "Delete release attachment", releaseAttachments[i])
 	}
 
 	if len(repo.Avatar) > 0 {
-		avatarPath := repo.CustomAvatarPath()
-		if com.IsExist(avatarPath) {
-			if err := os.Remove(avatarPath); err != nil {
-				return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
-			}
+		if err := storage.RepoAvatars.Delete(repo.CustomAvatarRelativePath()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("Failed to remove %s: %v", repo.Avatar, err)
 		}
 	}
 
@@ -2702,13 +2759,22 @@ func (repo *Repository) GetUserFork(userID int64) (*Repository, error) {
 	return &forkedRepo, nil
 }
 
-// CustomAvatarPath returns repository custom avatar file path.
-func (repo *Repository) CustomAvatarPath() string {
-	// Avatar empty by default
+// CustomAvatarRelativePath returns repository custom avatar relative path in the repository avatar storage.
+func (repo *Repository) CustomAvatarRelativePath() string {
+	return repo.Avatar
+}
+
+// hasCustomAvatar returns true if the repository has an avatar, the existence
+// of the avatar file is only checked for the local storage as it is cheap there
+func (repo *Repository) hasCustomAvatar() bool {
 	if len(repo.Avatar) == 0 {
-		return ""
+		return false
+	}
+	if setting.RepoAvatarStorage.IsLocal() {
+		_, err := storage.RepoAvatars.Stat(repo.CustomAvatarRelativePath())
+		return err == nil
 	}
-	return filepath.Join(setting.RepositoryAvatarUploadPath, repo.Avatar)
+	return true
 }
 
 // generateRandomAvatar generates a random avatar for repository.
@@ -2722,18 +2788,9 @@ func (repo *Repository) generateRandomAvatar(e Engine) error {
 	}
 
 	repo.Avatar = idToString
-	if err = os.MkdirAll(filepath.Dir(repo.CustomAvatarPath()), os.ModePerm); err != nil {
-		return fmt.Errorf("MkdirAll: %v", err)
-	}
-	fw, err := os.Create(repo.CustomAvatarPath())
-	if err != nil {
+	if err = saveAvatar(storage.RepoAvatars, repo.CustomAvatarRelativePath(), img); err != nil {
 		return fmt.Errorf("Create: %v", err)
 	}
-	defer fw.Close()
-
-	if err = png.Encode(fw, img); err != nil {
-		return fmt.Errorf("Encode: %v", err)
-	}
 	log.Info("New random avatar created for repository: %d", repo.ID)
 
 	if _, err := e.ID(repo.ID).Cols("avatar").NoAutoTime().Update(repo); err != nil {
@@ -2764,9 +2821,7 @@ func (repo *Repository) RelAvatarLink() string {
 }
 
 func (repo *Repository) relAvatarLink(e Engine) string {
-	// If no avatar - path is empty
-	avatarPath := repo.CustomAvatarPath()
-	if len(avatarPath) == 0 || !com.IsFile(avatarPath) {
+	if !repo.hasCustomAvatar() {
 		switch mode := setting.RepositoryAvatarFallback; mode {
 		case "image":
 			return setting.RepositoryAvatarFallbackImage
@@ -2808,7 +2863,7 @@ func (repo *Repository) UploadAvatar(data []byte) error {
 		return err
 	}
 
-	oldAvatarPath := repo.CustomAvatarPath()
+	oldAvatarPath := repo.CustomAvatarRelativePath()
 
 	// Users can upload the same image to other repo - prefix it with ID
 	// Then repo will be removed - only it avatar file will be removed
@@ -2817,22 +2872,12 @@ func (repo *Repository) UploadAvatar(data []byte) error {
 		return fmt.Errorf("UploadAvatar: Update repository avatar: %v", err)
 	}
 
-	if err := os.MkdirAll(setting.RepositoryAvatarUploadPath, os.ModePerm); err != nil {
-		return fmt.Errorf("UploadAvatar: Failed to create dir %s: %v", setting.RepositoryAvatarUploadPath, err)
-	}
-
-	fw, err := os.Create(repo.CustomAvatarPath())
-	if err != nil {
-		return fmt.Errorf("UploadAvatar: Create file: %v", err)
+	if err := saveAvatar(storage.RepoAvatars, repo.CustomAvatarRelativePath(), *m); err != nil {
+		return fmt.Errorf("UploadAvatar: %v", err)
 	}
-	defer fw.Close()
 
-	if err = png.Encode(fw, *m); err != nil {
-		return fmt.Errorf("UploadAvatar: Encode png: %v", err)
-	}
-
-	if len(oldAvatarPath) > 0 && oldAvatarPath != repo.CustomAvatarPath() {
-		if err := os.Remove(oldAvatarPath); err != nil {
+	if len(oldAvatarPath) > 0 && oldAvatarPath != repo.CustomAvatarRelativePath() {
+		if err := storage.RepoAvatars.Delete(oldAvatarPath); err != nil && !os.IsNotExist(err) {
 			return fmt.Errorf("UploadAvatar: Failed to remove old repo avatar %s: %v", oldAvatarPath, err)
 		}
 	}
@@ -2848,7 +2893,7 @@ func (repo *Repository) DeleteAvatar() error {
 		return nil
 	}
 
-	avatarPath := repo.CustomAvatarPath()
+	avatarPath := repo.CustomAvatarRelativePath()
 	log.Trace("DeleteAvatar[%d]: %s", repo.ID, avatarPath)
 
 	sess := x.NewSession()
@@ -2862,13 +2907,12 @@ func (repo *Repository) DeleteAvatar() error {
 		return fmt.Errorf("DeleteAvatar: Update repository avatar: %v", err)
 	}
 
-	if _, err := os.Stat(avatarPath); err == nil {
-		if err := os.Remove(avatarPath); err != nil {
+	if err := storage.RepoAvatars.Delete(avatarPath); err != nil {
+		if !os.IsNotExist(err) {
 			return fmt.Errorf("DeleteAvatar: Failed to remove %s: %v", avatarPath, err)
 		}
-	} else {
 		// // Schrodinger: file may or may not exist. See err for details.
-		log.Trace("DeleteAvatar[%d]: %v", err)
+		log.Trace("DeleteAvatar[%d]: %v", repo.ID, err)
 	}
 	return sess.Commit()
 }
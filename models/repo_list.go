@@ -148,6 +148,8 @@ type SearchRepoOptions struct {
 	// True -> include just has milestones
 	// False -> include just has no milestone
 	HasMilestones util.OptionalBool
+	// Only include the repositories the access token may be used for
+	AccessToken *AccessToken
 }
 
 //SearchOrderBy is used to sort the result
@@ -201,6 +203,13 @@ func SearchRepositoryCondition(opts *SearchRepoOptions) builder.Cond {
 		cond = cond.And(builder.Eq{"is_template": opts.Template == util.OptionalBoolTrue})
 	}
 
+	// Restrict to the repositories of a restricted access token
+	if opts.AccessToken != nil && opts.AccessToken.IsRestricted() {
+		cond = cond.And(builder.Or(
+			builder.In("`repository`.id", opts.AccessToken.RepoIDs),
+			builder.In("`repository`.owner_id", opts.AccessToken.OrgIDs)))
+	}
+
 	// Restrict to starred repositories
 	if opts.StarredByID > 0 {
 		cond = cond.And(builder.In("id", builder.Select("repo_id").From("star").Where(builder.Eq{"uid": opts.StarredByID})))
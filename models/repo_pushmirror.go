@@ -0,0 +1,101 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+// PushMirror represents a remote which a repository is pushed to.
+// The address and credentials of the remote are stored in the git
+// configuration of the repository under RemoteName.
+type PushMirror struct {
+	ID         int64       `xorm:"pk autoincr"`
+	RepoID     int64       `xorm:"INDEX"`
+	Repo       *Repository `xorm:"-"`
+	RemoteName string
+
+	SyncOnPush     bool
+	Interval       time.Duration
+	CreatedUnix    timeutil.TimeStamp `xorm:"created"`
+	LastUpdateUnix timeutil.TimeStamp `xorm:"INDEX last_update"`
+	LastError      string             `xorm:"text"`
+}
+
+// AfterLoad is invoked from XORM after setting the values of all fields of this object.
+func (m *PushMirror) AfterLoad(session *xorm.Session) {
+	if m == nil {
+		return
+	}
+
+	var err error
+	m.Repo, err = getRepositoryByID(session, m.RepoID)
+	if err != nil {
+		log.Error("getRepositoryByID[%d]: %v", m.ID, err)
+	}
+}
+
+// IsDue returns true if the interval of the push mirror has elapsed since its last update.
+func (m *PushMirror) IsDue() bool {
+	if m.Interval == 0 {
+		return false
+	}
+	return m.LastUpdateUnix.AddDuration(m.Interval) <= timeutil.TimeStampNow()
+}
+
+// InsertPushMirror inserts a push mirror to database
+func InsertPushMirror(m *PushMirror) error {
+	_, err := x.Insert(m)
+	return err
+}
+
+// UpdatePushMirror updates the push mirror
+func UpdatePushMirror(m *PushMirror) error {
+	_, err := x.ID(m.ID).AllCols().Update(m)
+	return err
+}
+
+// DeletePushMirrorByID deletes a push mirror by its ID
+func DeletePushMirrorByID(id int64) error {
+	_, err := x.ID(id).Delete(&PushMirror{})
+	return err
+}
+
+// GetPushMirrorByID returns the push mirror with the given ID.
+func GetPushMirrorByID(id int64) (*PushMirror, error) {
+	m := &PushMirror{}
+	has, err := x.ID(id).Get(m)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPushMirrorNotExist
+	}
+	return m, nil
+}
+
+// GetPushMirrorsByRepoID returns all push mirrors of a repository.
+func GetPushMirrorsByRepoID(repoID int64) ([]*PushMirror, error) {
+	mirrors := make([]*PushMirror, 0, 10)
+	return mirrors, x.Where("repo_id=?", repoID).Asc("id").Find(&mirrors)
+}
+
+// GetPushMirrorsSyncedOnPush returns the push mirrors of a repository which have to be synced on every push.
+func GetPushMirrorsSyncedOnPush(repoID int64) ([]*PushMirror, error) {
+	mirrors := make([]*PushMirror, 0, 10)
+	return mirrors, x.Where("repo_id=? AND sync_on_push=?", repoID, true).Find(&mirrors)
+}
+
+// PushMirrorsIterate iterates all push mirrors which are synced on an interval.
+func PushMirrorsIterate(f func(idx int, bean interface{}) error) error {
+	return x.
+		Where("`interval`!=0").
+		OrderBy("last_update ASC").
+		Iterate(new(PushMirror), f)
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPushMirrorsIterate(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	now := timeutil.TimeStampNow()
+
+	assert.NoError(t, InsertPushMirror(&PushMirror{
+		RepoID:     1,
+		RemoteName: "test-1",
+		SyncOnPush: true,
+	}))
+
+	m2 := &PushMirror{
+		RepoID:     1,
+		RemoteName: "test-2",
+		Interval:   time.Hour,
+	}
+	assert.NoError(t, InsertPushMirror(m2))
+	m2.LastUpdateUnix = now.Add(-7200)
+	assert.NoError(t, UpdatePushMirror(m2))
+
+	assert.NoError(t, InsertPushMirror(&PushMirror{
+		RepoID:         2,
+		RemoteName:     "test-3",
+		Interval:       time.Hour,
+		LastUpdateUnix: now,
+	}))
+
+	mirrors, err := GetPushMirrorsByRepoID(1)
+	assert.NoError(t, err)
+	assert.Len(t, mirrors, 2)
+
+	mirrors, err = GetPushMirrorsSyncedOnPush(1)
+	assert.NoError(t, err)
+	if assert.Len(t, mirrors, 1) {
+		assert.EqualValues(t, "test-1", mirrors[0].RemoteName)
+	}
+
+	due := make([]string, 0, 2)
+	assert.NoError(t, PushMirrorsIterate(func(idx int, bean interface{}) error {
+		m := bean.(*PushMirror)
+		assert.NotNil(t, m.Repo)
+		if m.IsDue() {
+			due = append(due, m.RemoteName)
+		}
+		return nil
+	}))
+	assert.Equal(t, []string{"test-2"}, due)
+
+	assert.NoError(t, DeletePushMirrorByID(m2.ID))
+	_, err = GetPushMirrorByID(m2.ID)
+	assert.Equal(t, ErrPushMirrorNotExist, err)
+}
@@ -0,0 +1,543 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	"xorm.io/core"
+	"xorm.io/xorm"
+)
+
+var dumpHeaderPattern = regexp.MustCompile(`^/\*Generated by xorm \S+ .* from (\w+) to (\w+)\*/$`)
+
+// DatabaseDump describes an SQL dump written by DumpDatabase
+type DatabaseDump struct {
+	Path string
+	// DBType is the database type the SQL syntax of the dump was written for
+	DBType string
+	// Version is the version of the database schema, -1 if the dump does not record it
+	Version int64
+	// Tables lists the tables of the dump in the order of their creation
+	Tables []string
+	// Rows is the number of rows of each table
+	Rows map[string]int64
+}
+
+// ReadDatabaseDump reads and validates the SQL dump at path
+func ReadDatabaseDump(path string) (*DatabaseDump, error) {
+	dump := &DatabaseDump{
+		Path:    path,
+		Version: -1,
+		Rows:    make(map[string]int64),
+	}
+	created := make(map[string]bool)
+	err := readDatabaseDump(path, func(dbType string) error {
+		dump.DBType = dbType
+		return nil
+	}, func(stmt *dumpStatement) error {
+		if stmt.insert == nil {
+			if stmt.table != "" && !created[stmt.table] {
+				created[stmt.table] = true
+				dump.Tables = append(dump.Tables, stmt.table)
+			}
+			return nil
+		}
+
+		if !created[stmt.insert.table] {
+			return fmt.Errorf("rows inserted into table %s before it is created", stmt.insert.table)
+		}
+		dump.Rows[stmt.insert.table]++
+		if stmt.insert.table == "version" {
+			for i, col := range stmt.insert.columns {
+				if version, ok := stmt.insert.values[i].(int64); ok && col == "version" {
+					dump.Version = version
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
+// IsDatabaseEmpty returns whether the database contains no tables at all
+func IsDatabaseEmpty() (bool, error) {
+	tables, err := x.DBMetas()
+	if err != nil {
+		return false, err
+	}
+	return len(tables) == 0, nil
+}
+
+// RestoreDatabase imports the dump into the empty database. If useDumpSchema is set the tables are
+// created by the statements of the dump, which must have been written for the type of the database.
+// Otherwise the tables are created from the current models and the version table of the dump is skipped,
+// the dump must then have been created by the same version of Gitea.
+func RestoreDatabase(dump *DatabaseDump, useDumpSchema bool) error {
+	if empty, err := IsDatabaseEmpty(); err != nil {
+		return err
+	} else if !empty {
+		return errors.New("the database is not empty")
+	}
+
+	if useDumpSchema {
+		if err := readDatabaseDump(dump.Path, nil, func(stmt *dumpStatement) error {
+			if stmt.insert != nil {
+				return nil
+			}
+			_, err := x.Exec(stmt.sql)
+			return err
+		}); err != nil {
+			return fmt.Errorf("create tables: %v", err)
+		}
+	} else if err := x.StoreEngine("InnoDB").Sync2(tables...); err != nil {
+		return fmt.Errorf("sync database struct error: %v", err)
+	}
+
+	metas, err := x.DBMetas()
+	if err != nil {
+		return err
+	}
+	targets := make(map[string]*core.Table, len(metas))
+	for _, table := range metas {
+		targets[table.Name] = table
+	}
+
+	r := &dumpRestorer{targets: targets}
+	if err := readDatabaseDump(dump.Path, nil, r.restore); err != nil {
+		if r.sess != nil {
+			r.sess.Close()
+		}
+		return err
+	}
+	if err := r.finishTable(); err != nil {
+		return err
+	}
+
+	if setting.Database.UsePostgreSQL {
+		// the sequences don't know about the inserted ids
+		for _, table := range metas {
+			if col := table.AutoIncrColumn(); col != nil {
+				if _, err := x.Exec(fmt.Sprintf("SELECT setval(pg_get_serial_sequence(?, ?), COALESCE(MAX(%s), 0) + 1, false) FROM %s",
+					x.Quote(col.Name), x.Quote(table.Name)), x.Quote(table.Name), col.Name); err != nil {
+					return fmt.Errorf("reset sequence of %s: %v", table.Name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// dumpRestorer inserts the rows of a dump, each table in its own transaction
+type dumpRestorer struct {
+	targets map[string]*core.Table
+	table   *core.Table
+	skipped string
+	sess    *xorm.Session
+}
+
+func (r *dumpRestorer) finishTable() error {
+	if r.sess == nil {
+		return nil
+	}
+	defer func() {
+		r.sess.Close()
+		r.sess = nil
+	}()
+	if setting.Database.UseMSSQL && r.table.AutoIncrColumn() != nil {
+		if _, err := r.sess.Exec("SET IDENTITY_INSERT " + x.Quote(r.table.Name) + " OFF"); err != nil {
+			return err
+		}
+	}
+	return r.sess.Commit()
+}
+
+func (r *dumpRestorer) restore(stmt *dumpStatement) error {
+	ins := stmt.insert
+	if ins == nil || ins.table == r.skipped {
+		return nil
+	}
+
+	if r.table == nil || r.table.Name != ins.table {
+		if err := r.finishTable(); err != nil {
+			return err
+		}
+		r.table = r.targets[ins.table]
+		if r.table == nil {
+			if ins.table != "version" {
+				return fmt.Errorf("table %s of the dump does not exist", ins.table)
+			}
+			// the migrations will create the table and record the current version
+			r.skipped = ins.table
+			return nil
+		}
+		log.Info("Restoring table %s", ins.table)
+
+		r.sess = x.NewSession()
+		if err := r.sess.Begin(); err != nil {
+			return err
+		}
+		if setting.Database.UseMSSQL && r.table.AutoIncrColumn() != nil {
+			if _, err := r.sess.Exec("SET IDENTITY_INSERT " + x.Quote(r.table.Name) + " ON"); err != nil {
+				return err
+			}
+		}
+	}
+
+	cols := make([]string, len(ins.columns))
+	args := make([]interface{}, 0, len(ins.values)+1)
+	args = append(args, "")
+	for i, name := range ins.columns {
+		col := r.table.GetColumn(name)
+		if col == nil {
+			return fmt.Errorf("column %s of table %s does not exist", name, ins.table)
+		}
+		cols[i] = x.Quote(name)
+		value := ins.values[i]
+		if s, ok := value.(string); ok && col.SQLType.IsBlob() {
+			value = []byte(s)
+		}
+		args = append(args, value)
+	}
+	args[0] = "INSERT INTO " + x.Quote(ins.table) + " (" + strings.Join(cols, ", ") + ") VALUES (" +
+		strings.Repeat("?, ", len(cols)-1) + "?)"
+
+	if _, err := r.sess.Exec(args...); err != nil {
+		return fmt.Errorf("insert into %s: %v", ins.table, err)
+	}
+	return nil
+}
+
+type dumpInsert struct {
+	table   string
+	columns []string
+	values  []interface{}
+}
+
+type dumpStatement struct {
+	sql string
+	// table is the table created by the statement, empty for other statements
+	table  string
+	insert *dumpInsert
+}
+
+// readDatabaseDump calls onStatement with each statement of the dump
+func readDatabaseDump(path string, onHeader func(dbType string) error, onStatement func(*dumpStatement) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	header, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	matches := dumpHeaderPattern.FindStringSubmatch(strings.TrimSpace(header))
+	if matches == nil {
+		return errors.New("not an SQL dump created by gitea dump")
+	}
+	if onHeader != nil {
+		if err := onHeader(strings.ToLower(matches[2])); err != nil {
+			return err
+		}
+	}
+
+	for {
+		sql, err := nextDumpStatement(r)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		stmt, err := parseDumpStatement(sql)
+		if err != nil {
+			return err
+		}
+		if err := onStatement(stmt); err != nil {
+			return err
+		}
+	}
+}
+
+// nextDumpStatement reads the next statement up to the semicolon which ends it, comments are skipped
+func nextDumpStatement(r *bufio.Reader) (string, error) {
+	var sb strings.Builder
+	var quote byte
+	for {
+		c, err := r.ReadByte()
+		if err == io.EOF {
+			if quote != 0 {
+				return "", io.ErrUnexpectedEOF
+			}
+			if stmt := strings.TrimSpace(sb.String()); stmt != "" {
+				return stmt, nil
+			}
+			return "", io.EOF
+		} else if err != nil {
+			return "", err
+		}
+
+		if quote != 0 {
+			sb.WriteByte(c)
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '[':
+			quote = ']'
+		case ';':
+			if stmt := strings.TrimSpace(sb.String()); stmt != "" {
+				return stmt, nil
+			}
+			continue
+		case '-':
+			if next, err := r.Peek(1); err == nil && next[0] == '-' {
+				if _, err := r.ReadString('\n'); err != nil && err != io.EOF {
+					return "", err
+				}
+				continue
+			}
+		case '/':
+			if next, err := r.Peek(1); err == nil && next[0] == '*' {
+				_, _ = r.ReadByte()
+				if err := skipBlockComment(r); err != nil {
+					return "", err
+				}
+				continue
+			}
+		}
+		sb.WriteByte(c)
+	}
+}
+
+func skipBlockComment(r *bufio.Reader) error {
+	var last byte
+	for {
+		c, err := r.ReadByte()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+		if last == '*' && c == '/' {
+			return nil
+		}
+		last = c
+	}
+}
+
+var createTablePattern = regexp.MustCompile("(?i)CREATE TABLE (?:IF NOT EXISTS )?([`\"\\[]?)([^`\"\\]\\s(]+)")
+
+func parseDumpStatement(sql string) (*dumpStatement, error) {
+	stmt := &dumpStatement{sql: sql}
+	if !strings.HasPrefix(strings.ToUpper(sql), "INSERT INTO") {
+		if matches := createTablePattern.FindStringSubmatch(sql); matches != nil {
+			stmt.table = matches[2]
+		}
+		return stmt, nil
+	}
+
+	p := &dumpParser{s: sql, pos: len("INSERT INTO")}
+	ins := &dumpInsert{}
+	var err error
+	if ins.table, err = p.identifier(); err != nil {
+		return nil, err
+	}
+	if err = p.expect("("); err != nil {
+		return nil, err
+	}
+	for {
+		col, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		ins.columns = append(ins.columns, col)
+		if p.accept(")") {
+			break
+		} else if err = p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+	if err = p.expect("VALUES"); err != nil {
+		return nil, err
+	}
+	if err = p.expect("("); err != nil {
+		return nil, err
+	}
+	for {
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		ins.values = append(ins.values, value)
+		if p.accept(")") {
+			break
+		} else if err = p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+	p.skipSpace()
+	if p.pos != len(p.s) {
+		return nil, p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	if len(ins.columns) != len(ins.values) {
+		return nil, fmt.Errorf("insert into %s: %d columns but %d values", ins.table, len(ins.columns), len(ins.values))
+	}
+	stmt.insert = ins
+	return stmt, nil
+}
+
+// dumpParser parses the literals of the insert statements written by xorm
+type dumpParser struct {
+	s   string
+	pos int
+}
+
+func (p *dumpParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid statement at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *dumpParser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *dumpParser) accept(token string) bool {
+	p.skipSpace()
+	if len(p.s)-p.pos >= len(token) && strings.EqualFold(p.s[p.pos:p.pos+len(token)], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *dumpParser) expect(token string) error {
+	if !p.accept(token) {
+		return p.errorf("expected %s", token)
+	}
+	return nil
+}
+
+func (p *dumpParser) identifier() (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return "", p.errorf("expected identifier")
+	}
+	var end byte
+	switch p.s[p.pos] {
+	case '`', '"':
+		end = p.s[p.pos]
+	case '[':
+		end = ']'
+	default:
+		start := p.pos
+		for p.pos < len(p.s) && (p.s[p.pos] == '_' || p.s[p.pos] >= '0' && p.s[p.pos] <= '9' ||
+			p.s[p.pos] >= 'a' && p.s[p.pos] <= 'z' || p.s[p.pos] >= 'A' && p.s[p.pos] <= 'Z') {
+			p.pos++
+		}
+		if start == p.pos {
+			return "", p.errorf("expected identifier")
+		}
+		return p.s[start:p.pos], nil
+	}
+	idx := strings.IndexByte(p.s[p.pos+1:], end)
+	if idx < 0 {
+		return "", p.errorf("unterminated identifier")
+	}
+	name := p.s[p.pos+1 : p.pos+1+idx]
+	p.pos += idx + 2
+	return name, nil
+}
+
+// value parses NULL, booleans, numbers, quoted strings and hexadecimal blobs
+func (p *dumpParser) value() (interface{}, error) {
+	p.skipSpace()
+	switch {
+	case p.accept("NULL"):
+		return nil, nil
+	case p.accept("true"):
+		return true, nil
+	case p.accept("false"):
+		return false, nil
+	case p.accept("0x"):
+		return p.hex(len(p.s))
+	case p.accept("X'"):
+		blob, err := p.hex(strings.IndexByte(p.s[p.pos:], '\'') + p.pos)
+		if err != nil {
+			return nil, err
+		}
+		return blob, p.expect("'")
+	case p.accept("'"):
+		var sb strings.Builder
+		for {
+			idx := strings.IndexByte(p.s[p.pos:], '\'')
+			if idx < 0 {
+				return nil, p.errorf("unterminated string")
+			}
+			sb.WriteString(p.s[p.pos : p.pos+idx])
+			p.pos += idx + 1
+			if p.pos < len(p.s) && p.s[p.pos] == '\'' {
+				sb.WriteByte('\'')
+				p.pos++
+				continue
+			}
+			return sb.String(), nil
+		}
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("+-.0123456789eE", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	num := p.s[start:p.pos]
+	if i, err := strconv.ParseInt(num, 10, 64); err == nil {
+		return i, nil
+	}
+	if u, err := strconv.ParseUint(num, 10, 64); err == nil {
+		return u, nil
+	}
+	if f, err := strconv.ParseFloat(num, 64); err == nil {
+		return f, nil
+	}
+	p.pos = start
+	return nil, p.errorf("unexpected value")
+}
+
+func (p *dumpParser) hex(limit int) ([]byte, error) {
+	if limit < p.pos {
+		return nil, p.errorf("unterminated blob")
+	}
+	start := p.pos
+	for p.pos < limit && strings.IndexByte("0123456789abcdefABCDEF", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	blob, err := hex.DecodeString(p.s[start:p.pos])
+	if err != nil {
+		return nil, p.errorf("invalid blob: %v", err)
+	}
+	return blob, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/core"
+	"xorm.io/xorm"
+)
+
+func TestParseDumpStatement(t *testing.T) {
+	stmt, err := parseDumpStatement("CREATE TABLE IF NOT EXISTS `user` (`id` INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL)")
+	assert.NoError(t, err)
+	assert.Equal(t, "user", stmt.table)
+	assert.Nil(t, stmt.insert)
+
+	stmt, err = parseDumpStatement(`INSERT INTO "repo" ("id", "name", "description", "is_private", "avatar", "size", "deleted") VALUES (1, 'it''s; a repo', 'multi
+line', true, 0xCAFE, -12, NULL)`)
+	assert.NoError(t, err)
+	assert.Equal(t, "repo", stmt.insert.table)
+	assert.Equal(t, []string{"id", "name", "description", "is_private", "avatar", "size", "deleted"}, stmt.insert.columns)
+	assert.Equal(t, []interface{}{int64(1), "it's; a repo", "multi\nline", true, []byte{0xca, 0xfe}, int64(-12), nil}, stmt.insert.values)
+
+	stmt, err = parseDumpStatement("INSERT INTO [attachment] ([id], [data]) VALUES (2, X'00ff')")
+	assert.NoError(t, err)
+	assert.Equal(t, "attachment", stmt.insert.table)
+	assert.Equal(t, []interface{}{int64(2), []byte{0x00, 0xff}}, stmt.insert.values)
+
+	_, err = parseDumpStatement("INSERT INTO `user` (`id`, `name`) VALUES (1)")
+	assert.Error(t, err)
+	_, err = parseDumpStatement("INSERT INTO `user` (`id`) VALUES ('unterminated)")
+	assert.Error(t, err)
+}
+
+func TestReadDatabaseDump(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "dump")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "invalid.sql")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("CREATE TABLE `user` (`id` INTEGER);\n"), 0600))
+	_, err = ReadDatabaseDump(path)
+	assert.Error(t, err)
+
+	path = filepath.Join(tmpDir, "dump.sql")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`/*Generated by xorm v0.8.0 2020-01-01 00:00:00, from sqlite3 to MYSQL*/
+
+CREATE TABLE IF NOT EXISTS `+"`user`"+` (`+"`id`"+` BIGINT(20) PRIMARY KEY AUTO_INCREMENT NOT NULL, `+"`name`"+` TEXT);
+INSERT INTO `+"`user`"+` (`+"`id`, `name`"+`) VALUES (1, 'user; one');
+INSERT INTO `+"`user`"+` (`+"`id`, `name`"+`) VALUES (2, 'user two');
+
+CREATE TABLE IF NOT EXISTS `+"`version`"+` (`+"`id`"+` BIGINT(20) PRIMARY KEY AUTO_INCREMENT NOT NULL, `+"`version`"+` BIGINT(20));
+INSERT INTO `+"`version`"+` (`+"`id`, `version`"+`) VALUES (1, 128);
+`), 0600))
+	dump, err := ReadDatabaseDump(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "mysql", dump.DBType)
+	assert.EqualValues(t, 128, dump.Version)
+	assert.Equal(t, []string{"user", "version"}, dump.Tables)
+	assert.EqualValues(t, 2, dump.Rows["user"])
+}
+
+func TestDumpAndRestoreDatabase(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	tmpDir, err := ioutil.TempDir("", "dump")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "gitea-db.sql")
+	assert.NoError(t, DumpDatabase(path, ""))
+	dump, err := ReadDatabaseDump(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "sqlite3", dump.DBType)
+	userCount, err := x.Count(new(User))
+	assert.NoError(t, err)
+	assert.EqualValues(t, userCount, dump.Rows["user"])
+
+	oldX := x
+	defer func() {
+		x = oldX
+	}()
+
+	for _, useDumpSchema := range []bool{true, false} {
+		x, err = xorm.NewEngine("sqlite3", "file:"+filepath.Join(tmpDir, "restored.db"))
+		assert.NoError(t, err)
+		x.SetMapper(core.GonicMapper{})
+
+		assert.NoError(t, RestoreDatabase(dump, useDumpSchema))
+		assert.Error(t, RestoreDatabase(dump, useDumpSchema), "database is not empty")
+
+		restored, err := x.Count(new(User))
+		assert.NoError(t, err)
+		assert.EqualValues(t, userCount, restored)
+		user := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+		assert.Equal(t, "user2", user.Name)
+
+		assert.NoError(t, x.Close())
+		assert.NoError(t, os.Remove(filepath.Join(tmpDir, "restored.db")))
+	}
+}
@@ -5,6 +5,7 @@
 package models
 
 import (
+	"fmt"
 	"strings"
 
 	"code.gitea.io/gitea/modules/timeutil"
@@ -27,6 +28,8 @@ const (
 	ReviewTypeComment
 	// ReviewTypeReject gives feedback blocking merge
 	ReviewTypeReject
+	// ReviewTypeRequest request review from others
+	ReviewTypeRequest
 )
 
 // Icon returns the corresponding icon for the review type
@@ -38,6 +41,8 @@ func (rt ReviewType) Icon() string {
 		return "x"
 	case ReviewTypeComment, ReviewTypeUnknown:
 		return "comment"
+	case ReviewTypeRequest:
+		return "primitive-dot"
 	default:
 		return "comment"
 	}
@@ -47,13 +52,20 @@ func (rt ReviewType) Icon() string {
 type Review struct {
 	ID         int64 `xorm:"pk autoincr"`
 	Type       ReviewType
-	Reviewer   *User  `xorm:"-"`
-	ReviewerID int64  `xorm:"index"`
-	Issue      *Issue `xorm:"-"`
-	IssueID    int64  `xorm:"index"`
-	Content    string `xorm:"TEXT"`
+	Reviewer   *User `xorm:"-"`
+	ReviewerID int64 `xorm:"index"`
+	// ReviewerTeamID is only set for review requests of a team
+	ReviewerTeamID int64  `xorm:"NOT NULL DEFAULT 0"`
+	ReviewerTeam   *Team  `xorm:"-"`
+	Issue          *Issue `xorm:"-"`
+	IssueID        int64  `xorm:"index"`
+	Content        string `xorm:"TEXT"`
 	// Official is a review made by an assigned approver (counts towards approval)
 	Official bool `xorm:"NOT NULL DEFAULT false"`
+	// CommitID is the head commit of the pull request when the review was submitted
+	CommitID string `xorm:"VARCHAR(40)"`
+	// Dismissed reviews no longer count towards approval or rejection
+	Dismissed bool `xorm:"NOT NULL DEFAULT false"`
 
 	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
@@ -75,8 +87,12 @@ func (r *Review) LoadCodeComments() error {
 }
 
 func (r *Review) loadIssue(e Engine) (err error) {
-	r.Issue, err = getIssueByID(e, r.IssueID)
-	return
+	if r.Issue == nil {
+		if r.Issue, err = getIssueByID(e, r.IssueID); err != nil {
+			return
+		}
+	}
+	return r.Issue.loadRepo(e)
 }
 
 func (r *Review) loadReviewer(e Engine) (err error) {
@@ -92,14 +108,23 @@ func (r *Review) LoadReviewer() error {
 	return r.loadReviewer(x)
 }
 
+func (r *Review) loadReviewerTeam(e Engine) (err error) {
+	if r.ReviewerTeamID == 0 || r.ReviewerTeam != nil {
+		return nil
+	}
+	r.ReviewerTeam, err = getTeamByID(e, r.ReviewerTeamID)
+	return
+}
+
 func (r *Review) loadAttributes(e Engine) (err error) {
-	if err = r.loadReviewer(e); err != nil {
+	if err = r.loadIssue(e); err != nil {
 		return
 	}
-	if err = r.loadIssue(e); err != nil {
+	if err = r.loadReviewerTeam(e); err != nil {
 		return
 	}
-	return
+	// the reviewer is loaded last, callers may replace a deleted one by the ghost user
+	return r.loadReviewer(e)
 }
 
 // LoadAttributes loads all attributes except CodeComments
@@ -107,6 +132,29 @@ func (r *Review) LoadAttributes() error {
 	return r.loadAttributes(x)
 }
 
+// GetCodeCommentsCount returns the number of code comments of the review
+func (r *Review) GetCodeCommentsCount() int {
+	count, err := x.Where("review_id = ?", r.ID).
+		And("type = ?", CommentTypeCode).
+		Count(new(Comment))
+	if err != nil {
+		return 0
+	}
+	return int(count)
+}
+
+// HTMLURL returns the HTML URL of the comment which published the review
+func (r *Review) HTMLURL() string {
+	comment := new(Comment)
+	has, err := x.Where("review_id = ?", r.ID).
+		And("type = ?", CommentTypeReview).
+		Get(comment)
+	if err != nil || !has {
+		return ""
+	}
+	return comment.HTMLURL()
+}
+
 func getReviewByID(e Engine, id int64) (*Review, error) {
 	review := new(Review)
 	if has, err := e.ID(id).Get(review); err != nil {
@@ -129,6 +177,9 @@ type FindReviewOptions struct {
 	IssueID      int64
 	ReviewerID   int64
 	OfficialOnly bool
+	// ExcludePending excludes pending reviews, except the ones of IncludePendingOf
+	ExcludePending   bool
+	IncludePendingOf *User
 }
 
 func (opts *FindReviewOptions) toCond() builder.Cond {
@@ -145,6 +196,14 @@ func (opts *FindReviewOptions) toCond() builder.Cond {
 	if opts.OfficialOnly {
 		cond = cond.And(builder.Eq{"official": true})
 	}
+	if opts.ExcludePending {
+		notPending := builder.Neq{"type": ReviewTypePending}
+		if opts.IncludePendingOf != nil {
+			cond = cond.And(notPending.Or(builder.Eq{"reviewer_id": opts.IncludePendingOf.ID}))
+		} else {
+			cond = cond.And(notPending)
+		}
+	}
 	return cond
 }
 
@@ -169,6 +228,7 @@ type CreateReviewOptions struct {
 	Issue    *Issue
 	Reviewer *User
 	Official bool
+	CommitID string
 }
 
 // IsOfficialReviewer check if reviewer can make official reviews in issue (counts towards required approvals)
@@ -200,6 +260,7 @@ func createReview(e Engine, opts CreateReviewOptions) (*Review, error) {
 		ReviewerID: opts.Reviewer.ID,
 		Content:    opts.Content,
 		Official:   opts.Official,
+		CommitID:   opts.CommitID,
 	}
 	if _, err := e.Insert(review); err != nil {
 		return nil, err
@@ -258,7 +319,7 @@ func IsContentEmptyErr(err error) bool {
 }
 
 // SubmitReview creates a review out of the existing pending review or creates a new one if no pending review exist
-func SubmitReview(doer *User, issue *Issue, reviewType ReviewType, content string) (*Review, *Comment, error) {
+func SubmitReview(doer *User, issue *Issue, reviewType ReviewType, content, commitID string) (*Review, *Comment, error) {
 	sess := x.NewSession()
 	defer sess.Close()
 	if err := sess.Begin(); err != nil {
@@ -295,6 +356,7 @@ func SubmitReview(doer *User, issue *Issue, reviewType ReviewType, content strin
 			Reviewer: doer,
 			Content:  content,
 			Official: official,
+			CommitID: commitID,
 		})
 		if err != nil {
 			return nil, nil, err
@@ -322,12 +384,17 @@ func SubmitReview(doer *User, issue *Issue, reviewType ReviewType, content strin
 		review.Issue = issue
 		review.Content = content
 		review.Type = reviewType
+		review.CommitID = commitID
 
-		if _, err := sess.ID(review.ID).Cols("content, type, official").Update(review); err != nil {
+		if _, err := sess.ID(review.ID).Cols("content, type, official, commit_id").Update(review); err != nil {
 			return nil, nil, err
 		}
 	}
 
+	if err := removeFulfilledReviewRequests(sess, issue, doer); err != nil {
+		return nil, nil, err
+	}
+
 	comm, err := createComment(sess, &CreateCommentOptions{
 		Type:     CommentTypeReview,
 		Doer:     doer,
@@ -355,8 +422,8 @@ func GetReviewersByIssueID(issueID int64) (reviews []*Review, err error) {
 	}
 
 	// Get latest review of each reviwer, sorted in order they were made
-	if err := sess.SQL("SELECT * FROM review WHERE id IN (SELECT max(id) as id FROM review WHERE issue_id = ? AND type in (?, ?) GROUP BY issue_id, reviewer_id) ORDER BY review.updated_unix ASC",
-		issueID, ReviewTypeApprove, ReviewTypeReject).
+	if err := sess.SQL("SELECT * FROM review WHERE id IN (SELECT max(id) as id FROM review WHERE issue_id = ? AND type in (?, ?) AND dismissed = ? GROUP BY issue_id, reviewer_id) ORDER BY review.updated_unix ASC",
+		issueID, ReviewTypeApprove, ReviewTypeReject, false).
 		Find(&reviewsUnfiltered); err != nil {
 		return nil, err
 	}
@@ -374,3 +441,192 @@ func GetReviewersByIssueID(issueID int64) (reviews []*Review, err error) {
 
 	return reviews, nil
 }
+
+// removeFulfilledReviewRequests removes the review requests of the reviewer
+// and of the teams the reviewer is a member of
+func removeFulfilledReviewRequests(e Engine, issue *Issue, reviewer *User) error {
+	_, err := e.Where(builder.Eq{"issue_id": issue.ID, "type": ReviewTypeRequest}.
+		And(builder.Eq{"reviewer_id": reviewer.ID}.
+			Or(builder.In("reviewer_team_id", builder.Select("team_id").From("team_user").Where(builder.Eq{"uid": reviewer.ID}))))).
+		Delete(new(Review))
+	return err
+}
+
+// GetReviewRequestsByIssueID returns the open review requests of users and teams for the pull request
+func GetReviewRequestsByIssueID(issueID int64) ([]*Review, error) {
+	requests := make([]*Review, 0, 5)
+	if err := x.Where("issue_id = ? AND type = ?", issueID, ReviewTypeRequest).
+		Asc("id").
+		Find(&requests); err != nil {
+		return nil, err
+	}
+
+	for _, request := range requests {
+		if err := request.loadReviewer(x); err != nil {
+			if !IsErrUserNotExist(err) {
+				return nil, err
+			}
+			request.Reviewer = NewGhostUser()
+		}
+		if err := request.loadReviewerTeam(x); err != nil {
+			return nil, err
+		}
+	}
+	return requests, nil
+}
+
+func getReviewRequest(e Engine, issueID, reviewerID, reviewerTeamID int64) (*Review, error) {
+	review := new(Review)
+	has, err := e.Where("issue_id = ? AND type = ? AND reviewer_id = ? AND reviewer_team_id = ?",
+		issueID, ReviewTypeRequest, reviewerID, reviewerTeamID).
+		Get(review)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, nil
+	}
+	return review, nil
+}
+
+// addReviewRequest creates a review request and its comment, it returns a nil comment if the request already exists
+func addReviewRequest(issue *Issue, reviewerID, reviewerTeamID int64, doer *User) (*Comment, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	request, err := getReviewRequest(sess, issue.ID, reviewerID, reviewerTeamID)
+	if err != nil {
+		return nil, err
+	} else if request != nil {
+		return nil, nil
+	}
+
+	if _, err = sess.Insert(&Review{
+		Type:           ReviewTypeRequest,
+		IssueID:        issue.ID,
+		ReviewerID:     reviewerID,
+		ReviewerTeamID: reviewerTeamID,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err = issue.loadRepo(sess); err != nil {
+		return nil, err
+	}
+
+	comment, err := createComment(sess, &CreateCommentOptions{
+		Type:           CommentTypeReviewRequest,
+		Doer:           doer,
+		Repo:           issue.Repo,
+		Issue:          issue,
+		AssigneeID:     reviewerID,
+		AssigneeTeamID: reviewerTeamID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, sess.Commit()
+}
+
+// removeReviewRequest removes a review request and creates its comment, it returns a nil comment if there was no request
+func removeReviewRequest(issue *Issue, reviewerID, reviewerTeamID int64, doer *User) (*Comment, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	request, err := getReviewRequest(sess, issue.ID, reviewerID, reviewerTeamID)
+	if err != nil {
+		return nil, err
+	} else if request == nil {
+		return nil, nil
+	}
+
+	if _, err = sess.ID(request.ID).Delete(new(Review)); err != nil {
+		return nil, err
+	}
+
+	if err = issue.loadRepo(sess); err != nil {
+		return nil, err
+	}
+
+	comment, err := createComment(sess, &CreateCommentOptions{
+		Type:            CommentTypeReviewRequest,
+		Doer:            doer,
+		Repo:            issue.Repo,
+		Issue:           issue,
+		AssigneeID:      reviewerID,
+		AssigneeTeamID:  reviewerTeamID,
+		RemovedAssignee: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, sess.Commit()
+}
+
+// AddReviewRequest requests a review of the pull request from the reviewer
+func AddReviewRequest(issue *Issue, reviewer, doer *User) (*Comment, error) {
+	return addReviewRequest(issue, reviewer.ID, 0, doer)
+}
+
+// RemoveReviewRequest removes the review request of the reviewer
+func RemoveReviewRequest(issue *Issue, reviewer, doer *User) (*Comment, error) {
+	return removeReviewRequest(issue, reviewer.ID, 0, doer)
+}
+
+// AddTeamReviewRequest requests a review of the pull request from the team
+func AddTeamReviewRequest(issue *Issue, team *Team, doer *User) (*Comment, error) {
+	return addReviewRequest(issue, 0, team.ID, doer)
+}
+
+// RemoveTeamReviewRequest removes the review request of the team
+func RemoveTeamReviewRequest(issue *Issue, team *Team, doer *User) (*Comment, error) {
+	return removeReviewRequest(issue, 0, team.ID, doer)
+}
+
+// UpdateReviewCols updates the given columns of a review
+func UpdateReviewCols(r *Review, cols ...string) error {
+	_, err := x.ID(r.ID).Cols(cols...).Update(r)
+	return err
+}
+
+// DismissReview change the dismiss status of a review
+func DismissReview(review *Review, isDismiss bool) error {
+	if review.Dismissed == isDismiss || (review.Type != ReviewTypeApprove && review.Type != ReviewTypeReject) {
+		return nil
+	}
+
+	review.Dismissed = isDismiss
+
+	_, err := x.ID(review.ID).Cols("dismissed").Update(review)
+	return err
+}
+
+// DeleteReview delete a review and its code comments
+func DeleteReview(r *Review) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if r.ID == 0 {
+		return fmt.Errorf("review is not allowed to be 0")
+	}
+
+	if _, err := sess.Where("review_id = ?", r.ID).Delete(new(Comment)); err != nil {
+		return err
+	}
+
+	if _, err := sess.ID(r.ID).Delete(new(Review)); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
@@ -48,7 +48,8 @@ func TestReviewType_Icon(t *testing.T) {
 	assert.Equal(t, "x", ReviewTypeReject.Icon())
 	assert.Equal(t, "comment", ReviewTypeComment.Icon())
 	assert.Equal(t, "comment", ReviewTypeUnknown.Icon())
-	assert.Equal(t, "comment", ReviewType(4).Icon())
+	assert.Equal(t, "primitive-dot", ReviewTypeRequest.Icon())
+	assert.Equal(t, "comment", ReviewType(5).Icon())
 }
 
 func TestFindReviews(t *testing.T) {
@@ -132,3 +133,69 @@ func TestGetReviewersByIssueID(t *testing.T) {
 		assert.Equal(t, expectedReviews[i].UpdatedUnix, review.UpdatedUnix)
 	}
 }
+
+func TestDismissReview(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	review := AssertExistsAndLoadBean(t, &Review{ID: 1}).(*Review)
+	assert.False(t, review.Dismissed)
+
+	assert.NoError(t, DismissReview(review, true))
+	AssertExistsAndLoadBean(t, &Review{ID: 1, Dismissed: true})
+
+	assert.NoError(t, DismissReview(review, false))
+	AssertExistsAndLoadBean(t, &Review{ID: 1}, Cond("dismissed = ?", false))
+
+	// pending reviews can not be dismissed
+	pending := AssertExistsAndLoadBean(t, &Review{ID: 4}).(*Review)
+	assert.NoError(t, DismissReview(pending, true))
+	AssertExistsAndLoadBean(t, &Review{ID: 4}, Cond("dismissed = ?", false))
+}
+
+func TestDeleteReview(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	review := AssertExistsAndLoadBean(t, &Review{ID: 4}).(*Review)
+	assert.NoError(t, DeleteReview(review))
+	AssertNotExistsBean(t, &Review{ID: 4})
+	AssertNotExistsBean(t, &Comment{ReviewID: 4})
+}
+
+func TestReviewRequests(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 2}).(*Issue)
+	reviewer := AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+	doer := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+
+	comment, err := AddReviewRequest(issue, reviewer, doer)
+	assert.NoError(t, err)
+	assert.NotNil(t, comment)
+	assert.EqualValues(t, CommentTypeReviewRequest, comment.Type)
+	assert.EqualValues(t, reviewer.ID, comment.AssigneeID)
+
+	// requesting twice is a no-op
+	comment, err = AddReviewRequest(issue, reviewer, doer)
+	assert.NoError(t, err)
+	assert.Nil(t, comment)
+
+	requests, err := GetReviewRequestsByIssueID(issue.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, requests, 1) {
+		assert.EqualValues(t, reviewer.ID, requests[0].Reviewer.ID)
+	}
+
+	// submitting a review fulfills the request
+	_, _, err = SubmitReview(reviewer, issue, ReviewTypeComment, "looks fine", "")
+	assert.NoError(t, err)
+	AssertNotExistsBean(t, &Review{IssueID: issue.ID, ReviewerID: reviewer.ID, Type: ReviewTypeRequest})
+
+	comment, err = AddReviewRequest(issue, reviewer, doer)
+	assert.NoError(t, err)
+	assert.NotNil(t, comment)
+	comment, err = RemoveReviewRequest(issue, reviewer, doer)
+	assert.NoError(t, err)
+	assert.NotNil(t, comment)
+	assert.True(t, comment.RemovedAssignee)
+	AssertNotExistsBean(t, &Review{IssueID: issue.ID, ReviewerID: reviewer.ID, Type: ReviewTypeRequest})
+}
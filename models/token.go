@@ -7,6 +7,7 @@ package models
 
 import (
 	"crypto/subtle"
+	"strings"
 	"time"
 
 	"code.gitea.io/gitea/modules/base"
@@ -25,6 +26,12 @@ type AccessToken struct {
 	TokenHash      string `xorm:"UNIQUE"` // sha256 of token
 	TokenSalt      string
 	TokenLastEight string `xorm:"token_last_eight"`
+	Scope          AccessTokenScope
+	// RepoIDs and OrgIDs restrict the token to the listed repositories and the repositories
+	// of the listed organizations, the token is not restricted if both are empty
+	RepoIDs     []int64            `xorm:"JSON TEXT"`
+	OrgIDs      []int64            `xorm:"JSON TEXT"`
+	ExpiresUnix timeutil.TimeStamp `xorm:"INDEX"`
 
 	CreatedUnix       timeutil.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix       timeutil.TimeStamp `xorm:"INDEX updated"`
@@ -38,8 +45,126 @@ func (t *AccessToken) AfterLoad() {
 	t.HasRecentActivity = t.UpdatedUnix.AddDuration(7*24*time.Hour) > timeutil.TimeStampNow()
 }
 
+// IsExpired returns true if the token has an expiry date which has passed
+func (t *AccessToken) IsExpired() bool {
+	return t.ExpiresUnix > 0 && t.ExpiresUnix <= timeutil.TimeStampNow()
+}
+
+// IsRestricted returns true if the token may only be used for some repositories or organizations
+func (t *AccessToken) IsRestricted() bool {
+	return len(t.RepoIDs) > 0 || len(t.OrgIDs) > 0
+}
+
+// CanAccessOrg returns true if the token may be used for the given organization
+func (t *AccessToken) CanAccessOrg(orgID int64) bool {
+	if !t.IsRestricted() {
+		return true
+	}
+	for _, id := range t.OrgIDs {
+		if id == orgID {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessRepo returns true if the token may be used for the given repository
+func (t *AccessToken) CanAccessRepo(repo *Repository) bool {
+	if !t.IsRestricted() {
+		return true
+	}
+	for _, id := range t.RepoIDs {
+		if id == repo.ID {
+			return true
+		}
+	}
+	for _, id := range t.OrgIDs {
+		if id == repo.OwnerID {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRestrictions restricts the token to the repositories and organizations with the
+// given names, repositories are given by their full name. Repositories and organizations
+// the owner of the token can't see are reported as not existing.
+func (t *AccessToken) SetRestrictions(doer *User, repoNames, orgNames []string) error {
+	t.RepoIDs = nil
+	for _, name := range repoNames {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		fields := strings.SplitN(name, "/", 2)
+		if len(fields) != 2 {
+			return ErrRepoNotExist{Name: name}
+		}
+		repo, err := GetRepositoryByOwnerAndName(fields[0], fields[1])
+		if err != nil {
+			return err
+		}
+		perm, err := GetUserRepoPermission(repo, doer)
+		if err != nil {
+			return err
+		} else if !perm.HasAccess() {
+			return ErrRepoNotExist{OwnerName: fields[0], Name: fields[1]}
+		}
+		t.RepoIDs = append(t.RepoIDs, repo.ID)
+	}
+
+	t.OrgIDs = nil
+	for _, name := range orgNames {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		org, err := GetOrgByName(name)
+		if err != nil {
+			return err
+		} else if !HasOrgVisible(org, doer) {
+			return ErrOrgNotExist{0, name}
+		}
+		t.OrgIDs = append(t.OrgIDs, org.ID)
+	}
+	return nil
+}
+
+// LoadRestrictions returns the full names of the repositories and the names of
+// the organizations the token is restricted to.
+func (t *AccessToken) LoadRestrictions() (repoNames, orgNames []string, err error) {
+	if len(t.RepoIDs) > 0 {
+		repos, err := GetRepositoriesMapByIDs(t.RepoIDs)
+		if err != nil {
+			return nil, nil, err
+		}
+		repoNames = make([]string, 0, len(repos))
+		for _, id := range t.RepoIDs {
+			repo, ok := repos[id]
+			if !ok {
+				continue
+			}
+			if err := repo.GetOwner(); err != nil {
+				return nil, nil, err
+			}
+			repoNames = append(repoNames, repo.FullName())
+		}
+	}
+	if len(t.OrgIDs) > 0 {
+		if orgNames, err = GetUserNamesByIDs(t.OrgIDs); err != nil {
+			return nil, nil, err
+		}
+	}
+	return repoNames, orgNames, nil
+}
+
 // NewAccessToken creates new access token.
+// A token without scope gets full access.
 func NewAccessToken(t *AccessToken) error {
+	scope, err := t.Scope.Normalize()
+	if err != nil {
+		return err
+	}
+	t.Scope = scope
+
 	salt, err := generate.GetRandomString(10)
 	if err != nil {
 		return err
@@ -71,6 +196,9 @@ func GetAccessTokenBySHA(token string) (*AccessToken, error) {
 	for _, t := range tokens {
 		tempHash := hashToken(token, t.TokenSalt)
 		if subtle.ConstantTimeCompare([]byte(t.TokenHash), []byte(tempHash)) == 1 {
+			if t.IsExpired() {
+				return nil, ErrAccessTokenNotExist{token}
+			}
 			return &t, nil
 		}
 	}
@@ -0,0 +1,187 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// AccessTokenScopeCategory represents a group of API routes an access token scope grants access to
+type AccessTokenScopeCategory string
+
+// Access token scope categories
+const (
+	AccessTokenScopeCategoryRepository   AccessTokenScopeCategory = "repository"
+	AccessTokenScopeCategoryIssue        AccessTokenScopeCategory = "issue"
+	AccessTokenScopeCategoryOrganization AccessTokenScopeCategory = "organization"
+	AccessTokenScopeCategoryAdmin        AccessTokenScopeCategory = "admin"
+	AccessTokenScopeCategoryUser         AccessTokenScopeCategory = "user"
+	AccessTokenScopeCategoryPackage      AccessTokenScopeCategory = "package"
+)
+
+// AccessTokenScopeCategories lists all scope categories in the order they are displayed
+var AccessTokenScopeCategories = []AccessTokenScopeCategory{
+	AccessTokenScopeCategoryRepository,
+	AccessTokenScopeCategoryIssue,
+	AccessTokenScopeCategoryOrganization,
+	AccessTokenScopeCategoryUser,
+	AccessTokenScopeCategoryPackage,
+	AccessTokenScopeCategoryAdmin,
+}
+
+// AccessTokenScopeLevel represents the access level an access token scope grants
+type AccessTokenScopeLevel int
+
+// Access token scope levels
+const (
+	AccessTokenScopeLevelNone AccessTokenScopeLevel = iota
+	AccessTokenScopeLevelRead
+	AccessTokenScopeLevelWrite
+)
+
+// String returns the prefix of the scopes of this level
+func (level AccessTokenScopeLevel) String() string {
+	switch level {
+	case AccessTokenScopeLevelRead:
+		return "read"
+	case AccessTokenScopeLevelWrite:
+		return "write"
+	}
+	return "none"
+}
+
+// AccessTokenScope represents the comma separated list of scopes of an access token,
+// each of them being either "all" or of the form "<level>:<category>"
+type AccessTokenScope string
+
+// AccessTokenScopeAll grants full access to the API, it is the scope of tokens created before scopes existed
+const AccessTokenScopeAll AccessTokenScope = "all"
+
+// ErrInvalidAccessTokenScope represents an unknown access token scope
+type ErrInvalidAccessTokenScope struct {
+	Scope string
+}
+
+// IsErrInvalidAccessTokenScope checks if an error is a ErrInvalidAccessTokenScope.
+func IsErrInvalidAccessTokenScope(err error) bool {
+	_, ok := err.(ErrInvalidAccessTokenScope)
+	return ok
+}
+
+func (err ErrInvalidAccessTokenScope) Error() string {
+	return "invalid access token scope: " + err.Scope
+}
+
+// AccessTokenScopeFromList builds a scope from a list of scope names
+func AccessTokenScopeFromList(scopes []string) AccessTokenScope {
+	return AccessTokenScope(strings.Join(scopes, ","))
+}
+
+func parseScope(scope string) (AccessTokenScopeCategory, AccessTokenScopeLevel, bool) {
+	fields := strings.SplitN(scope, ":", 2)
+	if len(fields) != 2 {
+		return "", AccessTokenScopeLevelNone, false
+	}
+
+	var level AccessTokenScopeLevel
+	switch fields[0] {
+	case "read":
+		level = AccessTokenScopeLevelRead
+	case "write":
+		level = AccessTokenScopeLevelWrite
+	default:
+		return "", AccessTokenScopeLevelNone, false
+	}
+
+	category := AccessTokenScopeCategory(fields[1])
+	for _, c := range AccessTokenScopeCategories {
+		if c == category {
+			return category, level, true
+		}
+	}
+	return "", AccessTokenScopeLevelNone, false
+}
+
+// levels returns the highest access level granted for each category
+func (s AccessTokenScope) levels() (all bool, levels map[AccessTokenScopeCategory]AccessTokenScopeLevel, err error) {
+	levels = make(map[AccessTokenScopeCategory]AccessTokenScopeLevel)
+	for _, scope := range strings.Split(string(s), ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		if AccessTokenScope(scope) == AccessTokenScopeAll {
+			all = true
+			continue
+		}
+		category, level, ok := parseScope(scope)
+		if !ok {
+			return false, nil, ErrInvalidAccessTokenScope{scope}
+		}
+		if level > levels[category] {
+			levels[category] = level
+		}
+	}
+	return all, levels, nil
+}
+
+// Normalize validates the scope and returns it without duplicated or redundant entries.
+// An empty scope is normalized to AccessTokenScopeAll.
+func (s AccessTokenScope) Normalize() (AccessTokenScope, error) {
+	all, levels, err := s.levels()
+	if err != nil {
+		return "", err
+	}
+	if all || len(levels) == 0 {
+		return AccessTokenScopeAll, nil
+	}
+
+	scopes := make([]string, 0, len(levels))
+	for category, level := range levels {
+		scopes = append(scopes, level.String()+":"+string(category))
+	}
+	sort.Strings(scopes)
+	return AccessTokenScopeFromList(scopes), nil
+}
+
+// StringSlice returns the scope as a list of scope names
+func (s AccessTokenScope) StringSlice() []string {
+	scopes := make([]string, 0, 2)
+	for _, scope := range strings.Split(string(s), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// Level returns the access level the scope grants for the given category.
+// Write access implies read access.
+func (s AccessTokenScope) Level(category AccessTokenScopeCategory) AccessTokenScopeLevel {
+	all, levels, err := s.levels()
+	if err != nil {
+		return AccessTokenScopeLevelNone
+	}
+	if all {
+		return AccessTokenScopeLevelWrite
+	}
+	return levels[category]
+}
+
+// HasScope returns true if the scope grants at least the given access level for the given category
+func (s AccessTokenScope) HasScope(category AccessTokenScopeCategory, level AccessTokenScopeLevel) bool {
+	return s.Level(category) >= level
+}
+
+// HasAllScopes returns true if the scope grants write access to all categories
+func (s AccessTokenScope) HasAllScopes() bool {
+	for _, category := range AccessTokenScopeCategories {
+		if !s.HasScope(category, AccessTokenScopeLevelWrite) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessTokenScope_Normalize(t *testing.T) {
+	tests := []struct {
+		in  AccessTokenScope
+		out AccessTokenScope
+	}{
+		{"", AccessTokenScopeAll},
+		{"all", AccessTokenScopeAll},
+		{"read:repository,all", AccessTokenScopeAll},
+		{"read:repository", "read:repository"},
+		{" read:repository , read:repository", "read:repository"},
+		{"write:repository,read:repository", "write:repository"},
+		{"write:issue,read:admin", "read:admin,write:issue"},
+	}
+	for _, test := range tests {
+		scope, err := test.in.Normalize()
+		assert.NoError(t, err)
+		assert.Equal(t, test.out, scope)
+	}
+
+	for _, scope := range []AccessTokenScope{"read", "read:everything", "admin:repository", "repository"} {
+		_, err := scope.Normalize()
+		assert.True(t, IsErrInvalidAccessTokenScope(err))
+	}
+}
+
+func TestAccessTokenScope_HasScope(t *testing.T) {
+	assert.True(t, AccessTokenScopeAll.HasScope(AccessTokenScopeCategoryAdmin, AccessTokenScopeLevelWrite))
+
+	scope := AccessTokenScope("write:repository,read:issue")
+	assert.True(t, scope.HasScope(AccessTokenScopeCategoryRepository, AccessTokenScopeLevelRead))
+	assert.True(t, scope.HasScope(AccessTokenScopeCategoryRepository, AccessTokenScopeLevelWrite))
+	assert.True(t, scope.HasScope(AccessTokenScopeCategoryIssue, AccessTokenScopeLevelRead))
+	assert.False(t, scope.HasScope(AccessTokenScopeCategoryIssue, AccessTokenScopeLevelWrite))
+	assert.False(t, scope.HasScope(AccessTokenScopeCategoryAdmin, AccessTokenScopeLevelRead))
+	assert.Equal(t, []string{"write:repository", "read:issue"}, scope.StringSlice())
+}
+
+func TestAccessTokenScope_HasAllScopes(t *testing.T) {
+	assert.True(t, AccessTokenScopeAll.HasAllScopes())
+	assert.False(t, AccessTokenScope("write:repository,read:issue").HasAllScopes())
+	assert.True(t, AccessTokenScope("write:repository,write:issue,write:organization,write:admin,write:user,write:package").HasAllScopes())
+}
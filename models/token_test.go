@@ -7,6 +7,8 @@ package models
 import (
 	"testing"
 
+	"code.gitea.io/gitea/modules/timeutil"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -92,3 +94,46 @@ func TestDeleteAccessTokenByID(t *testing.T) {
 	assert.Error(t, err)
 	assert.True(t, IsErrAccessTokenNotExist(err))
 }
+
+func TestAccessTokenExpiry(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	token := &AccessToken{
+		UID:         3,
+		Name:        "Token expired",
+		ExpiresUnix: timeutil.TimeStampNow() - 60,
+	}
+	assert.NoError(t, NewAccessToken(token))
+	assert.True(t, token.IsExpired())
+
+	_, err := GetAccessTokenBySHA(token.Token)
+	assert.True(t, IsErrAccessTokenNotExist(err))
+}
+
+func TestAccessToken_SetRestrictions(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	user2 := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	user4 := AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+	repo1 := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	repo2 := AssertExistsAndLoadBean(t, &Repository{ID: 2}).(*Repository)
+
+	token := &AccessToken{UID: user2.ID, Name: "Token restricted"}
+	assert.True(t, token.CanAccessRepo(repo2))
+
+	assert.NoError(t, token.SetRestrictions(user2, []string{"user2/repo1", " "}, nil))
+	assert.True(t, token.IsRestricted())
+	assert.True(t, token.CanAccessRepo(repo1))
+	assert.False(t, token.CanAccessRepo(repo2))
+	assert.NoError(t, NewAccessToken(token))
+
+	token = AssertExistsAndLoadBean(t, &AccessToken{ID: token.ID}).(*AccessToken)
+	repoNames, orgNames, err := token.LoadRestrictions()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user2/repo1"}, repoNames)
+	assert.Empty(t, orgNames)
+
+	// user4 can't see the private repository of user2
+	err = token.SetRestrictions(user4, []string{"user2/repo2"}, nil)
+	assert.True(t, IsErrRepoNotExist(err))
+	err = token.SetRestrictions(user2, nil, []string{"org-does-not-exist"})
+	assert.True(t, IsErrOrgNotExist(err))
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/pquerna/otp/totp"
 	"golang.org/x/crypto/pbkdf2"
+	"xorm.io/builder"
 )
 
 // TwoFactor represents a two-factor authentication token.
@@ -164,3 +165,35 @@ func DeleteTwoFactorByID(id, userID int64) error {
 	}
 	return nil
 }
+
+// HasTwoFactorByUID returns whether the user is enrolled in TOTP or has registered
+// a WebAuthn credential, either of them can be used as second factor.
+func HasTwoFactorByUID(uid int64) (bool, error) {
+	has, err := x.Where("uid = ?", uid).Exist(&TwoFactor{})
+	if err != nil || has {
+		return has, err
+	}
+	return ExistsWebAuthnCredentialsForUID(uid)
+}
+
+// IsTwoFactorRequired returns whether the user has to use a second factor, because
+// it is required for all users or by one of the organizations the user is member of.
+func IsTwoFactorRequired(uid int64) (bool, error) {
+	if setting.Service.RequireTwoFactorAuth {
+		return true, nil
+	}
+	return x.Where(builder.In("id", builder.Select("org_id").From("org_user").Where(builder.Eq{"uid": uid}))).
+		And("require_two_factor_auth = ?", true).
+		Exist(new(User))
+}
+
+// IsTwoFactorSetUpPending returns whether the user is required to use a second factor
+// but has not set one up yet.
+func IsTwoFactorSetUpPending(uid int64) (bool, error) {
+	required, err := IsTwoFactorRequired(uid)
+	if err != nil || !required {
+		return false, err
+	}
+	has, err := HasTwoFactorByUID(uid)
+	return !has, err
+}
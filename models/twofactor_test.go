@@ -0,0 +1,77 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasTwoFactorByUID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	for uid, expected := range map[int64]bool{
+		1:  true,  // WebAuthn credential
+		24: true,  // TOTP
+		2:  false, // nothing
+	} {
+		has, err := HasTwoFactorByUID(uid)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, has, "user %d", uid)
+	}
+}
+
+func TestIsTwoFactorRequired(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	required, err := IsTwoFactorRequired(2)
+	assert.NoError(t, err)
+	assert.False(t, required)
+
+	org := AssertExistsAndLoadBean(t, &User{ID: 3}).(*User)
+	org.RequireTwoFactorAuth = true
+	assert.NoError(t, UpdateUserCols(org, "require_two_factor_auth"))
+
+	// user 2 is a member of org 3, user 5 is not
+	required, err = IsTwoFactorRequired(2)
+	assert.NoError(t, err)
+	assert.True(t, required)
+	required, err = IsTwoFactorRequired(5)
+	assert.NoError(t, err)
+	assert.False(t, required)
+
+	setting.Service.RequireTwoFactorAuth = true
+	defer func() {
+		setting.Service.RequireTwoFactorAuth = false
+	}()
+	required, err = IsTwoFactorRequired(5)
+	assert.NoError(t, err)
+	assert.True(t, required)
+}
+
+func TestIsTwoFactorSetUpPending(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	pending, err := IsTwoFactorSetUpPending(2)
+	assert.NoError(t, err)
+	assert.False(t, pending)
+
+	setting.Service.RequireTwoFactorAuth = true
+	defer func() {
+		setting.Service.RequireTwoFactorAuth = false
+	}()
+	for uid, expected := range map[int64]bool{
+		1:  false, // WebAuthn credential
+		24: false, // TOTP
+		2:  true,  // nothing
+	} {
+		pending, err := IsTwoFactorSetUpPending(uid)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, pending, "user %d", uid)
+	}
+}
@@ -16,6 +16,7 @@ import (
 
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/unknwon/com"
@@ -59,6 +60,13 @@ func MainTest(m *testing.M, pathToGiteaRoot string) {
 		fatalTestError("TempDir: %v\n", err)
 	}
 	setting.AppWorkPath = pathToGiteaRoot
+	setting.AttachmentStorage = setting.Storage{Type: setting.LocalStorageType, Path: filepath.Join(setting.AppDataPath, "attachments")}
+	setting.LFSStorage = setting.Storage{Type: setting.LocalStorageType, Path: filepath.Join(setting.AppDataPath, "lfs")}
+	setting.AvatarStorage = setting.Storage{Type: setting.LocalStorageType, Path: filepath.Join(setting.AppDataPath, "avatars")}
+	setting.RepoAvatarStorage = setting.Storage{Type: setting.LocalStorageType, Path: filepath.Join(setting.AppDataPath, "repo-avatars")}
+	if err = storage.Init(); err != nil {
+		fatalTestError("storage.Init: %v\n", err)
+	}
 	setting.StaticRootPath = pathToGiteaRoot
 	setting.GravatarSourceURL, err = url.Parse("https://secure.gravatar.com/avatar/")
 	if err != nil {
@@ -6,6 +6,7 @@
 package models
 
 import (
+	"bytes"
 	"container/list"
 	"context"
 	"crypto/md5"
@@ -13,6 +14,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"image"
 	_ "image/jpeg" // Needed for jpeg support
 	"image/png"
 	"net/http"
@@ -31,6 +33,7 @@ import (
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/structs"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/timeutil"
@@ -161,6 +164,7 @@ type User struct {
 	MembersIsPublic           map[int64]bool      `xorm:"-"`
 	Visibility                structs.VisibleType `xorm:"NOT NULL DEFAULT 0"`
 	RepoAdminChangeTeamAccess bool                `xorm:"NOT NULL DEFAULT false"`
+	RequireTwoFactorAuth      bool                `xorm:"NOT NULL DEFAULT false"`
 
 	// Preferences
 	DiffViewStyle string `xorm:"NOT NULL DEFAULT ''"`
@@ -335,9 +339,32 @@ func (u *User) GenerateActivateCode() string {
 	return u.GenerateEmailActivateCode(u.Email)
 }
 
-// CustomAvatarPath returns user custom avatar file path.
-func (u *User) CustomAvatarPath() string {
-	return filepath.Join(setting.AvatarUploadPath, u.Avatar)
+// CustomAvatarRelativePath returns user custom avatar relative path in the avatar storage.
+func (u *User) CustomAvatarRelativePath() string {
+	return u.Avatar
+}
+
+// hasCustomAvatar returns true if the user has an avatar, the existence
+// of the avatar file is only checked for the local storage as it is cheap there
+func (u *User) hasCustomAvatar() bool {
+	if len(u.Avatar) == 0 {
+		return false
+	}
+	if setting.AvatarStorage.IsLocal() {
+		_, err := storage.Avatars.Stat(u.CustomAvatarRelativePath())
+		return err == nil
+	}
+	return true
+}
+
+// saveAvatar encodes the avatar as png and stores it at path
+func saveAvatar(bucket storage.ObjectStorage, path string, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return fmt.Errorf("Encode: %v", err)
+	}
+	_, err := bucket.Save(path, &buf)
+	return err
 }
 
 // GenerateRandomAvatar generates a random avatar for user.
@@ -360,23 +387,14 @@ func (u *User) generateRandomAvatar(e Engine) error {
 	if u.Avatar == "" {
 		u.Avatar = fmt.Sprintf("%d", u.ID)
 	}
-	if err = os.MkdirAll(filepath.Dir(u.CustomAvatarPath()), os.ModePerm); err != nil {
-		return fmt.Errorf("MkdirAll: %v", err)
-	}
-	fw, err := os.Create(u.CustomAvatarPath())
-	if err != nil {
+	if err = saveAvatar(storage.Avatars, u.CustomAvatarRelativePath(), img); err != nil {
 		return fmt.Errorf("Create: %v", err)
 	}
-	defer fw.Close()
 
 	if _, err := e.ID(u.ID).Cols("avatar").Update(u); err != nil {
 		return err
 	}
 
-	if err = png.Encode(fw, img); err != nil {
-		return fmt.Errorf("Encode: %v", err)
-	}
-
 	log.Info("New random avatar created: %d", u.ID)
 	return nil
 }
@@ -406,12 +424,12 @@ func (u *User) RealSizedAvatarLink(size int) string {
 
 	switch {
 	case u.UseCustomAvatar:
-		if !com.IsFile(u.CustomAvatarPath()) {
+		if !u.hasCustomAvatar() {
 			return base.DefaultAvatarLink()
 		}
 		return setting.AppSubURL + "/avatars/" + u.Avatar
 	case setting.DisableGravatar, setting.OfflineMode:
-		if !com.IsFile(u.CustomAvatarPath()) {
+		if !u.hasCustomAvatar() {
 			if err := u.GenerateRandomAvatar(); err != nil {
 				log.Error("GenerateRandomAvatar: %v", err)
 			}
@@ -538,29 +556,19 @@ func (u *User) UploadAvatar(data []byte) error {
 		return fmt.Errorf("updateUser: %v", err)
 	}
 
-	if err := os.MkdirAll(setting.AvatarUploadPath, os.ModePerm); err != nil {
-		return fmt.Errorf("Failed to create dir %s: %v", setting.AvatarUploadPath, err)
-	}
-
-	fw, err := os.Create(u.CustomAvatarPath())
-	if err != nil {
+	if err := saveAvatar(storage.Avatars, u.CustomAvatarRelativePath(), *m); err != nil {
 		return fmt.Errorf("Create: %v", err)
 	}
-	defer fw.Close()
-
-	if err = png.Encode(fw, *m); err != nil {
-		return fmt.Errorf("Encode: %v", err)
-	}
 
 	return sess.Commit()
 }
 
 // DeleteAvatar deletes the user's custom avatar.
 func (u *User) DeleteAvatar() error {
-	log.Trace("DeleteAvatar[%d]: %s", u.ID, u.CustomAvatarPath())
+	log.Trace("DeleteAvatar[%d]: %s", u.ID, u.CustomAvatarRelativePath())
 	if len(u.Avatar) > 0 {
-		if err := os.Remove(u.CustomAvatarPath()); err != nil {
-			return fmt.Errorf("Failed to remove %s: %v", u.CustomAvatarPath(), err)
+		if err := storage.Avatars.Delete(u.CustomAvatarRelativePath()); err != nil {
+			return fmt.Errorf("Failed to remove %s: %v", u.CustomAvatarRelativePath(), err)
 		}
 	}
 
@@ -1162,6 +1170,7 @@ func deleteUser(e *xorm.Session, u *User) error {
 		&IssueUser{UID: u.ID},
 		&EmailAddress{UID: u.ID},
 		&UserOpenID{UID: u.ID},
+		&WebAuthnCredential{UserID: u.ID},
 		&Reaction{UserID: u.ID},
 		&TeamUser{UID: u.ID},
 		&Collaboration{UserID: u.ID},
@@ -1211,11 +1220,8 @@ func deleteUser(e *xorm.Session, u *User) error {
 	}
 
 	if len(u.Avatar) > 0 {
-		avatarPath := u.CustomAvatarPath()
-		if com.IsExist(avatarPath) {
-			if err := os.Remove(avatarPath); err != nil {
-				return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
-			}
+		if err := storage.Avatars.Delete(u.CustomAvatarRelativePath()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("Failed to remove %s: %v", u.Avatar, err)
 		}
 	}
 
@@ -1382,6 +1388,17 @@ func GetUserIDsByNames(names []string, ignoreNonExistent bool) ([]int64, error)
 	return ids, nil
 }
 
+// GetUserNamesByIDs returns usernames for all resolved users from a list of Ids.
+func GetUserNamesByIDs(ids []int64) ([]string, error) {
+	unames := make([]string, 0, len(ids))
+	err := x.In("id", ids).
+		Table("user").
+		Asc("name").
+		Cols("name").
+		Find(&unames)
+	return unames, err
+}
+
 // UserCommit represents a commit with validation of user.
 type UserCommit struct {
 	User *User
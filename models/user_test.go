@@ -386,3 +386,11 @@ func TestGetUserIDsByNames(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, []int64(nil), IDs)
 }
+
+func TestGetUserNamesByIDs(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	names, err := GetUserNamesByIDs([]int64{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user1", "user2"}, names)
+}
@@ -0,0 +1,165 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/duo-labs/webauthn/webauthn"
+)
+
+// WebAuthnCredential represents a public key credential registered with WebAuthn,
+// security keys registered with the legacy U2F API are stored the same way.
+type WebAuthnCredential struct {
+	ID              int64 `xorm:"pk autoincr"`
+	Name            string
+	LowerName       string `xorm:"unique(s)"`
+	UserID          int64  `xorm:"INDEX unique(s)"`
+	CredentialID    string `xorm:"INDEX VARCHAR(410)"`
+	PublicKey       []byte
+	AttestationType string
+	AAGUID          []byte
+	SignCount       uint32 `xorm:"BIGINT"`
+	CloneWarning    bool
+	CreatedUnix     timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix     timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// TableName returns a better table name for WebAuthnCredential
+func (cred WebAuthnCredential) TableName() string {
+	return "webauthn_credential"
+}
+
+// IsLegacyU2F returns whether the credential was registered with the U2F API,
+// such credentials can only be used with the appid extension.
+func (cred *WebAuthnCredential) IsLegacyU2F() bool {
+	return cred.AttestationType == "fido-u2f" && len(cred.AAGUID) == 0
+}
+
+// EncodeCredentialID encodes the raw ID of a credential the way it is stored
+func EncodeCredentialID(id []byte) string {
+	return base64.RawURLEncoding.EncodeToString(id)
+}
+
+// ToCredential converts the credential to the credential type of the WebAuthn library
+func (cred *WebAuthnCredential) ToCredential() webauthn.Credential {
+	id, _ := base64.RawURLEncoding.DecodeString(cred.CredentialID)
+	return webauthn.Credential{
+		ID:              id,
+		PublicKey:       cred.PublicKey,
+		AttestationType: cred.AttestationType,
+		Authenticator: webauthn.Authenticator{
+			AAGUID:       cred.AAGUID,
+			SignCount:    cred.SignCount,
+			CloneWarning: cred.CloneWarning,
+		},
+	}
+}
+
+func (cred *WebAuthnCredential) updateSignCount(e Engine) error {
+	_, err := e.ID(cred.ID).Cols("sign_count", "clone_warning").Update(cred)
+	return err
+}
+
+// UpdateSignCount will update the database values of the sign counter and the clone warning
+func (cred *WebAuthnCredential) UpdateSignCount() error {
+	return cred.updateSignCount(x)
+}
+
+// WebAuthnCredentialList is a list of *WebAuthnCredential
+type WebAuthnCredentialList []*WebAuthnCredential
+
+// ToCredentials will convert all WebAuthnCredentials to webauthn.Credentials
+func (list WebAuthnCredentialList) ToCredentials() []webauthn.Credential {
+	creds := make([]webauthn.Credential, 0, len(list))
+	for _, cred := range list {
+		creds = append(creds, cred.ToCredential())
+	}
+	return creds
+}
+
+func getWebAuthnCredentialsByUID(e Engine, uid int64) (WebAuthnCredentialList, error) {
+	creds := make(WebAuthnCredentialList, 0)
+	return creds, e.Where("user_id = ?", uid).Find(&creds)
+}
+
+// GetWebAuthnCredentialsByUID returns all WebAuthn credentials of the given user
+func GetWebAuthnCredentialsByUID(uid int64) (WebAuthnCredentialList, error) {
+	return getWebAuthnCredentialsByUID(x, uid)
+}
+
+// ExistsWebAuthnCredentialsForUID returns whether the given user has registered WebAuthn credentials
+func ExistsWebAuthnCredentialsForUID(uid int64) (bool, error) {
+	return x.Where("user_id = ?", uid).Exist(&WebAuthnCredential{})
+}
+
+// GetWebAuthnCredentialByID returns WebAuthn credential by id
+func GetWebAuthnCredentialByID(id int64) (*WebAuthnCredential, error) {
+	return getWebAuthnCredentialByID(x, id)
+}
+
+func getWebAuthnCredentialByID(e Engine, id int64) (*WebAuthnCredential, error) {
+	cred := new(WebAuthnCredential)
+	if found, err := e.ID(id).Get(cred); err != nil {
+		return nil, err
+	} else if !found {
+		return nil, ErrWebAuthnCredentialNotExist{ID: id}
+	}
+	return cred, nil
+}
+
+// GetWebAuthnCredentialByCredID returns the WebAuthn credential of the user with the given raw credential ID
+func GetWebAuthnCredentialByCredID(uid int64, credID []byte) (*WebAuthnCredential, error) {
+	cred := &WebAuthnCredential{
+		UserID:       uid,
+		CredentialID: EncodeCredentialID(credID),
+	}
+	if found, err := x.Get(cred); err != nil {
+		return nil, err
+	} else if !found {
+		return nil, ErrWebAuthnCredentialNotExist{CredentialID: cred.CredentialID}
+	}
+	return cred, nil
+}
+
+// IsWebAuthnCredentialNameUsed returns whether the user has already a credential with the given name
+func IsWebAuthnCredentialNameUsed(uid int64, name string) (bool, error) {
+	return x.Exist(&WebAuthnCredential{
+		UserID:    uid,
+		LowerName: strings.ToLower(name),
+	})
+}
+
+func createWebAuthnCredential(e Engine, uid int64, name string, cred *webauthn.Credential) (*WebAuthnCredential, error) {
+	c := &WebAuthnCredential{
+		UserID:          uid,
+		Name:            name,
+		LowerName:       strings.ToLower(name),
+		CredentialID:    EncodeCredentialID(cred.ID),
+		PublicKey:       cred.PublicKey,
+		AttestationType: cred.AttestationType,
+		AAGUID:          cred.Authenticator.AAGUID,
+		SignCount:       cred.Authenticator.SignCount,
+		CloneWarning:    false,
+	}
+	if _, err := e.InsertOne(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// CreateWebAuthnCredential will create a new WebAuthnCredential from the given credential
+func CreateWebAuthnCredential(uid int64, name string, cred *webauthn.Credential) (*WebAuthnCredential, error) {
+	return createWebAuthnCredential(x, uid, name, cred)
+}
+
+// DeleteWebAuthnCredential will delete the WebAuthn credential of the user
+func DeleteWebAuthnCredential(id, uid int64) (bool, error) {
+	cnt, err := x.ID(id).Delete(&WebAuthnCredential{UserID: uid})
+	return cnt > 0, err
+}
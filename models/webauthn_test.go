@@ -0,0 +1,86 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/duo-labs/webauthn/webauthn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWebAuthnCredentialByID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	res, err := GetWebAuthnCredentialByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "WebAuthn credential", res.Name)
+
+	_, err = GetWebAuthnCredentialByID(342432)
+	assert.Error(t, err)
+	assert.True(t, IsErrWebAuthnCredentialNotExist(err))
+}
+
+func TestGetWebAuthnCredentialsByUID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	res, err := GetWebAuthnCredentialsByUID(1)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, "WebAuthn credential", res[0].Name)
+
+	exists, err := ExistsWebAuthnCredentialsForUID(1)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = ExistsWebAuthnCredentialsForUID(2)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestGetWebAuthnCredentialByCredID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	res, err := GetWebAuthnCredentialByCredID(1, []byte("TestCredential"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, res.ID)
+
+	_, err = GetWebAuthnCredentialByCredID(2, []byte("TestCredential"))
+	assert.True(t, IsErrWebAuthnCredentialNotExist(err))
+}
+
+func TestWebAuthnCredential_TableName(t *testing.T) {
+	assert.Equal(t, "webauthn_credential", WebAuthnCredential{}.TableName())
+}
+
+func TestWebAuthnCredential_UpdateLargeCounter(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	cred := AssertExistsAndLoadBean(t, &WebAuthnCredential{ID: 1}).(*WebAuthnCredential)
+	cred.SignCount = 0xffffffff
+	assert.NoError(t, cred.UpdateSignCount())
+	AssertExistsIf(t, true, &WebAuthnCredential{ID: 1, SignCount: 0xffffffff})
+}
+
+func TestCreateAndDeleteWebAuthnCredential(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	res, err := CreateWebAuthnCredential(1, "WebAuthn Created Credential", &webauthn.Credential{ID: []byte("Test")})
+	assert.NoError(t, err)
+	assert.Equal(t, "WebAuthn Created Credential", res.Name)
+	assert.Equal(t, []byte("Test"), res.ToCredential().ID)
+
+	used, err := IsWebAuthnCredentialNameUsed(1, "webauthn created credential")
+	assert.NoError(t, err)
+	assert.True(t, used)
+
+	deleted, err := DeleteWebAuthnCredential(res.ID, 2)
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+
+	deleted, err = DeleteWebAuthnCredential(res.ID, 1)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+	AssertNotExistsBean(t, &WebAuthnCredential{ID: res.ID})
+}
@@ -6,6 +6,7 @@
 package models
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -57,15 +58,22 @@ func IsValidHookContentType(name string) bool {
 
 // HookEvents is a set of web hook events
 type HookEvents struct {
-	Create       bool `json:"create"`
-	Delete       bool `json:"delete"`
-	Fork         bool `json:"fork"`
-	Issues       bool `json:"issues"`
-	IssueComment bool `json:"issue_comment"`
-	Push         bool `json:"push"`
-	PullRequest  bool `json:"pull_request"`
-	Repository   bool `json:"repository"`
-	Release      bool `json:"release"`
+	Create               bool `json:"create"`
+	Delete               bool `json:"delete"`
+	Fork                 bool `json:"fork"`
+	Issues               bool `json:"issues"`
+	IssueAssign          bool `json:"issue_assign"`
+	IssueLabel           bool `json:"issue_label"`
+	IssueMilestone       bool `json:"issue_milestone"`
+	IssueComment         bool `json:"issue_comment"`
+	Push                 bool `json:"push"`
+	PullRequest          bool `json:"pull_request"`
+	PullRequestAssign    bool `json:"pull_request_assign"`
+	PullRequestLabel     bool `json:"pull_request_label"`
+	PullRequestMilestone bool `json:"pull_request_milestone"`
+	PullRequestSync      bool `json:"pull_request_sync"`
+	Repository           bool `json:"repository"`
+	Release              bool `json:"release"`
 }
 
 // HookEvent represents events that will delivery hook.
@@ -90,21 +98,22 @@ const (
 
 // Webhook represents a web hook object.
 type Webhook struct {
-	ID           int64  `xorm:"pk autoincr"`
-	RepoID       int64  `xorm:"INDEX"`
-	OrgID        int64  `xorm:"INDEX"`
-	URL          string `xorm:"url TEXT"`
-	Signature    string `xorm:"TEXT"`
-	HTTPMethod   string `xorm:"http_method"`
-	ContentType  HookContentType
-	Secret       string `xorm:"TEXT"`
-	Events       string `xorm:"TEXT"`
-	*HookEvent   `xorm:"-"`
-	IsSSL        bool `xorm:"is_ssl"`
-	IsActive     bool `xorm:"INDEX"`
-	HookTaskType HookTaskType
-	Meta         string     `xorm:"TEXT"` // store hook-specific attributes
-	LastStatus   HookStatus // Last delivery status
+	ID              int64  `xorm:"pk autoincr"`
+	RepoID          int64  `xorm:"INDEX"` // An ID of 0 indicates either a default or system webhook
+	OrgID           int64  `xorm:"INDEX"`
+	IsSystemWebhook bool   `xorm:"NOT NULL DEFAULT false"`
+	URL             string `xorm:"url TEXT"`
+	Signature       string `xorm:"TEXT"`
+	HTTPMethod      string `xorm:"http_method"`
+	ContentType     HookContentType
+	Secret          string `xorm:"TEXT"`
+	Events          string `xorm:"TEXT"`
+	*HookEvent      `xorm:"-"`
+	IsSSL           bool `xorm:"is_ssl"`
+	IsActive        bool `xorm:"INDEX"`
+	HookTaskType    HookTaskType
+	Meta            string     `xorm:"TEXT"` // store hook-specific attributes
+	LastStatus      HookStatus // Last delivery status
 
 	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
@@ -154,6 +163,24 @@ func (w *Webhook) HasIssuesEvent() bool {
 		(w.ChooseEvents && w.HookEvents.Issues)
 }
 
+// HasIssuesAssignEvent returns true if hook enabled issues assign event.
+func (w *Webhook) HasIssuesAssignEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.IssueAssign)
+}
+
+// HasIssuesLabelEvent returns true if hook enabled issues label event.
+func (w *Webhook) HasIssuesLabelEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.IssueLabel)
+}
+
+// HasIssuesMilestoneEvent returns true if hook enabled issues milestone event.
+func (w *Webhook) HasIssuesMilestoneEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.IssueMilestone)
+}
+
 // HasIssueCommentEvent returns true if hook enabled issue_comment event.
 func (w *Webhook) HasIssueCommentEvent() bool {
 	return w.SendEverything ||
@@ -172,6 +199,30 @@ func (w *Webhook) HasPullRequestEvent() bool {
 		(w.ChooseEvents && w.HookEvents.PullRequest)
 }
 
+// HasPullRequestAssignEvent returns true if hook enabled pull request assign event.
+func (w *Webhook) HasPullRequestAssignEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.PullRequestAssign)
+}
+
+// HasPullRequestLabelEvent returns true if hook enabled pull request label event.
+func (w *Webhook) HasPullRequestLabelEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.PullRequestLabel)
+}
+
+// HasPullRequestMilestoneEvent returns true if hook enabled pull request milestone event.
+func (w *Webhook) HasPullRequestMilestoneEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.PullRequestMilestone)
+}
+
+// HasPullRequestSyncEvent returns true if hook enabled pull request sync event.
+func (w *Webhook) HasPullRequestSyncEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.PullRequestSync)
+}
+
 // HasReleaseEvent returns if hook enabled release event.
 func (w *Webhook) HasReleaseEvent() bool {
 	return w.SendEverything ||
@@ -198,8 +249,16 @@ func (w *Webhook) EventCheckers() []struct {
 		{w.HasForkEvent, HookEventFork},
 		{w.HasPushEvent, HookEventPush},
 		{w.HasIssuesEvent, HookEventIssues},
+		{w.HasIssuesAssignEvent, HookEventIssueAssign},
+		{w.HasIssuesLabelEvent, HookEventIssueLabel},
+		{w.HasIssuesMilestoneEvent, HookEventIssueMilestone},
 		{w.HasIssueCommentEvent, HookEventIssueComment},
 		{w.HasPullRequestEvent, HookEventPullRequest},
+		{w.HasPullRequestAssignEvent, HookEventPullRequestAssign},
+		{w.HasPullRequestLabelEvent, HookEventPullRequestLabel},
+		{w.HasPullRequestMilestoneEvent, HookEventPullRequestMilestone},
+		{w.HasPullRequestSyncEvent, HookEventPullRequestSync},
+		{w.HasPullRequestEvent, HookEventPullRequestReviewRequest},
 		{w.HasRepositoryEvent, HookEventRepository},
 		{w.HasReleaseEvent, HookEventRelease},
 	}
@@ -207,7 +266,7 @@ func (w *Webhook) EventCheckers() []struct {
 
 // EventsArray returns an array of hook events
 func (w *Webhook) EventsArray() []string {
-	events := make([]string, 0, 7)
+	events := make([]string, 0, 16)
 
 	for _, c := range w.EventCheckers() {
 		if c.Has() {
@@ -298,8 +357,8 @@ func GetWebhooksByOrgID(orgID int64) (ws []*Webhook, err error) {
 	return ws, err
 }
 
-// GetDefaultWebhook returns admin-default webhook by given ID.
-func GetDefaultWebhook(id int64) (*Webhook, error) {
+// GetSystemOrDefaultWebhook returns admin system or default webhook by given ID.
+func GetSystemOrDefaultWebhook(id int64) (*Webhook, error) {
 	webhook := &Webhook{ID: id}
 	has, err := x.
 		Where("repo_id=? AND org_id=?", 0, 0).
@@ -320,7 +379,24 @@ func GetDefaultWebhooks() ([]*Webhook, error) {
 func getDefaultWebhooks(e Engine) ([]*Webhook, error) {
 	webhooks := make([]*Webhook, 0, 5)
 	return webhooks, e.
-		Where("repo_id=? AND org_id=?", 0, 0).
+		Where("repo_id=? AND org_id=? AND is_system_webhook=?", 0, 0, false).
+		Find(&webhooks)
+}
+
+// GetSystemWebhooks returns all admin system webhooks.
+func GetSystemWebhooks() ([]*Webhook, error) {
+	webhooks := make([]*Webhook, 0, 5)
+	return webhooks, x.
+		Where("repo_id=? AND org_id=? AND is_system_webhook=?", 0, 0, true).
+		Find(&webhooks)
+}
+
+// GetActiveSystemWebhooks returns all active admin system webhooks.
+func GetActiveSystemWebhooks() ([]*Webhook, error) {
+	webhooks := make([]*Webhook, 0, 5)
+	return webhooks, x.
+		Where("repo_id=? AND org_id=? AND is_system_webhook=?", 0, 0, true).
+		And("is_active=?", true).
 		Find(&webhooks)
 }
 
@@ -372,8 +448,8 @@ func DeleteWebhookByOrgID(orgID, id int64) error {
 	})
 }
 
-// DeleteDefaultWebhook deletes an admin-default webhook by given ID.
-func DeleteDefaultWebhook(id int64) error {
+// DeleteDefaultSystemWebhook deletes an admin-default or system webhook by given ID.
+func DeleteDefaultSystemWebhook(id int64) error {
 	sess := x.NewSession()
 	defer sess.Close()
 	if err := sess.Begin(); err != nil {
@@ -432,6 +508,7 @@ const (
 	DINGTALK
 	TELEGRAM
 	MSTEAMS
+	MATRIX
 )
 
 var hookTaskTypes = map[string]HookTaskType{
@@ -442,6 +519,7 @@ var hookTaskTypes = map[string]HookTaskType{
 	"dingtalk": DINGTALK,
 	"telegram": TELEGRAM,
 	"msteams":  MSTEAMS,
+	"matrix":   MATRIX,
 }
 
 // ToHookTaskType returns HookTaskType by given name.
@@ -466,6 +544,8 @@ func (t HookTaskType) Name() string {
 		return "telegram"
 	case MSTEAMS:
 		return "msteams"
+	case MATRIX:
+		return "matrix"
 	}
 	return ""
 }
@@ -481,20 +561,41 @@ type HookEventType string
 
 // Types of hook events
 const (
-	HookEventCreate              HookEventType = "create"
-	HookEventDelete              HookEventType = "delete"
-	HookEventFork                HookEventType = "fork"
-	HookEventPush                HookEventType = "push"
-	HookEventIssues              HookEventType = "issues"
-	HookEventIssueComment        HookEventType = "issue_comment"
-	HookEventPullRequest         HookEventType = "pull_request"
-	HookEventRepository          HookEventType = "repository"
-	HookEventRelease             HookEventType = "release"
-	HookEventPullRequestApproved HookEventType = "pull_request_approved"
-	HookEventPullRequestRejected HookEventType = "pull_request_rejected"
-	HookEventPullRequestComment  HookEventType = "pull_request_comment"
+	HookEventCreate                   HookEventType = "create"
+	HookEventDelete                   HookEventType = "delete"
+	HookEventFork                     HookEventType = "fork"
+	HookEventPush                     HookEventType = "push"
+	HookEventIssues                   HookEventType = "issues"
+	HookEventIssueAssign              HookEventType = "issue_assign"
+	HookEventIssueLabel               HookEventType = "issue_label"
+	HookEventIssueMilestone           HookEventType = "issue_milestone"
+	HookEventIssueComment             HookEventType = "issue_comment"
+	HookEventPullRequest              HookEventType = "pull_request"
+	HookEventPullRequestAssign        HookEventType = "pull_request_assign"
+	HookEventPullRequestLabel         HookEventType = "pull_request_label"
+	HookEventPullRequestMilestone     HookEventType = "pull_request_milestone"
+	HookEventPullRequestSync          HookEventType = "pull_request_sync"
+	HookEventRepository               HookEventType = "repository"
+	HookEventRelease                  HookEventType = "release"
+	HookEventPullRequestApproved      HookEventType = "pull_request_approved"
+	HookEventPullRequestRejected      HookEventType = "pull_request_rejected"
+	HookEventPullRequestComment       HookEventType = "pull_request_comment"
+	HookEventPullRequestReviewRequest HookEventType = "pull_request_review_request"
 )
 
+// Event returns the name of the event the hook event type belongs to, i.e. the
+// granular issue and pull request events are reported as issues and pull_request
+// events, so receivers which don't know them can handle them as before.
+func (h HookEventType) Event() string {
+	switch h {
+	case HookEventIssueAssign, HookEventIssueLabel, HookEventIssueMilestone:
+		return string(HookEventIssues)
+	case HookEventPullRequestAssign, HookEventPullRequestLabel, HookEventPullRequestMilestone, HookEventPullRequestSync:
+		return string(HookEventPullRequest)
+	}
+	return string(h)
+}
+
 // HookRequest represents hook task request information.
 type HookRequest struct {
 	Headers map[string]string `json:"headers"`
@@ -526,6 +627,11 @@ type HookTask struct {
 	Delivered       int64
 	DeliveredString string `xorm:"-"`
 
+	// Retry info, a task is delivered once it succeeded or
+	// has been attempted setting.Webhook.DeliverMaxAttempts times.
+	Attempts        int                `xorm:"NOT NULL DEFAULT 0"`
+	NextAttemptUnix timeutil.TimeStamp `xorm:"INDEX NOT NULL DEFAULT 0"`
+
 	// History info.
 	IsSucceed       bool
 	RequestContent  string        `xorm:"TEXT"`
@@ -607,20 +713,174 @@ func UpdateHookTask(t *HookTask) error {
 	return err
 }
 
+// GetHookTaskByHookID returns the hook task of the webhook by given ID.
+func GetHookTaskByHookID(hookID, id int64) (*HookTask, error) {
+	t := &HookTask{
+		ID:     id,
+		HookID: hookID,
+	}
+	has, err := x.Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrHookTaskNotExist{ID: id, HookID: hookID}
+	}
+	return t, nil
+}
+
+// FindHookTasks returns a page of the hook tasks of the webhook, newest first.
+func FindHookTasks(hookID int64, page, pageSize int) ([]*HookTask, error) {
+	if page <= 0 {
+		page = 1
+	}
+	tasks := make([]*HookTask, 0, pageSize)
+	return tasks, x.
+		Limit(pageSize, (page-1)*pageSize).
+		Where("hook_id=?", hookID).
+		Desc("id").
+		Find(&tasks)
+}
+
+// RedeliverHookTask creates a new hook task with the payload of the given one,
+// it will be delivered like a newly triggered event.
+func RedeliverHookTask(t *HookTask) (*HookTask, error) {
+	newTask := &HookTask{
+		RepoID:         t.RepoID,
+		HookID:         t.HookID,
+		UUID:           gouuid.NewV4().String(),
+		Type:           t.Type,
+		URL:            t.URL,
+		Signature:      t.Signature,
+		PayloadContent: t.PayloadContent,
+		HTTPMethod:     t.HTTPMethod,
+		ContentType:    t.ContentType,
+		EventType:      t.EventType,
+		IsSSL:          t.IsSSL,
+	}
+	if _, err := x.Insert(newTask); err != nil {
+		return nil, err
+	}
+	return newTask, nil
+}
+
+// GetHookTaskByID returns the hook task by given ID.
+func GetHookTaskByID(id int64) (*HookTask, error) {
+	t := new(HookTask)
+	has, err := x.ID(id).Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrHookTaskNotExist{ID: id}
+	}
+	return t, nil
+}
+
 // FindUndeliveredHookTasks represents find the undelivered hook tasks
+// which are due to be attempted
 func FindUndeliveredHookTasks() ([]*HookTask, error) {
 	tasks := make([]*HookTask, 0, 10)
-	if err := x.Where("is_delivered=?", false).Find(&tasks); err != nil {
+	if err := x.Where("is_delivered=? AND next_attempt_unix<=?", false, timeutil.TimeStampNow()).
+		Find(&tasks); err != nil {
 		return nil, err
 	}
 	return tasks, nil
 }
 
-// FindRepoUndeliveredHookTasks represents find the undelivered hook tasks of one repository
-func FindRepoUndeliveredHookTasks(repoID int64) ([]*HookTask, error) {
-	tasks := make([]*HookTask, 0, 5)
-	if err := x.Where("repo_id=? AND is_delivered=?", repoID, false).Find(&tasks); err != nil {
-		return nil, err
+// FindUndeliveredHookTaskIDs returns up to limit IDs greater than afterID of the
+// undelivered hook tasks which are due to be attempted, ordered by ID
+func FindUndeliveredHookTaskIDs(afterID int64, limit int) ([]int64, error) {
+	ids := make([]int64, 0, limit)
+	return ids, x.Table("hook_task").
+		Where("is_delivered=? AND next_attempt_unix<=? AND id>?", false, timeutil.TimeStampNow(), afterID).
+		Cols("id").
+		OrderBy("id").
+		Limit(limit).
+		Find(&ids)
+}
+
+// QueueHookTask marks a due hook task as queued until the given time, so that it is not
+// queued again meanwhile. It returns false if the task is not due or has been queued by
+// someone else.
+func QueueHookTask(id int64, until timeutil.TimeStamp) (bool, error) {
+	affected, err := x.ID(id).
+		Where("is_delivered=? AND next_attempt_unix<=?", false, timeutil.TimeStampNow()).
+		Cols("next_attempt_unix").
+		NoAutoTime().
+		Update(&HookTask{NextAttemptUnix: until})
+	if err != nil {
+		return false, err
 	}
-	return tasks, nil
+	return affected == 1, nil
+}
+
+// ClaimHookTask reserves a hook task which has been queued until queuedUntil for a delivery
+// attempt until the given time, so that it is not attempted concurrently. It returns false
+// if the task has been delivered, queued again or claimed by someone else meanwhile.
+func ClaimHookTask(t *HookTask, queuedUntil, until timeutil.TimeStamp) (bool, error) {
+	affected, err := x.ID(t.ID).
+		Where("is_delivered=? AND next_attempt_unix=?", false, queuedUntil).
+		Cols("next_attempt_unix").
+		NoAutoTime().
+		Update(&HookTask{NextAttemptUnix: until})
+	if err != nil {
+		return false, err
+	}
+	if affected == 1 {
+		t.NextAttemptUnix = until
+	}
+	return affected == 1, nil
+}
+
+// CleanupHookTaskTable deletes delivered hook tasks according to the
+// cron.cleanup_hook_task_table settings
+func CleanupHookTaskTable(ctx context.Context) {
+	log.Trace("Doing: CleanupHookTaskTable")
+
+	opts := setting.Cron.CleanupHookTaskTable
+	switch opts.CleanupType {
+	case "OlderThan":
+		deleteBefore := time.Now().Add(-opts.OlderThan).UnixNano()
+		if _, err := x.Where("is_delivered=? AND delivered<?", true, deleteBefore).Delete(new(HookTask)); err != nil {
+			log.Error("CleanupHookTaskTable: %v", err)
+		}
+	case "PerWebhook":
+		hookIDs := make([]int64, 0, 10)
+		if err := x.Table("webhook").Cols("id").Find(&hookIDs); err != nil {
+			log.Error("CleanupHookTaskTable: %v", err)
+			return
+		}
+		for _, hookID := range hookIDs {
+			select {
+			case <-ctx.Done():
+				log.Warn("CleanupHookTaskTable: Aborted due to shutdown")
+				return
+			default:
+			}
+			if err := deleteDeliveredHookTasksByHookID(hookID, opts.NumberToKeep); err != nil {
+				log.Error("CleanupHookTaskTable [hook_id: %d]: %v", hookID, err)
+			}
+		}
+	default:
+		log.Error("CleanupHookTaskTable: Unknown cleanup type %s", opts.CleanupType)
+	}
+	log.Trace("Finished: CleanupHookTaskTable")
+}
+
+// deleteDeliveredHookTasksByHookID deletes all delivered hook tasks of the webhook
+// except the numberToKeep newest ones
+func deleteDeliveredHookTasksByHookID(hookID int64, numberToKeep int) error {
+	ids := make([]int64, 0, 1)
+	if err := x.Table("hook_task").
+		Where("hook_id=? AND is_delivered=?", hookID, true).
+		Cols("id").
+		Desc("id").
+		Limit(1, numberToKeep).
+		Find(&ids); err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := x.Where("hook_id=? AND is_delivered=? AND id<=?", hookID, true, ids[0]).Delete(new(HookTask))
+	return err
 }
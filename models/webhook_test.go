@@ -5,10 +5,13 @@
 package models
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
+	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -60,8 +63,38 @@ func TestWebhook_UpdateEvent(t *testing.T) {
 	assert.Equal(t, *hookEvent, *actualHookEvent)
 }
 
+func TestWebhook_GranularEvents(t *testing.T) {
+	w := &Webhook{
+		HookEvent: &HookEvent{
+			ChooseEvents: true,
+			HookEvents: HookEvents{
+				Issues:          true,
+				PullRequestSync: true,
+			},
+		},
+	}
+	assert.True(t, w.HasIssuesEvent())
+	assert.False(t, w.HasIssuesAssignEvent())
+	assert.False(t, w.HasIssuesLabelEvent())
+	assert.False(t, w.HasPullRequestEvent())
+	assert.True(t, w.HasPullRequestSyncEvent())
+	assert.Equal(t, []string{"issues", "pull_request_sync"}, w.EventsArray())
+}
+
+func TestHookEventType_Event(t *testing.T) {
+	assert.Equal(t, "issues", HookEventIssues.Event())
+	assert.Equal(t, "issues", HookEventIssueLabel.Event())
+	assert.Equal(t, "pull_request", HookEventPullRequestMilestone.Event())
+	assert.Equal(t, "pull_request", HookEventPullRequestSync.Event())
+	assert.Equal(t, "pull_request_review_request", HookEventPullRequestReviewRequest.Event())
+	assert.Equal(t, "push", HookEventPush.Event())
+}
+
 func TestWebhook_EventsArray(t *testing.T) {
-	assert.Equal(t, []string{"create", "delete", "fork", "push", "issues", "issue_comment", "pull_request", "repository", "release"},
+	assert.Equal(t, []string{"create", "delete", "fork", "push",
+		"issues", "issue_assign", "issue_label", "issue_milestone", "issue_comment",
+		"pull_request", "pull_request_assign", "pull_request_label", "pull_request_milestone", "pull_request_sync",
+		"pull_request_review_request", "repository", "release"},
 		(&Webhook{
 			HookEvent: &HookEvent{SendEverything: true},
 		}).EventsArray(),
@@ -128,6 +161,52 @@ func TestGetWebhooksByRepoID(t *testing.T) {
 	}
 }
 
+func TestGetSystemAndDefaultWebhooks(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	defaultHook := &Webhook{URL: "www.example.com/default", HookEvent: &HookEvent{PushOnly: true}, IsActive: true}
+	systemHook := &Webhook{URL: "www.example.com/system", HookEvent: &HookEvent{PushOnly: true}, IsActive: true, IsSystemWebhook: true}
+	for _, w := range []*Webhook{defaultHook, systemHook} {
+		assert.NoError(t, w.UpdateEvent())
+		assert.NoError(t, CreateWebhook(w))
+	}
+
+	hooks, err := GetDefaultWebhooks()
+	assert.NoError(t, err)
+	if assert.Len(t, hooks, 1) {
+		assert.Equal(t, defaultHook.ID, hooks[0].ID)
+	}
+
+	hooks, err = GetSystemWebhooks()
+	assert.NoError(t, err)
+	if assert.Len(t, hooks, 1) {
+		assert.Equal(t, systemHook.ID, hooks[0].ID)
+	}
+
+	hooks, err = GetActiveSystemWebhooks()
+	assert.NoError(t, err)
+	assert.Len(t, hooks, 1)
+
+	hook, err := GetSystemOrDefaultWebhook(systemHook.ID)
+	assert.NoError(t, err)
+	assert.True(t, hook.IsSystemWebhook)
+	_, err = GetSystemOrDefaultWebhook(1)
+	assert.True(t, IsErrWebhookNotExist(err))
+
+	// only the default webhooks are copied into new repositories
+	assert.NoError(t, copyDefaultWebhooksToRepo(x, 1))
+	hooks, err = GetWebhooksByRepoID(1)
+	assert.NoError(t, err)
+	if assert.Len(t, hooks, 3) {
+		assert.Equal(t, "www.example.com/default", hooks[2].URL)
+		assert.False(t, hooks[2].IsSystemWebhook)
+	}
+
+	assert.NoError(t, DeleteDefaultSystemWebhook(systemHook.ID))
+	AssertNotExistsBean(t, &Webhook{ID: systemHook.ID})
+	assert.True(t, IsErrWebhookNotExist(DeleteDefaultSystemWebhook(1)))
+}
+
 func TestGetActiveWebhooksByOrgID(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	hooks, err := GetActiveWebhooksByOrgID(3)
@@ -186,6 +265,7 @@ func TestToHookTaskType(t *testing.T) {
 	assert.Equal(t, SLACK, ToHookTaskType("slack"))
 	assert.Equal(t, GITEA, ToHookTaskType("gitea"))
 	assert.Equal(t, TELEGRAM, ToHookTaskType("telegram"))
+	assert.Equal(t, MATRIX, ToHookTaskType("matrix"))
 }
 
 func TestHookTaskType_Name(t *testing.T) {
@@ -193,6 +273,7 @@ func TestHookTaskType_Name(t *testing.T) {
 	assert.Equal(t, "slack", SLACK.Name())
 	assert.Equal(t, "gitea", GITEA.Name())
 	assert.Equal(t, "telegram", TELEGRAM.Name())
+	assert.Equal(t, "matrix", MATRIX.Name())
 }
 
 func TestIsValidHookTaskType(t *testing.T) {
@@ -200,6 +281,7 @@ func TestIsValidHookTaskType(t *testing.T) {
 	assert.True(t, IsValidHookTaskType("slack"))
 	assert.True(t, IsValidHookTaskType("gitea"))
 	assert.True(t, IsValidHookTaskType("telegram"))
+	assert.True(t, IsValidHookTaskType("matrix"))
 	assert.False(t, IsValidHookTaskType("invalid"))
 }
 
@@ -241,3 +323,153 @@ func TestUpdateHookTask(t *testing.T) {
 	assert.NoError(t, UpdateHookTask(hook))
 	AssertExistsAndLoadBean(t, hook)
 }
+
+func TestGetHookTaskByHookID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	hookTask, err := GetHookTaskByHookID(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "uuid1", hookTask.UUID)
+
+	_, err = GetHookTaskByHookID(2, 1)
+	assert.True(t, IsErrHookTaskNotExist(err))
+}
+
+func TestRedeliverHookTask(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	hookTask := AssertExistsAndLoadBean(t, &HookTask{ID: 1}).(*HookTask)
+	newTask, err := RedeliverHookTask(hookTask)
+	assert.NoError(t, err)
+	assert.NotEqual(t, hookTask.ID, newTask.ID)
+	assert.NotEqual(t, hookTask.UUID, newTask.UUID)
+
+	newTask = AssertExistsAndLoadBean(t, &HookTask{ID: newTask.ID}).(*HookTask)
+	assert.Equal(t, hookTask.HookID, newTask.HookID)
+	assert.Equal(t, hookTask.PayloadContent, newTask.PayloadContent)
+	assert.False(t, newTask.IsDelivered)
+	assert.Zero(t, newTask.Attempts)
+
+	ids, err := FindUndeliveredHookTaskIDs(0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{newTask.ID}, ids)
+}
+
+func TestFindUndeliveredHookTaskIDs(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	var created []int64
+	for i := 0; i < 3; i++ {
+		hookTask := &HookTask{
+			RepoID:    1,
+			HookID:    1,
+			Type:      GITEA,
+			URL:       "http://www.example.com/unit_test",
+			Payloader: &api.PushPayload{},
+		}
+		assert.NoError(t, CreateHookTask(hookTask))
+		created = append(created, hookTask.ID)
+	}
+
+	ids, err := FindUndeliveredHookTaskIDs(0, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, created[:2], ids)
+	ids, err = FindUndeliveredHookTaskIDs(ids[1], 2)
+	assert.NoError(t, err)
+	assert.Equal(t, created[2:], ids)
+}
+
+func TestFindUndeliveredHookTasks(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	hookTask := &HookTask{
+		RepoID:          3,
+		HookID:          3,
+		Type:            GITEA,
+		URL:             "http://www.example.com/unit_test",
+		Payloader:       &api.PushPayload{},
+		Attempts:        1,
+		NextAttemptUnix: timeutil.TimeStampNow().Add(60),
+	}
+	assert.NoError(t, CreateHookTask(hookTask))
+
+	// the retry is not due yet
+	tasks, err := FindUndeliveredHookTasks()
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 0)
+
+	hookTask.NextAttemptUnix = timeutil.TimeStampNow()
+	assert.NoError(t, UpdateHookTask(hookTask))
+	tasks, err = FindUndeliveredHookTasks()
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+}
+
+func TestCleanupHookTaskTable(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	undelivered := &HookTask{
+		RepoID:    1,
+		HookID:    1,
+		Type:      GITEA,
+		URL:       "http://www.example.com/unit_test",
+		Payloader: &api.PushPayload{},
+	}
+	assert.NoError(t, CreateHookTask(undelivered))
+
+	setting.Cron.CleanupHookTaskTable.CleanupType = "PerWebhook"
+	setting.Cron.CleanupHookTaskTable.NumberToKeep = 1
+	CleanupHookTaskTable(context.Background())
+	AssertExistsAndLoadBean(t, &HookTask{ID: 1})
+
+	setting.Cron.CleanupHookTaskTable.NumberToKeep = 0
+	CleanupHookTaskTable(context.Background())
+	AssertNotExistsBean(t, &HookTask{ID: 1})
+	AssertExistsAndLoadBean(t, &HookTask{ID: undelivered.ID})
+}
+
+func TestQueueAndClaimHookTask(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	hookTask := &HookTask{
+		RepoID:    1,
+		HookID:    1,
+		Type:      GITEA,
+		URL:       "http://www.example.com/unit_test",
+		Payloader: &api.PushPayload{},
+	}
+	assert.NoError(t, CreateHookTask(hookTask))
+
+	queuedUntil := timeutil.TimeStampNow().Add(60)
+	queued, err := QueueHookTask(hookTask.ID, queuedUntil)
+	assert.NoError(t, err)
+	assert.True(t, queued)
+
+	// a queued task is not due anymore
+	queued, err = QueueHookTask(hookTask.ID, queuedUntil)
+	assert.NoError(t, err)
+	assert.False(t, queued)
+	ids, err := FindUndeliveredHookTaskIDs(0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, ids, 0)
+
+	// the task can only be claimed by the queued item
+	until := queuedUntil.Add(60)
+	claimed, err := ClaimHookTask(hookTask, queuedUntil.Add(-1), until)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+	claimed, err = ClaimHookTask(hookTask, queuedUntil, until)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Equal(t, until, hookTask.NextAttemptUnix)
+
+	// a claimed task can not be claimed again
+	claimed, err = ClaimHookTask(hookTask, queuedUntil, until)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	// neither can a delivered task be queued
+	queued, err = QueueHookTask(1, queuedUntil)
+	assert.NoError(t, err)
+	assert.False(t, queued)
+}
@@ -0,0 +1,244 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/unknwon/com"
+)
+
+// ErrInvalidAlgorithmType represents an invalid algorithm error.
+type ErrInvalidAlgorithmType struct {
+	Algorithm string
+}
+
+func (err ErrInvalidAlgorithmType) Error() string {
+	return fmt.Sprintf("JWT signing algorithm is not supported: %s", err.Algorithm)
+}
+
+// JWTSigningKey represents an asymmetric key used to sign the JWTs Gitea issues
+// as OpenID Connect provider. Its public part is published as JSON Web Key.
+type JWTSigningKey struct {
+	method     jwt.SigningMethod
+	privateKey crypto.Signer
+	keyID      string
+}
+
+// DefaultSigningKey is the signing key used to sign OpenID Connect ID tokens
+var DefaultSigningKey *JWTSigningKey
+
+// NewJWTSigningKey creates a signing key for the given algorithm, the private key
+// has to match the algorithm.
+func NewJWTSigningKey(algorithm string, privateKey crypto.Signer) (*JWTSigningKey, error) {
+	method := jwt.GetSigningMethod(algorithm)
+	if method == nil {
+		return nil, ErrInvalidAlgorithmType{algorithm}
+	}
+
+	switch m := method.(type) {
+	case *jwt.SigningMethodRSA:
+		if _, ok := privateKey.(*rsa.PrivateKey); !ok {
+			return nil, fmt.Errorf("%s requires a RSA key", algorithm)
+		}
+	case *jwt.SigningMethodECDSA:
+		key, ok := privateKey.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%s requires an ECDSA key", algorithm)
+		}
+		if key.Curve.Params().BitSize != m.CurveBits {
+			return nil, fmt.Errorf("%s requires a %d bit curve", algorithm, m.CurveBits)
+		}
+	default:
+		return nil, ErrInvalidAlgorithmType{algorithm}
+	}
+
+	key := &JWTSigningKey{
+		method:     method,
+		privateKey: privateKey,
+	}
+	thumbprint, err := key.thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	key.keyID = thumbprint
+	return key, nil
+}
+
+// SigningMethod returns the signing method of the key
+func (key *JWTSigningKey) SigningMethod() jwt.SigningMethod {
+	return key.method
+}
+
+// VerifyKey returns the public key to verify the signatures made with this key
+func (key *JWTSigningKey) VerifyKey() crypto.PublicKey {
+	return key.privateKey.Public()
+}
+
+// KeyID returns the identifier of the key, its JWK thumbprint (RFC 7638)
+func (key *JWTSigningKey) KeyID() string {
+	return key.keyID
+}
+
+// SignToken signs the claims with the key
+func (key *JWTSigningKey) SignToken(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.keyID
+	return token.SignedString(key.privateKey)
+}
+
+// publicJWK returns the members of the JSON Web Key of the public key required by RFC 7638
+func (key *JWTSigningKey) publicJWK() map[string]string {
+	switch pub := key.VerifyKey().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{
+			"kty": "RSA",
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		return map[string]string{
+			"kty": "EC",
+			"crv": pub.Curve.Params().Name,
+			"x":   base64.RawURLEncoding.EncodeToString(padBytes(pub.X.Bytes(), size)),
+			"y":   base64.RawURLEncoding.EncodeToString(padBytes(pub.Y.Bytes(), size)),
+		}
+	}
+	return nil
+}
+
+func (key *JWTSigningKey) thumbprint() (string, error) {
+	// json.Marshal sorts the keys of maps, which is the order RFC 7638 requires
+	data, err := json.Marshal(key.publicJWK())
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// ToJWK returns the public key as JSON Web Key (RFC 7517)
+func (key *JWTSigningKey) ToJWK() map[string]string {
+	jwk := key.publicJWK()
+	jwk["alg"] = key.method.Alg()
+	jwk["use"] = "sig"
+	jwk["kid"] = key.keyID
+	return jwk
+}
+
+func padBytes(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
+
+func generatePrivateKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case "RS256", "RS384", "RS512":
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ES384":
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "ES512":
+		return ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	}
+	return nil, ErrInvalidAlgorithmType{algorithm}
+}
+
+func loadPrivateKey(path string) (crypto.Signer, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key in %s", path)
+		}
+		return signer, nil
+	}
+	return nil, fmt.Errorf("unsupported PEM block %s in %s", block.Type, path)
+}
+
+func savePrivateKey(path string, key crypto.Signer) error {
+	data, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: data})
+}
+
+// InitSigningKey loads the private key used to sign OpenID Connect ID tokens
+// and generates it if it does not exist yet.
+func InitSigningKey() error {
+	algorithm := setting.OAuth2.JWTSigningAlgorithm
+	path := setting.OAuth2.JWTSigningPrivateKeyFile
+
+	var privateKey crypto.Signer
+	var err error
+	if com.IsFile(path) {
+		privateKey, err = loadPrivateKey(path)
+	} else {
+		log.Info("Generating OAuth2 JWT signing key %s", path)
+		if privateKey, err = generatePrivateKey(algorithm); err == nil {
+			err = savePrivateKey(path, privateKey)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("cannot load OAuth2 JWT signing key %s: %v", path, err)
+	}
+
+	key, err := NewJWTSigningKey(algorithm, privateKey)
+	if err != nil {
+		return fmt.Errorf("invalid OAuth2 JWT signing key %s: %v", path, err)
+	}
+	DefaultSigningKey = key
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWTSigningKey(t *testing.T) {
+	for _, algorithm := range []string{"RS256", "ES256", "ES512"} {
+		privateKey, err := generatePrivateKey(algorithm)
+		assert.NoError(t, err)
+		key, err := NewJWTSigningKey(algorithm, privateKey)
+		assert.NoError(t, err)
+
+		signed, err := key.SignToken(&jwt.StandardClaims{Subject: "1"})
+		assert.NoError(t, err)
+		claims := new(jwt.StandardClaims)
+		token, err := jwt.ParseWithClaims(signed, claims, func(token *jwt.Token) (interface{}, error) {
+			assert.Equal(t, key.KeyID(), token.Header["kid"])
+			return key.VerifyKey(), nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, token.Valid)
+		assert.Equal(t, "1", claims.Subject)
+
+		jwk := key.ToJWK()
+		assert.Equal(t, algorithm, jwk["alg"])
+		assert.Equal(t, key.KeyID(), jwk["kid"])
+	}
+
+	privateKey, err := generatePrivateKey("ES256")
+	assert.NoError(t, err)
+	_, err = NewJWTSigningKey("RS256", privateKey)
+	assert.Error(t, err)
+	_, err = NewJWTSigningKey("ES384", privateKey)
+	assert.Error(t, err)
+	_, err = NewJWTSigningKey("HS256", privateKey)
+	assert.Error(t, err)
+}
+
+func TestInitSigningKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwt")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	oldAlgorithm, oldPath := setting.OAuth2.JWTSigningAlgorithm, setting.OAuth2.JWTSigningPrivateKeyFile
+	defer func() {
+		setting.OAuth2.JWTSigningAlgorithm, setting.OAuth2.JWTSigningPrivateKeyFile = oldAlgorithm, oldPath
+	}()
+	setting.OAuth2.JWTSigningAlgorithm = "ES384"
+	setting.OAuth2.JWTSigningPrivateKeyFile = filepath.Join(dir, "jwt", "private.pem")
+
+	// the key is generated on first use and loaded afterwards
+	assert.NoError(t, InitSigningKey())
+	keyID := DefaultSigningKey.KeyID()
+	assert.FileExists(t, setting.OAuth2.JWTSigningPrivateKeyFile)
+	assert.NoError(t, InitSigningKey())
+	assert.Equal(t, keyID, DefaultSigningKey.KeyID())
+	assert.Equal(t, "EC", DefaultSigningKey.ToJWK()["kty"])
+	assert.Equal(t, "P-384", DefaultSigningKey.ToJWK()["crv"])
+
+	setting.OAuth2.JWTSigningAlgorithm = "RS256"
+	assert.Error(t, InitSigningKey())
+}
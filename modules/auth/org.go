@@ -42,6 +42,7 @@ type UpdateOrgSettingForm struct {
 	Visibility                structs.VisibleType
 	MaxRepoCreation           int
 	RepoAdminChangeTeamAccess bool
+	RequireTwoFactorAuth      bool
 }
 
 // Validate validates the fields
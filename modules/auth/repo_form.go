@@ -11,6 +11,7 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/routers/utils"
 
 	"gitea.com/macaron/binding"
@@ -70,6 +71,9 @@ type MigrateRepoForm struct {
 	Issues       bool   `json:"issues"`
 	PullRequests bool   `json:"pull_requests"`
 	Releases     bool   `json:"releases"`
+	// Service is the git service to migrate from: 1 plain git, 2 github, 4 gitlab,
+	// it is detected from the clone address when not set
+	Service structs.GitServiceType `json:"service"`
 }
 
 // Validate validates the fields
@@ -105,6 +109,22 @@ func (f MigrateRepoForm) ParseRemoteAddr(user *models.User) (string, error) {
 	return remoteAddr, nil
 }
 
+// ParsePushMirrorAddress checks if given push mirror address is valid,
+// and returns composed URL with needed username and password.
+// Only HTTP/HTTPS URLs are accepted since git can't push to other remotes
+// without credentials beyond a username and a password.
+func ParsePushMirrorAddress(addr, username, password string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(addr))
+	if err != nil || u.Opaque != "" || u.Host == "" || !u.IsAbs() ||
+		!(u.Scheme == "http" || u.Scheme == "https") {
+		return "", models.ErrInvalidCloneAddr{IsURLError: true}
+	}
+	if len(username)+len(password) > 0 {
+		u.User = url.UserPassword(username, password)
+	}
+	return u.String(), nil
+}
+
 // RepoSettingForm form for changing repository settings
 type RepoSettingForm struct {
 	RepoName       string `binding:"Required;AlphaDashDot;MaxSize(100)"`
@@ -118,6 +138,14 @@ type RepoSettingForm struct {
 	Template       bool
 	EnablePrune    bool
 
+	// Push mirror settings
+	PushMirrorID         int64
+	PushMirrorAddress    string
+	PushMirrorUsername   string
+	PushMirrorPassword   string
+	PushMirrorSyncOnPush bool
+	PushMirrorInterval   string
+
 	// Advanced settings
 	EnableWiki                       bool
 	EnableExternalWiki               bool
@@ -136,6 +164,7 @@ type RepoSettingForm struct {
 	EnableTimetracker                bool
 	AllowOnlyContributorsToTrackTime bool
 	EnableIssueDependencies          bool
+	EnableProjects                   bool
 	IsArchived                       bool
 
 	// Admin settings
@@ -172,6 +201,9 @@ type ProtectBranchForm struct {
 	ApprovalsWhitelistUsers  string
 	ApprovalsWhitelistTeams  string
 	BlockOnRejectedReviews   bool
+	RequireCodeOwnerApproval bool
+	RequireSignedCommits     bool
+	ProtectedFilePatterns    string
 }
 
 // Validate validates the fields
@@ -188,18 +220,25 @@ func (f *ProtectBranchForm) Validate(ctx *macaron.Context, errs binding.Errors)
 
 // WebhookForm form for changing web hook
 type WebhookForm struct {
-	Events       string
-	Create       bool
-	Delete       bool
-	Fork         bool
-	Issues       bool
-	IssueComment bool
-	Release      bool
-	Push         bool
-	PullRequest  bool
-	Repository   bool
-	Active       bool
-	BranchFilter string `binding:"GlobPattern"`
+	Events               string
+	Create               bool
+	Delete               bool
+	Fork                 bool
+	Issues               bool
+	IssueAssign          bool
+	IssueLabel           bool
+	IssueMilestone       bool
+	IssueComment         bool
+	Release              bool
+	Push                 bool
+	PullRequest          bool
+	PullRequestAssign    bool
+	PullRequestLabel     bool
+	PullRequestMilestone bool
+	PullRequestSync      bool
+	Repository           bool
+	Active               bool
+	BranchFilter         string `binding:"GlobPattern"`
 }
 
 // PushOnly if the hook will be triggered when push
@@ -311,6 +350,20 @@ func (f *NewMSTeamsHookForm) Validate(ctx *macaron.Context, errs binding.Errors)
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// NewMatrixHookForm form for creating matrix hook
+type NewMatrixHookForm struct {
+	HomeserverURL string `binding:"Required;ValidUrl"`
+	RoomID        string `binding:"Required"`
+	AccessToken   string `binding:"Required"`
+	MessageType   string `binding:"Required;In(m.notice,m.text)"`
+	WebhookForm
+}
+
+// Validate validates the fields
+func (f *NewMatrixHookForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // .___
 // |   | ______ ________ __   ____
 // |   |/  ___//  ___/  |  \_/ __ \
@@ -402,6 +455,36 @@ func (f *CreateMilestoneForm) Validate(ctx *macaron.Context, errs binding.Errors
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// __________                   __               __
+// \______   \_______  ____    |__| ____   _____/  |_
+//  |     ___/\_  __ \/  _ \   |  |/ __ \_/ ___\   __\
+//  |    |     |  | \(  <_> )  |  \  ___/\  \___|  |
+//  |____|     |__|   \____/\__|  |\___  >\___  >__|
+//                         \______|    \/     \/
+
+// CreateProjectForm form for creating a project
+type CreateProjectForm struct {
+	Title     string `binding:"Required;MaxSize(100)"`
+	Content   string
+	BoardType models.ProjectBoardType
+}
+
+// Validate validates the fields
+func (f *CreateProjectForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// EditProjectBoardForm form for creating or editing a project column
+type EditProjectBoardForm struct {
+	Title   string `binding:"Required;MaxSize(100)"`
+	Sorting int
+}
+
+// Validate validates the fields
+func (f *EditProjectBoardForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // .____          ___.          .__
 // |    |   _____ \_ |__   ____ |  |
 // |    |   \__  \ | __ \_/ __ \|  |
@@ -72,12 +72,16 @@ func (b *Basic) VerifyAuthData(ctx *macaron.Context, sess session.Store) *models
 		authToken = passwd
 	}
 
-	uid := CheckOAuthAccessToken(authToken)
-	if uid != 0 {
+	if grant := CheckOAuthAccessToken(authToken); grant != nil {
 		var err error
+		token := grant.ToAccessToken()
+		if !isTokenScopeAllowed(ctx, token) {
+			return nil
+		}
 		ctx.Data["IsApiToken"] = true
+		ctx.Data["ApiToken"] = token
 
-		u, err = models.GetUserByID(uid)
+		u, err = models.GetUserByID(token.UID)
 		if err != nil {
 			log.Error("GetUserByID:  %v", err)
 			return nil
@@ -85,6 +89,9 @@ func (b *Basic) VerifyAuthData(ctx *macaron.Context, sess session.Store) *models
 	}
 	token, err := models.GetAccessTokenBySHA(authToken)
 	if err == nil {
+		if !isTokenScopeAllowed(ctx, token) {
+			return nil
+		}
 		if isUsernameToken {
 			u, err = models.GetUserByID(token.UID)
 			if err != nil {
@@ -105,6 +112,7 @@ func (b *Basic) VerifyAuthData(ctx *macaron.Context, sess session.Store) *models
 		if err = models.UpdateAccessToken(token); err != nil {
 			log.Error("UpdateAccessToken:  %v", err)
 		}
+		ctx.Data["ApiToken"] = token
 	} else if !models.IsErrAccessTokenNotExist(err) && !models.IsErrAccessTokenEmpty(err) {
 		log.Error("GetAccessTokenBySha: %v", err)
 	}
@@ -117,6 +125,15 @@ func (b *Basic) VerifyAuthData(ctx *macaron.Context, sess session.Store) *models
 			}
 			return nil
 		}
+
+		// users who are required to use a second factor can not use their password
+		// until they have set one up, access tokens keep working
+		if pending, err := models.IsTwoFactorSetUpPending(u.ID); err != nil {
+			log.Error("IsTwoFactorSetUpPending: %v", err)
+			return nil
+		} else if pending {
+			return nil
+		}
 	} else {
 		ctx.Data["IsApiToken"] = true
 	}
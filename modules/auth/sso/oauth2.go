@@ -22,28 +22,28 @@ var (
 	_ SingleSignOn = &OAuth2{}
 )
 
-// CheckOAuthAccessToken returns uid of user from oauth token
-func CheckOAuthAccessToken(accessToken string) int64 {
+// CheckOAuthAccessToken returns the grant of an oauth token, or nil if the token is not valid
+func CheckOAuthAccessToken(accessToken string) *models.OAuth2Grant {
 	// JWT tokens require a "."
 	if !strings.Contains(accessToken, ".") {
-		return 0
+		return nil
 	}
 	token, err := models.ParseOAuth2Token(accessToken)
 	if err != nil {
 		log.Trace("ParseOAuth2Token: %v", err)
-		return 0
+		return nil
 	}
 	var grant *models.OAuth2Grant
 	if grant, err = models.GetOAuth2GrantByID(token.GrantID); err != nil || grant == nil {
-		return 0
+		return nil
 	}
 	if token.Type != models.TypeAccessToken {
-		return 0
+		return nil
 	}
 	if token.ExpiresAt < time.Now().Unix() || token.IssuedAt > time.Now().Unix() {
-		return 0
+		return nil
 	}
-	return grant.UserID
+	return grant
 }
 
 // OAuth2 implements the SingleSignOn interface and authenticates requests
@@ -85,11 +85,17 @@ func (o *OAuth2) userIDFromToken(ctx *macaron.Context) int64 {
 
 	// Let's see if token is valid.
 	if strings.Contains(tokenSHA, ".") {
-		uid := CheckOAuthAccessToken(tokenSHA)
-		if uid != 0 {
-			ctx.Data["IsApiToken"] = true
+		grant := CheckOAuthAccessToken(tokenSHA)
+		if grant == nil {
+			return 0
 		}
-		return uid
+		t := grant.ToAccessToken()
+		if !isTokenScopeAllowed(ctx, t) {
+			return 0
+		}
+		ctx.Data["IsApiToken"] = true
+		ctx.Data["ApiToken"] = t
+		return t.UID
 	}
 	t, err := models.GetAccessTokenBySHA(tokenSHA)
 	if err != nil {
@@ -98,11 +104,15 @@ func (o *OAuth2) userIDFromToken(ctx *macaron.Context) int64 {
 		}
 		return 0
 	}
+	if !isTokenScopeAllowed(ctx, t) {
+		return 0
+	}
 	t.UpdatedUnix = timeutil.TimeStampNow()
 	if err = models.UpdateAccessToken(t); err != nil {
 		log.Error("UpdateAccessToken: %v", err)
 	}
 	ctx.Data["IsApiToken"] = true
+	ctx.Data["ApiToken"] = t
 	return t.UID
 }
 
@@ -105,6 +105,24 @@ func isAttachmentDownload(ctx *macaron.Context) bool {
 	return strings.HasPrefix(ctx.Req.URL.Path, "/attachments/") && ctx.Req.Method == "GET"
 }
 
+// isTokenScopeAllowed checks if an access token may be used for the requested path,
+// the scopes of API routes are checked by the API router. Outside of the API only tokens
+// with full access are accepted, apart from attachment downloads, as the web routes do
+// not check scopes. Git smart HTTP authenticates tokens and checks their scope itself.
+func isTokenScopeAllowed(ctx *macaron.Context, token *models.AccessToken) bool {
+	if isAPIPath(ctx) {
+		return true
+	}
+	if token.IsRestricted() {
+		return false
+	}
+	if isAttachmentDownload(ctx) {
+		return token.Scope.HasScope(models.AccessTokenScopeCategoryRepository, models.AccessTokenScopeLevelRead) ||
+			token.Scope.HasScope(models.AccessTokenScopeCategoryIssue, models.AccessTokenScopeLevelRead)
+	}
+	return token.Scope.HasAllScopes()
+}
+
 // handleSignIn clears existing session variables and stores new ones for the specified user object
 func handleSignIn(ctx *macaron.Context, sess session.Store, user *models.User) {
 	_ = sess.Delete("openid_verified_uri")
@@ -113,7 +131,7 @@ func handleSignIn(ctx *macaron.Context, sess session.Store, user *models.User) {
 	_ = sess.Delete("openid_determined_username")
 	_ = sess.Delete("twofaUid")
 	_ = sess.Delete("twofaRemember")
-	_ = sess.Delete("u2fChallenge")
+	_ = sess.Delete("webauthnAssertion")
 	_ = sess.Delete("linkAccount")
 	err := sess.Set("uid", user.ID)
 	if err != nil {
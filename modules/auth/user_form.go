@@ -145,6 +145,8 @@ type AuthorizationForm struct {
 	ClientID     string `binding:"Required"`
 	RedirectURI  string
 	State        string
+	Scope        string
+	Nonce        string
 
 	// PKCE support
 	CodeChallengeMethod string // S256, plain
@@ -161,6 +163,8 @@ type GrantApplicationForm struct {
 	ClientID    string `binding:"Required"`
 	RedirectURI string
 	State       string
+	Scope       string
+	Nonce       string
 }
 
 // Validate valideates the fields
@@ -186,6 +190,19 @@ func (f *AccessTokenForm) Validate(ctx *macaron.Context, errs binding.Errors) bi
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// RevokeOAuth2TokenForm for revoking access tokens or refresh tokens (RFC 7009)
+type RevokeOAuth2TokenForm struct {
+	Token         string `json:"token"`
+	TokenTypeHint string `json:"token_type_hint"`
+	ClientID      string `json:"client_id"`
+	ClientSecret  string `json:"client_secret"`
+}
+
+// Validate valideates the fields
+func (f *RevokeOAuth2TokenForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 //   __________________________________________.___ _______    ________  _________
 //  /   _____/\_   _____/\__    ___/\__    ___/|   |\      \  /  _____/ /   _____/
 //  \_____  \  |    __)_   |    |     |    |   |   |/   |   \/   \  ___ \_____  \
@@ -300,7 +317,11 @@ func (f *AddKeyForm) Validate(ctx *macaron.Context, errs binding.Errors) binding
 
 // NewAccessTokenForm form for creating access token
 type NewAccessTokenForm struct {
-	Name string `binding:"Required;MaxSize(255)"`
+	Name          string `binding:"Required;MaxSize(255)"`
+	Scope         []string
+	Repositories  string
+	Organizations string
+	ExpiresAt     string
 }
 
 // Validate valideates the fields
@@ -339,22 +360,22 @@ func (f *TwoFactorScratchAuthForm) Validate(ctx *macaron.Context, errs binding.E
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
-// U2FRegistrationForm for reserving an U2F name
-type U2FRegistrationForm struct {
-	Name string `binding:"Required"`
+// WebAuthnRegistrationForm for reserving a WebAuthn name
+type WebAuthnRegistrationForm struct {
+	Name string `binding:"Required;MaxSize(255)"`
 }
 
 // Validate valideates the fields
-func (f *U2FRegistrationForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+func (f *WebAuthnRegistrationForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
-// U2FDeleteForm for deleting U2F keys
-type U2FDeleteForm struct {
+// WebAuthnDeleteForm for deleting WebAuthn keys
+type WebAuthnDeleteForm struct {
 	ID int64 `binding:"Required"`
 }
 
 // Validate valideates the fields
-func (f *U2FDeleteForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+func (f *WebAuthnDeleteForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
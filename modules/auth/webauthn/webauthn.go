@@ -0,0 +1,161 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"net/url"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/webauthn"
+)
+
+// WebAuthn represents the global WebAuthn instance
+var WebAuthn *webauthn.WebAuthn
+
+// ErrClonedCredential is returned if the sign counter of a credential indicates
+// that it has been cloned
+var ErrClonedCredential = errors.New("the credential might have been cloned")
+
+// Init initializes the WebAuthn relying party for the domain of the application
+func Init() error {
+	appURL, err := url.Parse(setting.AppURL)
+	if err != nil {
+		return err
+	}
+	WebAuthn, err = webauthn.New(&webauthn.Config{
+		RPDisplayName: setting.AppName,
+		RPID:          appURL.Hostname(),
+		RPOrigin:      protocol.FullyQualifiedOrigin(appURL),
+		// Any authenticator may be used, including the platform authenticators of the device
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			UserVerification: protocol.VerificationPreferred,
+		},
+		AttestationPreference: protocol.PreferNoAttestation,
+	})
+	return err
+}
+
+// User wraps models.User to implement webauthn.User
+type User models.User
+
+// WebAuthnID returns the user handle of the user
+func (u *User) WebAuthnID() []byte {
+	id := make([]byte, 8)
+	binary.PutVarint(id, u.ID)
+	return id
+}
+
+// WebAuthnName returns the name of the user
+func (u *User) WebAuthnName() string {
+	return u.Name
+}
+
+// WebAuthnDisplayName returns the display name of the user
+func (u *User) WebAuthnDisplayName() string {
+	return (*models.User)(u).DisplayName()
+}
+
+// WebAuthnIcon returns the avatar of the user
+func (u *User) WebAuthnIcon() string {
+	return (*models.User)(u).AvatarLink()
+}
+
+// WebAuthnCredentials returns the credentials registered by the user
+func (u *User) WebAuthnCredentials() []webauthn.Credential {
+	creds, err := models.GetWebAuthnCredentialsByUID(u.ID)
+	if err != nil {
+		log.Error("GetWebAuthnCredentialsByUID: %v", err)
+		return nil
+	}
+	return creds.ToCredentials()
+}
+
+// BeginRegistration starts the registration of a new credential, the existing
+// credentials of the user are excluded.
+func BeginRegistration(user *models.User) (*protocol.CredentialCreation, *webauthn.SessionData, error) {
+	wUser := (*User)(user)
+	creds := wUser.WebAuthnCredentials()
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(creds))
+	for _, cred := range creds {
+		exclusions = append(exclusions, protocol.CredentialDescriptor{
+			Type:         protocol.PublicKeyCredentialType,
+			CredentialID: cred.ID,
+		})
+	}
+	return WebAuthn.BeginRegistration(wUser, webauthn.WithExclusions(exclusions))
+}
+
+// FinishRegistration verifies the response of the authenticator and returns the new credential
+func FinishRegistration(user *models.User, session webauthn.SessionData, body []byte) (*webauthn.Credential, error) {
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	return WebAuthn.CreateCredential((*User)(user), session, parsed)
+}
+
+// BeginLogin starts the assertion of one of the credentials of the user
+func BeginLogin(user *models.User) (*protocol.CredentialAssertion, *webauthn.SessionData, error) {
+	creds, err := models.GetWebAuthnCredentialsByUID(user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var opts []webauthn.LoginOption
+	for _, cred := range creds {
+		if cred.IsLegacyU2F() && setting.U2F.AppID != "" {
+			// Security keys registered with U2F only sign for the App ID
+			opts = append(opts, webauthn.WithAssertionExtensions(protocol.AuthenticationExtensions{
+				"appid": setting.U2F.AppID,
+			}))
+			break
+		}
+	}
+	return WebAuthn.BeginLogin((*User)(user), opts...)
+}
+
+// FinishLogin verifies the assertion of the authenticator, updates the sign
+// counter of the credential and returns it.
+func FinishLogin(user *models.User, session webauthn.SessionData, body []byte) (*models.WebAuthnCredential, error) {
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	cred, err := models.GetWebAuthnCredentialByCredID(user.ID, parsed.RawID)
+	if err != nil {
+		return nil, err
+	}
+
+	rp := WebAuthn
+	appIDHash := sha256.Sum256([]byte(setting.U2F.AppID))
+	if cred.IsLegacyU2F() && bytes.Equal(parsed.Response.AuthenticatorData.RPIDHash, appIDHash[:]) {
+		config := *WebAuthn.Config
+		config.RPID = setting.U2F.AppID
+		rp = &webauthn.WebAuthn{Config: &config}
+	}
+
+	validated, err := rp.ValidateLogin((*User)(user), session, parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	cred.SignCount = validated.Authenticator.SignCount
+	cred.CloneWarning = validated.Authenticator.CloneWarning
+	if err := cred.UpdateSignCount(); err != nil {
+		return nil, err
+	}
+	if cred.CloneWarning {
+		return nil, ErrClonedCredential
+	}
+	return cred, nil
+}
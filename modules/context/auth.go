@@ -6,6 +6,8 @@
 package context
 
 import (
+	"strings"
+
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/log"
@@ -57,6 +59,15 @@ func Toggle(options *ToggleOptions) macaron.Handler {
 				// make sure that the form cannot be accessed by users who don't need this
 				ctx.Redirect(setting.AppSubURL + "/")
 				return
+			} else if !ctx.IsBasicAuth && !auth.IsAPIPath(ctx.Req.URL.Path) && !isTwoFactorSetUpPath(ctx.Req.URL.Path) {
+				// users who are required to use a second factor can only set one up or sign out until they have done so
+				if pending, err := models.IsTwoFactorSetUpPending(ctx.User.ID); err != nil {
+					ctx.ServerError("IsTwoFactorSetUpPending", err)
+					return
+				} else if pending {
+					ctx.Redirect(setting.AppSubURL + "/user/settings/security")
+					return
+				}
 			}
 		}
 
@@ -94,10 +105,24 @@ func Toggle(options *ToggleOptions) macaron.Handler {
 			if ctx.IsSigned && auth.IsAPIPath(ctx.Req.URL.Path) && ctx.IsBasicAuth {
 				twofa, err := models.GetTwoFactorByUID(ctx.User.ID)
 				if err != nil {
-					if models.IsErrTwoFactorNotEnrolled(err) {
-						return // No 2FA enrollment for this user
+					if !models.IsErrTwoFactorNotEnrolled(err) {
+						ctx.Error(500)
+						return
+					}
+					if _, isToken := ctx.Data["ApiToken"]; isToken {
+						return
+					}
+					// Security keys cannot be used here, users who have one must use a token
+					hasWebAuthn, err := models.ExistsWebAuthnCredentialsForUID(ctx.User.ID)
+					if err != nil {
+						ctx.Error(500)
+						return
+					}
+					if hasWebAuthn {
+						ctx.JSON(403, map[string]string{
+							"message": "Users with security keys must use an access token to call APIs.",
+						})
 					}
-					ctx.Error(500)
 					return
 				}
 				otpHeader := ctx.Req.Header.Get("X-Gitea-OTP")
@@ -132,3 +157,12 @@ func Toggle(options *ToggleOptions) macaron.Handler {
 		}
 	}
 }
+
+// isTwoFactorSetUpPath returns whether the path can be visited by users who still have to set
+// up a required second factor, i.e. the security settings where TOTP and WebAuthn are enrolled
+// and the sign out
+func isTwoFactorSetUpPath(path string) bool {
+	return path == "/user/logout" ||
+		path == "/user/settings/security" ||
+		strings.HasPrefix(path, "/user/settings/security/")
+}
@@ -0,0 +1,28 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTwoFactorSetUpPath(t *testing.T) {
+	var kases = map[string]bool{
+		"/user/logout":                                      true,
+		"/user/settings/security":                           true,
+		"/user/settings/security/two_factor/enroll":         true,
+		"/user/settings/security/webauthn/request_register": true,
+		"/user/settings/security/webauthn/register":         true,
+		"/user/settings/securityx":                          false,
+		"/user/settings":                                    false,
+		"/user2/repo1":                                      false,
+		"/":                                                 false,
+	}
+	for path, allowed := range kases {
+		assert.Equal(t, allowed, isTwoFactorSetUpPath(path), path)
+	}
+}
@@ -16,7 +16,9 @@ import (
 	"code.gitea.io/gitea/modules/cache"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/markup/markdown"
 	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
 
 	"gitea.com/macaron/macaron"
 	"github.com/editorconfig/editorconfig-core-go/v2"
@@ -172,6 +174,76 @@ func (r *Repository) GetEditorconfig() (*editorconfig.Editorconfig, error) {
 	return editorconfig.ParseBytes(data)
 }
 
+// IssueTemplateDirCandidates are the directories issue templates are read from,
+// only the first one containing templates is used
+var IssueTemplateDirCandidates = []string{
+	"ISSUE_TEMPLATE",
+	"issue_template",
+	".gitea/ISSUE_TEMPLATE",
+	".gitea/issue_template",
+	".github/ISSUE_TEMPLATE",
+	".github/issue_template",
+}
+
+// GetIssueTemplates returns the valid issue templates found in the
+// HEAD of the default repo branch.
+func (r *Repository) GetIssueTemplates() ([]api.IssueTemplate, error) {
+	templates := make([]api.IssueTemplate, 0, 5)
+	if r.GitRepo == nil || r.Repository.IsEmpty {
+		return templates, nil
+	}
+	commit, err := r.GitRepo.GetBranchCommit(r.Repository.DefaultBranch)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dirName := range IssueTemplateDirCandidates {
+		tree, err := commit.SubTree(dirName)
+		if err != nil {
+			// the directory does not exist or is not a directory
+			continue
+		}
+		entries, err := tree.ListEntries()
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.IsRegular() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".md") {
+				continue
+			}
+			if entry.Blob().Size() >= setting.UI.MaxDisplayFileSize {
+				log.Debug("Issue template is too large: %s/%s", dirName, entry.Name())
+				continue
+			}
+			reader, err := entry.Blob().DataAsync()
+			if err != nil {
+				return nil, err
+			}
+			data, err := ioutil.ReadAll(reader)
+			reader.Close()
+			if err != nil {
+				return nil, err
+			}
+
+			var it api.IssueTemplate
+			content, err := markdown.ExtractMetadata(string(data), &it)
+			if err != nil {
+				log.Debug("ExtractMetadata of issue template %s/%s: %v", dirName, entry.Name(), err)
+				continue
+			}
+			it.Content = content
+			it.FileName = entry.Name()
+			if it.Valid() {
+				templates = append(templates, it)
+			}
+		}
+		if len(templates) > 0 {
+			return templates, nil
+		}
+	}
+	return templates, nil
+}
+
 // RetrieveBaseRepo retrieves base repository
 func RetrieveBaseRepo(ctx *Context, repo *models.Repository) {
 	// Non-fork repository will not return error in this method.
@@ -771,5 +843,6 @@ func UnitTypes() macaron.Handler {
 		ctx.Data["UnitTypeWiki"] = models.UnitTypeWiki
 		ctx.Data["UnitTypeExternalWiki"] = models.UnitTypeExternalWiki
 		ctx.Data["UnitTypeExternalTracker"] = models.UnitTypeExternalTracker
+		ctx.Data["UnitTypeProjects"] = models.UnitTypeProjects
 	}
 }
@@ -69,6 +69,58 @@ func ToBranch(repo *models.Repository, b *git.Branch, c *git.Commit, bp *models.
 	return branch, nil
 }
 
+// ToBranchProtection convert a ProtectedBranch to api.BranchProtection
+func ToBranchProtection(bp *models.ProtectedBranch) *api.BranchProtection {
+	pushWhitelistUsernames, err := models.GetUserNamesByIDs(bp.WhitelistUserIDs)
+	if err != nil {
+		log.Error("GetUserNamesByIDs (WhitelistUserIDs): %v", err)
+	}
+	mergeWhitelistUsernames, err := models.GetUserNamesByIDs(bp.MergeWhitelistUserIDs)
+	if err != nil {
+		log.Error("GetUserNamesByIDs (MergeWhitelistUserIDs): %v", err)
+	}
+	approvalsWhitelistUsernames, err := models.GetUserNamesByIDs(bp.ApprovalsWhitelistUserIDs)
+	if err != nil {
+		log.Error("GetUserNamesByIDs (ApprovalsWhitelistUserIDs): %v", err)
+	}
+	pushWhitelistTeams, err := models.GetTeamNamesByID(bp.WhitelistTeamIDs)
+	if err != nil {
+		log.Error("GetTeamNamesByID (WhitelistTeamIDs): %v", err)
+	}
+	mergeWhitelistTeams, err := models.GetTeamNamesByID(bp.MergeWhitelistTeamIDs)
+	if err != nil {
+		log.Error("GetTeamNamesByID (MergeWhitelistTeamIDs): %v", err)
+	}
+	approvalsWhitelistTeams, err := models.GetTeamNamesByID(bp.ApprovalsWhitelistTeamIDs)
+	if err != nil {
+		log.Error("GetTeamNamesByID (ApprovalsWhitelistTeamIDs): %v", err)
+	}
+
+	return &api.BranchProtection{
+		BranchName:                  bp.BranchName,
+		EnablePush:                  bp.CanPush,
+		EnablePushWhitelist:         bp.EnableWhitelist,
+		PushWhitelistUsernames:      pushWhitelistUsernames,
+		PushWhitelistTeams:          pushWhitelistTeams,
+		PushWhitelistDeployKeys:     bp.WhitelistDeployKeys,
+		EnableMergeWhitelist:        bp.EnableMergeWhitelist,
+		MergeWhitelistUsernames:     mergeWhitelistUsernames,
+		MergeWhitelistTeams:         mergeWhitelistTeams,
+		EnableStatusCheck:           bp.EnableStatusCheck,
+		StatusCheckContexts:         bp.StatusCheckContexts,
+		RequiredApprovals:           bp.RequiredApprovals,
+		EnableApprovalsWhitelist:    bp.EnableApprovalsWhitelist,
+		ApprovalsWhitelistUsernames: approvalsWhitelistUsernames,
+		ApprovalsWhitelistTeams:     approvalsWhitelistTeams,
+		BlockOnRejectedReviews:      bp.BlockOnRejectedReviews,
+		RequireCodeOwnerApproval:    bp.RequireCodeOwnerApproval,
+		RequireSignedCommits:        bp.RequireSignedCommits,
+		ProtectedFilePatterns:       bp.ProtectedFilePatterns,
+		Created:                     bp.CreatedUnix.AsTime(),
+		Updated:                     bp.UpdatedUnix.AsTime(),
+	}
+}
+
 // ToTag convert a git.Tag to an api.Tag
 func ToTag(repo *models.Repository, t *git.Tag) *api.Tag {
 	return &api.Tag{
@@ -205,17 +257,60 @@ func ToHook(repoLink string, w *models.Webhook) *api.Hook {
 		config["icon_url"] = s.IconURL
 		config["color"] = s.Color
 	}
+	if w.HookTaskType == models.MATRIX {
+		// the access token is not returned
+		s := webhook.GetMatrixHook(w)
+		config["homeserver_url"] = s.HomeserverURL
+		config["room_id"] = s.Room
+		config["message_type"] = s.MessageType
+	}
 
 	return &api.Hook{
-		ID:      w.ID,
-		Type:    w.HookTaskType.Name(),
-		URL:     fmt.Sprintf("%s/settings/hooks/%d", repoLink, w.ID),
-		Active:  w.IsActive,
-		Config:  config,
-		Events:  w.EventsArray(),
-		Updated: w.UpdatedUnix.AsTime(),
-		Created: w.CreatedUnix.AsTime(),
+		ID:              w.ID,
+		Type:            w.HookTaskType.Name(),
+		URL:             fmt.Sprintf("%s/settings/hooks/%d", repoLink, w.ID),
+		Active:          w.IsActive,
+		Config:          config,
+		Events:          w.EventsArray(),
+		IsSystemWebhook: w.IsSystemWebhook,
+		Updated:         w.UpdatedUnix.AsTime(),
+		Created:         w.CreatedUnix.AsTime(),
+	}
+}
+
+// ToHookDelivery convert models.HookTask to api.HookDelivery
+func ToHookDelivery(t *models.HookTask) *api.HookDelivery {
+	delivery := &api.HookDelivery{
+		ID:          t.ID,
+		UUID:        t.UUID,
+		Event:       string(t.EventType),
+		URL:         t.URL,
+		IsDelivered: t.IsDelivered,
+		IsSucceed:   t.IsSucceed,
+		Attempts:    t.Attempts,
+		Request: &api.HookDeliveryRequest{
+			Headers: map[string]string{},
+			Payload: t.PayloadContent,
+		},
+	}
+	if t.Delivered > 0 {
+		delivered := time.Unix(0, t.Delivered)
+		delivery.Delivered = &delivered
+	}
+	if !t.IsDelivered {
+		delivery.NextAttempt = t.NextAttemptUnix.AsTimePtr()
+	}
+	if t.RequestInfo != nil {
+		delivery.Request.Headers = t.RequestInfo.Headers
 	}
+	if t.ResponseInfo != nil {
+		delivery.Response = &api.HookDeliveryResponse{
+			Status:  t.ResponseInfo.Status,
+			Headers: t.ResponseInfo.Headers,
+			Body:    t.ResponseInfo.Body,
+		}
+	}
+	return delivery
 }
 
 // ToGitHook convert git.Hook to api.GitHook
@@ -241,6 +336,26 @@ func ToDeployKey(apiLink string, key *models.DeployKey) *api.DeployKey {
 	}
 }
 
+// ToPushMirror convert models.PushMirror to api.PushMirror
+func ToPushMirror(m *models.PushMirror, remoteAddress string) *api.PushMirror {
+	var lastUpdate *time.Time
+	if m.LastUpdateUnix != 0 {
+		t := m.LastUpdateUnix.AsTime()
+		lastUpdate = &t
+	}
+	return &api.PushMirror{
+		ID:            m.ID,
+		RepoName:      m.Repo.Name,
+		RemoteName:    m.RemoteName,
+		RemoteAddress: remoteAddress,
+		Created:       m.CreatedUnix.AsTime(),
+		LastUpdate:    lastUpdate,
+		LastError:     m.LastError,
+		Interval:      m.Interval.String(),
+		SyncOnPush:    m.SyncOnPush,
+	}
+}
+
 // ToOrganization convert models.User to api.Organization
 func ToOrganization(org *models.User) *api.Organization {
 	return &api.Organization{
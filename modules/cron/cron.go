@@ -28,6 +28,7 @@ const (
 	syncExternalUsers       = "sync_external_users"
 	deletedBranchesCleanup  = "deleted_branches_cleanup"
 	updateMigrationPosterID = "update_migration_post_id"
+	cleanupHookTaskTable    = "cleanup_hook_task_table"
 )
 
 var c = cron.New()
@@ -123,6 +124,17 @@ func NewContext() {
 			go WithUnique(deletedBranchesCleanup, models.RemoveOldDeletedBranches)()
 		}
 	}
+	if setting.Cron.CleanupHookTaskTable.Enabled {
+		entry, err = c.AddFunc("Clean up hook_task table", setting.Cron.CleanupHookTaskTable.Schedule, WithUnique(cleanupHookTaskTable, models.CleanupHookTaskTable))
+		if err != nil {
+			log.Fatal("Cron[Clean up hook_task table]: %v", err)
+		}
+		if setting.Cron.CleanupHookTaskTable.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go WithUnique(cleanupHookTaskTable, models.CleanupHookTaskTable)()
+		}
+	}
 
 	entry, err = c.AddFunc("Update migrated repositories' issues and comments' posterid", setting.Cron.UpdateMigrationPosterID.Schedule, WithUnique(updateMigrationPosterID, migrations.UpdateMigrationPosterID))
 	if err != nil {
@@ -20,6 +20,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
@@ -98,6 +99,28 @@ func convertCommit(c *object.Commit) *Commit {
 	}
 }
 
+// CommitFromReader parses a raw commit object, e.g. the output of cat-file. This
+// allows to read commits which are not accessible through gitRepo yet, like
+// the quarantined objects of a push in the pre-receive hook.
+func CommitFromReader(gitRepo *Repository, sha SHA1, reader io.Reader) (*Commit, error) {
+	obj := &plumbing.MemoryObject{}
+	obj.SetType(plumbing.CommitObject)
+	if _, err := io.Copy(obj, reader); err != nil {
+		return nil, err
+	}
+
+	gogitCommit := &object.Commit{}
+	if err := gogitCommit.Decode(obj); err != nil {
+		return nil, err
+	}
+
+	commit := convertCommit(gogitCommit)
+	commit.ID = sha
+	commit.Tree.ID = gogitCommit.TreeHash
+	commit.repo = gitRepo
+	return commit, nil
+}
+
 // Message returns the commit message. Same as retrieving CommitMessage directly.
 func (c *Commit) Message() string {
 	return c.CommitMessage
@@ -6,6 +6,7 @@ package git
 
 import (
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -36,3 +37,32 @@ func TestGetFullCommitIDError(t *testing.T) {
 		assert.EqualError(t, err, "object does not exist [id: unknown, rel_path: ]")
 	}
 }
+
+func TestCommitFromReader(t *testing.T) {
+	commitString := `tree f1a6cb52b2d16773290cefe49ad0684b50a4f930
+parent 37991dec2c8e592043f47155ce4808d4580f9123
+author silverwind <me@silverwind.io> 1563741793 +0200
+committer silverwind <me@silverwind.io> 1563741793 +0200
+gpgsig -----BEGIN PGP SIGNATURE-----
+ 
+ iQIzBAABCAAdFiEEWPb2jX6FS2mqyJRQLmK0HJOGlEMFAl00ZmEACgkQLmK0HJOG
+ lEOm9A//SirK4EfZNDEgCVsUWMvTMDfEW8uLcnY5BLGYJZbmeGfAsOf9AR5HtW3N
+ =9D7m
+ -----END PGP SIGNATURE-----
+
+add signed commit
+`
+
+	sha := MustIDFromString("feaf4ba6bc635fec442f46ddd4512416ec43c2c2")
+	commit, err := CommitFromReader(nil, sha, strings.NewReader(commitString))
+	assert.NoError(t, err)
+	assert.Equal(t, sha, commit.ID)
+	assert.Equal(t, "f1a6cb52b2d16773290cefe49ad0684b50a4f930", commit.Tree.ID.String())
+	assert.Equal(t, "silverwind", commit.Committer.Name)
+	assert.Equal(t, "add signed commit\n", commit.CommitMessage)
+	if assert.NotNil(t, commit.Signature) {
+		assert.Contains(t, commit.Signature.Signature, "-----BEGIN PGP SIGNATURE-----")
+		assert.True(t, strings.HasPrefix(commit.Signature.Payload, "tree f1a6cb52b2d16773290cefe49ad0684b50a4f930\n"))
+		assert.NotContains(t, commit.Signature.Payload, "gpgsig")
+	}
+}
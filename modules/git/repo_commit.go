@@ -324,6 +324,15 @@ func (repo *Repository) FilesCountBetween(startCommitID, endCommitID string) (in
 	return len(strings.Split(stdout, "\n")) - 1, nil
 }
 
+// GetFilesChangedSinceMergeBase returns the names of the files changed on head since it diverged from base
+func (repo *Repository) GetFilesChangedSinceMergeBase(base, head string) ([]string, error) {
+	stdout, err := NewCommand("diff", "--name-only", "-z", base+"..."+head).RunInDirBytes(repo.Path)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(string(stdout), "\x00"), "\x00"), nil
+}
+
 // CommitsBetween returns a list that contains commits between [last, before).
 func (repo *Repository) CommitsBetween(last *Commit, before *Commit) (*list.List, error) {
 	var stdout []byte
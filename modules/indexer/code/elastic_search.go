@@ -0,0 +1,290 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/charset"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/indexer/elastic"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	esRepoIndexerLatestVersion = 1
+
+	// the highlight tags mark the matched parts of the content
+	esHighlightPreTag  = "<gitea-highlight>"
+	esHighlightPostTag = "</gitea-highlight>"
+)
+
+var (
+	_ Indexer = &ElasticSearchIndexer{}
+)
+
+// ElasticSearchIndexer implements Indexer interface
+type ElasticSearchIndexer struct {
+	client           *elastic.Client
+	indexerAliasName string
+}
+
+// NewElasticSearchIndexer creates a new elasticsearch indexer, the index name is
+// suffixed with the version of the mapping so that a changed mapping creates a new
+// index and indexerName becomes an alias of it. It returns true if the index had
+// to be created.
+func NewElasticSearchIndexer(url, indexerName string) (*ElasticSearchIndexer, bool, error) {
+	client, err := elastic.NewClient(url)
+	if err != nil {
+		return nil, false, err
+	}
+
+	indexer := &ElasticSearchIndexer{
+		client:           client,
+		indexerAliasName: indexerName,
+	}
+	created, err := indexer.init()
+	return indexer, created, err
+}
+
+const (
+	defaultMapping = `{
+		"mappings": {
+			"properties": {
+				"repo_id": {
+					"type": "long",
+					"index": true
+				},
+				"content": {
+					"type": "text",
+					"index": true
+				}
+			}
+		}
+	}`
+)
+
+// esRepoIndexerData is the document stored for a file
+type esRepoIndexerData struct {
+	RepoID  int64  `json:"repo_id"`
+	Content string `json:"content"`
+}
+
+func (b *ElasticSearchIndexer) realIndexerName() string {
+	return fmt.Sprintf("%s.v%d", b.indexerAliasName, esRepoIndexerLatestVersion)
+}
+
+// init will initialize the indexer, searches go through the alias which is
+// switched to the index of the latest version. A newly created index is empty
+// until the repositories are reindexed, which its creation triggers.
+func (b *ElasticSearchIndexer) init() (bool, error) {
+	exists, err := b.client.IndexExists(b.realIndexerName())
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		if err = b.client.CreateIndex(b.realIndexerName(), json.RawMessage(defaultMapping)); err != nil {
+			return false, fmt.Errorf("Unable to create index %s: %v", b.realIndexerName(), err)
+		}
+	}
+
+	if err = b.client.SwitchAlias(b.indexerAliasName, b.realIndexerName()); err != nil {
+		return false, fmt.Errorf("Unable to switch alias %s to %s: %v", b.indexerAliasName, b.realIndexerName(), err)
+	}
+	return !exists, nil
+}
+
+func (b *ElasticSearchIndexer) addUpdate(update fileUpdate, repo *models.Repository) ([]elastic.BulkRequest, error) {
+	stdout, err := git.NewCommand("cat-file", "-s", update.BlobSha).
+		RunInDir(repo.RepoPath())
+	if err != nil {
+		return nil, err
+	}
+	if size, err := strconv.Atoi(strings.TrimSpace(stdout)); err != nil {
+		return nil, fmt.Errorf("Misformatted git cat-file output: %v", err)
+	} else if int64(size) > setting.Indexer.MaxIndexerFileSize {
+		return b.addDelete(update.Filename, repo), nil
+	}
+
+	fileContents, err := git.NewCommand("cat-file", "blob", update.BlobSha).
+		RunInDirBytes(repo.RepoPath())
+	if err != nil {
+		return nil, err
+	} else if !base.IsTextFile(fileContents) {
+		// FIXME: UTF-16 files will probably fail here
+		return nil, nil
+	}
+
+	return []elastic.BulkRequest{
+		{
+			ID: filenameIndexerID(repo.ID, update.Filename),
+			Doc: &esRepoIndexerData{
+				RepoID:  repo.ID,
+				Content: string(charset.ToUTF8DropErrors(fileContents)),
+			},
+		},
+	}, nil
+}
+
+func (b *ElasticSearchIndexer) addDelete(filename string, repo *models.Repository) []elastic.BulkRequest {
+	return []elastic.BulkRequest{
+		{
+			Delete: true,
+			ID:     filenameIndexerID(repo.ID, filename),
+		},
+	}
+}
+
+// Index will save the index data
+func (b *ElasticSearchIndexer) Index(repoID int64) error {
+	repo, err := models.GetRepositoryByID(repoID)
+	if err != nil {
+		return err
+	}
+
+	sha, err := getDefaultBranchSha(repo)
+	if err != nil {
+		return err
+	}
+	changes, err := getRepoChanges(repo, sha)
+	if err != nil {
+		return err
+	} else if changes == nil {
+		return nil
+	}
+
+	reqs := make([]elastic.BulkRequest, 0, len(changes.Updates)+len(changes.RemovedFilenames))
+	for _, update := range changes.Updates {
+		updateReqs, err := b.addUpdate(update, repo)
+		if err != nil {
+			return err
+		}
+		reqs = append(reqs, updateReqs...)
+	}
+	for _, filename := range changes.RemovedFilenames {
+		reqs = append(reqs, b.addDelete(filename, repo)...)
+	}
+
+	for len(reqs) > 0 {
+		n := maxBatchSize
+		if n > len(reqs) {
+			n = len(reqs)
+		}
+		if err := b.client.Bulk(b.realIndexerName(), reqs[:n]...); err != nil {
+			return err
+		}
+		reqs = reqs[n:]
+	}
+	return repo.UpdateIndexerStatus(sha)
+}
+
+// Delete deletes indexes by ids
+func (b *ElasticSearchIndexer) Delete(repoID int64) error {
+	return b.client.DeleteByQuery(b.realIndexerName(), map[string]interface{}{
+		"term": map[string]interface{}{
+			"repo_id": repoID,
+		},
+	})
+}
+
+// highlightRange returns the range of the content between the first and the
+// last match of a highlighted content
+func highlightRange(highlighted string) (int, int) {
+	var startIndex, endIndex = -1, -1
+	var offset int
+	for {
+		i := strings.Index(highlighted, esHighlightPreTag)
+		if i < 0 {
+			break
+		}
+		offset += i
+		if startIndex < 0 {
+			startIndex = offset
+		}
+		highlighted = highlighted[i+len(esHighlightPreTag):]
+
+		j := strings.Index(highlighted, esHighlightPostTag)
+		if j < 0 {
+			break
+		}
+		offset += j
+		endIndex = offset
+		highlighted = highlighted[j+len(esHighlightPostTag):]
+	}
+	return startIndex, endIndex
+}
+
+// Search searches for codes and language stats by given conditions.
+func (b *ElasticSearchIndexer) Search(repoIDs []int64, keyword string, page, pageSize int) (int64, []*SearchResult, error) {
+	query := map[string]interface{}{
+		"match_phrase": map[string]interface{}{
+			"content": keyword,
+		},
+	}
+	if len(repoIDs) > 0 {
+		query = map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": query,
+				"filter": map[string]interface{}{
+					"terms": map[string]interface{}{
+						"repo_id": repoIDs,
+					},
+				},
+			},
+		}
+	}
+
+	result, err := b.client.Search(b.indexerAliasName, map[string]interface{}{
+		"query": query,
+		"highlight": map[string]interface{}{
+			"pre_tags":  []string{esHighlightPreTag},
+			"post_tags": []string{esHighlightPostTag},
+			"fields": map[string]interface{}{
+				// return the whole content so that the positions of the matches are known
+				"content": map[string]interface{}{
+					"number_of_fragments": 0,
+				},
+			},
+		},
+		"sort": []interface{}{
+			map[string]interface{}{"_score": map[string]string{"order": "desc"}},
+		},
+		"from": (page - 1) * pageSize,
+		"size": pageSize,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	searchResults := make([]*SearchResult, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		var data esRepoIndexerData
+		if err := json.Unmarshal(hit.Source, &data); err != nil {
+			return 0, nil, err
+		}
+
+		var startIndex, endIndex = -1, -1
+		if highlighted, ok := hit.Highlight["content"]; ok && len(highlighted) > 0 {
+			startIndex, endIndex = highlightRange(highlighted[0])
+		}
+
+		searchResults = append(searchResults, &SearchResult{
+			RepoID:     data.RepoID,
+			StartIndex: startIndex,
+			EndIndex:   endIndex,
+			Filename:   filenameOfIndexerID(hit.ID),
+			Content:    data.Content,
+		})
+	}
+	return result.Total, searchResults, nil
+}
+
+// Close implements indexer
+func (b *ElasticSearchIndexer) Close() {}
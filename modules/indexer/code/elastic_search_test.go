@@ -0,0 +1,27 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlightRange(t *testing.T) {
+	for _, kase := range []struct {
+		highlighted string
+		start, end  int
+	}{
+		{"no match", -1, -1},
+		{"<gitea-highlight>foo</gitea-highlight> bar", 0, 3},
+		{"a <gitea-highlight>foo</gitea-highlight> bar", 2, 5},
+		{"a <gitea-highlight>foo</gitea-highlight> <gitea-highlight>foo</gitea-highlight> b", 2, 9},
+	} {
+		start, end := highlightRange(kase.highlighted)
+		assert.Equal(t, kase.start, start, kase.highlighted)
+		assert.Equal(t, kase.end, end, kase.highlighted)
+	}
+}
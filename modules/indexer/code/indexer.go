@@ -51,18 +51,42 @@ func Init() {
 	waitChannel := make(chan time.Duration)
 	go func() {
 		start := time.Now()
-		log.Info("PID: %d Initializing Repository Indexer at: %s", os.Getpid(), setting.Indexer.RepoPath)
-		bleveIndexer, created, err := NewBleveIndexer(setting.Indexer.RepoPath)
-		if err != nil {
-			if bleveIndexer != nil {
-				bleveIndexer.Close()
+		var (
+			rIndexer Indexer
+			created  bool
+			err      error
+		)
+		switch setting.Indexer.RepoType {
+		case "bleve":
+			log.Info("PID: %d Initializing Repository Indexer at: %s", os.Getpid(), setting.Indexer.RepoPath)
+			var bleveIndexer *BleveIndexer
+			bleveIndexer, created, err = NewBleveIndexer(setting.Indexer.RepoPath)
+			if err != nil {
+				if bleveIndexer != nil {
+					bleveIndexer.Close()
+				}
+				cancel()
+				indexer.Close()
+				close(waitChannel)
+				log.Fatal("PID: %d Unable to initialize the Repository Indexer at path: %s Error: %v", os.Getpid(), setting.Indexer.RepoPath, err)
+			}
+			rIndexer = bleveIndexer
+		case "elasticsearch":
+			log.Info("PID: %d Initializing Repository Indexer at: %s", os.Getpid(), setting.Indexer.RepoIndexerName)
+			rIndexer, created, err = NewElasticSearchIndexer(setting.Indexer.RepoConnStr, setting.Indexer.RepoIndexerName)
+			if err != nil {
+				cancel()
+				indexer.Close()
+				close(waitChannel)
+				log.Fatal("PID: %d Unable to initialize the Elastic Search Repository Indexer: %s Error: %v", os.Getpid(), setting.Indexer.RepoIndexerName, err)
 			}
+		default:
 			cancel()
 			indexer.Close()
 			close(waitChannel)
-			log.Fatal("PID: %d Unable to initialize the Repository Indexer at path: %s Error: %v", os.Getpid(), setting.Indexer.RepoPath, err)
+			log.Fatal("PID: %d Unknown Repository Indexer type: %s", os.Getpid(), setting.Indexer.RepoType)
 		}
-		indexer.set(bleveIndexer)
+		indexer.set(rIndexer)
 
 		go processRepoIndexerOperationQueue(indexer)
 
@@ -0,0 +1,213 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"code.gitea.io/gitea/modules/indexer/elastic"
+	"code.gitea.io/gitea/modules/log"
+)
+
+const (
+	esIssueIndexerLatestVersion = 1
+)
+
+var (
+	_ Indexer = &ElasticSearchIndexer{}
+)
+
+// ElasticSearchIndexer implements Indexer interface
+type ElasticSearchIndexer struct {
+	client           *elastic.Client
+	indexerAliasName string
+}
+
+// NewElasticSearchIndexer creates a new elasticsearch indexer, the index name is
+// suffixed with the version of the mapping so that a changed mapping creates a new
+// index and indexerName becomes an alias of it.
+func NewElasticSearchIndexer(url, indexerName string) (*ElasticSearchIndexer, error) {
+	client, err := elastic.NewClient(url)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ElasticSearchIndexer{
+		client:           client,
+		indexerAliasName: indexerName,
+	}, nil
+}
+
+const (
+	defaultMapping = `{
+		"mappings": {
+			"properties": {
+				"id": {
+					"type": "long",
+					"index": true
+				},
+				"repo_id": {
+					"type": "long",
+					"index": true
+				},
+				"title": {
+					"type": "text",
+					"index": true
+				},
+				"content": {
+					"type": "text",
+					"index": true
+				},
+				"comments": {
+					"type" : "text",
+					"index": true
+				}
+			}
+		}
+	}`
+)
+
+// elasticSearchIssue is the document stored for an issue
+type elasticSearchIssue struct {
+	ID       int64    `json:"id"`
+	RepoID   int64    `json:"repo_id"`
+	Title    string   `json:"title"`
+	Content  string   `json:"content"`
+	Comments []string `json:"comments"`
+}
+
+func (b *ElasticSearchIndexer) realIndexerName() string {
+	return fmt.Sprintf("%s.v%d", b.indexerAliasName, esIssueIndexerLatestVersion)
+}
+
+// Init will initialize the indexer, searches go through the alias which is switched
+// to the index of the latest version. It returns true if that index already existed,
+// a new index is empty until the issues are populated.
+func (b *ElasticSearchIndexer) Init() (bool, error) {
+	// the index was named like the alias before it was versioned, it is replaced by the new one
+	isAlias, err := b.client.AliasExists(b.indexerAliasName)
+	if err != nil {
+		return false, err
+	}
+	if !isAlias {
+		unversioned, err := b.client.IndexExists(b.indexerAliasName)
+		if err != nil {
+			return false, err
+		}
+		if unversioned {
+			log.Warn("Deleting the issue index %s, it is replaced by %s", b.indexerAliasName, b.realIndexerName())
+			if err = b.client.DeleteIndex(b.indexerAliasName); err != nil {
+				return false, fmt.Errorf("Unable to delete index %s: %v", b.indexerAliasName, err)
+			}
+		}
+	}
+
+	exists, err := b.client.IndexExists(b.realIndexerName())
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		if err = b.client.CreateIndex(b.realIndexerName(), json.RawMessage(defaultMapping)); err != nil {
+			return false, fmt.Errorf("Unable to create index %s: %v", b.realIndexerName(), err)
+		}
+	}
+
+	if err = b.client.SwitchAlias(b.indexerAliasName, b.realIndexerName()); err != nil {
+		return false, fmt.Errorf("Unable to switch alias %s to %s: %v", b.indexerAliasName, b.realIndexerName(), err)
+	}
+	return exists, nil
+}
+
+// Index will save the index data
+func (b *ElasticSearchIndexer) Index(issues []*IndexerData) error {
+	if len(issues) == 0 {
+		return nil
+	}
+
+	reqs := make([]elastic.BulkRequest, 0, len(issues))
+	for _, issue := range issues {
+		reqs = append(reqs, elastic.BulkRequest{
+			ID: strconv.FormatInt(issue.ID, 10),
+			Doc: &elasticSearchIssue{
+				ID:       issue.ID,
+				RepoID:   issue.RepoID,
+				Title:    issue.Title,
+				Content:  issue.Content,
+				Comments: issue.Comments,
+			},
+		})
+	}
+	return b.client.Bulk(b.realIndexerName(), reqs...)
+}
+
+// Delete deletes indexes by ids
+func (b *ElasticSearchIndexer) Delete(ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	reqs := make([]elastic.BulkRequest, 0, len(ids))
+	for _, id := range ids {
+		reqs = append(reqs, elastic.BulkRequest{
+			Delete: true,
+			ID:     strconv.FormatInt(id, 10),
+		})
+	}
+	return b.client.Bulk(b.realIndexerName(), reqs...)
+}
+
+// Search searches for issues by given conditions.
+// Returns the matching issue IDs
+func (b *ElasticSearchIndexer) Search(keyword string, repoIDs []int64, limit, start int) (*SearchResult, error) {
+	result, err := b.client.Search(b.indexerAliasName, map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": map[string]interface{}{
+					"multi_match": map[string]interface{}{
+						"query":  keyword,
+						"fields": []string{"title", "content", "comments"},
+						"type":   "phrase_prefix",
+					},
+				},
+				"filter": map[string]interface{}{
+					"terms": map[string]interface{}{
+						"repo_id": repoIDs,
+					},
+				},
+			},
+		},
+		"sort": []interface{}{
+			map[string]interface{}{"_score": map[string]string{"order": "desc"}},
+		},
+		"from": start,
+		"size": limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]Match, 0, limit)
+	for _, hit := range result.Hits {
+		id, err := strconv.ParseInt(hit.ID, 10, 64)
+		if err != nil {
+			log.Error("Unexpected ID in issue indexer: %s", hit.ID)
+			continue
+		}
+		hits = append(hits, Match{
+			ID:    id,
+			Score: hit.Score,
+		})
+	}
+
+	return &SearchResult{
+		Total: result.Total,
+		Hits:  hits,
+	}, nil
+}
+
+// Close implements indexer
+func (b *ElasticSearchIndexer) Close() {}
@@ -0,0 +1,216 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newFakeElasticSearch serves the requests of the issue indexer from memory, searches
+// match the keyword as a substring of the title, the content or the comments
+func newFakeElasticSearch(t *testing.T, indices map[string]bool) (*httptest.Server, map[string]map[string]interface{}) {
+	var lock sync.Mutex
+	aliases := map[string]string{}
+	docs := map[string]map[string]interface{}{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		path := strings.Trim(r.URL.Path, "/")
+		switch {
+		case r.Method == http.MethodHead && strings.HasPrefix(path, "_alias/"):
+			if _, ok := aliases[strings.TrimPrefix(path, "_alias/")]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case r.Method == http.MethodGet && strings.HasPrefix(path, "_alias/"):
+			index, ok := aliases[strings.TrimPrefix(path, "_alias/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":"alias missing","status":404}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"` + index + `":{"aliases":{}}}`))
+		case r.Method == http.MethodHead:
+			if _, ok := aliases[path]; !ok && !indices[path] {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case r.Method == http.MethodPut:
+			indices[path] = true
+		case r.Method == http.MethodDelete:
+			delete(indices, path)
+		case r.Method == http.MethodPost && path == "_aliases":
+			var body struct {
+				Actions []map[string]map[string]string `json:"actions"`
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			for _, action := range body.Actions {
+				if add, ok := action["add"]; ok {
+					assert.True(t, indices[add["index"]], add["index"])
+					aliases[add["alias"]] = add["index"]
+				}
+			}
+		case r.Method == http.MethodPost && path == "_bulk":
+			scanner := bufio.NewScanner(r.Body)
+			for scanner.Scan() {
+				var action map[string]map[string]string
+				assert.NoError(t, json.Unmarshal(scanner.Bytes(), &action))
+				if meta, ok := action["delete"]; ok {
+					delete(docs, meta["_id"])
+					continue
+				}
+				meta := action["index"]
+				assert.True(t, indices[meta["_index"]], meta["_index"])
+				assert.True(t, scanner.Scan())
+				var doc map[string]interface{}
+				assert.NoError(t, json.Unmarshal(scanner.Bytes(), &doc))
+				docs[meta["_id"]] = doc
+			}
+			_, _ = w.Write([]byte(`{"errors":false,"items":[]}`))
+		case r.Method == http.MethodGet && strings.HasSuffix(path, "/_search"):
+			_, ok := aliases[strings.TrimSuffix(path, "/_search")]
+			assert.True(t, ok, "searches go through the alias")
+
+			var body struct {
+				Query struct {
+					Bool struct {
+						Must struct {
+							MultiMatch struct {
+								Query string `json:"query"`
+							} `json:"multi_match"`
+						} `json:"must"`
+						Filter struct {
+							Terms struct {
+								RepoID []int64 `json:"repo_id"`
+							} `json:"terms"`
+						} `json:"filter"`
+					} `json:"bool"`
+				} `json:"query"`
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			keyword := body.Query.Bool.Must.MultiMatch.Query
+			repoIDs := body.Query.Bool.Filter.Terms.RepoID
+
+			var ids []string
+			for id, doc := range docs {
+				inRepo := false
+				for _, repoID := range repoIDs {
+					inRepo = inRepo || doc["repo_id"].(float64) == float64(repoID)
+				}
+				data, _ := json.Marshal([]interface{}{doc["title"], doc["content"], doc["comments"]})
+				if inRepo && strings.Contains(string(data), keyword) {
+					ids = append(ids, id)
+				}
+			}
+			sort.Strings(ids)
+			hits := make([]map[string]interface{}, 0, len(ids))
+			for _, id := range ids {
+				hits = append(hits, map[string]interface{}{"_id": id, "_score": 1.0, "_source": docs[id]})
+			}
+			assert.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+				"hits": map[string]interface{}{
+					"total": map[string]interface{}{"value": len(ids), "relation": "eq"},
+					"hits":  hits,
+				},
+			}))
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	return server, docs
+}
+
+func TestElasticSearchIndexAndSearch(t *testing.T) {
+	indices := map[string]bool{}
+	server, docs := newFakeElasticSearch(t, indices)
+	defer server.Close()
+
+	indexer, err := NewElasticSearchIndexer(server.URL, "gitea_issues")
+	assert.NoError(t, err)
+	exists, err := indexer.Init()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Equal(t, map[string]bool{"gitea_issues.v1": true}, indices)
+
+	assert.NoError(t, indexer.Index([]*IndexerData{
+		{
+			ID:       1,
+			RepoID:   2,
+			Title:    "Issue search should support Chinese",
+			Content:  "As title",
+			Comments: []string{"test1", "test2"},
+		},
+		{
+			ID:      2,
+			RepoID:  2,
+			Title:   "CJK support could be optional",
+			Content: "Chinese should be supported",
+		},
+		{
+			ID:      3,
+			RepoID:  3,
+			Title:   "Chinese in another repository",
+			Content: "Not searched",
+		},
+	}))
+	assert.Len(t, docs, 3)
+	assert.EqualValues(t, map[string]interface{}{
+		"id":       1.0,
+		"repo_id":  2.0,
+		"title":    "Issue search should support Chinese",
+		"content":  "As title",
+		"comments": []interface{}{"test1", "test2"},
+	}, docs["1"])
+
+	searchIDs := func(keyword string, repoIDs ...int64) []int64 {
+		res, err := indexer.Search(keyword, repoIDs, 10, 0)
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(res.Hits), res.Total)
+		ids := make([]int64, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []int64{1, 2}, searchIDs("Chinese", 2))
+	assert.Equal(t, []int64{1, 2, 3}, searchIDs("Chinese", 2, 3))
+	assert.Equal(t, []int64{1}, searchIDs("test2", 2))
+	assert.Equal(t, []int64{}, searchIDs("Japanese", 2))
+
+	assert.NoError(t, indexer.Delete(1, 3))
+	assert.Len(t, docs, 1)
+	assert.Equal(t, []int64{2}, searchIDs("Chinese", 2, 3))
+
+	// the index of the current version is kept
+	exists, err = indexer.Init()
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Len(t, docs, 1)
+}
+
+func TestElasticSearchReplaceUnversionedIndex(t *testing.T) {
+	// older versions created the index under the name of the alias
+	indices := map[string]bool{"gitea_issues": true}
+	server, _ := newFakeElasticSearch(t, indices)
+	defer server.Close()
+
+	indexer, err := NewElasticSearchIndexer(server.URL, "gitea_issues")
+	assert.NoError(t, err)
+	exists, err := indexer.Init()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Equal(t, map[string]bool{"gitea_issues.v" + strconv.Itoa(esIssueIndexerLatestVersion): true}, indices)
+}
@@ -100,7 +100,7 @@ func InitIssueIndexer(syncReindex bool) {
 
 	// Create the Queue
 	switch setting.Indexer.IssueType {
-	case "bleve":
+	case "bleve", "elasticsearch":
 		handler := func(data ...queue.Data) {
 			indexer := holder.get()
 			if indexer == nil {
@@ -160,6 +160,20 @@ func InitIssueIndexer(syncReindex bool) {
 				log.Info("PID: %d Issue Indexer closed", os.Getpid())
 			})
 			log.Debug("Created Bleve Indexer")
+		case "elasticsearch":
+			issueIndexer, err := NewElasticSearchIndexer(setting.Indexer.IssueConnStr, setting.Indexer.IssueIndexerName)
+			if err != nil {
+				holder.cancel()
+				log.Fatal("Unable to initialize Elastic Search Issue Indexer: %v", err)
+			}
+			exist, err := issueIndexer.Init()
+			if err != nil {
+				holder.cancel()
+				log.Fatal("Unable to issueIndexer.Init: %v", err)
+			}
+			populate = !exist
+			holder.set(issueIndexer)
+			log.Debug("Created Elastic Search Indexer")
 		case "db":
 			issueIndexer := &DBIndexer{}
 			holder.set(issueIndexer)
@@ -16,6 +16,7 @@ import (
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 
 	"gitea.com/macaron/macaron"
 	"github.com/dgrijalva/jwt-go"
@@ -146,6 +147,21 @@ func getContentHandler(ctx *context.Context) {
 		return
 	}
 
+	if setting.LFSStorage.ServeDirect {
+		// If we have a signed url (S3, object storage), redirect to this directly.
+		// Range requests are passed on unchanged to the object storage
+		name := meta.Oid
+		if decodedFilename, err := base64.RawURLEncoding.DecodeString(ctx.Params("filename")); err == nil && len(decodedFilename) > 0 {
+			name = string(decodedFilename)
+		}
+		u, err := storage.LFS.URL(meta.RelativePath(), name)
+		if u != nil && err == nil {
+			ctx.Redirect(u.String())
+			logRequest(ctx.Req, http.StatusFound)
+			return
+		}
+	}
+
 	// Support resume download using Range header
 	var fromByte int64
 	statusCode := 200
@@ -159,7 +175,7 @@ func getContentHandler(ctx *context.Context) {
 		}
 	}
 
-	contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
+	contentStore := &ContentStore{ObjectStorage: storage.LFS}
 	content, err := contentStore.Get(meta, fromByte)
 	if err != nil {
 		writeStatus(ctx, 404)
@@ -242,7 +258,7 @@ func PostHandler(ctx *context.Context) {
 	ctx.Resp.Header().Set("Content-Type", metaMediaType)
 
 	sentStatus := 202
-	contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
+	contentStore := &ContentStore{ObjectStorage: storage.LFS}
 	if meta.Existing && contentStore.Exists(meta) {
 		sentStatus = 200
 	}
@@ -293,7 +309,7 @@ func BatchHandler(ctx *context.Context) {
 			return
 		}
 
-		contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
+		contentStore := &ContentStore{ObjectStorage: storage.LFS}
 
 		meta, err := repository.GetLFSMetaObjectByOid(object.Oid)
 		if err == nil && contentStore.Exists(meta) { // Object is found and exists
@@ -326,7 +342,7 @@ func PutHandler(ctx *context.Context) {
 		return
 	}
 
-	contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
+	contentStore := &ContentStore{ObjectStorage: storage.LFS}
 	bodyReader := ctx.Req.Body().ReadCloser()
 	defer bodyReader.Close()
 	if err := contentStore.Put(meta, bodyReader); err != nil {
@@ -360,7 +376,7 @@ func VerifyHandler(ctx *context.Context) {
 		return
 	}
 
-	contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
+	contentStore := &ContentStore{ObjectStorage: storage.LFS}
 	ok, err := contentStore.Verify(meta)
 	if err != nil {
 		ctx.Resp.WriteHeader(500)
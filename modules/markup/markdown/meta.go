@@ -0,0 +1,46 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"errors"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const separator = "---"
+
+// ExtractMetadata consumes a markdown file, parses YAML front matter into out
+// and returns the rest of the file
+func ExtractMetadata(contents string, out interface{}) (string, error) {
+	var front, body []string
+	var seenFirst, seenLast bool
+	lines := strings.Split(contents, "\n")
+	for i, line := range lines {
+		if strings.TrimRight(line, "\r") == separator {
+			if !seenFirst {
+				seenFirst = true
+				continue
+			}
+			seenLast = true
+			body = lines[i+1:]
+			break
+		}
+		if !seenFirst {
+			return "", errors.New("could not determine metadata")
+		}
+		front = append(front, line)
+	}
+
+	if !seenLast {
+		return "", errors.New("could not determine metadata")
+	}
+
+	if err := yaml.Unmarshal([]byte(strings.Join(front, "\n")), out); err != nil {
+		return "", err
+	}
+	return strings.Join(body, "\n"), nil
+}
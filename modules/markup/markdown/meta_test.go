@@ -0,0 +1,63 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package markdown
+
+import (
+	"fmt"
+	"testing"
+
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractMetadata(t *testing.T) {
+	t.Run("ValidFrontAndBody", func(t *testing.T) {
+		var meta api.IssueTemplate
+		body, err := ExtractMetadata(fmt.Sprintf("%s\n%s\n%s\n%s", separator, frontTpl, separator, bodyTpl), &meta)
+		assert.NoError(t, err)
+		assert.Equal(t, bodyTpl, body)
+		assert.Equal(t, "Bug Report", meta.Name)
+		assert.Equal(t, "[BUG] ", meta.Title)
+		assert.Equal(t, []string{"bug", "needs triage"}, meta.Labels)
+		assert.Equal(t, []string{"user2"}, meta.Assignees)
+		assert.True(t, meta.Valid())
+	})
+
+	t.Run("NoFirstSeparator", func(t *testing.T) {
+		var meta api.IssueTemplate
+		_, err := ExtractMetadata(fmt.Sprintf("%s\n%s\n%s", frontTpl, separator, bodyTpl), &meta)
+		assert.Error(t, err)
+	})
+
+	t.Run("NoLastSeparator", func(t *testing.T) {
+		var meta api.IssueTemplate
+		_, err := ExtractMetadata(fmt.Sprintf("%s\n%s\n%s", separator, frontTpl, bodyTpl), &meta)
+		assert.Error(t, err)
+	})
+
+	t.Run("NoBody", func(t *testing.T) {
+		var meta api.IssueTemplate
+		body, err := ExtractMetadata(fmt.Sprintf("%s\n%s\n%s", separator, frontTpl, separator), &meta)
+		assert.NoError(t, err)
+		assert.Equal(t, "", body)
+		assert.Equal(t, "Bug Report", meta.Name)
+	})
+}
+
+var (
+	frontTpl = `name: "Bug Report"
+about: "Report something that does not work"
+title: "[BUG] "
+labels:
+  - bug
+  - needs triage
+assignees:
+  - user2`
+
+	bodyTpl = `This is the body
+
+# More things here`
+)
@@ -0,0 +1,655 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations/base"
+	"code.gitea.io/gitea/modules/structs"
+)
+
+var (
+	_ base.Downloader        = &GitlabDownloader{}
+	_ base.DownloaderFactory = &GitlabDownloaderFactory{}
+)
+
+func init() {
+	RegisterDownloaderFactory(&GitlabDownloaderFactory{})
+}
+
+// GitlabDownloaderFactory defines a gitlab downloader factory
+type GitlabDownloaderFactory struct {
+}
+
+// Match returns ture if the migration remote URL matched this downloader factory
+func (f *GitlabDownloaderFactory) Match(opts base.MigrateOptions) (bool, error) {
+	u, err := url.Parse(opts.CloneAddr)
+	if err != nil {
+		return false, err
+	}
+
+	return opts.GitServiceType == structs.GitlabService || strings.EqualFold(u.Host, "gitlab.com"), nil
+}
+
+// New returns a Downloader related to this factory according MigrateOptions
+func (f *GitlabDownloaderFactory) New(opts base.MigrateOptions) (base.Downloader, error) {
+	u, err := url.Parse(opts.CloneAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := u.Scheme + "://" + u.Host
+	repoPath := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if !strings.Contains(repoPath, "/") {
+		return nil, fmt.Errorf("invalid gitlab project path: %s", repoPath)
+	}
+
+	log.Trace("Create gitlab downloader: %s/%s", baseURL, repoPath)
+
+	token := opts.AuthPassword
+	if token == "" {
+		token = opts.AuthUsername
+	}
+	return NewGitlabDownloader(baseURL, repoPath, token), nil
+}
+
+// GitServiceType returns the type of git service
+func (f *GitlabDownloaderFactory) GitServiceType() structs.GitServiceType {
+	return structs.GitlabService
+}
+
+// GitlabDownloader implements a Downloader interface to get repository informations
+// from gitlab via API v4
+type GitlabDownloader struct {
+	ctx       context.Context
+	client    *http.Client
+	baseURL   string
+	token     string
+	repoPath  string
+	repoOwner string
+	repoName  string
+
+	// gitlab numbers issues and merge requests independently, merge requests are
+	// numbered after the last issue to share the same index in gitea
+	maxIssueIndex int64
+	mergeRequests map[int64]int64
+	projects      map[int64]*gitlabProject
+}
+
+// NewGitlabDownloader creates a gitlab Downloader via gitlab API v4,
+// token is a personal access token and may be empty for public projects
+func NewGitlabDownloader(baseURL, repoPath, token string) *GitlabDownloader {
+	idx := strings.LastIndex(repoPath, "/")
+	return &GitlabDownloader{
+		ctx:           context.Background(),
+		client:        http.DefaultClient,
+		baseURL:       strings.TrimSuffix(baseURL, "/"),
+		token:         token,
+		repoPath:      repoPath,
+		repoOwner:     repoPath[:idx],
+		repoName:      repoPath[idx+1:],
+		mergeRequests: make(map[int64]int64),
+		projects:      make(map[int64]*gitlabProject),
+	}
+}
+
+// SetContext set context
+func (g *GitlabDownloader) SetContext(ctx context.Context) {
+	g.ctx = ctx
+}
+
+type gitlabUser struct {
+	ID          int64  `json:"id"`
+	Username    string `json:"username"`
+	PublicEmail string `json:"public_email"`
+}
+
+type gitlabProject struct {
+	ID            int64    `json:"id"`
+	Path          string   `json:"path"`
+	Description   string   `json:"description"`
+	Visibility    string   `json:"visibility"`
+	WebURL        string   `json:"web_url"`
+	HTTPURLToRepo string   `json:"http_url_to_repo"`
+	TagList       []string `json:"tag_list"`
+	Topics        []string `json:"topics"`
+	Namespace     struct {
+		FullPath string `json:"full_path"`
+	} `json:"namespace"`
+}
+
+type gitlabMilestone struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	State       string     `json:"state"`
+	DueDate     string     `json:"due_date"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+}
+
+type gitlabLabel struct {
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+}
+
+type gitlabRelease struct {
+	TagName     string     `json:"tag_name"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ReleasedAt  *time.Time `json:"released_at"`
+	Author      gitlabUser `json:"author"`
+	Commit      struct {
+		ID string `json:"id"`
+	} `json:"commit"`
+	Assets struct {
+		Links []struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"links"`
+	} `json:"assets"`
+}
+
+type gitlabIssue struct {
+	IID              int64            `json:"iid"`
+	Title            string           `json:"title"`
+	Description      string           `json:"description"`
+	State            string           `json:"state"`
+	Author           gitlabUser       `json:"author"`
+	Assignees        []gitlabUser     `json:"assignees"`
+	Labels           []string         `json:"labels"`
+	Milestone        *gitlabMilestone `json:"milestone"`
+	DiscussionLocked bool             `json:"discussion_locked"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+	ClosedAt         *time.Time       `json:"closed_at"`
+}
+
+type gitlabMergeRequest struct {
+	gitlabIssue
+	MergedAt        *time.Time `json:"merged_at"`
+	MergeCommitSHA  string     `json:"merge_commit_sha"`
+	SHA             string     `json:"sha"`
+	SourceBranch    string     `json:"source_branch"`
+	TargetBranch    string     `json:"target_branch"`
+	SourceProjectID int64      `json:"source_project_id"`
+	TargetProjectID int64      `json:"target_project_id"`
+	WebURL          string     `json:"web_url"`
+	DiffRefs        struct {
+		BaseSHA string `json:"base_sha"`
+		HeadSHA string `json:"head_sha"`
+	} `json:"diff_refs"`
+}
+
+type gitlabNote struct {
+	ID        int64      `json:"id"`
+	Body      string     `json:"body"`
+	Author    gitlabUser `json:"author"`
+	System    bool       `json:"system"`
+	Type      string     `json:"type"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Position  *struct {
+		OldPath string `json:"old_path"`
+		NewPath string `json:"new_path"`
+		OldLine int64  `json:"old_line"`
+		NewLine int64  `json:"new_line"`
+	} `json:"position"`
+}
+
+type gitlabAward struct {
+	Name string     `json:"name"`
+	User gitlabUser `json:"user"`
+}
+
+// get requests the API path and decodes the response into v,
+// it returns the next page announced by gitlab, 0 on the last page
+func (g *GitlabDownloader) get(path string, query url.Values, v interface{}) (int, error) {
+	u := g.baseURL + "/api/v4/" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(g.ctx)
+	if g.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status %q requesting %s", resp.Status, path)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return 0, fmt.Errorf("decode response of %s: %v", path, err)
+	}
+
+	nextPage, _ := strconv.Atoi(resp.Header.Get("X-Next-Page"))
+	return nextPage, nil
+}
+
+// projectPath returns the API path of the migrated project followed by sub
+func (g *GitlabDownloader) projectPath(sub string) string {
+	return "projects/" + url.PathEscape(g.repoPath) + sub
+}
+
+func listQuery(page, perPage int, values ...string) url.Values {
+	query := url.Values{
+		"page":     {strconv.Itoa(page)},
+		"per_page": {strconv.Itoa(perPage)},
+	}
+	for i := 0; i+1 < len(values); i += 2 {
+		query.Set(values[i], values[i+1])
+	}
+	return query
+}
+
+// GetRepoInfo returns a repository information
+func (g *GitlabDownloader) GetRepoInfo() (*base.Repository, error) {
+	var gp gitlabProject
+	if _, err := g.get(g.projectPath(""), nil, &gp); err != nil {
+		return nil, err
+	}
+
+	return &base.Repository{
+		Owner:       g.repoOwner,
+		Name:        gp.Path,
+		IsPrivate:   gp.Visibility != "public",
+		Description: gp.Description,
+		OriginalURL: gp.WebURL,
+		CloneURL:    gp.HTTPURLToRepo,
+	}, nil
+}
+
+// GetTopics return gitlab topics
+func (g *GitlabDownloader) GetTopics() ([]string, error) {
+	var gp gitlabProject
+	if _, err := g.get(g.projectPath(""), nil, &gp); err != nil {
+		return nil, err
+	}
+	if len(gp.Topics) > 0 {
+		return gp.Topics, nil
+	}
+	return gp.TagList, nil
+}
+
+// GetMilestones returns milestones
+func (g *GitlabDownloader) GetMilestones() ([]*base.Milestone, error) {
+	var perPage = 100
+	var milestones = make([]*base.Milestone, 0, perPage)
+	for page := 1; page > 0; {
+		var ms []*gitlabMilestone
+		nextPage, err := g.get(g.projectPath("/milestones"), listQuery(page, perPage), &ms)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range ms {
+			milestone := &base.Milestone{
+				Title:       m.Title,
+				Description: m.Description,
+				State:       "open",
+				Created:     m.CreatedAt,
+				Updated:     m.UpdatedAt,
+			}
+			if m.DueDate != "" {
+				if deadline, err := time.Parse("2006-01-02", m.DueDate); err == nil {
+					milestone.Deadline = &deadline
+				}
+			}
+			// gitlab does not record when a milestone has been closed
+			if m.State == "closed" {
+				milestone.State = "closed"
+				milestone.Closed = m.UpdatedAt
+			}
+			milestones = append(milestones, milestone)
+		}
+		page = nextPage
+	}
+	return milestones, nil
+}
+
+// GetLabels returns labels
+func (g *GitlabDownloader) GetLabels() ([]*base.Label, error) {
+	var perPage = 100
+	var labels = make([]*base.Label, 0, perPage)
+	for page := 1; page > 0; {
+		var ls []*gitlabLabel
+		nextPage, err := g.get(g.projectPath("/labels"), listQuery(page, perPage), &ls)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, label := range ls {
+			labels = append(labels, &base.Label{
+				Name:        label.Name,
+				Color:       strings.TrimPrefix(label.Color, "#"),
+				Description: label.Description,
+			})
+		}
+		page = nextPage
+	}
+	return labels, nil
+}
+
+func convertGitlabRelease(rel *gitlabRelease) *base.Release {
+	r := &base.Release{
+		TagName:         rel.TagName,
+		TargetCommitish: rel.Commit.ID,
+		Name:            rel.Name,
+		Body:            rel.Description,
+		Created:         rel.CreatedAt,
+		Published:       rel.CreatedAt,
+		PublisherID:     rel.Author.ID,
+		PublisherName:   rel.Author.Username,
+		PublisherEmail:  rel.Author.PublicEmail,
+	}
+	if rel.ReleasedAt != nil {
+		r.Published = *rel.ReleasedAt
+	}
+
+	for _, link := range rel.Assets.Links {
+		var size, downloadCount int
+		r.Assets = append(r.Assets, base.ReleaseAsset{
+			URL:           link.URL,
+			Name:          link.Name,
+			Size:          &size,
+			DownloadCount: &downloadCount,
+			Created:       rel.CreatedAt,
+			Updated:       rel.CreatedAt,
+		})
+	}
+	return r
+}
+
+// GetReleases returns releases
+func (g *GitlabDownloader) GetReleases() ([]*base.Release, error) {
+	var perPage = 100
+	var releases = make([]*base.Release, 0, perPage)
+	for page := 1; page > 0; {
+		var rels []*gitlabRelease
+		nextPage, err := g.get(g.projectPath("/releases"), listQuery(page, perPage), &rels)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, release := range rels {
+			releases = append(releases, convertGitlabRelease(release))
+		}
+		page = nextPage
+	}
+	return releases, nil
+}
+
+// getReactions returns the award emojis of the API path as reactions
+func (g *GitlabDownloader) getReactions(path string) (*base.Reactions, error) {
+	var reactions *base.Reactions
+	for page := 1; page > 0; {
+		var awards []*gitlabAward
+		nextPage, err := g.get(path, listQuery(page, 100), &awards)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, award := range awards {
+			if reactions == nil {
+				reactions = &base.Reactions{}
+			}
+			reactions.TotalCount++
+			switch award.Name {
+			case "thumbsup":
+				reactions.PlusOne++
+			case "thumbsdown":
+				reactions.MinusOne++
+			case "laughing":
+				reactions.Laugh++
+			case "confused":
+				reactions.Confused++
+			case "heart":
+				reactions.Heart++
+			case "tada":
+				reactions.Hooray++
+			}
+		}
+		page = nextPage
+	}
+	return reactions, nil
+}
+
+func convertGitlabLabels(names []string) []*base.Label {
+	var labels = make([]*base.Label, 0, len(names))
+	for _, name := range names {
+		labels = append(labels, &base.Label{Name: name})
+	}
+	return labels
+}
+
+func convertGitlabState(state string) string {
+	if state == "opened" || state == "locked" {
+		return "open"
+	}
+	return "closed"
+}
+
+// GetIssues returns issues according start and limit
+func (g *GitlabDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	var issues []*gitlabIssue
+	nextPage, err := g.get(g.projectPath("/issues"), listQuery(page, perPage,
+		"scope", "all",
+		"state", "all",
+		"order_by", "created_at",
+		"sort", "asc",
+	), &issues)
+	if err != nil {
+		return nil, false, fmt.Errorf("error while listing issues: %v", err)
+	}
+
+	var allIssues = make([]*base.Issue, 0, len(issues))
+	for _, issue := range issues {
+		var milestone string
+		if issue.Milestone != nil {
+			milestone = issue.Milestone.Title
+		}
+
+		reactions, err := g.getReactions(g.projectPath(fmt.Sprintf("/issues/%d/award_emoji", issue.IID)))
+		if err != nil {
+			return nil, false, fmt.Errorf("error while listing issue reactions: %v", err)
+		}
+
+		allIssues = append(allIssues, &base.Issue{
+			Title:       issue.Title,
+			Number:      issue.IID,
+			PosterID:    issue.Author.ID,
+			PosterName:  issue.Author.Username,
+			PosterEmail: issue.Author.PublicEmail,
+			Content:     issue.Description,
+			Milestone:   milestone,
+			State:       convertGitlabState(issue.State),
+			Created:     issue.CreatedAt,
+			Updated:     issue.UpdatedAt,
+			Labels:      convertGitlabLabels(issue.Labels),
+			Reactions:   reactions,
+			Closed:      issue.ClosedAt,
+			IsLocked:    issue.DiscussionLocked,
+		})
+
+		if issue.IID > g.maxIssueIndex {
+			g.maxIssueIndex = issue.IID
+		}
+	}
+
+	return allIssues, nextPage == 0, nil
+}
+
+// GetComments returns comments according issueNumber, which may be
+// the number of an issue or of a merge request
+func (g *GitlabDownloader) GetComments(issueNumber int64) ([]*base.Comment, error) {
+	notesPath := g.projectPath(fmt.Sprintf("/issues/%d/notes", issueNumber))
+	if iid, ok := g.mergeRequests[issueNumber]; ok {
+		notesPath = g.projectPath(fmt.Sprintf("/merge_requests/%d/notes", iid))
+	}
+
+	var allComments = make([]*base.Comment, 0, 100)
+	for page := 1; page > 0; {
+		var notes []*gitlabNote
+		nextPage, err := g.get(notesPath, listQuery(page, 100,
+			"order_by", "created_at",
+			"sort", "asc",
+		), &notes)
+		if err != nil {
+			return nil, fmt.Errorf("error while listing comments: %v", err)
+		}
+
+		for _, note := range notes {
+			// system notes record events like label or milestone changes
+			if note.System {
+				continue
+			}
+
+			reactions, err := g.getReactions(fmt.Sprintf("%s/%d/award_emoji", notesPath, note.ID))
+			if err != nil {
+				return nil, fmt.Errorf("error while listing comment reactions: %v", err)
+			}
+
+			content := note.Body
+			if note.Type == "DiffNote" && note.Position != nil {
+				// review comments keep a reference to the commented line
+				treePath, line := note.Position.NewPath, note.Position.NewLine
+				if line == 0 {
+					treePath, line = note.Position.OldPath, note.Position.OldLine
+				}
+				content = fmt.Sprintf("`%s` line %d:\n\n%s", treePath, line, note.Body)
+			}
+
+			allComments = append(allComments, &base.Comment{
+				IssueIndex:  issueNumber,
+				PosterID:    note.Author.ID,
+				PosterName:  note.Author.Username,
+				PosterEmail: note.Author.PublicEmail,
+				Content:     content,
+				Created:     note.CreatedAt,
+				Updated:     note.UpdatedAt,
+				Reactions:   reactions,
+			})
+		}
+		page = nextPage
+	}
+	return allComments, nil
+}
+
+// getProject returns the project with the id, projects are cached
+func (g *GitlabDownloader) getProject(id int64) (*gitlabProject, error) {
+	if gp, ok := g.projects[id]; ok {
+		return gp, nil
+	}
+	var gp gitlabProject
+	if _, err := g.get(fmt.Sprintf("projects/%d", id), nil, &gp); err != nil {
+		return nil, err
+	}
+	g.projects[id] = &gp
+	return &gp, nil
+}
+
+// GetPullRequests returns merge requests according page and perPage
+func (g *GitlabDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, error) {
+	var mrs []*gitlabMergeRequest
+	_, err := g.get(g.projectPath("/merge_requests"), listQuery(page, perPage,
+		"state", "all",
+		"order_by", "created_at",
+		"sort", "asc",
+	), &mrs)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing merge requests: %v", err)
+	}
+
+	var allPRs = make([]*base.PullRequest, 0, len(mrs))
+	for _, mr := range mrs {
+		var milestone string
+		if mr.Milestone != nil {
+			milestone = mr.Milestone.Title
+		}
+
+		assignees := make([]string, 0, len(mr.Assignees))
+		for _, assignee := range mr.Assignees {
+			assignees = append(assignees, assignee.Username)
+		}
+
+		head := base.PullRequestBranch{
+			Ref:       mr.SourceBranch,
+			SHA:       mr.DiffRefs.HeadSHA,
+			OwnerName: g.repoOwner,
+			RepoName:  g.repoName,
+		}
+		if head.SHA == "" {
+			head.SHA = mr.SHA
+		}
+		if mr.SourceProjectID != mr.TargetProjectID {
+			gp, err := g.getProject(mr.SourceProjectID)
+			if err != nil {
+				// the fork may be private or deleted
+				log.Warn("Get source project %d of merge request !%d: %v", mr.SourceProjectID, mr.IID, err)
+				head.OwnerName = mr.Author.Username
+			} else {
+				head.OwnerName = gp.Namespace.FullPath
+				head.RepoName = gp.Path
+				head.CloneURL = gp.HTTPURLToRepo
+			}
+		}
+
+		closed := mr.ClosedAt
+		if closed == nil {
+			closed = mr.MergedAt
+		}
+
+		number := g.maxIssueIndex + mr.IID
+		g.mergeRequests[number] = mr.IID
+
+		allPRs = append(allPRs, &base.PullRequest{
+			Title:          mr.Title,
+			Number:         number,
+			PosterName:     mr.Author.Username,
+			PosterID:       mr.Author.ID,
+			PosterEmail:    mr.Author.PublicEmail,
+			Content:        mr.Description,
+			Milestone:      milestone,
+			State:          convertGitlabState(mr.State),
+			Created:        mr.CreatedAt,
+			Updated:        mr.UpdatedAt,
+			Closed:         closed,
+			Labels:         convertGitlabLabels(mr.Labels),
+			Merged:         mr.State == "merged",
+			MergeCommitSHA: mr.MergeCommitSHA,
+			MergedTime:     mr.MergedAt,
+			IsLocked:       mr.DiscussionLocked,
+			Assignees:      assignees,
+			Head:           head,
+			Base: base.PullRequestBranch{
+				Ref:       mr.TargetBranch,
+				SHA:       mr.DiffRefs.BaseSHA,
+				OwnerName: g.repoOwner,
+				RepoName:  g.repoName,
+			},
+			PatchURL: mr.WebURL + ".patch",
+		})
+	}
+
+	return allPRs, nil
+}
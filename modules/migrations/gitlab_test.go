@@ -0,0 +1,270 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/migrations/base"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newGitlabTestServer serves the API responses recorded in testdata/gitlab,
+// the file of a request is named after its path relative to the project
+func newGitlabTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+
+		rel := strings.TrimPrefix(r.URL.Path, "/api/v4/projects/")
+		if strings.HasPrefix(rel, "gitea/test_repo") {
+			rel = strings.TrimPrefix(strings.TrimPrefix(rel, "gitea/test_repo"), "/")
+			if rel == "" {
+				rel = "project"
+			}
+		} else {
+			rel = "project_" + rel
+		}
+		name := strings.Replace(rel, "/", "_", -1)
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page > 1 {
+			name += "_page" + strconv.Itoa(page)
+		}
+		if _, err := os.Stat(filepath.Join("testdata", "gitlab", strings.Replace(rel, "/", "_", -1)+"_page"+strconv.Itoa(page+1)+".json")); err == nil {
+			w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join("testdata", "gitlab", name+".json"))
+		if err != nil {
+			if !strings.HasSuffix(rel, "award_emoji") {
+				http.NotFound(w, r)
+				return
+			}
+			data = []byte("[]")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+}
+
+func TestGitlabDownloadRepo(t *testing.T) {
+	server := newGitlabTestServer(t)
+	defer server.Close()
+
+	downloader := NewGitlabDownloader(server.URL, "gitea/test_repo", "secret")
+
+	repo, err := downloader.GetRepoInfo()
+	assert.NoError(t, err)
+	assert.EqualValues(t, &base.Repository{
+		Name:        "test_repo",
+		Owner:       "gitea",
+		Description: "Test repository for testing migration from gitlab to gitea",
+		CloneURL:    "https://gitlab.com/gitea/test_repo.git",
+		OriginalURL: "https://gitlab.com/gitea/test_repo",
+	}, repo)
+
+	topics, err := downloader.GetTopics()
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"migration", "test"}, topics)
+
+	milestones, err := downloader.GetMilestones()
+	assert.NoError(t, err)
+	if assert.Len(t, milestones, 2) {
+		for _, milestone := range milestones {
+			switch milestone.Title {
+			case "1.0.0":
+				assertMilestoneEqual(t, "", "1.0.0", "2019-11-30 00:00:00 +0000 UTC",
+					"2019-11-28 08:42:30.301 +0000 UTC",
+					"2019-11-28 15:57:52.401 +0000 UTC",
+					"2019-11-28 15:57:52.401 +0000 UTC",
+					"closed", milestone)
+			case "1.1.0":
+				assertMilestoneEqual(t, "", "1.1.0", "",
+					"2019-11-28 08:42:44.575 +0000 UTC",
+					"2019-11-28 08:42:44.575 +0000 UTC",
+					"",
+					"open", milestone)
+			}
+		}
+	}
+
+	labels, err := downloader.GetLabels()
+	assert.NoError(t, err)
+	if assert.Len(t, labels, 3) {
+		assertLabelEqual(t, "bug", "d9534f", "", labels[0])
+		assertLabelEqual(t, "confirmed", "d9534f", "Issue is confirmed", labels[1])
+		assertLabelEqual(t, "discussion", "428bca", "", labels[2])
+	}
+
+	releases, err := downloader.GetReleases()
+	assert.NoError(t, err)
+	var zero int
+	assert.EqualValues(t, []*base.Release{
+		{
+			TagName:         "v0.9.99",
+			TargetCommitish: "0720a3ec57c1f843568298117b874319e7deee75",
+			Name:            "First Release",
+			Body:            "A test release",
+			Created:         time.Date(2019, 11, 28, 9, 9, 48, 840000000, time.UTC),
+			Published:       time.Date(2019, 11, 28, 9, 9, 48, 836000000, time.UTC),
+			PublisherID:     1241334,
+			PublisherName:   "lafriks",
+			Assets: []base.ReleaseAsset{
+				{
+					URL:           "https://example.com/binary",
+					Name:          "binary",
+					Size:          &zero,
+					DownloadCount: &zero,
+					Created:       time.Date(2019, 11, 28, 9, 9, 48, 840000000, time.UTC),
+					Updated:       time.Date(2019, 11, 28, 9, 9, 48, 840000000, time.UTC),
+				},
+			},
+		},
+	}, releases)
+
+	issues, isEnd, err := downloader.GetIssues(1, 2)
+	assert.NoError(t, err)
+	assert.True(t, isEnd)
+	closed1 := time.Date(2019, 11, 28, 8, 46, 23, 275000000, time.UTC)
+	assert.EqualValues(t, []*base.Issue{
+		{
+			Number:     1,
+			Title:      "Please add an animated gif icon to the merge button",
+			Content:    "I just want the merge button to hurt my eyes a little. :stuck_out_tongue_closed_eyes:",
+			Milestone:  "1.0.0",
+			PosterID:   1241334,
+			PosterName: "lafriks",
+			State:      "closed",
+			Created:    time.Date(2019, 11, 28, 8, 43, 35, 459000000, time.UTC),
+			Updated:    time.Date(2019, 11, 28, 8, 46, 23, 304000000, time.UTC),
+			Labels: []*base.Label{
+				{Name: "bug"},
+				{Name: "discussion"},
+			},
+			Reactions: &base.Reactions{
+				TotalCount: 2,
+				PlusOne:    1,
+			},
+			Closed: &closed1,
+		},
+		{
+			Number:     3,
+			Title:      "Test issue",
+			Content:    "This is test issue 3, do not touch!",
+			PosterID:   1241334,
+			PosterName: "lafriks",
+			State:      "open",
+			Created:    time.Date(2019, 11, 28, 8, 44, 46, 277000000, time.UTC),
+			Updated:    time.Date(2019, 11, 28, 8, 45, 44, 987000000, time.UTC),
+			Labels: []*base.Label{
+				{Name: "confirmed"},
+			},
+			IsLocked: true,
+		},
+	}, issues)
+
+	// system notes are skipped
+	comments, err := downloader.GetComments(1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*base.Comment{
+		{
+			IssueIndex: 1,
+			PosterID:   1241334,
+			PosterName: "lafriks",
+			Created:    time.Date(2019, 11, 28, 8, 44, 52, 501000000, time.UTC),
+			Updated:    time.Date(2019, 11, 28, 8, 44, 52, 501000000, time.UTC),
+			Content:    "This is a comment",
+			Reactions: &base.Reactions{
+				TotalCount: 1,
+				Hooray:     1,
+			},
+		},
+	}, comments)
+
+	// merge requests are numbered after the last issue
+	prs, err := downloader.GetPullRequests(1, 2)
+	assert.NoError(t, err)
+	merged1 := time.Date(2019, 11, 28, 16, 2, 8, 392000000, time.UTC)
+	assert.EqualValues(t, []*base.PullRequest{
+		{
+			Number:     4,
+			Title:      "Update README.md",
+			Content:    "add new line to README.md",
+			Milestone:  "1.1.0",
+			PosterID:   527793,
+			PosterName: "lafriks",
+			State:      "closed",
+			Created:    time.Date(2019, 11, 28, 8, 54, 41, 34000000, time.UTC),
+			Updated:    time.Date(2019, 11, 28, 16, 2, 8, 377000000, time.UTC),
+			Closed:     &merged1,
+			Labels: []*base.Label{
+				{Name: "bug"},
+			},
+			PatchURL: "https://gitlab.com/gitea/test_repo/merge_requests/1.patch",
+			Head: base.PullRequestBranch{
+				Ref:       "feat/test",
+				SHA:       "9f733b96b98a4175276edf6a2e1231489c3bdd23",
+				RepoName:  "test_repo",
+				OwnerName: "gitea",
+			},
+			Base: base.PullRequestBranch{
+				Ref:       "master",
+				SHA:       "c59c9b451acca9d106cc19d61d87afe3fbbb8b83",
+				RepoName:  "test_repo",
+				OwnerName: "gitea",
+			},
+			Merged:         true,
+			MergedTime:     &merged1,
+			MergeCommitSHA: "f58ea8a8ea3ddabb44e3c5c4f1d7fa4a2e1ef0d7",
+			Assignees:      []string{"lafriks"},
+		},
+		{
+			Number:     5,
+			Title:      "Test branch",
+			Content:    "do not merge this PR",
+			PosterID:   1241334,
+			PosterName: "lafriks",
+			State:      "open",
+			Created:    time.Date(2019, 11, 28, 15, 56, 54, 104000000, time.UTC),
+			Updated:    time.Date(2019, 11, 28, 15, 56, 54, 104000000, time.UTC),
+			Labels:     []*base.Label{},
+			PatchURL:   "https://gitlab.com/gitea/test_repo/merge_requests/2.patch",
+			Head: base.PullRequestBranch{
+				Ref:       "feat/fork",
+				SHA:       "6a7d1a6b6d0bcec2cc5a1e6ebcb2bba2e2d7cc16",
+				RepoName:  "test_repo",
+				OwnerName: "lafriks",
+				CloneURL:  "https://gitlab.com/lafriks/test_repo.git",
+			},
+			Base: base.PullRequestBranch{
+				Ref:       "master",
+				SHA:       "c59c9b451acca9d106cc19d61d87afe3fbbb8b83",
+				RepoName:  "test_repo",
+				OwnerName: "gitea",
+			},
+			Assignees: []string{},
+		},
+	}, prs)
+	assert.True(t, prs[1].IsForkPullRequest())
+
+	// review comments keep a reference to the commented line
+	comments, err = downloader.GetComments(4)
+	assert.NoError(t, err)
+	if assert.Len(t, comments, 2) {
+		assert.EqualValues(t, 4, comments[0].IssueIndex)
+		assert.Equal(t, "`README.md` line 3:\n\nShould this line end with a period?", comments[0].Content)
+		assert.Equal(t, "Looks good", comments[1].Content)
+		assert.Nil(t, comments[1].Reactions)
+	}
+}
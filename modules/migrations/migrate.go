@@ -37,6 +37,10 @@ func MigrateRepository(ctx context.Context, doer *models.User, ownerName string,
 	)
 
 	for _, factory := range factories {
+		// a git service chosen explicitly only matches its own downloader
+		if opts.GitServiceType != structs.NotMigrated && opts.GitServiceType != factory.GitServiceType() {
+			continue
+		}
 		if match, err := factory.Match(opts); err != nil {
 			return nil, err
 		} else if match {
@@ -42,6 +42,10 @@ func (*NullNotifier) NotifyNewPullRequest(pr *models.PullRequest) {
 func (*NullNotifier) NotifyPullRequestReview(pr *models.PullRequest, r *models.Review, comment *models.Comment) {
 }
 
+// NotifyPullReviewRequest places a place holder function
+func (*NullNotifier) NotifyPullReviewRequest(doer *models.User, issue *models.Issue, reviewer *models.User, reviewerTeam *models.Team, isRequest bool, comment *models.Comment) {
+}
+
 // NotifyMergePullRequest places a place holder function
 func (*NullNotifier) NotifyMergePullRequest(pr *models.PullRequest, doer *models.User, baseRepo *git.Repository) {
 }
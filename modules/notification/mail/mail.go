@@ -101,6 +101,33 @@ func (m *mailNotifier) NotifyIssueChangeAssignee(doer *models.User, issue *model
 	}
 }
 
+func (m *mailNotifier) NotifyPullReviewRequest(doer *models.User, issue *models.Issue, reviewer *models.User, reviewerTeam *models.Team, isRequest bool, comment *models.Comment) {
+	// mail only sent to requested reviewers and not on removed requests
+	if !isRequest {
+		return
+	}
+
+	reviewers := []*models.User{reviewer}
+	if reviewerTeam != nil {
+		if err := reviewerTeam.GetMembers(); err != nil {
+			log.Error("GetMembers: %v", err)
+			return
+		}
+		reviewers = reviewerTeam.Members
+	}
+
+	tos := make([]string, 0, len(reviewers))
+	for _, u := range reviewers {
+		if u.ID != doer.ID && u.EmailNotifications() == models.EmailNotificationsEnabled {
+			tos = append(tos, u.Email)
+		}
+	}
+	if len(tos) > 0 {
+		ct := fmt.Sprintf("Requested to review #%d.", issue.Index)
+		mailer.SendIssueReviewRequestMail(issue, doer, ct, comment, tos)
+	}
+}
+
 func (m *mailNotifier) NotifyMergePullRequest(pr *models.PullRequest, doer *models.User, baseRepo *git.Repository) {
 	if err := pr.LoadIssue(); err != nil {
 		log.Error("pr.LoadIssue: %v", err)
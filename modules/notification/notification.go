@@ -87,6 +87,13 @@ func NotifyPullRequestReview(pr *models.PullRequest, review *models.Review, comm
 	}
 }
 
+// NotifyPullReviewRequest notifies when a review of a user or a team was requested or the request was removed
+func NotifyPullReviewRequest(doer *models.User, issue *models.Issue, reviewer *models.User, reviewerTeam *models.Team, isRequest bool, comment *models.Comment) {
+	for _, notifier := range notifiers {
+		notifier.NotifyPullReviewRequest(doer, issue, reviewer, reviewerTeam, isRequest, comment)
+	}
+}
+
 // NotifyPullRequestChangeTargetBranch notifies when a pull request's target branch was changed
 func NotifyPullRequestChangeTargetBranch(doer *models.User, pr *models.PullRequest, oldBranch string) {
 	for _, notifier := range notifiers {
@@ -21,6 +21,7 @@ type (
 		issueID              int64
 		commentID            int64
 		notificationAuthorID int64
+		receiverID           int64 // 0 -- ALL Watcher
 	}
 )
 
@@ -37,7 +38,7 @@ func NewNotifier() base.Notifier {
 
 func (ns *notificationService) Run() {
 	for opts := range ns.issueQueue {
-		if err := models.CreateOrUpdateIssueNotifications(opts.issueID, opts.commentID, opts.notificationAuthorID); err != nil {
+		if err := models.CreateOrUpdateIssueNotifications(opts.issueID, opts.commentID, opts.notificationAuthorID, opts.receiverID); err != nil {
 			log.Error("Was unable to create issue notification: %v", err)
 		}
 	}
@@ -93,3 +94,30 @@ func (ns *notificationService) NotifyPullRequestReview(pr *models.PullRequest, r
 	}
 	ns.issueQueue <- opts
 }
+
+func (ns *notificationService) NotifyPullReviewRequest(doer *models.User, issue *models.Issue, reviewer *models.User, reviewerTeam *models.Team, isRequest bool, comment *models.Comment) {
+	if !isRequest {
+		return
+	}
+
+	reviewers := []*models.User{reviewer}
+	if reviewerTeam != nil {
+		if err := reviewerTeam.GetMembers(); err != nil {
+			log.Error("GetMembers: %v", err)
+			return
+		}
+		reviewers = reviewerTeam.Members
+	}
+
+	for _, u := range reviewers {
+		var opts = issueNotificationOpts{
+			issueID:              issue.ID,
+			notificationAuthorID: doer.ID,
+			receiverID:           u.ID,
+		}
+		if comment != nil {
+			opts.commentID = comment.ID
+		}
+		ns.issueQueue <- opts
+	}
+}
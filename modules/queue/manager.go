@@ -44,6 +44,7 @@ type ManagedQueue struct {
 type ManagedPool interface {
 	AddWorkers(number int, timeout time.Duration) context.CancelFunc
 	NumberOfWorkers() int
+	NumberInQueue() int
 	MaxNumberOfWorkers() int
 	SetMaxNumberOfWorkers(int)
 	BoostTimeout() time.Duration
@@ -52,6 +53,11 @@ type ManagedPool interface {
 	SetSettings(maxNumberOfWorkers, boostWorkers int, timeout time.Duration)
 }
 
+// persistedQueue is implemented by the queues which keep data outside of their worker pool
+type persistedQueue interface {
+	NumberPersisted() int
+}
+
 // ManagedQueueList implements the sort.Interface
 type ManagedQueueList []*ManagedQueue
 
@@ -206,6 +212,19 @@ func (q *ManagedQueue) NumberOfWorkers() int {
 	return -1
 }
 
+// NumberInQueue returns the number of data waiting for the workers of the queue,
+// including the data persisted by the queue which has not been read yet
+func (q *ManagedQueue) NumberInQueue() int {
+	if q.Pool == nil {
+		return -1
+	}
+	number := q.Pool.NumberInQueue()
+	if persisted, ok := q.Queue.(persistedQueue); ok {
+		number += persisted.NumberPersisted()
+	}
+	return number
+}
+
 // MaxNumberOfWorkers returns the maximum number of workers for the pool
 func (q *ManagedQueue) MaxNumberOfWorkers() int {
 	if q.Pool != nil {
@@ -155,6 +155,11 @@ func (l *LevelQueue) readToChan() {
 	}
 }
 
+// NumberPersisted returns the number of data in the level queue which has not been read yet
+func (l *LevelQueue) NumberPersisted() int {
+	return int(l.queue.Len())
+}
+
 // Push will push the indexer data to queue
 func (l *LevelQueue) Push(data Data) error {
 	if l.exemplar != nil {
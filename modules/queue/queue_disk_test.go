@@ -83,6 +83,9 @@ func TestLevelQueue(t *testing.T) {
 		assert.Fail(t, "Handler processing should have stopped")
 	default:
 	}
+	levelQueue := queue.(*LevelQueue)
+	assert.Equal(t, 2, levelQueue.NumberPersisted())
+	assert.Equal(t, 2, GetManager().GetManagedQueue(levelQueue.pool.qid).NumberInQueue())
 	for _, callback := range queueTerminate {
 		callback()
 	}
@@ -25,6 +25,7 @@ const RedisQueueType Type = "redis"
 type redisClient interface {
 	RPush(key string, args ...interface{}) *redis.IntCmd
 	LPop(key string) *redis.StringCmd
+	LLen(key string) *redis.IntCmd
 	Ping() *redis.StatusCmd
 	Close() error
 }
@@ -196,6 +197,16 @@ func (r *RedisQueue) Push(data Data) error {
 	return r.client.RPush(r.queueName, bs).Err()
 }
 
+// NumberPersisted returns the number of data in the redis list which has not been read yet
+func (r *RedisQueue) NumberPersisted() int {
+	length, err := r.client.LLen(r.queueName).Result()
+	if err != nil {
+		log.Error("RedisQueue: %s Error on LLen: %v", r.name, err)
+		return 0
+	}
+	return int(length)
+}
+
 // Shutdown processing from this queue
 func (r *RedisQueue) Shutdown() {
 	log.Trace("Shutdown: %s", r.name)
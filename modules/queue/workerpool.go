@@ -106,6 +106,11 @@ func (p *WorkerPool) NumberOfWorkers() int {
 	return p.numberOfWorkers
 }
 
+// NumberInQueue returns the number of data waiting in the pool for a worker
+func (p *WorkerPool) NumberInQueue() int {
+	return len(p.dataChan)
+}
+
 // MaxNumberOfWorkers returns the maximum number of workers automatically added to the pool
 func (p *WorkerPool) MaxNumberOfWorkers() int {
 	p.lock.Lock()
@@ -52,6 +52,14 @@ var (
 		UpdateMigrationPosterID struct {
 			Schedule string
 		} `ini:"cron.update_migration_poster_id"`
+		CleanupHookTaskTable struct {
+			Enabled      bool
+			RunAtStart   bool
+			Schedule     string
+			CleanupType  string
+			OlderThan    time.Duration
+			NumberToKeep int
+		} `ini:"cron.cleanup_hook_task_table"`
 	}{
 		UpdateMirror: struct {
 			Enabled    bool
@@ -122,6 +130,21 @@ var (
 		}{
 			Schedule: "@every 24h",
 		},
+		CleanupHookTaskTable: struct {
+			Enabled      bool
+			RunAtStart   bool
+			Schedule     string
+			CleanupType  string
+			OlderThan    time.Duration
+			NumberToKeep int
+		}{
+			Enabled:      true,
+			RunAtStart:   false,
+			Schedule:     "@every 24h",
+			CleanupType:  "OlderThan",
+			OlderThan:    168 * time.Hour,
+			NumberToKeep: 10,
+		},
 	}
 )
 
@@ -0,0 +1,73 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	ini "gopkg.in/ini.v1"
+)
+
+// enumerates all the storage types
+const (
+	LocalStorageType = "local"
+	MinioStorageType = "minio"
+)
+
+// Storage represents the configuration of a storage backend
+type Storage struct {
+	Type        string
+	Path        string
+	ServeDirect bool
+	Minio       struct {
+		Endpoint        string
+		AccessKeyID     string
+		SecretAccessKey string
+		Bucket          string
+		Location        string
+		BasePath        string
+		UseSSL          bool
+	}
+}
+
+// IsLocal returns true if the storage keeps its data on the local file system
+func (s Storage) IsLocal() bool {
+	return s.Type == LocalStorageType
+}
+
+var (
+	// AttachmentStorage is the storage of issue, comment and release attachments
+	AttachmentStorage Storage
+	// LFSStorage is the storage of LFS objects
+	LFSStorage Storage
+	// AvatarStorage is the storage of user avatars
+	AvatarStorage Storage
+	// RepoAvatarStorage is the storage of repository avatars
+	RepoAvatarStorage Storage
+)
+
+// getStorage reads the storage configuration from the keys of sec starting with prefix,
+// the [storage] section provides the defaults of all keys except the base path
+func getStorage(sec *ini.Section, prefix, path, basePath string) Storage {
+	defaults := Cfg.Section("storage")
+
+	var storage Storage
+	storage.Type = sec.Key(prefix + "STORAGE_TYPE").MustString(defaults.Key("STORAGE_TYPE").MustString(LocalStorageType))
+	storage.Path = path
+	storage.ServeDirect = sec.Key(prefix + "SERVE_DIRECT").MustBool(defaults.Key("SERVE_DIRECT").MustBool(false))
+	storage.Minio.Endpoint = sec.Key(prefix + "MINIO_ENDPOINT").MustString(defaults.Key("MINIO_ENDPOINT").MustString("localhost:9000"))
+	storage.Minio.AccessKeyID = sec.Key(prefix + "MINIO_ACCESS_KEY_ID").MustString(defaults.Key("MINIO_ACCESS_KEY_ID").String())
+	storage.Minio.SecretAccessKey = sec.Key(prefix + "MINIO_SECRET_ACCESS_KEY").MustString(defaults.Key("MINIO_SECRET_ACCESS_KEY").String())
+	storage.Minio.Bucket = sec.Key(prefix + "MINIO_BUCKET").MustString(defaults.Key("MINIO_BUCKET").MustString("gitea"))
+	storage.Minio.Location = sec.Key(prefix + "MINIO_LOCATION").MustString(defaults.Key("MINIO_LOCATION").MustString("us-east-1"))
+	storage.Minio.BasePath = sec.Key(prefix + "MINIO_BASE_PATH").MustString(basePath)
+	storage.Minio.UseSSL = sec.Key(prefix + "MINIO_USE_SSL").MustBool(defaults.Key("MINIO_USE_SSL").MustBool(false))
+	return storage
+}
+
+func newStorageService() {
+	AttachmentStorage = getStorage(Cfg.Section("attachment"), "", AttachmentPath, "attachments/")
+	LFSStorage = getStorage(Cfg.Section("lfs"), "", LFS.ContentPath, "lfs/")
+	AvatarStorage = getStorage(Cfg.Section("picture"), "AVATAR_", AvatarUploadPath, "avatars/")
+	RepoAvatarStorage = getStorage(Cfg.Section("picture"), "REPOSITORY_AVATAR_", RepositoryAvatarUploadPath, "repo-avatars/")
+}
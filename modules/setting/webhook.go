@@ -6,6 +6,7 @@ package setting
 
 import (
 	"net/url"
+	"time"
 
 	"code.gitea.io/gitea/modules/log"
 )
@@ -13,30 +14,49 @@ import (
 var (
 	// Webhook settings
 	Webhook = struct {
-		QueueLength    int
-		DeliverTimeout int
-		SkipTLSVerify  bool
-		Types          []string
-		PagingNum      int
-		ProxyURL       string
-		ProxyURLFixed  *url.URL
-		ProxyHosts     []string
+		QueueLength          int
+		DeliverTimeout       int
+		DeliverMaxAttempts   int
+		DeliverRetryBackoff  time.Duration
+		MaxDeliveriesPerHost int
+		SkipTLSVerify        bool
+		Types                []string
+		PagingNum            int
+		ProxyURL             string
+		ProxyURLFixed        *url.URL
+		ProxyHosts           []string
 	}{
-		QueueLength:    1000,
-		DeliverTimeout: 5,
-		SkipTLSVerify:  false,
-		PagingNum:      10,
-		ProxyURL:       "",
-		ProxyHosts:     []string{},
+		QueueLength:          1000,
+		DeliverTimeout:       5,
+		DeliverMaxAttempts:   5,
+		DeliverRetryBackoff:  time.Minute,
+		MaxDeliveriesPerHost: 4,
+		SkipTLSVerify:        false,
+		PagingNum:            10,
+		ProxyURL:             "",
+		ProxyHosts:           []string{},
 	}
 )
 
+// maxWebhookDeliverAttempts is the largest number of attempts to deliver a webhook,
+// with the longest backoff between them they span several weeks
+const maxWebhookDeliverAttempts = 25
+
 func newWebhookService() {
 	sec := Cfg.Section("webhook")
 	Webhook.QueueLength = sec.Key("QUEUE_LENGTH").MustInt(1000)
 	Webhook.DeliverTimeout = sec.Key("DELIVER_TIMEOUT").MustInt(5)
+	Webhook.DeliverMaxAttempts = sec.Key("DELIVER_MAX_ATTEMPTS").MustInt(5)
+	if Webhook.DeliverMaxAttempts < 1 {
+		Webhook.DeliverMaxAttempts = 1
+	} else if Webhook.DeliverMaxAttempts > maxWebhookDeliverAttempts {
+		log.Warn("Webhook DELIVER_MAX_ATTEMPTS %d is too large, using %d", Webhook.DeliverMaxAttempts, maxWebhookDeliverAttempts)
+		Webhook.DeliverMaxAttempts = maxWebhookDeliverAttempts
+	}
+	Webhook.DeliverRetryBackoff = sec.Key("DELIVER_RETRY_BACKOFF").MustDuration(time.Minute)
+	Webhook.MaxDeliveriesPerHost = sec.Key("MAX_DELIVERIES_PER_HOST").MustInt(4)
 	Webhook.SkipTLSVerify = sec.Key("SKIP_TLS_VERIFY").MustBool()
-	Webhook.Types = []string{"gitea", "gogs", "slack", "discord", "dingtalk", "telegram", "msteams"}
+	Webhook.Types = []string{"gitea", "gogs", "slack", "discord", "dingtalk", "telegram", "msteams", "matrix"}
 	Webhook.PagingNum = sec.Key("PAGING_NUM").MustInt(10)
 	Webhook.ProxyURL = sec.Key("PROXY_URL").MustString("")
 	if Webhook.ProxyURL != "" {
@@ -48,4 +68,11 @@ func newWebhookService() {
 		}
 	}
 	Webhook.ProxyHosts = sec.Key("PROXY_HOSTS").Strings(",")
+
+	// The deliveries are queued in queue.webhook_sender, QUEUE_LENGTH is kept for backwards compatibility
+	queueSec := Cfg.Section("queue.webhook_sender")
+	queueSec.Key("LENGTH").MustInt(Webhook.QueueLength)
+	// every worker delivers a single hook at a time
+	queueSec.Key("BATCH_LENGTH").MustInt(1)
+	queueSec.Key("WORKERS").MustInt(4)
 }
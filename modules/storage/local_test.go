@@ -0,0 +1,54 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	l, err := NewLocalStorage(filepath.Join(dir, "local"))
+	assert.NoError(t, err)
+
+	written, err := l.Save("a/b/file", strings.NewReader("Hello, World!"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 13, written)
+	assert.FileExists(t, filepath.Join(dir, "local", "a", "b", "file"))
+
+	fi, err := l.Stat("a/b/file")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 13, fi.Size())
+
+	_, err = l.URL("a/b/file", "file")
+	assert.Equal(t, ErrURLNotSupported, err)
+
+	dst, err := NewLocalStorage(filepath.Join(dir, "copy"))
+	assert.NoError(t, err)
+	assert.NoError(t, l.IterateObjects(func(path string, obj Object) error {
+		assert.Equal(t, "a/b/file", path)
+		_, err := Copy(dst, path, l, path)
+		return err
+	}))
+	obj, err := dst.Open("a/b/file")
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(obj)
+	assert.NoError(t, err)
+	assert.NoError(t, obj.Close())
+	assert.Equal(t, "Hello, World!", string(data))
+
+	assert.NoError(t, l.Delete("a/b/file"))
+	_, err = l.Stat("a/b/file")
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,173 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v6"
+)
+
+var (
+	_ ObjectStorage = &MinioStorage{}
+
+	quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+)
+
+const (
+	// presignedURLExpiry is how long a redirect URL returned by URL stays valid
+	presignedURLExpiry = 5 * time.Minute
+
+	// minioPartSize is the size of the parts of multipart uploads, objects of
+	// up to 10000 parts can be saved as their size is not known beforehand
+	minioPartSize = 16 * 1024 * 1024
+)
+
+// MinioStorage stores objects in a bucket of an S3 compatible object storage like MinIO
+type MinioStorage struct {
+	ctx      context.Context
+	client   *minio.Client
+	bucket   string
+	basePath string
+}
+
+// NewMinioStorage returns a minio storage and creates the bucket if it does not exist yet
+func NewMinioStorage(ctx context.Context, endpoint, accessKeyID, secretAccessKey, bucket, location, basePath string, useSSL bool) (*MinioStorage, error) {
+	minioClient, err := minio.NewWithRegion(endpoint, accessKeyID, secretAccessKey, useSSL, location)
+	if err != nil {
+		return nil, convertMinioErr(err)
+	}
+
+	exists, err := minioClient.BucketExistsWithContext(ctx, bucket)
+	if err != nil {
+		return nil, convertMinioErr(err)
+	}
+	if !exists {
+		if err := minioClient.MakeBucketWithContext(ctx, bucket, location); err != nil {
+			return nil, convertMinioErr(err)
+		}
+	}
+
+	return &MinioStorage{
+		ctx:      ctx,
+		client:   minioClient,
+		bucket:   bucket,
+		basePath: strings.Trim(basePath, "/"),
+	}, nil
+}
+
+func (m *MinioStorage) buildMinioPath(p string) string {
+	if m.basePath == "" {
+		return strings.TrimPrefix(path.Clean("/"+p), "/")
+	}
+	return m.basePath + path.Clean("/"+p)
+}
+
+// Open opens the object at path, the object is requested once and then read from that response
+func (m *MinioStorage) Open(path string) (Object, error) {
+	object, err := m.client.GetObjectWithContext(m.ctx, m.bucket, m.buildMinioPath(path), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, convertMinioErr(err)
+	}
+	// Stat sends the first request of the object, a missing object is reported here instead of on Read
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		return nil, convertMinioErr(err)
+	}
+	return object, nil
+}
+
+// Save stores the content of r at path with a multipart upload
+func (m *MinioStorage) Save(path string, r io.Reader) (int64, error) {
+	written, err := m.client.PutObjectWithContext(
+		m.ctx,
+		m.bucket,
+		m.buildMinioPath(path),
+		r,
+		-1,
+		minio.PutObjectOptions{ContentType: "application/octet-stream", PartSize: minioPartSize},
+	)
+	if err != nil {
+		return 0, convertMinioErr(err)
+	}
+	return written, nil
+}
+
+// Stat returns the info of the object at path
+func (m *MinioStorage) Stat(path string) (os.FileInfo, error) {
+	info, err := m.client.StatObjectWithContext(m.ctx, m.bucket, m.buildMinioPath(path), minio.StatObjectOptions{})
+	if err != nil {
+		return nil, convertMinioErr(err)
+	}
+	return &minioFileInfo{info}, nil
+}
+
+// Delete deletes the object at path
+func (m *MinioStorage) Delete(path string) error {
+	return convertMinioErr(m.client.RemoveObject(m.bucket, m.buildMinioPath(path)))
+}
+
+// URL returns a pre-signed URL which downloads the object at path as name
+func (m *MinioStorage) URL(path, name string) (*url.URL, error) {
+	reqParams := make(url.Values)
+	reqParams.Set("response-content-disposition", "attachment; filename=\""+quoteEscaper.Replace(name)+"\"")
+	u, err := m.client.PresignedGetObject(m.bucket, m.buildMinioPath(path), presignedURLExpiry, reqParams)
+	return u, convertMinioErr(err)
+}
+
+// IterateObjects iterates across the objects in the minio storage
+func (m *MinioStorage) IterateObjects(fn func(path string, obj Object) error) error {
+	prefix := ""
+	if m.basePath != "" {
+		prefix = m.basePath + "/"
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	for info := range m.client.ListObjectsV2(m.bucket, prefix, true, doneCh) {
+		if info.Err != nil {
+			return convertMinioErr(info.Err)
+		}
+		object, err := m.client.GetObjectWithContext(m.ctx, m.bucket, info.Key, minio.GetObjectOptions{})
+		if err != nil {
+			return convertMinioErr(err)
+		}
+		err = fn(strings.TrimPrefix(info.Key, prefix), object)
+		object.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertMinioErr converts a missing object to os.ErrNotExist, other errors are returned as they are
+func convertMinioErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	errResp, ok := err.(minio.ErrorResponse)
+	if !ok || errResp.Code != "NoSuchKey" {
+		return err
+	}
+	return &os.PathError{Op: "open", Path: errResp.Key, Err: os.ErrNotExist}
+}
+
+type minioFileInfo struct {
+	minio.ObjectInfo
+}
+
+func (fi *minioFileInfo) Name() string       { return path.Base(fi.Key) }
+func (fi *minioFileInfo) Size() int64        { return fi.ObjectInfo.Size }
+func (fi *minioFileInfo) Mode() os.FileMode  { return 0644 }
+func (fi *minioFileInfo) ModTime() time.Time { return fi.LastModified }
+func (fi *minioFileInfo) IsDir() bool        { return false }
+func (fi *minioFileInfo) Sys() interface{}   { return nil }
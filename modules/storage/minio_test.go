@@ -0,0 +1,183 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newFakeS3Server serves an in memory bucket named "gitea"
+func newFakeS3Server(t *testing.T) *httptest.Server {
+	var (
+		lock    sync.Mutex
+		created bool
+		objects = map[string][]byte{}
+		uploads = map[string]map[int][]byte{}
+		nextID  int
+	)
+	lastModified := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/"))
+
+		query := r.URL.Query()
+		key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/gitea"), "/")
+		if key == "" {
+			switch r.Method {
+			case http.MethodHead:
+				if !created {
+					w.WriteHeader(http.StatusNotFound)
+				}
+			case http.MethodPut:
+				created = true
+			case http.MethodGet:
+				assert.Equal(t, "2", query.Get("list-type"))
+				var names []string
+				for name := range objects {
+					if strings.HasPrefix(name, query.Get("prefix")) {
+						names = append(names, name)
+					}
+				}
+				sort.Strings(names)
+				_, _ = fmt.Fprint(w, "<ListBucketResult>")
+				for _, name := range names {
+					_, _ = fmt.Fprintf(w, "<Contents><Key>%s</Key><Size>%d</Size><ETag>\"etag\"</ETag></Contents>", name, len(objects[name]))
+				}
+				_, _ = fmt.Fprint(w, "<IsTruncated>false</IsTruncated></ListBucketResult>")
+			}
+			return
+		}
+
+		// multipart uploads
+		if _, ok := query["uploads"]; ok && r.Method == http.MethodPost {
+			nextID++
+			uploadID := strconv.Itoa(nextID)
+			uploads[uploadID] = map[int][]byte{}
+			_, _ = fmt.Fprintf(w, "<InitiateMultipartUploadResult><Bucket>gitea</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>", key, uploadID)
+			return
+		}
+		if uploadID := query.Get("uploadId"); uploadID != "" {
+			parts, ok := uploads[uploadID]
+			assert.True(t, ok)
+			switch r.Method {
+			case http.MethodPut:
+				partNumber, err := strconv.Atoi(query.Get("partNumber"))
+				assert.NoError(t, err)
+				body, err := ioutil.ReadAll(r.Body)
+				assert.NoError(t, err)
+				parts[partNumber] = body
+				w.Header().Set("ETag", `"part"`)
+			case http.MethodPost:
+				var data []byte
+				for i := 1; i <= len(parts); i++ {
+					data = append(data, parts[i]...)
+				}
+				objects[key] = data
+				delete(uploads, uploadID)
+				_, _ = fmt.Fprintf(w, "<CompleteMultipartUploadResult><Bucket>gitea</Bucket><Key>%s</Key><ETag>\"etag\"</ETag></CompleteMultipartUploadResult>", key)
+			case http.MethodDelete:
+				delete(uploads, uploadID)
+				w.WriteHeader(http.StatusNoContent)
+			}
+			return
+		}
+
+		data, exists := objects[key]
+		switch r.Method {
+		case http.MethodDelete:
+			delete(objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodHead, http.MethodGet:
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+				if r.Method == http.MethodGet {
+					_, _ = w.Write([]byte("<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>"))
+				}
+				return
+			}
+			status := http.StatusOK
+			if rng := r.Header.Get("Range"); rng != "" {
+				from, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+				assert.NoError(t, err)
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", from, len(data)-1, len(data)))
+				data = data[from:]
+				status = http.StatusPartialContent
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Header().Set("Last-Modified", lastModified)
+			w.Header().Set("ETag", `"etag"`)
+			w.WriteHeader(status)
+			if r.Method == http.MethodGet {
+				_, _ = w.Write(data)
+			}
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+}
+
+func TestMinioStorage(t *testing.T) {
+	server := newFakeS3Server(t)
+	defer server.Close()
+
+	m, err := NewMinioStorage(context.Background(), strings.TrimPrefix(server.URL, "http://"), "key", "secret", "gitea", "us-east-1", "attachments/", false)
+	assert.NoError(t, err)
+
+	written, err := m.Save("a/b/file", strings.NewReader("Hello, World!"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 13, written)
+
+	fi, err := m.Stat("a/b/file")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 13, fi.Size())
+	assert.Equal(t, "file", fi.Name())
+
+	obj, err := m.Open("a/b/file")
+	assert.NoError(t, err)
+	_, err = obj.Seek(7, os.SEEK_SET)
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, "World!", string(data))
+	assert.NoError(t, obj.Close())
+
+	var paths []string
+	assert.NoError(t, m.IterateObjects(func(path string, obj Object) error {
+		data, err := ioutil.ReadAll(obj)
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello, World!", string(data))
+		paths = append(paths, path)
+		return nil
+	}))
+	assert.EqualValues(t, []string{"a/b/file"}, paths)
+
+	u, err := m.URL("a/b/file", "hello.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "/gitea/attachments/a/b/file", u.Path)
+	assert.Equal(t, `attachment; filename="hello.txt"`, u.Query().Get("response-content-disposition"))
+	assert.NotEmpty(t, u.Query().Get("X-Amz-Signature"))
+
+	assert.NoError(t, m.Delete("a/b/file"))
+	_, err = m.Stat("a/b/file")
+	assert.True(t, os.IsNotExist(err))
+	_, err = m.Open("a/b/file")
+	assert.True(t, os.IsNotExist(err))
+}
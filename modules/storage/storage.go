@@ -0,0 +1,102 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"code.gitea.io/gitea/modules/setting"
+)
+
+var (
+	// ErrURLNotSupported represents url is not supported
+	ErrURLNotSupported = errors.New("url method not supported")
+)
+
+// Object represents an object read from a storage
+type Object interface {
+	io.ReadCloser
+	io.Seeker
+}
+
+// ObjectStorage represents an object storage to handle a bucket and files
+type ObjectStorage interface {
+	Open(path string) (Object, error)
+	Save(path string, r io.Reader) (int64, error)
+	Stat(path string) (os.FileInfo, error)
+	Delete(path string) error
+	URL(path, name string) (*url.URL, error)
+	IterateObjects(func(path string, obj Object) error) error
+}
+
+// Copy copies a file from source ObjectStorage to dest ObjectStorage
+func Copy(dstStorage ObjectStorage, dstPath string, srcStorage ObjectStorage, srcPath string) (int64, error) {
+	f, err := srcStorage.Open(srcPath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return dstStorage.Save(dstPath, f)
+}
+
+var (
+	// Attachments represents attachments storage
+	Attachments ObjectStorage
+
+	// LFS represents lfs storage
+	LFS ObjectStorage
+
+	// Avatars represents user avatars storage
+	Avatars ObjectStorage
+
+	// RepoAvatars represents repository avatars storage
+	RepoAvatars ObjectStorage
+)
+
+// NewStorage creates the storage described by cfg
+func NewStorage(cfg setting.Storage) (ObjectStorage, error) {
+	switch cfg.Type {
+	case setting.LocalStorageType:
+		return NewLocalStorage(cfg.Path)
+	case setting.MinioStorageType:
+		minio := cfg.Minio
+		return NewMinioStorage(
+			context.Background(),
+			minio.Endpoint,
+			minio.AccessKeyID,
+			minio.SecretAccessKey,
+			minio.Bucket,
+			minio.Location,
+			minio.BasePath,
+			minio.UseSSL,
+		)
+	default:
+		return nil, fmt.Errorf("Unsupported storage type: %s", cfg.Type)
+	}
+}
+
+// Init initializes the storages of all data types
+func Init() error {
+	var err error
+	if Attachments, err = NewStorage(setting.AttachmentStorage); err != nil {
+		return fmt.Errorf("attachments: %v", err)
+	}
+	if LFS, err = NewStorage(setting.LFSStorage); err != nil {
+		return fmt.Errorf("lfs: %v", err)
+	}
+	if Avatars, err = NewStorage(setting.AvatarStorage); err != nil {
+		return fmt.Errorf("avatars: %v", err)
+	}
+	if RepoAvatars, err = NewStorage(setting.RepoAvatarStorage); err != nil {
+		return fmt.Errorf("repo-avatars: %v", err)
+	}
+	return nil
+}
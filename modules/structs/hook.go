@@ -25,6 +25,8 @@ type Hook struct {
 	Config map[string]string `json:"config"`
 	Events []string          `json:"events"`
 	Active bool              `json:"active"`
+	// whether the hook is an admin system webhook firing for all repositories
+	IsSystemWebhook bool `json:"is_system_webhook"`
 	// swagger:strfmt date-time
 	Updated time.Time `json:"updated_at"`
 	// swagger:strfmt date-time
@@ -41,7 +43,7 @@ type CreateHookOptionConfig map[string]string
 // CreateHookOption options when create a hook
 type CreateHookOption struct {
 	// required: true
-	// enum: dingtalk,discord,gitea,gogs,msteams,slack,telegram
+	// enum: dingtalk,discord,gitea,gogs,matrix,msteams,slack,telegram
 	Type string `json:"type" binding:"Required"`
 	// required: true
 	Config       CreateHookOptionConfig `json:"config" binding:"Required"`
@@ -49,6 +51,10 @@ type CreateHookOption struct {
 	BranchFilter string                 `json:"branch_filter" binding:"GlobPattern"`
 	// default: false
 	Active bool `json:"active"`
+	// only used when creating an admin hook: whether it fires for all
+	// repositories instead of being copied into new ones
+	// default: false
+	IsSystemWebhook bool `json:"is_system_webhook"`
 }
 
 // EditHookOption options when modify one hook
@@ -59,6 +65,38 @@ type EditHookOption struct {
 	Active       *bool             `json:"active"`
 }
 
+// HookDelivery represents a delivery of a webhook
+type HookDelivery struct {
+	ID    int64  `json:"id"`
+	UUID  string `json:"uuid"`
+	Event string `json:"event"`
+	URL   string `json:"url"`
+	// whether the delivery is finished, either because it succeeded
+	// or because it ran out of attempts
+	IsDelivered bool `json:"is_delivered"`
+	IsSucceed   bool `json:"is_succeed"`
+	Attempts    int  `json:"attempts"`
+	// swagger:strfmt date-time
+	Delivered *time.Time `json:"delivered_at"`
+	// swagger:strfmt date-time
+	NextAttempt *time.Time            `json:"next_attempt_at"`
+	Request     *HookDeliveryRequest  `json:"request"`
+	Response    *HookDeliveryResponse `json:"response"`
+}
+
+// HookDeliveryRequest represents the request sent by a delivery of a webhook
+type HookDeliveryRequest struct {
+	Headers map[string]string `json:"headers"`
+	Payload string            `json:"payload"`
+}
+
+// HookDeliveryResponse represents the response received by a delivery of a webhook
+type HookDeliveryResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
 // Payloader payload is some part of one hook
 type Payloader interface {
 	SetSecret(string)
@@ -374,6 +412,10 @@ const (
 	HookIssueMilestoned HookIssueAction = "milestoned"
 	// HookIssueDemilestoned is an issue action for when a milestone is cleared on an issue.
 	HookIssueDemilestoned HookIssueAction = "demilestoned"
+	// HookIssueReviewRequested is a pull request action for when a review of a user or team is requested.
+	HookIssueReviewRequested HookIssueAction = "review_requested"
+	// HookIssueReviewRequestRemoved is a pull request action for when a review request is removed.
+	HookIssueReviewRequestRemoved HookIssueAction = "review_request_removed"
 )
 
 // IssuePayload represents the payload information that is sent along with an issue event.
@@ -426,6 +468,9 @@ type PullRequestPayload struct {
 	Repository  *Repository     `json:"repository"`
 	Sender      *User           `json:"sender"`
 	Review      *ReviewPayload  `json:"review"`
+
+	RequestedReviewer *User `json:"requested_reviewer,omitempty"`
+	RequestedTeam     *Team `json:"requested_team,omitempty"`
 }
 
 // SetSecret modifies the secret of the PullRequestPayload.
@@ -5,6 +5,7 @@
 package structs
 
 import (
+	"strings"
 	"time"
 )
 
@@ -117,3 +118,20 @@ type IssueDeadline struct {
 	// swagger:strfmt date-time
 	Deadline *time.Time `json:"due_date"`
 }
+
+// IssueTemplate represents an issue template of a repository
+// swagger:model
+type IssueTemplate struct {
+	Name      string   `json:"name" yaml:"name"`
+	Title     string   `json:"title" yaml:"title"`
+	About     string   `json:"about" yaml:"about"`
+	Labels    []string `json:"labels" yaml:"labels"`
+	Assignees []string `json:"assignees" yaml:"assignees"`
+	Content   string   `json:"content" yaml:"-"`
+	FileName  string   `json:"file_name" yaml:"-"`
+}
+
+// Valid checks whether the template has the fields required to be listed
+func (it IssueTemplate) Valid() bool {
+	return strings.TrimSpace(it.Name) != "" && strings.TrimSpace(it.About) != ""
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// Project is a board of columns holding issues and pull requests of one repository
+type Project struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// enum: none,basic_kanban,bug_triage
+	BoardType    string    `json:"board_type"`
+	State        StateType `json:"state"`
+	OpenIssues   int       `json:"open_issues"`
+	ClosedIssues int       `json:"closed_issues"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+	// swagger:strfmt date-time
+	Closed *time.Time `json:"closed_at"`
+}
+
+// CreateProjectOption options for creating a project
+type CreateProjectOption struct {
+	// required:true
+	Title       string `json:"title" binding:"Required"`
+	Description string `json:"description"`
+	// the predefined columns the project is created with
+	// enum: none,basic_kanban,bug_triage
+	BoardType string `json:"board_type"`
+}
+
+// EditProjectOption options for editing a project
+type EditProjectOption struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	State       *string `json:"state"`
+}
+
+// ProjectColumn is a column of a project
+type ProjectColumn struct {
+	ID        int64  `json:"id"`
+	Title     string `json:"title"`
+	Sorting   int    `json:"sorting"`
+	ProjectID int64  `json:"project_id"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+}
+
+// CreateProjectColumnOption options for creating a project column
+type CreateProjectColumnOption struct {
+	// required:true
+	Title   string `json:"title" binding:"Required"`
+	Sorting int    `json:"sorting"`
+}
+
+// EditProjectColumnOption options for editing a project column
+type EditProjectColumnOption struct {
+	Title   *string `json:"title"`
+	Sorting *int    `json:"sorting"`
+}
+
+// MoveProjectCardOption options for moving an issue or pull request to a column of a project
+type MoveProjectCardOption struct {
+	// index of the issue or pull request, it is added to the project if needed
+	// required:true
+	Issue int64 `json:"issue" binding:"Required"`
+	// id of the column, 0 moves the card to the uncategorized column
+	ColumnID int64 `json:"column_id"`
+}
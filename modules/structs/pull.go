@@ -91,3 +91,22 @@ type EditPullRequestOption struct {
 	Deadline       *time.Time `json:"due_date"`
 	RemoveDeadline *bool      `json:"unset_due_date"`
 }
+
+// ChangedFile store information about a file changed by a pull request or between two refs
+type ChangedFile struct {
+	Filename string `json:"filename"`
+	// the name before the file has been renamed, only set if status is renamed
+	PreviousFilename string `json:"previous_filename,omitempty"`
+	// enum: added,removed,modified,renamed
+	Status    string `json:"status"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Changes   int    `json:"changes"`
+	IsBinary  bool   `json:"is_binary"`
+	// the patch is cut off if the diff exceeds the configured limits
+	IsIncomplete bool   `json:"is_incomplete"`
+	Patch        string `json:"patch"`
+	HTMLURL      string `json:"html_url,omitempty"`
+	ContentsURL  string `json:"contents_url,omitempty"`
+	RawURL       string `json:"raw_url,omitempty"`
+}
@@ -0,0 +1,16 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+// Compare represents the comparison of two refs of a repository
+type Compare struct {
+	BaseCommitID      string         `json:"base_commit_id"`
+	HeadCommitID      string         `json:"head_commit_id"`
+	MergeBaseCommitID string         `json:"merge_base_commit_id"`
+	TotalCommits      int            `json:"total_commits"`
+	Commits           []*Commit      `json:"commits"`
+	TotalFiles        int            `json:"total_files"`
+	Files             []*ChangedFile `json:"files"`
+}
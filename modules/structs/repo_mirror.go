@@ -0,0 +1,33 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import "time"
+
+// PushMirror represents information of a push mirror
+type PushMirror struct {
+	ID            int64  `json:"id"`
+	RepoName      string `json:"repo_name"`
+	RemoteName    string `json:"remote_name"`
+	RemoteAddress string `json:"remote_address"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created"`
+	// swagger:strfmt date-time
+	LastUpdate *time.Time `json:"last_update"`
+	LastError  string     `json:"last_error"`
+	Interval   string     `json:"interval"`
+	SyncOnPush bool       `json:"sync_on_push"`
+}
+
+// CreatePushMirrorOption represents need information to create a push mirror of a repository.
+type CreatePushMirrorOption struct {
+	// required: true
+	RemoteAddress  string `json:"remote_address" binding:"Required"`
+	RemoteUsername string `json:"remote_username"`
+	RemotePassword string `json:"remote_password"`
+	// interval as a duration between each periodic sync, e.g. "8h", 0 to disable periodic sync
+	Interval   string `json:"interval"`
+	SyncOnPush bool   `json:"sync_on_push"`
+}
@@ -7,6 +7,7 @@ package structs
 
 import (
 	"encoding/base64"
+	"time"
 )
 
 // BasicAuthEncode generate base64 of basic auth head
@@ -17,10 +18,17 @@ func BasicAuthEncode(user, pass string) string {
 // AccessToken represents an API access token.
 // swagger:response AccessToken
 type AccessToken struct {
-	ID             int64  `json:"id"`
-	Name           string `json:"name"`
-	Token          string `json:"sha1"`
-	TokenLastEight string `json:"token_last_eight"`
+	ID             int64    `json:"id"`
+	Name           string   `json:"name"`
+	Token          string   `json:"sha1"`
+	TokenLastEight string   `json:"token_last_eight"`
+	Scopes         []string `json:"scopes"`
+	// full names of the repositories the token is restricted to
+	Repositories []string `json:"repositories"`
+	// names of the organizations the token is restricted to
+	Organizations []string `json:"organizations"`
+	// swagger:strfmt date-time
+	ExpiresAt *time.Time `json:"expires_at"`
 }
 
 // AccessTokenList represents a list of API access token.
@@ -28,7 +36,16 @@ type AccessToken struct {
 type AccessTokenList []*AccessToken
 
 // CreateAccessTokenOption options when create access token
-// swagger:parameters userCreateToken
 type CreateAccessTokenOption struct {
+	// required: true
 	Name string `json:"name" binding:"Required"`
+	// scopes of the token like `read:repository` or `write:issue`, the token
+	// has full access if no scope is given
+	Scopes []string `json:"scopes"`
+	// full names of the repositories to restrict the token to
+	Repositories []string `json:"repositories"`
+	// names of the organizations to restrict the token to
+	Organizations []string `json:"organizations"`
+	// swagger:strfmt date-time
+	ExpiresAt *time.Time `json:"expires_at"`
 }
@@ -19,14 +19,39 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/graceful"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/queue"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
 	"github.com/gobwas/glob"
-	"github.com/unknwon/com"
 )
 
-// Deliver deliver hook task
-func Deliver(t *models.HookTask) error {
-	t.IsDelivered = true
+// maxRetryBackoff is the longest wait before the next attempt of a task
+const maxRetryBackoff = 24 * time.Hour
+
+// retryBackoff returns how long to wait before the next attempt of a task
+// which has been attempted the given number of times, at most maxRetryBackoff
+func retryBackoff(attempts int) time.Duration {
+	backoff := setting.Webhook.DeliverRetryBackoff
+	if backoff <= 0 {
+		return 0
+	} else if backoff >= maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	// double the backoff for every attempt after the first, stopping at the maximum to not overflow
+	for i := 1; i < attempts; i++ {
+		if backoff >= maxRetryBackoff/2 {
+			return maxRetryBackoff
+		}
+		backoff *= 2
+	}
+	return backoff
+}
+
+// newHookRequest creates the request delivering the payload of the hook task
+func newHookRequest(t *models.HookTask) (*http.Request, error) {
+	if t.Type == models.MATRIX {
+		return newMatrixHookRequest(t)
+	}
 
 	var req *http.Request
 	var err error
@@ -40,7 +65,7 @@ func Deliver(t *models.HookTask) error {
 		case models.ContentTypeJSON:
 			req, err = http.NewRequest("POST", t.URL, strings.NewReader(t.PayloadContent))
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			req.Header.Set("Content-Type", "application/json")
@@ -52,7 +77,7 @@ func Deliver(t *models.HookTask) error {
 			req, err = http.NewRequest("POST", t.URL, strings.NewReader(forms.Encode()))
 			if err != nil {
 
-				return err
+				return nil, err
 			}
 
 			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
@@ -60,33 +85,65 @@ func Deliver(t *models.HookTask) error {
 	case http.MethodGet:
 		u, err := url.Parse(t.URL)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		vals := u.Query()
 		vals["payload"] = []string{t.PayloadContent}
 		u.RawQuery = vals.Encode()
 		req, err = http.NewRequest("GET", u.String(), nil)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	default:
-		return fmt.Errorf("Invalid http method for webhook: [%d] %v", t.ID, t.HTTPMethod)
+		return nil, fmt.Errorf("Invalid http method for webhook: [%d] %v", t.ID, t.HTTPMethod)
+	}
+	if req == nil {
+		return nil, fmt.Errorf("Invalid content type for webhook: [%d] %v", t.ID, t.ContentType)
 	}
+	return req, nil
+}
+
+// Deliver deliver hook task, a failed delivery is retried with an
+// exponential backoff until setting.Webhook.DeliverMaxAttempts is reached
+func Deliver(t *models.HookTask) error {
+	t.Attempts++
 
+	req, err := newHookRequest(t)
+	if err != nil {
+		// retrying will not help, so give up on the task
+		t.IsDelivered = true
+		t.Delivered = time.Now().UnixNano()
+		t.ResponseInfo = &models.HookResponse{
+			Body: fmt.Sprintf("Delivery: %v", err),
+		}
+		if err := models.UpdateHookTask(t); err != nil {
+			log.Error("UpdateHookTask [%d]: %v", t.ID, err)
+		}
+		return err
+	}
+
+	event := t.EventType.Event()
 	req.Header.Add("X-Gitea-Delivery", t.UUID)
-	req.Header.Add("X-Gitea-Event", string(t.EventType))
+	req.Header.Add("X-Gitea-Event", event)
+	req.Header.Add("X-Gitea-Event-Type", string(t.EventType))
 	req.Header.Add("X-Gitea-Signature", t.Signature)
 	req.Header.Add("X-Gogs-Delivery", t.UUID)
-	req.Header.Add("X-Gogs-Event", string(t.EventType))
+	req.Header.Add("X-Gogs-Event", event)
+	req.Header.Add("X-Gogs-Event-Type", string(t.EventType))
 	req.Header.Add("X-Gogs-Signature", t.Signature)
 	req.Header["X-GitHub-Delivery"] = []string{t.UUID}
-	req.Header["X-GitHub-Event"] = []string{string(t.EventType)}
+	req.Header["X-GitHub-Event"] = []string{event}
 
 	// Record delivery information.
 	t.RequestInfo = &models.HookRequest{
 		Headers: map[string]string{},
 	}
 	for k, vals := range req.Header {
+		if k == "Authorization" {
+			// don't store the credentials of the hook
+			t.RequestInfo.Headers[k] = "******"
+			continue
+		}
 		t.RequestInfo.Headers[k] = strings.Join(vals, ",")
 	}
 
@@ -98,8 +155,14 @@ func Deliver(t *models.HookTask) error {
 		t.Delivered = time.Now().UnixNano()
 		if t.IsSucceed {
 			log.Trace("Hook delivered: %s", t.UUID)
+			t.IsDelivered = true
+		} else if t.Attempts >= setting.Webhook.DeliverMaxAttempts {
+			log.Trace("Hook delivery failed: %s, giving up after %d attempts", t.UUID, t.Attempts)
+			t.IsDelivered = true
 		} else {
-			log.Trace("Hook delivery failed: %s", t.UUID)
+			backoff := retryBackoff(t.Attempts)
+			log.Trace("Hook delivery failed: %s, retrying in %v", t.UUID, backoff)
+			t.NextAttemptUnix = timeutil.TimeStampNow().AddDuration(backoff)
 		}
 
 		if err := models.UpdateHookTask(t); err != nil {
@@ -146,67 +209,177 @@ func Deliver(t *models.HookTask) error {
 	return nil
 }
 
-// DeliverHooks checks and delivers undelivered hooks.
-// FIXME: graceful: This would likely benefit from either a worker pool with dummy queue
-// or a full queue. Then more hooks could be sent at same time.
-func DeliverHooks(ctx context.Context) {
-	select {
-	case <-ctx.Done():
-		return
-	default:
+// hookQueue is a global queue of the hook tasks to be delivered
+var hookQueue queue.Queue
+
+// hookTaskQueueLease is how long a queued hook task is not queued again, it
+// is queued again after that if the queue has lost it
+const hookTaskQueueLease = 10 * time.Minute
+
+// hookTaskPageSize is the number of due hook tasks loaded at once
+const hookTaskPageSize = 50
+
+// hookTaskQueueItem is a hook task in the delivery queue, the task is only
+// delivered as long as it is marked as queued until QueuedUntil
+type hookTaskQueueItem struct {
+	ID          int64
+	QueuedUntil timeutil.TimeStamp
+}
+
+// enqueueHookTask adds the hook task to the delivery queue unless it is not
+// due or already queued, it returns whether the task has been queued
+func enqueueHookTask(id int64) bool {
+	if hookQueue == nil {
+		// the delivery has not been started, the task is picked up once it is
+		return false
 	}
-	tasks, err := models.FindUndeliveredHookTasks()
-	if err != nil {
-		log.Error("DeliverHooks: %v", err)
-		return
+	item := &hookTaskQueueItem{ID: id, QueuedUntil: timeutil.TimeStampNow().AddDuration(hookTaskQueueLease)}
+	if queued, err := models.QueueHookTask(id, item.QueuedUntil); err != nil {
+		log.Error("Unable to mark hook task %d as queued: %v", id, err)
+		return false
+	} else if !queued {
+		return false
 	}
+	pushHookTaskQueueItem(item)
+	return true
+}
 
-	// Update hook task status.
-	for _, t := range tasks {
-		select {
-		case <-ctx.Done():
-			return
-		default:
+func pushHookTaskQueueItem(item *hookTaskQueueItem) {
+	if err := hookQueue.Push(item); err != nil {
+		log.Error("Unable to push hook task %d to the delivery queue: %v", item.ID, err)
+	}
+}
+
+// hostLimiter limits the number of concurrent deliveries to a host
+type hostLimiter struct {
+	lock   sync.Mutex
+	active map[string]int
+}
+
+// acquire returns false if the host already has the maximum number of deliveries running
+func (l *hostLimiter) acquire(host string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if setting.Webhook.MaxDeliveriesPerHost > 0 && l.active[host] >= setting.Webhook.MaxDeliveriesPerHost {
+		return false
+	}
+	l.active[host]++
+	return true
+}
+
+func (l *hostLimiter) release(host string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.active[host]--
+	if l.active[host] <= 0 {
+		delete(l.active, host)
+	}
+}
+
+var deliveriesPerHost = &hostLimiter{active: make(map[string]int)}
+
+// busyHostRetryDelay is the time to wait before queueing a task again
+// whose host already has the maximum number of deliveries running
+const busyHostRetryDelay = time.Second
+
+// handle delivers the queued hook tasks
+func handle(data ...queue.Data) {
+	for _, datum := range data {
+		item := datum.(*hookTaskQueueItem)
+		if err := deliverHookTask(item); err != nil {
+			log.Error("Unable to deliver hook task %d: %v", item.ID, err)
 		}
-		if err = Deliver(t); err != nil {
-			log.Error("deliver: %v", err)
+	}
+}
+
+// deliverHookTask delivers the queued hook task unless it has been delivered,
+// queued again or is being delivered elsewhere
+func deliverHookTask(item *hookTaskQueueItem) error {
+	t, err := models.GetHookTaskByID(item.ID)
+	if err != nil {
+		if models.IsErrHookTaskNotExist(err) {
+			return nil
 		}
+		return err
+	}
+	if t.IsDelivered || t.NextAttemptUnix != item.QueuedUntil {
+		return nil
 	}
 
-	// Start listening on new hook requests.
-	for {
-		select {
-		case <-ctx.Done():
-			hookQueue.Close()
-			return
-		case repoIDStr := <-hookQueue.Queue():
-			log.Trace("DeliverHooks [repo_id: %v]", repoIDStr)
-			hookQueue.Remove(repoIDStr)
+	var host string
+	if u, err := url.Parse(t.URL); err == nil {
+		host = u.Host
+	}
+	if !deliveriesPerHost.acquire(host) {
+		// don't hold up a worker for a busy host, the task stays marked as queued
+		time.AfterFunc(busyHostRetryDelay, func() {
+			pushHookTaskQueueItem(item)
+		})
+		return nil
+	}
+	defer deliveriesPerHost.release(host)
 
-			repoID, err := com.StrTo(repoIDStr).Int64()
-			if err != nil {
-				log.Error("Invalid repo ID: %s", repoIDStr)
-				continue
-			}
+	// the claim expires if this instance does not finish the attempt
+	lease := time.Duration(setting.Webhook.DeliverTimeout)*time.Second + time.Minute
+	if claimed, err := models.ClaimHookTask(t, item.QueuedUntil, timeutil.TimeStampNow().AddDuration(lease)); err != nil {
+		return err
+	} else if !claimed {
+		return nil
+	}
+	return Deliver(t)
+}
 
-			tasks, err := models.FindRepoUndeliveredHookTasks(repoID)
-			if err != nil {
-				log.Error("Get repository [%d] hook tasks: %v", repoID, err)
-				continue
+// queueDueHookTasks queues up to limit of the undelivered hook tasks which are due,
+// it returns the number of queued tasks
+func queueDueHookTasks(ctx context.Context, limit int) int {
+	queued := 0
+	var afterID int64
+	for {
+		ids, err := models.FindUndeliveredHookTaskIDs(afterID, hookTaskPageSize)
+		if err != nil {
+			log.Error("FindUndeliveredHookTaskIDs: %v", err)
+			return queued
+		}
+		for _, id := range ids {
+			select {
+			case <-ctx.Done():
+				return queued
+			default:
 			}
-			for _, t := range tasks {
-				select {
-				case <-ctx.Done():
-					return
-				default:
-				}
-				if err = Deliver(t); err != nil {
-					log.Error("deliver: %v", err)
+			if enqueueHookTask(id) {
+				queued++
+				if queued >= limit {
+					return queued
 				}
 			}
 		}
+		if len(ids) < hookTaskPageSize {
+			return queued
+		}
+		afterID = ids[len(ids)-1]
+	}
+}
+
+// DeliverHooks regularly queues the undelivered hook tasks which are due,
+// i.e. the failed deliveries to retry and the tasks not queued before.
+// At most as many tasks as fit into the queue are queued at each tick.
+func DeliverHooks(ctx context.Context) {
+	interval := setting.Webhook.DeliverRetryBackoff
+	if interval <= 0 || interval > time.Minute {
+		interval = time.Minute
 	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		queueDueHookTasks(ctx, setting.GetQueueSettings("webhook_sender").Length)
 
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
 var (
@@ -240,7 +413,7 @@ func webhookProxy() func(req *http.Request) (*url.URL, error) {
 	}
 }
 
-// InitDeliverHooks starts the hooks delivery thread
+// InitDeliverHooks starts the hooks delivery queue
 func InitDeliverHooks() {
 	timeout := time.Duration(setting.Webhook.DeliverTimeout) * time.Second
 
@@ -259,5 +432,11 @@ func InitDeliverHooks() {
 		},
 	}
 
+	hookQueue = queue.CreateQueue("webhook_sender", handle, &hookTaskQueueItem{})
+	if hookQueue == nil {
+		log.Fatal("Unable to create webhook_sender Queue")
+	}
+	go graceful.GetManager().RunWithShutdownFns(hookQueue.Run)
+
 	go graceful.GetManager().RunWithShutdownContext(DeliverHooks)
 }
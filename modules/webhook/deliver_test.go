@@ -5,11 +5,18 @@
 package webhook
 
 import (
+	"context"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/queue"
 	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -37,3 +44,212 @@ func TestWebhookProxy(t *testing.T) {
 		}
 	}
 }
+
+func TestRetryBackoff(t *testing.T) {
+	defer func(backoff time.Duration) {
+		setting.Webhook.DeliverRetryBackoff = backoff
+	}(setting.Webhook.DeliverRetryBackoff)
+	setting.Webhook.DeliverRetryBackoff = time.Minute
+
+	assert.Equal(t, time.Minute, retryBackoff(1))
+	assert.Equal(t, 2*time.Minute, retryBackoff(2))
+	assert.Equal(t, 8*time.Minute, retryBackoff(4))
+
+	// the backoff does not overflow but stops at the maximum
+	assert.Equal(t, maxRetryBackoff, retryBackoff(12))
+	assert.Equal(t, maxRetryBackoff, retryBackoff(100))
+
+	setting.Webhook.DeliverRetryBackoff = 48 * time.Hour
+	assert.Equal(t, maxRetryBackoff, retryBackoff(1))
+}
+
+func TestDeliverRetries(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	defer func(maxAttempts int) {
+		setting.Webhook.DeliverMaxAttempts = maxAttempts
+	}(setting.Webhook.DeliverMaxAttempts)
+	setting.Webhook.DeliverMaxAttempts = 2
+	webhookHTTPClient = http.DefaultClient
+
+	var status = http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	hookTask := &models.HookTask{
+		RepoID:      1,
+		HookID:      1,
+		Type:        models.GITEA,
+		URL:         server.URL,
+		HTTPMethod:  http.MethodPost,
+		ContentType: models.ContentTypeJSON,
+		Payloader:   &api.PushPayload{},
+	}
+	assert.NoError(t, models.CreateHookTask(hookTask))
+
+	// a failed delivery is scheduled to be retried
+	assert.NoError(t, Deliver(hookTask))
+	hookTask = models.AssertExistsAndLoadBean(t, &models.HookTask{ID: hookTask.ID}).(*models.HookTask)
+	assert.Equal(t, 1, hookTask.Attempts)
+	assert.False(t, hookTask.IsDelivered)
+	assert.False(t, hookTask.IsSucceed)
+	assert.True(t, hookTask.NextAttemptUnix > timeutil.TimeStampNow())
+
+	// the last attempt gives up
+	assert.NoError(t, Deliver(hookTask))
+	hookTask = models.AssertExistsAndLoadBean(t, &models.HookTask{ID: hookTask.ID}).(*models.HookTask)
+	assert.Equal(t, 2, hookTask.Attempts)
+	assert.True(t, hookTask.IsDelivered)
+	assert.False(t, hookTask.IsSucceed)
+
+	// a redelivery starts over
+	newTask, err := models.RedeliverHookTask(hookTask)
+	assert.NoError(t, err)
+	status = http.StatusOK
+	assert.NoError(t, Deliver(newTask))
+	newTask = models.AssertExistsAndLoadBean(t, &models.HookTask{ID: newTask.ID}).(*models.HookTask)
+	assert.Equal(t, 1, newTask.Attempts)
+	assert.True(t, newTask.IsDelivered)
+	assert.True(t, newTask.IsSucceed)
+}
+
+func TestHostLimiter(t *testing.T) {
+	defer func(max int) {
+		setting.Webhook.MaxDeliveriesPerHost = max
+	}(setting.Webhook.MaxDeliveriesPerHost)
+	setting.Webhook.MaxDeliveriesPerHost = 1
+
+	limiter := &hostLimiter{active: make(map[string]int)}
+	assert.True(t, limiter.acquire("example.com"))
+	assert.False(t, limiter.acquire("example.com"))
+	assert.True(t, limiter.acquire("example.org"))
+	limiter.release("example.com")
+	assert.True(t, limiter.acquire("example.com"))
+
+	setting.Webhook.MaxDeliveriesPerHost = 0
+	assert.True(t, limiter.acquire("example.com"))
+}
+
+func TestDeliverHookTask(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	webhookHTTPClient = http.DefaultClient
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	hookTask := &models.HookTask{
+		RepoID:      1,
+		HookID:      1,
+		Type:        models.GITEA,
+		URL:         server.URL,
+		HTTPMethod:  http.MethodPost,
+		ContentType: models.ContentTypeJSON,
+		Payloader:   &api.PushPayload{},
+	}
+	assert.NoError(t, models.CreateHookTask(hookTask))
+
+	// the queue item of a task which has been queued again is stale
+	item := &hookTaskQueueItem{ID: hookTask.ID, QueuedUntil: timeutil.TimeStampNow().Add(60)}
+	assert.NoError(t, deliverHookTask(item))
+	assert.Equal(t, 0, requests)
+
+	queued, err := models.QueueHookTask(hookTask.ID, item.QueuedUntil)
+	assert.NoError(t, err)
+	assert.True(t, queued)
+	assert.NoError(t, deliverHookTask(item))
+	hookTask = models.AssertExistsAndLoadBean(t, &models.HookTask{ID: hookTask.ID}).(*models.HookTask)
+	assert.True(t, hookTask.IsDelivered)
+	assert.True(t, hookTask.IsSucceed)
+
+	// a delivered task is not delivered again, e.g. when it has been queued twice
+	assert.NoError(t, deliverHookTask(item))
+	assert.Equal(t, 1, requests)
+
+	// a deleted task is ignored
+	assert.NoError(t, deliverHookTask(&hookTaskQueueItem{ID: models.NonexistentID}))
+}
+
+// fakeQueue records the pushed data
+type fakeQueue struct {
+	data []queue.Data
+}
+
+func (q *fakeQueue) Run(atShutdown, atTerminate func(context.Context, func())) {}
+
+func (q *fakeQueue) Push(data queue.Data) error {
+	q.data = append(q.data, data)
+	return nil
+}
+
+func TestQueueDueHookTasks(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	q := &fakeQueue{}
+	hookQueue = q
+	defer func() {
+		hookQueue = nil
+	}()
+
+	var ids []int64
+	for i := 0; i < hookTaskPageSize+2; i++ {
+		hookTask := &models.HookTask{
+			RepoID:    1,
+			HookID:    1,
+			Type:      models.GITEA,
+			URL:       "http://www.example.com/unit_test",
+			Payloader: &api.PushPayload{},
+		}
+		assert.NoError(t, models.CreateHookTask(hookTask))
+		ids = append(ids, hookTask.ID)
+	}
+
+	// a queued task is not queued again
+	assert.True(t, enqueueHookTask(ids[0]))
+	assert.False(t, enqueueHookTask(ids[0]))
+	assert.Len(t, q.data, 1)
+
+	// the tasks are queued up to the limit, across the pages
+	assert.Equal(t, hookTaskPageSize, queueDueHookTasks(context.Background(), hookTaskPageSize))
+	assert.Equal(t, 1, queueDueHookTasks(context.Background(), hookTaskPageSize))
+	assert.Equal(t, 0, queueDueHookTasks(context.Background(), hookTaskPageSize))
+
+	queuedIDs := make([]int64, 0, len(q.data))
+	for _, datum := range q.data {
+		queuedIDs = append(queuedIDs, datum.(*hookTaskQueueItem).ID)
+	}
+	assert.Equal(t, ids, queuedIDs)
+}
+
+func TestDeliverEventHeaders(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	webhookHTTPClient = http.DefaultClient
+
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	hookTask := &models.HookTask{
+		RepoID:      1,
+		HookID:      1,
+		Type:        models.GITEA,
+		URL:         server.URL,
+		HTTPMethod:  http.MethodPost,
+		ContentType: models.ContentTypeJSON,
+		EventType:   models.HookEventIssueLabel,
+		Payloader:   &api.IssuePayload{},
+	}
+	assert.NoError(t, models.CreateHookTask(hookTask))
+
+	assert.NoError(t, Deliver(hookTask))
+	assert.Equal(t, "issues", header.Get("X-Gitea-Event"))
+	assert.Equal(t, "issue_label", header.Get("X-Gitea-Event-Type"))
+	assert.Equal(t, "issues", header.Get("X-GitHub-Event"))
+}
@@ -261,13 +261,14 @@ func GetDingtalkPayload(p api.Payloader, event models.HookEventType, meta string
 		return getDingtalkDeletePayload(p.(*api.DeletePayload))
 	case models.HookEventFork:
 		return getDingtalkForkPayload(p.(*api.ForkPayload))
-	case models.HookEventIssues:
+	case models.HookEventIssues, models.HookEventIssueAssign, models.HookEventIssueLabel, models.HookEventIssueMilestone:
 		return getDingtalkIssuesPayload(p.(*api.IssuePayload))
 	case models.HookEventIssueComment:
 		return getDingtalkIssueCommentPayload(p.(*api.IssueCommentPayload))
 	case models.HookEventPush:
 		return getDingtalkPushPayload(p.(*api.PushPayload))
-	case models.HookEventPullRequest:
+	case models.HookEventPullRequest, models.HookEventPullRequestAssign, models.HookEventPullRequestLabel,
+		models.HookEventPullRequestMilestone, models.HookEventPullRequestSync, models.HookEventPullRequestReviewRequest:
 		return getDingtalkPullRequestPayload(p.(*api.PullRequestPayload))
 	case models.HookEventPullRequestApproved, models.HookEventPullRequestRejected, models.HookEventPullRequestComment:
 		return getDingtalkPullRequestApprovalPayload(p.(*api.PullRequestPayload), event)
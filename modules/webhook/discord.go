@@ -405,13 +405,14 @@ func GetDiscordPayload(p api.Payloader, event models.HookEventType, meta string)
 		return getDiscordDeletePayload(p.(*api.DeletePayload), discord)
 	case models.HookEventFork:
 		return getDiscordForkPayload(p.(*api.ForkPayload), discord)
-	case models.HookEventIssues:
+	case models.HookEventIssues, models.HookEventIssueAssign, models.HookEventIssueLabel, models.HookEventIssueMilestone:
 		return getDiscordIssuesPayload(p.(*api.IssuePayload), discord)
 	case models.HookEventIssueComment:
 		return getDiscordIssueCommentPayload(p.(*api.IssueCommentPayload), discord)
 	case models.HookEventPush:
 		return getDiscordPushPayload(p.(*api.PushPayload), discord)
-	case models.HookEventPullRequest:
+	case models.HookEventPullRequest, models.HookEventPullRequestAssign, models.HookEventPullRequestLabel,
+		models.HookEventPullRequestMilestone, models.HookEventPullRequestSync, models.HookEventPullRequestReviewRequest:
 		return getDiscordPullRequestPayload(p.(*api.PullRequestPayload), discord)
 	case models.HookEventPullRequestRejected, models.HookEventPullRequestApproved, models.HookEventPullRequestComment:
 		return getDiscordPullRequestApprovalPayload(p.(*api.PullRequestPayload), discord, event)
@@ -119,6 +119,12 @@ func getPullRequestPayloadInfo(p *api.PullRequestPayload, linkFormatter linkForm
 			linkFormatter(mileStoneLink, p.PullRequest.Milestone.Title), titleLink)
 	case api.HookIssueDemilestoned:
 		text = fmt.Sprintf("[%s] Pull request milestone cleared: %s", repoLink, titleLink)
+	case api.HookIssueReviewRequested:
+		text = fmt.Sprintf("[%s] Pull request review requested: %s from %s", repoLink, titleLink,
+			getRequestedReviewerLink(p, linkFormatter))
+	case api.HookIssueReviewRequestRemoved:
+		text = fmt.Sprintf("[%s] Pull request review request removed: %s from %s", repoLink, titleLink,
+			getRequestedReviewerLink(p, linkFormatter))
 	}
 	if withSender {
 		text += fmt.Sprintf(" by %s", linkFormatter(setting.AppURL+p.Sender.UserName, p.Sender.UserName))
@@ -132,6 +138,16 @@ func getPullRequestPayloadInfo(p *api.PullRequestPayload, linkFormatter linkForm
 	return text, issueTitle, attachmentText, color
 }
 
+func getRequestedReviewerLink(p *api.PullRequestPayload, linkFormatter linkFormatter) string {
+	if p.RequestedTeam != nil {
+		return p.RequestedTeam.Name
+	}
+	if p.RequestedReviewer != nil {
+		return linkFormatter(setting.AppURL+p.RequestedReviewer.UserName, p.RequestedReviewer.UserName)
+	}
+	return ""
+}
+
 func getReleasePayloadInfo(p *api.ReleasePayload, linkFormatter linkFormatter, withSender bool) (text string, color int) {
 	repoLink := linkFormatter(p.Repository.HTMLURL, p.Repository.FullName)
 	refLink := linkFormatter(p.Repository.HTMLURL+"/src/"+p.Release.TagName, p.Release.TagName)
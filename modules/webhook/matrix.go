@@ -0,0 +1,261 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// Matrix message types the hook can send
+const (
+	MatrixMessageTypeNotice = "m.notice"
+	MatrixMessageTypeText   = "m.text"
+)
+
+type (
+	// MatrixMeta contains the matrix metadata
+	MatrixMeta struct {
+		HomeserverURL string `json:"homeserver_url"`
+		Room          string `json:"room_id"`
+		AccessToken   string `json:"access_token"`
+		MessageType   string `json:"message_type"`
+	}
+
+	// MatrixPayload contains the content of an m.room.message event
+	MatrixPayload struct {
+		Body          string `json:"body"`
+		MsgType       string `json:"msgtype"`
+		Format        string `json:"format"`
+		FormattedBody string `json:"formatted_body"`
+	}
+)
+
+// IsValidMatrixMessageType returns true if given name is a message type the matrix hook can send
+func IsValidMatrixMessageType(name string) bool {
+	return name == MatrixMessageTypeNotice || name == MatrixMessageTypeText
+}
+
+// GetMatrixHook returns matrix metadata
+func GetMatrixHook(w *models.Webhook) *MatrixMeta {
+	s := &MatrixMeta{}
+	if err := json.Unmarshal([]byte(w.Meta), s); err != nil {
+		log.Error("webhook.GetMatrixHook(%d): %v", w.ID, err)
+	}
+	return s
+}
+
+// MatrixSendURL returns the URL of the endpoint sending m.room.message events to the room,
+// the transaction ID is appended to it on delivery
+func MatrixSendURL(homeserverURL, room string) string {
+	return fmt.Sprintf("%s/_matrix/client/r0/rooms/%s/send/m.room.message",
+		strings.TrimSuffix(homeserverURL, "/"), url.PathEscape(room))
+}
+
+// SetSecret sets the matrix secret
+func (p *MatrixPayload) SetSecret(_ string) {}
+
+// JSONPayload Marshals the MatrixPayload to json
+func (p *MatrixPayload) JSONPayload() ([]byte, error) {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return []byte{}, err
+	}
+	return data, nil
+}
+
+// newMatrixHookRequest creates the request sending the payload of the hook task
+// as a message event. The task UUID is used as transaction ID, so the homeserver
+// ignores the retries of a delivery which has already been received.
+func newMatrixHookRequest(t *models.HookTask) (*http.Request, error) {
+	w, err := models.GetWebhookByID(t.HookID)
+	if err != nil {
+		return nil, fmt.Errorf("GetWebhookByID: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, t.URL+"/"+url.PathEscape(t.UUID), strings.NewReader(t.PayloadContent))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+GetMatrixHook(w).AccessToken)
+	return req, nil
+}
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// newMatrixPayload creates a payload with the given HTML message and its plain text version
+func newMatrixPayload(matrix *MatrixMeta, message string) *MatrixPayload {
+	msgType := matrix.MessageType
+	if !IsValidMatrixMessageType(msgType) {
+		msgType = MatrixMessageTypeNotice
+	}
+	return &MatrixPayload{
+		Body:          html.UnescapeString(htmlTagPattern.ReplaceAllString(strings.Replace(message, "<br>", "\n", -1), "")),
+		MsgType:       msgType,
+		Format:        "org.matrix.custom.html",
+		FormattedBody: message,
+	}
+}
+
+func getMatrixCreatePayload(p *api.CreatePayload, matrix *MatrixMeta) (*MatrixPayload, error) {
+	repoLink := htmlLinkFormatter(p.Repo.HTMLURL, p.Repo.FullName)
+	refName := git.RefEndName(p.Ref)
+	refLink := htmlLinkFormatter(p.Repo.HTMLURL+"/src/"+refName, refName)
+	text := fmt.Sprintf("[%s:%s] %s created by %s", repoLink, refLink, p.RefType, html.EscapeString(p.Sender.UserName))
+
+	return newMatrixPayload(matrix, text), nil
+}
+
+func getMatrixDeletePayload(p *api.DeletePayload, matrix *MatrixMeta) (*MatrixPayload, error) {
+	repoLink := htmlLinkFormatter(p.Repo.HTMLURL, p.Repo.FullName)
+	refName := git.RefEndName(p.Ref)
+	text := fmt.Sprintf("[%s:%s] %s deleted by %s", repoLink, html.EscapeString(refName), p.RefType, html.EscapeString(p.Sender.UserName))
+
+	return newMatrixPayload(matrix, text), nil
+}
+
+func getMatrixForkPayload(p *api.ForkPayload, matrix *MatrixMeta) (*MatrixPayload, error) {
+	baseLink := htmlLinkFormatter(p.Forkee.HTMLURL, p.Forkee.FullName)
+	forkLink := htmlLinkFormatter(p.Repo.HTMLURL, p.Repo.FullName)
+	text := fmt.Sprintf("%s is forked to %s", baseLink, forkLink)
+
+	return newMatrixPayload(matrix, text), nil
+}
+
+func getMatrixPushPayload(p *api.PushPayload, matrix *MatrixMeta) (*MatrixPayload, error) {
+	var commitDesc string
+	if len(p.Commits) == 1 {
+		commitDesc = "1 new commit"
+	} else {
+		commitDesc = fmt.Sprintf("%d new commits", len(p.Commits))
+	}
+	if len(p.CompareURL) > 0 {
+		commitDesc = htmlLinkFormatter(p.CompareURL, commitDesc)
+	}
+
+	repoLink := htmlLinkFormatter(p.Repo.HTMLURL, p.Repo.FullName)
+	branchName := git.RefEndName(p.Ref)
+	branchLink := htmlLinkFormatter(p.Repo.HTMLURL+"/src/"+branchName, branchName)
+	text := fmt.Sprintf("[%s:%s] %s pushed by %s", repoLink, branchLink, commitDesc, html.EscapeString(p.Pusher.UserName))
+
+	// for each commit, generate a line
+	for _, commit := range p.Commits {
+		var authorName string
+		if commit.Author != nil {
+			authorName = " - " + html.EscapeString(commit.Author.Name)
+		}
+		text += fmt.Sprintf("<br>%s: %s", htmlLinkFormatter(commit.URL, commit.ID[:7]),
+			html.EscapeString(strings.Split(strings.TrimRight(commit.Message, "\r\n"), "\n")[0])) + authorName
+	}
+
+	return newMatrixPayload(matrix, text), nil
+}
+
+func getMatrixIssuesPayload(p *api.IssuePayload, matrix *MatrixMeta) (*MatrixPayload, error) {
+	text, _, _, _ := getIssuesPayloadInfo(p, htmlLinkFormatter, true)
+
+	return newMatrixPayload(matrix, text), nil
+}
+
+func getMatrixIssueCommentPayload(p *api.IssueCommentPayload, matrix *MatrixMeta) (*MatrixPayload, error) {
+	text, _, _ := getIssueCommentPayloadInfo(p, htmlLinkFormatter, true)
+
+	return newMatrixPayload(matrix, text), nil
+}
+
+func getMatrixPullRequestPayload(p *api.PullRequestPayload, matrix *MatrixMeta) (*MatrixPayload, error) {
+	text, _, _, _ := getPullRequestPayloadInfo(p, htmlLinkFormatter, true)
+
+	return newMatrixPayload(matrix, text), nil
+}
+
+func getMatrixPullRequestApprovalPayload(p *api.PullRequestPayload, matrix *MatrixMeta, event models.HookEventType) (*MatrixPayload, error) {
+	senderLink := htmlLinkFormatter(setting.AppURL+p.Sender.UserName, p.Sender.UserName)
+	titleLink := htmlLinkFormatter(fmt.Sprintf("%s/pulls/%d", p.Repository.HTMLURL, p.Index), fmt.Sprintf("#%d %s", p.Index, p.PullRequest.Title))
+	repoLink := htmlLinkFormatter(p.Repository.HTMLURL, p.Repository.FullName)
+	var text string
+
+	switch p.Action {
+	case api.HookIssueSynchronized:
+		action, err := parseHookPullRequestEventType(event)
+		if err != nil {
+			return nil, err
+		}
+
+		text = fmt.Sprintf("[%s] Pull request review %s: %s by %s", repoLink, action, titleLink, senderLink)
+	}
+
+	return newMatrixPayload(matrix, text), nil
+}
+
+func getMatrixRepositoryPayload(p *api.RepositoryPayload, matrix *MatrixMeta) (*MatrixPayload, error) {
+	senderLink := htmlLinkFormatter(setting.AppURL+p.Sender.UserName, p.Sender.UserName)
+	repoLink := htmlLinkFormatter(p.Repository.HTMLURL, p.Repository.FullName)
+	var text string
+
+	switch p.Action {
+	case api.HookRepoCreated:
+		text = fmt.Sprintf("[%s] Repository created by %s", repoLink, senderLink)
+	case api.HookRepoDeleted:
+		text = fmt.Sprintf("[%s] Repository deleted by %s", html.EscapeString(p.Repository.FullName), senderLink)
+	}
+
+	return newMatrixPayload(matrix, text), nil
+}
+
+func getMatrixReleasePayload(p *api.ReleasePayload, matrix *MatrixMeta) (*MatrixPayload, error) {
+	text, _ := getReleasePayloadInfo(p, htmlLinkFormatter, true)
+
+	return newMatrixPayload(matrix, text), nil
+}
+
+// GetMatrixPayload converts a matrix webhook into a MatrixPayload
+func GetMatrixPayload(p api.Payloader, event models.HookEventType, meta string) (*MatrixPayload, error) {
+	s := new(MatrixPayload)
+
+	matrix := &MatrixMeta{}
+	if err := json.Unmarshal([]byte(meta), &matrix); err != nil {
+		return s, errors.New("GetMatrixPayload meta json:" + err.Error())
+	}
+
+	switch event {
+	case models.HookEventCreate:
+		return getMatrixCreatePayload(p.(*api.CreatePayload), matrix)
+	case models.HookEventDelete:
+		return getMatrixDeletePayload(p.(*api.DeletePayload), matrix)
+	case models.HookEventFork:
+		return getMatrixForkPayload(p.(*api.ForkPayload), matrix)
+	case models.HookEventIssues, models.HookEventIssueAssign, models.HookEventIssueLabel, models.HookEventIssueMilestone:
+		return getMatrixIssuesPayload(p.(*api.IssuePayload), matrix)
+	case models.HookEventIssueComment:
+		return getMatrixIssueCommentPayload(p.(*api.IssueCommentPayload), matrix)
+	case models.HookEventPush:
+		return getMatrixPushPayload(p.(*api.PushPayload), matrix)
+	case models.HookEventPullRequest, models.HookEventPullRequestAssign, models.HookEventPullRequestLabel,
+		models.HookEventPullRequestMilestone, models.HookEventPullRequestSync, models.HookEventPullRequestReviewRequest:
+		return getMatrixPullRequestPayload(p.(*api.PullRequestPayload), matrix)
+	case models.HookEventPullRequestRejected, models.HookEventPullRequestApproved, models.HookEventPullRequestComment:
+		return getMatrixPullRequestApprovalPayload(p.(*api.PullRequestPayload), matrix, event)
+	case models.HookEventRepository:
+		return getMatrixRepositoryPayload(p.(*api.RepositoryPayload), matrix)
+	case models.HookEventRelease:
+		return getMatrixReleasePayload(p.(*api.ReleasePayload), matrix)
+	}
+
+	return s, nil
+}
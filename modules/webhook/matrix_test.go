@@ -0,0 +1,165 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatrixIssuesPayloadOpened(t *testing.T) {
+	p := issueTestPayload()
+	m := &MatrixMeta{
+		MessageType: MatrixMessageTypeText,
+	}
+
+	p.Action = api.HookIssueOpened
+	pl, err := getMatrixIssuesPayload(p, m)
+	require.Nil(t, err)
+	require.NotNil(t, pl)
+
+	assert.Equal(t, "m.text", pl.MsgType)
+	assert.Equal(t, "org.matrix.custom.html", pl.Format)
+	assert.Equal(t, "[<a href=\"http://localhost:3000/test/repo\">test/repo</a>] Issue opened: <a href=\"http://localhost:3000/test/repo/issues/2\">#2 crash</a> by <a href=\"https://try.gitea.io/user1\">user1</a>", pl.FormattedBody)
+	assert.Equal(t, "[test/repo] Issue opened: #2 crash by user1", pl.Body)
+}
+
+func TestMatrixIssueCommentPayload(t *testing.T) {
+	p := issueCommentTestPayload()
+
+	pl, err := getMatrixIssueCommentPayload(p, &MatrixMeta{})
+	require.Nil(t, err)
+	require.NotNil(t, pl)
+
+	// an unknown message type falls back to a notice
+	assert.Equal(t, "m.notice", pl.MsgType)
+	assert.Equal(t, "[test/repo] New comment on issue #2 crash by user1", pl.Body)
+}
+
+func TestMatrixPushPayload(t *testing.T) {
+	p := &api.PushPayload{
+		Ref:        "refs/heads/master",
+		CompareURL: "http://localhost:3000/test/repo/compare/abc...def",
+		Commits: []*api.PayloadCommit{{
+			ID:      "2020558fe2e34debb818a514715839cabd25e778",
+			Message: "fix <script> injection\n\ndetails",
+			URL:     "http://localhost:3000/test/repo/commit/2020558fe2e34debb818a514715839cabd25e778",
+			Author: &api.PayloadUser{
+				Name: "user1",
+			},
+		}},
+		Repo: &api.Repository{
+			HTMLURL:  "http://localhost:3000/test/repo",
+			FullName: "test/repo",
+		},
+		Pusher: &api.User{
+			UserName: "user1",
+		},
+	}
+
+	pl, err := getMatrixPushPayload(p, &MatrixMeta{})
+	require.Nil(t, err)
+	require.NotNil(t, pl)
+
+	assert.Equal(t, "[<a href=\"http://localhost:3000/test/repo\">test/repo</a>:<a href=\"http://localhost:3000/test/repo/src/master\">master</a>] <a href=\"http://localhost:3000/test/repo/compare/abc...def\">1 new commit</a> pushed by user1<br><a href=\"http://localhost:3000/test/repo/commit/2020558fe2e34debb818a514715839cabd25e778\">2020558</a>: fix &lt;script&gt; injection - user1", pl.FormattedBody)
+	assert.Equal(t, "[test/repo:master] 1 new commit pushed by user1\n2020558: fix <script> injection - user1", pl.Body)
+}
+
+func TestMatrixReleasePayload(t *testing.T) {
+	p := pullReleaseTestPayload()
+
+	pl, err := getMatrixReleasePayload(p, &MatrixMeta{})
+	require.Nil(t, err)
+	require.NotNil(t, pl)
+
+	assert.Equal(t, "[test/repo] Release created: v1.0 by user1", pl.Body)
+}
+
+func TestGetMatrixPayload(t *testing.T) {
+	meta, err := json.Marshal(&MatrixMeta{
+		HomeserverURL: "https://matrix.example.com",
+		Room:          "!room:example.com",
+		AccessToken:   "secret",
+		MessageType:   MatrixMessageTypeText,
+	})
+	require.Nil(t, err)
+
+	pl, err := GetMatrixPayload(pullRequestTestPayload(), models.HookEventPullRequest, string(meta))
+	require.Nil(t, err)
+	require.NotNil(t, pl)
+	assert.Equal(t, "m.text", pl.MsgType)
+	assert.Equal(t, "[test/repo] Pull request opened: #2 Fix bug by user1", pl.Body)
+
+	data, err := pl.JSONPayload()
+	require.Nil(t, err)
+	assert.NotContains(t, string(data), "secret")
+}
+
+func TestMatrixSendURL(t *testing.T) {
+	assert.Equal(t, "https://matrix.example.com/_matrix/client/r0/rooms/%21room:example.com/send/m.room.message",
+		MatrixSendURL("https://matrix.example.com/", "!room:example.com"))
+}
+
+func TestDeliverMatrixHookTask(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	webhookHTTPClient = http.DefaultClient
+
+	var request *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	meta, err := json.Marshal(&MatrixMeta{
+		HomeserverURL: server.URL,
+		Room:          "!room:example.com",
+		AccessToken:   "secret",
+		MessageType:   MatrixMessageTypeNotice,
+	})
+	assert.NoError(t, err)
+	w := &models.Webhook{
+		RepoID:       1,
+		URL:          MatrixSendURL(server.URL, "!room:example.com"),
+		ContentType:  models.ContentTypeJSON,
+		HTTPMethod:   http.MethodPut,
+		HookEvent:    &models.HookEvent{PushOnly: true},
+		IsActive:     true,
+		HookTaskType: models.MATRIX,
+		Meta:         string(meta),
+	}
+	assert.NoError(t, w.UpdateEvent())
+	assert.NoError(t, models.CreateWebhook(w))
+
+	hookTask := &models.HookTask{
+		RepoID:      1,
+		HookID:      w.ID,
+		Type:        models.MATRIX,
+		URL:         w.URL,
+		HTTPMethod:  w.HTTPMethod,
+		ContentType: w.ContentType,
+		Payloader:   &MatrixPayload{Body: "test", MsgType: MatrixMessageTypeNotice},
+	}
+	assert.NoError(t, models.CreateHookTask(hookTask))
+
+	assert.NoError(t, Deliver(hookTask))
+	require.NotNil(t, request)
+	assert.Equal(t, http.MethodPut, request.Method)
+	assert.Equal(t, "/_matrix/client/r0/rooms/!room:example.com/send/m.room.message/"+hookTask.UUID, request.URL.Path)
+	assert.Equal(t, "Bearer secret", request.Header.Get("Authorization"))
+
+	// the access token is not recorded
+	hookTask = models.AssertExistsAndLoadBean(t, &models.HookTask{ID: hookTask.ID}).(*models.HookTask)
+	assert.True(t, hookTask.IsSucceed)
+	assert.NotContains(t, hookTask.PayloadContent, "secret")
+	assert.Equal(t, "******", hookTask.RequestInfo.Headers["Authorization"])
+}
@@ -555,13 +555,14 @@ func GetMSTeamsPayload(p api.Payloader, event models.HookEventType, meta string)
 		return getMSTeamsDeletePayload(p.(*api.DeletePayload))
 	case models.HookEventFork:
 		return getMSTeamsForkPayload(p.(*api.ForkPayload))
-	case models.HookEventIssues:
+	case models.HookEventIssues, models.HookEventIssueAssign, models.HookEventIssueLabel, models.HookEventIssueMilestone:
 		return getMSTeamsIssuesPayload(p.(*api.IssuePayload))
 	case models.HookEventIssueComment:
 		return getMSTeamsIssueCommentPayload(p.(*api.IssueCommentPayload))
 	case models.HookEventPush:
 		return getMSTeamsPushPayload(p.(*api.PushPayload))
-	case models.HookEventPullRequest:
+	case models.HookEventPullRequest, models.HookEventPullRequestAssign, models.HookEventPullRequestLabel,
+		models.HookEventPullRequestMilestone, models.HookEventPullRequestSync, models.HookEventPullRequestReviewRequest:
 		return getMSTeamsPullRequestPayload(p.(*api.PullRequestPayload))
 	case models.HookEventPullRequestRejected, models.HookEventPullRequestApproved, models.HookEventPullRequestComment:
 		return getMSTeamsPullRequestApprovalPayload(p.(*api.PullRequestPayload), event)
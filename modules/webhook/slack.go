@@ -324,13 +324,14 @@ func GetSlackPayload(p api.Payloader, event models.HookEventType, meta string) (
 		return getSlackDeletePayload(p.(*api.DeletePayload), slack)
 	case models.HookEventFork:
 		return getSlackForkPayload(p.(*api.ForkPayload), slack)
-	case models.HookEventIssues:
+	case models.HookEventIssues, models.HookEventIssueAssign, models.HookEventIssueLabel, models.HookEventIssueMilestone:
 		return getSlackIssuesPayload(p.(*api.IssuePayload), slack)
 	case models.HookEventIssueComment:
 		return getSlackIssueCommentPayload(p.(*api.IssueCommentPayload), slack)
 	case models.HookEventPush:
 		return getSlackPushPayload(p.(*api.PushPayload), slack)
-	case models.HookEventPullRequest:
+	case models.HookEventPullRequest, models.HookEventPullRequestAssign, models.HookEventPullRequestLabel,
+		models.HookEventPullRequestMilestone, models.HookEventPullRequestSync, models.HookEventPullRequestReviewRequest:
 		return getSlackPullRequestPayload(p.(*api.PullRequestPayload), slack)
 	case models.HookEventPullRequestRejected, models.HookEventPullRequestApproved, models.HookEventPullRequestComment:
 		return getSlackPullRequestApprovalPayload(p.(*api.PullRequestPayload), slack, event)
@@ -203,13 +203,14 @@ func GetTelegramPayload(p api.Payloader, event models.HookEventType, meta string
 		return getTelegramDeletePayload(p.(*api.DeletePayload))
 	case models.HookEventFork:
 		return getTelegramForkPayload(p.(*api.ForkPayload))
-	case models.HookEventIssues:
+	case models.HookEventIssues, models.HookEventIssueAssign, models.HookEventIssueLabel, models.HookEventIssueMilestone:
 		return getTelegramIssuesPayload(p.(*api.IssuePayload))
 	case models.HookEventIssueComment:
 		return getTelegramIssueCommentPayload(p.(*api.IssueCommentPayload))
 	case models.HookEventPush:
 		return getTelegramPushPayload(p.(*api.PushPayload))
-	case models.HookEventPullRequest:
+	case models.HookEventPullRequest, models.HookEventPullRequestAssign, models.HookEventPullRequestLabel,
+		models.HookEventPullRequestMilestone, models.HookEventPullRequestSync, models.HookEventPullRequestReviewRequest:
 		return getTelegramPullRequestPayload(p.(*api.PullRequestPayload))
 	case models.HookEventPullRequestRejected, models.HookEventPullRequestApproved, models.HookEventPullRequestComment:
 		return getTelegramPullRequestApprovalPayload(p.(*api.PullRequestPayload), event)
@@ -14,15 +14,10 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
-	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
-	"code.gitea.io/gitea/modules/sync"
 	"github.com/gobwas/glob"
 )
 
-// hookQueue is a global queue of web hooks
-var hookQueue = sync.NewUniqueQueue(setting.Webhook.QueueLength)
-
 // getPayloadBranch returns branch for hook event, if applicable.
 func getPayloadBranch(p api.Payloader) string {
 	switch pp := p.(type) {
@@ -44,12 +39,18 @@ func getPayloadBranch(p api.Payloader) string {
 
 // PrepareWebhook adds special webhook to task queue for given payload.
 func PrepareWebhook(w *models.Webhook, repo *models.Repository, event models.HookEventType, p api.Payloader) error {
-	if err := prepareWebhook(w, repo, event, p); err != nil {
-		return err
+	return prepareWebhook(w, repo, event, p)
+}
+
+// Redeliver adds a new hook task with the payload of the given one to the task queue.
+func Redeliver(t *models.HookTask) (*models.HookTask, error) {
+	newTask, err := models.RedeliverHookTask(t)
+	if err != nil {
+		return nil, err
 	}
 
-	go hookQueue.Add(repo.ID)
-	return nil
+	enqueueHookTask(newTask.ID)
+	return newTask, nil
 }
 
 func checkBranch(w *models.Webhook, branch string) bool {
@@ -114,6 +115,11 @@ func prepareWebhook(w *models.Webhook, repo *models.Repository, event models.Hoo
 		if err != nil {
 			return fmt.Errorf("GetMSTeamsPayload: %v", err)
 		}
+	case models.MATRIX:
+		payloader, err = GetMatrixPayload(p, event, w.Meta)
+		if err != nil {
+			return fmt.Errorf("GetMatrixPayload: %v", err)
+		}
 	default:
 		p.SetSecret(w.Secret)
 		payloader = p
@@ -133,7 +139,7 @@ func prepareWebhook(w *models.Webhook, repo *models.Repository, event models.Hoo
 		signature = hex.EncodeToString(sig.Sum(nil))
 	}
 
-	if err = models.CreateHookTask(&models.HookTask{
+	t := &models.HookTask{
 		RepoID:      repo.ID,
 		HookID:      w.ID,
 		Type:        w.HookTaskType,
@@ -144,20 +150,18 @@ func prepareWebhook(w *models.Webhook, repo *models.Repository, event models.Hoo
 		ContentType: w.ContentType,
 		EventType:   event,
 		IsSSL:       w.IsSSL,
-	}); err != nil {
+	}
+	if err = models.CreateHookTask(t); err != nil {
 		return fmt.Errorf("CreateHookTask: %v", err)
 	}
+
+	enqueueHookTask(t.ID)
 	return nil
 }
 
 // PrepareWebhooks adds new webhooks to task queue for given payload.
 func PrepareWebhooks(repo *models.Repository, event models.HookEventType, p api.Payloader) error {
-	if err := prepareWebhooks(repo, event, p); err != nil {
-		return err
-	}
-
-	go hookQueue.Add(repo.ID)
-	return nil
+	return prepareWebhooks(repo, event, p)
 }
 
 func prepareWebhooks(repo *models.Repository, event models.HookEventType, p api.Payloader) error {
@@ -176,6 +180,13 @@ func prepareWebhooks(repo *models.Repository, event models.HookEventType, p api.
 		ws = append(ws, orgHooks...)
 	}
 
+	// Add any admin-defined system webhooks
+	systemHooks, err := models.GetActiveSystemWebhooks()
+	if err != nil {
+		return fmt.Errorf("GetActiveSystemWebhooks: %v", err)
+	}
+	ws = append(ws, systemHooks...)
+
 	if len(ws) == 0 {
 		return nil
 	}
@@ -74,6 +74,30 @@ func TestPrepareWebhooksBranchFilterNoMatch(t *testing.T) {
 	}
 }
 
+func TestPrepareWebhooksSystemWebhook(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	w := &models.Webhook{
+		IsSystemWebhook: true,
+		URL:             "www.example.com/system",
+		ContentType:     models.ContentTypeJSON,
+		HookEvent:       &models.HookEvent{PushOnly: true},
+		IsActive:        true,
+		HookTaskType:    models.GITEA,
+	}
+	assert.NoError(t, w.UpdateEvent())
+	assert.NoError(t, models.CreateWebhook(w))
+
+	// system webhooks fire for repositories of users and organizations alike
+	for _, repoID := range []int64{1, 3} {
+		repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: repoID}).(*models.Repository)
+		hookTask := &models.HookTask{RepoID: repo.ID, HookID: w.ID, EventType: models.HookEventPush}
+		models.AssertNotExistsBean(t, hookTask)
+		assert.NoError(t, PrepareWebhooks(repo, models.HookEventPush, &api.PushPayload{}))
+		models.AssertExistsAndLoadBean(t, hookTask)
+	}
+}
+
 // TODO TestHookTask_deliver
 
 // TODO TestDeliverHooks
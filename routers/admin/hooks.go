@@ -16,16 +16,27 @@ const (
 	tplAdminHooks base.TplName = "admin/hooks"
 )
 
-// DefaultWebhooks render admin-default webhook list page
-func DefaultWebhooks(ctx *context.Context) {
-	ctx.Data["Title"] = ctx.Tr("admin.hooks")
-	ctx.Data["PageIsAdminHooks"] = true
-	ctx.Data["BaseLink"] = setting.AppSubURL + "/admin/hooks"
-	ctx.Data["Description"] = ctx.Tr("admin.hooks.desc")
-
-	ws, err := models.GetDefaultWebhooks()
+// DefaultOrSystemWebhooks renders both admin default and system webhook list pages
+func DefaultOrSystemWebhooks(ctx *context.Context) {
+	var ws []*models.Webhook
+	var err error
+
+	if ctx.Params(":configType") == "system-hooks" {
+		ctx.Data["Title"] = ctx.Tr("admin.systemhooks")
+		ctx.Data["PageIsAdminSystemHooks"] = true
+		ctx.Data["BaseLink"] = setting.AppSubURL + "/admin/system-hooks"
+		ctx.Data["Description"] = ctx.Tr("admin.systemhooks.desc")
+		ws, err = models.GetSystemWebhooks()
+	} else {
+		ctx.Data["Title"] = ctx.Tr("admin.hooks")
+		ctx.Data["PageIsAdminHooks"] = true
+		ctx.Data["BaseLink"] = setting.AppSubURL + "/admin/hooks"
+		ctx.Data["Description"] = ctx.Tr("admin.hooks.desc")
+		ws, err = models.GetDefaultWebhooks()
+	}
+
 	if err != nil {
-		ctx.ServerError("GetWebhooksDefaults", err)
+		ctx.ServerError("GetWebhooksAdmin", err)
 		return
 	}
 
@@ -33,15 +44,15 @@ func DefaultWebhooks(ctx *context.Context) {
 	ctx.HTML(200, tplAdminHooks)
 }
 
-// DeleteDefaultWebhook response for delete admin-default webhook
-func DeleteDefaultWebhook(ctx *context.Context) {
-	if err := models.DeleteDefaultWebhook(ctx.QueryInt64("id")); err != nil {
+// DeleteDefaultOrSystemWebhook handler to delete an admin-defined system or default webhook
+func DeleteDefaultOrSystemWebhook(ctx *context.Context) {
+	if err := models.DeleteDefaultSystemWebhook(ctx.QueryInt64("id")); err != nil {
 		ctx.Flash.Error("DeleteDefaultWebhook: " + err.Error())
 	} else {
 		ctx.Flash.Success(ctx.Tr("repo.settings.webhook_deletion_success"))
 	}
 
 	ctx.JSON(200, map[string]interface{}{
-		"redirect": setting.AppSubURL + "/admin/hooks",
+		"redirect": setting.AppSubURL + "/admin/" + ctx.Params(":configType"),
 	})
 }
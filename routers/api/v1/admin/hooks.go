@@ -0,0 +1,184 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// ListHooks list the admin default and system webhooks
+func ListHooks(ctx *context.APIContext) {
+	// swagger:operation GET /admin/hooks admin adminListHooks
+	// ---
+	// summary: List the default and system webhooks
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: type
+	//   in: query
+	//   description: kind of the hooks to list, all of them if empty
+	//   type: string
+	//   enum: [default, system]
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/HookList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+
+	var ws []*models.Webhook
+	kind := ctx.Query("type")
+	if kind != "system" {
+		defaultHooks, err := models.GetDefaultWebhooks()
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetDefaultWebhooks", err)
+			return
+		}
+		ws = append(ws, defaultHooks...)
+	}
+	if kind != "default" {
+		systemHooks, err := models.GetSystemWebhooks()
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetSystemWebhooks", err)
+			return
+		}
+		ws = append(ws, systemHooks...)
+	}
+
+	hooks := make([]*api.Hook, len(ws))
+	for i, hook := range ws {
+		hooks[i] = convert.ToHook(setting.AppURL+"admin", hook)
+	}
+	ctx.JSON(http.StatusOK, hooks)
+}
+
+// GetHook get an admin default or system hook by id
+func GetHook(ctx *context.APIContext) {
+	// swagger:operation GET /admin/hooks/{id} admin adminGetHook
+	// ---
+	// summary: Get a default or system hook
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the hook to get
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Hook"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	hook, err := utils.GetAdminHook(ctx, ctx.ParamsInt64(":id"))
+	if err != nil {
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToHook(setting.AppURL+"admin", hook))
+}
+
+// CreateHook create a default or system hook
+func CreateHook(ctx *context.APIContext, form api.CreateHookOption) {
+	// swagger:operation POST /admin/hooks admin adminCreateHook
+	// ---
+	// summary: Create a default or system hook
+	// description: Default hooks are copied into every newly created repository,
+	//              system hooks fire for all the repositories.
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/CreateHookOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/Hook"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+
+	if !utils.CheckCreateHookOption(ctx, &form) {
+		return
+	}
+	utils.AddAdminHook(ctx, &form)
+}
+
+// EditHook modify a default or system hook
+func EditHook(ctx *context.APIContext, form api.EditHookOption) {
+	// swagger:operation PATCH /admin/hooks/{id} admin adminEditHook
+	// ---
+	// summary: Update a default or system hook
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the hook to update
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditHookOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Hook"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	utils.EditAdminHook(ctx, &form, ctx.ParamsInt64(":id"))
+}
+
+// DeleteHook delete a default or system hook
+func DeleteHook(ctx *context.APIContext) {
+	// swagger:operation DELETE /admin/hooks/{id} admin adminDeleteHook
+	// ---
+	// summary: Delete a default or system hook
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the hook to delete
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	hookID := ctx.ParamsInt64(":id")
+	if err := models.DeleteDefaultSystemWebhook(hookID); err != nil {
+		if models.IsErrWebhookNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "DeleteDefaultSystemWebhook", err)
+		}
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
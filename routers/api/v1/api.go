@@ -59,6 +59,7 @@
 package v1
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -70,6 +71,7 @@ import (
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/routers/api/v1/admin"
 	"code.gitea.io/gitea/routers/api/v1/misc"
+	"code.gitea.io/gitea/routers/api/v1/notify"
 	"code.gitea.io/gitea/routers/api/v1/org"
 	"code.gitea.io/gitea/routers/api/v1/repo"
 	_ "code.gitea.io/gitea/routers/api/v1/swagger" // for swagger generation
@@ -153,6 +155,11 @@ func repoAssignment() macaron.Handler {
 			return
 		}
 
+		if token, ok := ctx.Data["ApiToken"].(*models.AccessToken); ok && !token.CanAccessRepo(repo) {
+			ctx.NotFound()
+			return
+		}
+
 		repo.Owner = owner
 		ctx.Repo.Repository = repo
 
@@ -187,6 +194,63 @@ func reqToken() macaron.Handler {
 	}
 }
 
+// tokenRequiresScopes checks that the access token used to sign in, if any, grants read access
+// to the given category for GET and HEAD requests and write access for all other requests.
+func tokenRequiresScopes(category models.AccessTokenScopeCategory) macaron.Handler {
+	return func(ctx *context.APIContext) {
+		level := models.AccessTokenScopeLevelWrite
+		if ctx.Req.Method == "GET" || ctx.Req.Method == "HEAD" {
+			level = models.AccessTokenScopeLevelRead
+		}
+		checkTokenScope(ctx, category, level)
+	}
+}
+
+// tokenRequiresReadScope checks that the access token used to sign in, if any, grants read access
+// to the given category, it is used by routes which only read data whatever their method is.
+func tokenRequiresReadScope(category models.AccessTokenScopeCategory) macaron.Handler {
+	return func(ctx *context.APIContext) {
+		checkTokenScope(ctx, category, models.AccessTokenScopeLevelRead)
+	}
+}
+
+func checkTokenScope(ctx *context.APIContext, category models.AccessTokenScopeCategory, level models.AccessTokenScopeLevel) {
+	token, ok := ctx.Data["ApiToken"].(*models.AccessToken)
+	if !ok {
+		return
+	}
+	if !token.Scope.HasScope(category, level) {
+		ctx.Error(http.StatusForbidden, "tokenRequiresScopes", fmt.Sprintf("token does not have the required scope %s:%s", level, category))
+	}
+}
+
+// reqUnrestrictedToken rejects access tokens which are restricted to some repositories or organizations
+func reqUnrestrictedToken() macaron.Handler {
+	return func(ctx *context.APIContext) {
+		if token, ok := ctx.Data["ApiToken"].(*models.AccessToken); ok && token.IsRestricted() {
+			ctx.Error(http.StatusForbidden, "reqUnrestrictedToken", "token is restricted to some repositories or organizations")
+			return
+		}
+	}
+}
+
+// tokenSiteAdmin drops the site admin privileges of the signed in user if the
+// request is authenticated by an access token without the admin scope.
+func tokenSiteAdmin() macaron.Handler {
+	return func(ctx *context.APIContext) {
+		token, ok := ctx.Data["ApiToken"].(*models.AccessToken)
+		if !ok || !ctx.IsSigned || !ctx.User.IsAdmin {
+			return
+		}
+		if !token.Scope.HasScope(models.AccessTokenScopeCategoryAdmin, models.AccessTokenScopeLevelRead) {
+			// work on a copy so the change can never be saved
+			user := *ctx.User
+			user.IsAdmin = false
+			ctx.User = &user
+		}
+	}
+}
+
 func reqBasicAuth() macaron.Handler {
 	return func(ctx *context.APIContext) {
 		if !ctx.Context.IsBasicAuth {
@@ -404,6 +468,14 @@ func orgAssignment(args ...bool) macaron.Handler {
 				return
 			}
 		}
+
+		if token, ok := ctx.Data["ApiToken"].(*models.AccessToken); ok {
+			if (ctx.Org.Organization != nil && !token.CanAccessOrg(ctx.Org.Organization.ID)) ||
+				(ctx.Org.Team != nil && !token.CanAccessOrg(ctx.Org.Team.OrgID)) {
+				ctx.NotFound()
+				return
+			}
+		}
 	}
 }
 
@@ -509,26 +581,37 @@ func RegisterRoutes(m *macaron.Macaron) {
 		}
 		m.Get("/version", misc.Version)
 		m.Get("/signing-key.gpg", misc.SigningKey)
-		m.Post("/markdown", bind(api.MarkdownOption{}), misc.Markdown)
-		m.Post("/markdown/raw", misc.MarkdownRaw)
+		m.Post("/markdown", tokenRequiresReadScope(models.AccessTokenScopeCategoryRepository), bind(api.MarkdownOption{}), misc.Markdown)
+		m.Post("/markdown/raw", tokenRequiresReadScope(models.AccessTokenScopeCategoryRepository), misc.MarkdownRaw)
 
 		// Users
 		m.Group("/users", func() {
-			m.Get("/search", user.Search)
+			m.Get("/search", tokenRequiresScopes(models.AccessTokenScopeCategoryUser), user.Search)
 
 			m.Group("/:username", func() {
 				m.Get("", user.GetInfo)
 				m.Get("/heatmap", mustEnableUserHeatmap, user.GetUserHeatmapData)
 
-				m.Get("/repos", user.ListUserRepos)
 				m.Group("/tokens", func() {
 					m.Combo("").Get(user.ListAccessTokens).
 						Post(bind(api.CreateAccessTokenOption{}), user.CreateAccessToken)
 					m.Combo("/:id").Delete(user.DeleteAccessToken)
 				}, reqBasicAuth())
-			})
+			}, tokenRequiresScopes(models.AccessTokenScopeCategoryUser))
+			m.Get("/:username/repos", tokenRequiresScopes(models.AccessTokenScopeCategoryRepository), user.ListUserRepos)
 		})
 
+		// Notifications
+		m.Group("/notifications", func() {
+			m.Combo("").
+				Get(notify.ListNotifications).
+				Put(notify.ReadNotifications)
+			m.Get("/new", notify.NewAvailable)
+			m.Combo("/threads/:id").
+				Get(notify.GetThread).
+				Patch(notify.ReadThread)
+		}, reqToken(), tokenRequiresScopes(models.AccessTokenScopeCategoryUser))
+
 		m.Group("/users", func() {
 			m.Group("/:username", func() {
 				m.Get("/keys", user.ListPublicKeys)
@@ -544,7 +627,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 
 				m.Get("/subscriptions", user.GetWatchedRepos)
 			})
-		}, reqToken())
+		}, reqToken(), tokenRequiresScopes(models.AccessTokenScopeCategoryUser))
 
 		m.Group("/user", func() {
 			m.Get("", user.GetAuthenticatedUser)
@@ -572,9 +655,6 @@ func RegisterRoutes(m *macaron.Macaron) {
 					Delete(user.DeleteGPGKey)
 			})
 
-			m.Combo("/repos").Get(user.ListMyRepos).
-				Post(bind(api.CreateRepoOption{}), repo.Create)
-
 			m.Group("/starred", func() {
 				m.Get("", user.GetMyStarredRepos)
 				m.Group("/:username/:reponame", func() {
@@ -583,28 +663,29 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Delete("", user.Unstar)
 				}, repoAssignment())
 			})
-			m.Get("/times", repo.ListMyTrackedTimes)
-
-			m.Get("/stopwatches", repo.GetStopwatches)
-
 			m.Get("/subscriptions", user.GetMyWatchedRepos)
+		}, reqToken(), tokenRequiresScopes(models.AccessTokenScopeCategoryUser))
 
-			m.Get("/teams", org.ListUserTeams)
-		}, reqToken())
+		m.Group("/user", func() {
+			m.Get("/times", repo.ListMyTrackedTimes)
+			m.Get("/stopwatches", repo.GetStopwatches)
+		}, reqToken(), tokenRequiresScopes(models.AccessTokenScopeCategoryIssue))
 
 		// Repositories
-		m.Post("/org/:org/repos", reqToken(), bind(api.CreateRepoOption{}), repo.CreateOrgRepo)
+		m.Combo("/user/repos", reqToken(), tokenRequiresScopes(models.AccessTokenScopeCategoryRepository)).Get(user.ListMyRepos).
+			Post(reqUnrestrictedToken(), bind(api.CreateRepoOption{}), repo.Create)
+		m.Post("/org/:org/repos", reqToken(), tokenRequiresScopes(models.AccessTokenScopeCategoryRepository), orgAssignment(true), bind(api.CreateRepoOption{}), repo.CreateOrgRepo)
 
 		m.Group("/repos", func() {
-			m.Get("/search", repo.Search)
+			m.Get("/search", tokenRequiresScopes(models.AccessTokenScopeCategoryRepository), repo.Search)
 		})
 
-		m.Get("/repos/issues/search", repo.SearchIssues)
+		m.Get("/repos/issues/search", tokenRequiresScopes(models.AccessTokenScopeCategoryIssue), repo.SearchIssues)
 
-		m.Combo("/repositories/:id", reqToken()).Get(repo.GetByID)
+		m.Combo("/repositories/:id", reqToken(), tokenRequiresScopes(models.AccessTokenScopeCategoryRepository)).Get(repo.GetByID)
 
 		m.Group("/repos", func() {
-			m.Post("/migrate", reqToken(), bind(auth.MigrateRepoForm{}), repo.Migrate)
+			m.Post("/migrate", reqToken(), tokenRequiresScopes(models.AccessTokenScopeCategoryRepository), reqUnrestrictedToken(), bind(auth.MigrateRepoForm{}), repo.Migrate)
 
 			m.Group("/:username/:reponame", func() {
 				m.Combo("").Get(reqAnyRepoReader(), repo.Get).
@@ -618,6 +699,11 @@ func RegisterRoutes(m *macaron.Macaron) {
 							Patch(bind(api.EditHookOption{}), repo.EditHook).
 							Delete(repo.DeleteHook)
 						m.Post("/tests", context.RepoRef(), repo.TestHook)
+						m.Group("/deliveries", func() {
+							m.Get("", repo.ListHookDeliveries)
+							m.Get("/:delivery", repo.GetHookDelivery)
+							m.Post("/:delivery/redeliver", repo.RedeliverHookDelivery)
+						})
 					})
 					m.Group("/git", func() {
 						m.Combo("").Get(repo.ListGitHooks)
@@ -642,6 +728,13 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Get("", repo.ListBranches)
 					m.Get("/*", context.RepoRefByType(context.RepoRefBranch), repo.GetBranch)
 				}, reqRepoReader(models.UnitTypeCode))
+				m.Group("/branch_protections", func() {
+					m.Get("", repo.ListBranchProtections)
+					m.Post("", bind(api.CreateBranchProtectionOption{}), repo.CreateBranchProtection)
+					m.Combo("/*").Get(repo.GetBranchProtection).
+						Patch(bind(api.EditBranchProtectionOption{}), repo.EditBranchProtection).
+						Delete(repo.DeleteBranchProtection)
+				}, reqToken(), reqAdmin())
 				m.Group("/tags", func() {
 					m.Get("", repo.ListTags)
 				}, reqRepoReader(models.UnitTypeCode), context.ReferencesGitRepo(true))
@@ -651,81 +744,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Combo("/:id").Get(repo.GetDeployKey).
 						Delete(repo.DeleteDeploykey)
 				}, reqToken(), reqAdmin())
-				m.Group("/times", func() {
-					m.Combo("").Get(repo.ListTrackedTimesByRepository)
-					m.Combo("/:timetrackingusername").Get(repo.ListTrackedTimesByUser)
-				}, mustEnableIssues)
-				m.Group("/issues", func() {
-					m.Combo("").Get(repo.ListIssues).
-						Post(reqToken(), mustNotBeArchived, bind(api.CreateIssueOption{}), repo.CreateIssue)
-					m.Group("/comments", func() {
-						m.Get("", repo.ListRepoIssueComments)
-						m.Group("/:id", func() {
-							m.Combo("", reqToken()).
-								Patch(mustNotBeArchived, bind(api.EditIssueCommentOption{}), repo.EditIssueComment).
-								Delete(repo.DeleteIssueComment)
-							m.Combo("/reactions").
-								Get(repo.GetIssueCommentReactions).
-								Post(bind(api.EditReactionOption{}), reqToken(), repo.PostIssueCommentReaction).
-								Delete(bind(api.EditReactionOption{}), reqToken(), repo.DeleteIssueCommentReaction)
-						})
-					})
-					m.Group("/:index", func() {
-						m.Combo("").Get(repo.GetIssue).
-							Patch(reqToken(), bind(api.EditIssueOption{}), repo.EditIssue)
-						m.Group("/comments", func() {
-							m.Combo("").Get(repo.ListIssueComments).
-								Post(reqToken(), mustNotBeArchived, bind(api.CreateIssueCommentOption{}), repo.CreateIssueComment)
-							m.Combo("/:id", reqToken()).Patch(bind(api.EditIssueCommentOption{}), repo.EditIssueCommentDeprecated).
-								Delete(repo.DeleteIssueCommentDeprecated)
-						})
-						m.Group("/labels", func() {
-							m.Combo("").Get(repo.ListIssueLabels).
-								Post(reqToken(), bind(api.IssueLabelsOption{}), repo.AddIssueLabels).
-								Put(reqToken(), bind(api.IssueLabelsOption{}), repo.ReplaceIssueLabels).
-								Delete(reqToken(), repo.ClearIssueLabels)
-							m.Delete("/:id", reqToken(), repo.DeleteIssueLabel)
-						})
-						m.Group("/times", func() {
-							m.Combo("", reqToken()).
-								Get(repo.ListTrackedTimes).
-								Post(bind(api.AddTimeOption{}), repo.AddTime).
-								Delete(repo.ResetIssueTime)
-							m.Delete("/:id", reqToken(), repo.DeleteTime)
-						})
-						m.Combo("/deadline").Post(reqToken(), bind(api.EditDeadlineOption{}), repo.UpdateIssueDeadline)
-						m.Group("/stopwatch", func() {
-							m.Post("/start", reqToken(), repo.StartIssueStopwatch)
-							m.Post("/stop", reqToken(), repo.StopIssueStopwatch)
-							m.Delete("/delete", reqToken(), repo.DeleteIssueStopwatch)
-						})
-						m.Group("/subscriptions", func() {
-							m.Get("", repo.GetIssueSubscribers)
-							m.Put("/:user", reqToken(), repo.AddIssueSubscription)
-							m.Delete("/:user", reqToken(), repo.DelIssueSubscription)
-						})
-						m.Combo("/reactions").
-							Get(repo.GetIssueReactions).
-							Post(bind(api.EditReactionOption{}), reqToken(), repo.PostIssueReaction).
-							Delete(bind(api.EditReactionOption{}), reqToken(), repo.DeleteIssueReaction)
-					})
-				}, mustEnableIssuesOrPulls)
-				m.Group("/labels", func() {
-					m.Combo("").Get(repo.ListLabels).
-						Post(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), bind(api.CreateLabelOption{}), repo.CreateLabel)
-					m.Combo("/:id").Get(repo.GetLabel).
-						Patch(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), bind(api.EditLabelOption{}), repo.EditLabel).
-						Delete(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), repo.DeleteLabel)
-				})
 				m.Post("/markdown", bind(api.MarkdownOption{}), misc.Markdown)
 				m.Post("/markdown/raw", misc.MarkdownRaw)
-				m.Group("/milestones", func() {
-					m.Combo("").Get(repo.ListMilestones).
-						Post(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), bind(api.CreateMilestoneOption{}), repo.CreateMilestone)
-					m.Combo("/:id").Get(repo.GetMilestone).
-						Patch(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), bind(api.EditMilestoneOption{}), repo.EditMilestone).
-						Delete(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), repo.DeleteMilestone)
-				})
 				m.Get("/stargazers", repo.ListStargazers)
 				m.Get("/subscribers", repo.ListSubscribers)
 				m.Group("/subscription", func() {
@@ -750,6 +770,15 @@ func RegisterRoutes(m *macaron.Macaron) {
 					})
 				}, reqRepoReader(models.UnitTypeReleases))
 				m.Post("/mirror-sync", reqToken(), reqRepoWriter(models.UnitTypeCode), repo.MirrorSync)
+				m.Group("/push_mirrors", func() {
+					m.Combo("").Get(repo.ListPushMirrors).
+						Post(bind(api.CreatePushMirrorOption{}), repo.AddPushMirror)
+					m.Group("/:id", func() {
+						m.Combo("").Get(repo.GetPushMirror).
+							Delete(repo.DeletePushMirror)
+						m.Post("/sync", repo.PushMirrorSync)
+					})
+				}, reqToken(), reqAdmin())
 				m.Get("/editorconfig/:filename", context.RepoRef(), reqRepoReader(models.UnitTypeCode), repo.GetEditorconfig)
 				m.Group("/pulls", func() {
 					m.Combo("").Get(bind(api.ListPullRequestsOptions{}), repo.ListPullRequests).
@@ -757,14 +786,36 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Group("/:index", func() {
 						m.Combo("").Get(repo.GetPullRequest).
 							Patch(reqToken(), reqRepoWriter(models.UnitTypePullRequests), bind(api.EditPullRequestOption{}), repo.EditPullRequest)
+						m.Get(".diff", repo.DownloadPullDiff)
+						m.Get(".patch", repo.DownloadPullPatch)
+						m.Get("/files", repo.GetPullRequestFiles)
 						m.Combo("/merge").Get(repo.IsPullRequestMerged).
 							Post(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypePullRequests), bind(auth.MergePullRequestForm{}), repo.MergePullRequest)
+						m.Group("/reviews", func() {
+							m.Combo("").
+								Get(repo.ListPullReviews).
+								Post(reqToken(), mustNotBeArchived, bind(api.CreatePullReviewOptions{}), repo.CreatePullReview)
+							m.Group("/:id", func() {
+								m.Combo("").
+									Get(repo.GetPullReview).
+									Delete(reqToken(), repo.DeletePullReview).
+									Post(reqToken(), mustNotBeArchived, bind(api.SubmitPullReviewOptions{}), repo.SubmitPullReview)
+								m.Combo("/comments").
+									Get(repo.GetPullReviewComments).
+									Post(reqToken(), mustNotBeArchived, bind(api.CreatePullReviewComment{}), repo.CreatePullReviewComment)
+								m.Post("/dismissals", reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypePullRequests), repo.DismissPullReview)
+							})
+						})
+						m.Combo("/requested_reviewers").
+							Post(reqToken(), mustNotBeArchived, bind(api.PullReviewRequestOptions{}), repo.CreateReviewRequests).
+							Delete(reqToken(), mustNotBeArchived, bind(api.PullReviewRequestOptions{}), repo.DeleteReviewRequests)
 					})
 				}, mustAllowPulls, reqRepoReader(models.UnitTypeCode), context.ReferencesGitRepo(false))
 				m.Group("/statuses", func() {
 					m.Combo("/:sha").Get(repo.GetCommitStatuses).
 						Post(reqToken(), bind(api.CreateStatusOption{}), repo.NewCommitStatus)
 				}, reqRepoReader(models.UnitTypeCode))
+				m.Get("/compare/*", reqRepoReader(models.UnitTypeCode), context.ReferencesGitRepo(false), repo.CompareDiff)
 				m.Group("/commits", func() {
 					m.Get("", repo.GetAllCommits)
 					m.Group("/:ref", func() {
@@ -801,15 +852,115 @@ func RegisterRoutes(m *macaron.Macaron) {
 							Delete(reqToken(), repo.DeleteTopic)
 					}, reqAdmin())
 				}, reqAnyRepoReader())
-			}, repoAssignment())
+			}, tokenRequiresScopes(models.AccessTokenScopeCategoryRepository), repoAssignment())
 		})
 
+		m.Group("/repos/:username/:reponame", func() {
+			m.Group("/times", func() {
+				m.Combo("").Get(repo.ListTrackedTimesByRepository)
+				m.Combo("/:timetrackingusername").Get(repo.ListTrackedTimesByUser)
+			}, mustEnableIssues)
+			m.Group("/issues", func() {
+				m.Combo("").Get(repo.ListIssues).
+					Post(reqToken(), mustNotBeArchived, bind(api.CreateIssueOption{}), repo.CreateIssue)
+				m.Group("/comments", func() {
+					m.Get("", repo.ListRepoIssueComments)
+					m.Group("/:id", func() {
+						m.Combo("", reqToken()).
+							Patch(mustNotBeArchived, bind(api.EditIssueCommentOption{}), repo.EditIssueComment).
+							Delete(repo.DeleteIssueComment)
+						m.Combo("/reactions").
+							Get(repo.GetIssueCommentReactions).
+							Post(bind(api.EditReactionOption{}), reqToken(), repo.PostIssueCommentReaction).
+							Delete(bind(api.EditReactionOption{}), reqToken(), repo.DeleteIssueCommentReaction)
+					})
+				})
+				m.Group("/:index", func() {
+					m.Combo("").Get(repo.GetIssue).
+						Patch(reqToken(), bind(api.EditIssueOption{}), repo.EditIssue)
+					m.Group("/comments", func() {
+						m.Combo("").Get(repo.ListIssueComments).
+							Post(reqToken(), mustNotBeArchived, bind(api.CreateIssueCommentOption{}), repo.CreateIssueComment)
+						m.Combo("/:id", reqToken()).Patch(bind(api.EditIssueCommentOption{}), repo.EditIssueCommentDeprecated).
+							Delete(repo.DeleteIssueCommentDeprecated)
+					})
+					m.Group("/labels", func() {
+						m.Combo("").Get(repo.ListIssueLabels).
+							Post(reqToken(), bind(api.IssueLabelsOption{}), repo.AddIssueLabels).
+							Put(reqToken(), bind(api.IssueLabelsOption{}), repo.ReplaceIssueLabels).
+							Delete(reqToken(), repo.ClearIssueLabels)
+						m.Delete("/:id", reqToken(), repo.DeleteIssueLabel)
+					})
+					m.Group("/times", func() {
+						m.Combo("", reqToken()).
+							Get(repo.ListTrackedTimes).
+							Post(bind(api.AddTimeOption{}), repo.AddTime).
+							Delete(repo.ResetIssueTime)
+						m.Delete("/:id", reqToken(), repo.DeleteTime)
+					})
+					m.Combo("/deadline").Post(reqToken(), bind(api.EditDeadlineOption{}), repo.UpdateIssueDeadline)
+					m.Group("/stopwatch", func() {
+						m.Post("/start", reqToken(), repo.StartIssueStopwatch)
+						m.Post("/stop", reqToken(), repo.StopIssueStopwatch)
+						m.Delete("/delete", reqToken(), repo.DeleteIssueStopwatch)
+					})
+					m.Group("/subscriptions", func() {
+						m.Get("", repo.GetIssueSubscribers)
+						m.Put("/:user", reqToken(), repo.AddIssueSubscription)
+						m.Delete("/:user", reqToken(), repo.DelIssueSubscription)
+					})
+					m.Combo("/reactions").
+						Get(repo.GetIssueReactions).
+						Post(bind(api.EditReactionOption{}), reqToken(), repo.PostIssueReaction).
+						Delete(bind(api.EditReactionOption{}), reqToken(), repo.DeleteIssueReaction)
+				})
+			}, mustEnableIssuesOrPulls)
+			m.Group("/labels", func() {
+				m.Combo("").Get(repo.ListLabels).
+					Post(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), bind(api.CreateLabelOption{}), repo.CreateLabel)
+				m.Combo("/:id").Get(repo.GetLabel).
+					Patch(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), bind(api.EditLabelOption{}), repo.EditLabel).
+					Delete(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), repo.DeleteLabel)
+			})
+			m.Group("/milestones", func() {
+				m.Combo("").Get(repo.ListMilestones).
+					Post(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), bind(api.CreateMilestoneOption{}), repo.CreateMilestone)
+				m.Combo("/:id").Get(repo.GetMilestone).
+					Patch(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), bind(api.EditMilestoneOption{}), repo.EditMilestone).
+					Delete(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), repo.DeleteMilestone)
+			})
+			m.Group("/projects", func() {
+				m.Combo("").Get(repo.ListProjects).
+					Post(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeProjects), bind(api.CreateProjectOption{}), repo.CreateProject)
+				m.Group("/:id", func() {
+					m.Combo("").Get(repo.GetProject).
+						Patch(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeProjects), bind(api.EditProjectOption{}), repo.EditProject).
+						Delete(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeProjects), repo.DeleteProject)
+					m.Group("/columns", func() {
+						m.Combo("").Get(repo.ListProjectColumns).
+							Post(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeProjects), bind(api.CreateProjectColumnOption{}), repo.CreateProjectColumn)
+						m.Combo("/:column_id").
+							Patch(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeProjects), bind(api.EditProjectColumnOption{}), repo.EditProjectColumn).
+							Delete(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeProjects), repo.DeleteProjectColumn)
+						m.Get("/:column_id/cards", repo.ListProjectColumnCards)
+					})
+					m.Post("/cards", reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypeProjects), bind(api.MoveProjectCardOption{}), repo.MoveProjectCard)
+				})
+			}, reqRepoReader(models.UnitTypeProjects))
+			m.Get("/issue_templates", context.ReferencesGitRepo(false), reqRepoReader(models.UnitTypeIssues), repo.GetIssueTemplates)
+		}, tokenRequiresScopes(models.AccessTokenScopeCategoryIssue), repoAssignment())
+
+		m.Combo("/repos/:username/:reponame/notifications", reqToken(), tokenRequiresScopes(models.AccessTokenScopeCategoryUser), repoAssignment()).
+			Get(notify.ListRepoNotifications).
+			Put(notify.ReadRepoNotifications)
+
 		// Organizations
-		m.Get("/user/orgs", reqToken(), org.ListMyOrgs)
-		m.Get("/users/:username/orgs", org.ListUserOrgs)
-		m.Post("/orgs", reqToken(), bind(api.CreateOrgOption{}), org.Create)
+		m.Get("/user/orgs", reqToken(), tokenRequiresScopes(models.AccessTokenScopeCategoryOrganization), org.ListMyOrgs)
+		m.Get("/user/teams", reqToken(), tokenRequiresScopes(models.AccessTokenScopeCategoryOrganization), org.ListUserTeams)
+		m.Get("/users/:username/orgs", tokenRequiresScopes(models.AccessTokenScopeCategoryOrganization), org.ListUserOrgs)
+		m.Post("/orgs", reqToken(), tokenRequiresScopes(models.AccessTokenScopeCategoryOrganization), reqUnrestrictedToken(), bind(api.CreateOrgOption{}), org.Create)
 		m.Group("/orgs/:org", func() {
-			m.Get("/repos", user.ListOrgRepos)
+			m.Get("/repos", tokenRequiresScopes(models.AccessTokenScopeCategoryRepository), user.ListOrgRepos)
 			m.Combo("").Get(org.Get).
 				Patch(reqToken(), reqOrgOwnership(), bind(api.EditOrgOption{}), org.Edit).
 				Delete(reqToken(), reqOrgOwnership(), org.Delete)
@@ -829,14 +980,28 @@ func RegisterRoutes(m *macaron.Macaron) {
 					Post(reqOrgOwnership(), bind(api.CreateTeamOption{}), org.CreateTeam)
 				m.Get("/search", org.SearchTeam)
 			}, reqOrgMembership())
+			m.Group("/labels", func() {
+				m.Get("", org.ListLabels)
+				m.Post("", reqToken(), reqOrgOwnership(), bind(api.CreateLabelOption{}), org.CreateLabel)
+				m.Combo("/:id").Get(org.GetLabel).
+					Patch(reqToken(), reqOrgOwnership(), bind(api.EditLabelOption{}), org.EditLabel).
+					Delete(reqToken(), reqOrgOwnership(), org.DeleteLabel)
+			})
 			m.Group("/hooks", func() {
 				m.Combo("").Get(org.ListHooks).
 					Post(bind(api.CreateHookOption{}), org.CreateHook)
-				m.Combo("/:id").Get(org.GetHook).
-					Patch(bind(api.EditHookOption{}), org.EditHook).
-					Delete(org.DeleteHook)
+				m.Group("/:id", func() {
+					m.Combo("").Get(org.GetHook).
+						Patch(bind(api.EditHookOption{}), org.EditHook).
+						Delete(org.DeleteHook)
+					m.Group("/deliveries", func() {
+						m.Get("", org.ListHookDeliveries)
+						m.Get("/:delivery", org.GetHookDelivery)
+						m.Post("/:delivery/redeliver", org.RedeliverHookDelivery)
+					})
+				})
 			}, reqToken(), reqOrgOwnership())
-		}, orgAssignment(true))
+		}, tokenRequiresScopes(models.AccessTokenScopeCategoryOrganization), orgAssignment(true))
 		m.Group("/teams/:teamid", func() {
 			m.Combo("").Get(org.GetTeam).
 				Patch(reqOrgOwnership(), bind(api.EditTeamOption{}), org.EditTeam).
@@ -854,7 +1019,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 					Put(org.AddTeamRepository).
 					Delete(org.RemoveTeamRepository)
 			})
-		}, orgAssignment(false, true), reqToken(), reqTeamMembership())
+		}, tokenRequiresScopes(models.AccessTokenScopeCategoryOrganization), orgAssignment(false, true), reqToken(), reqTeamMembership())
 
 		m.Any("/*", func(ctx *context.APIContext) {
 			ctx.NotFound()
@@ -862,6 +1027,13 @@ func RegisterRoutes(m *macaron.Macaron) {
 
 		m.Group("/admin", func() {
 			m.Get("/orgs", admin.GetAllOrgs)
+			m.Group("/hooks", func() {
+				m.Combo("").Get(admin.ListHooks).
+					Post(bind(api.CreateHookOption{}), admin.CreateHook)
+				m.Combo("/:id").Get(admin.GetHook).
+					Patch(bind(api.EditHookOption{}), admin.EditHook).
+					Delete(admin.DeleteHook)
+			})
 			m.Group("/users", func() {
 				m.Get("", admin.GetAllUsers)
 				m.Post("", bind(api.CreateUserOption{}), admin.CreateUser)
@@ -877,12 +1049,12 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Post("/repos", bind(api.CreateRepoOption{}), admin.CreateRepo)
 				})
 			})
-		}, reqToken(), reqSiteAdmin())
+		}, reqToken(), tokenRequiresScopes(models.AccessTokenScopeCategoryAdmin), reqSiteAdmin())
 
 		m.Group("/topics", func() {
-			m.Get("/search", repo.TopicSearch)
+			m.Get("/search", tokenRequiresScopes(models.AccessTokenScopeCategoryRepository), repo.TopicSearch)
 		})
-	}, securityHeaders(), context.APIContexter(), sudo())
+	}, securityHeaders(), context.APIContexter(), tokenSiteAdmin(), sudo())
 }
 
 func securityHeaders() macaron.Handler {
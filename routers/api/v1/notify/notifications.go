@@ -0,0 +1,186 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// NewAvailable check if unread notifications exist
+func NewAvailable(ctx *context.APIContext) {
+	// swagger:operation GET /notifications/new notification notifyNewAvailable
+	// ---
+	// summary: Check if unread notifications exist
+	// description: The count is a single cheap query, so it can be used to poll
+	//              for new notifications before listing them.
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/NotificationCount"
+
+	count, err := models.GetNotificationCount(ctx.User, models.NotificationStatusUnread)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetNotificationCount", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, api.NotificationCount{New: count})
+}
+
+// getFindNotificationOptions builds the options listing the notifications of the
+// signed in user from the query. If the query is invalid, write to `ctx` accordingly
+func getFindNotificationOptions(ctx *context.APIContext) *models.FindNotificationOptions {
+	opts := &models.FindNotificationOptions{
+		UserID:   ctx.User.ID,
+		Page:     ctx.QueryInt("page"),
+		PageSize: convert.ToCorrectPageSize(ctx.QueryInt("limit")),
+	}
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+
+	var err error
+	if opts.UpdatedAfterUnix, err = parseQueryTime(ctx, "since"); err != nil {
+		return nil
+	}
+	if opts.UpdatedBeforeUnix, err = parseQueryTime(ctx, "before"); err != nil {
+		return nil
+	}
+
+	if !ctx.QueryBool("all") {
+		statuses := ctx.QueryStrings("status-types")
+		if len(statuses) == 0 {
+			statuses = []string{"unread", "pinned"}
+		}
+		if opts.Status, err = toNotificationStatuses(statuses); err != nil {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+			return nil
+		}
+	}
+
+	for _, subjectType := range ctx.QueryStrings("subject-type") {
+		switch strings.ToLower(subjectType) {
+		case "issue":
+			opts.Source = append(opts.Source, models.NotificationSourceIssue)
+		case "pull":
+			opts.Source = append(opts.Source, models.NotificationSourcePullRequest)
+		case "commit":
+			opts.Source = append(opts.Source, models.NotificationSourceCommit)
+		default:
+			ctx.Error(http.StatusUnprocessableEntity, "", "Invalid subject type: "+subjectType)
+			return nil
+		}
+	}
+
+	return opts
+}
+
+// listNotifications writes the notifications matching `opts` to `ctx`
+func listNotifications(ctx *context.APIContext, opts *models.FindNotificationOptions) {
+	nl, err := models.GetNotifications(*opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetNotifications", err)
+		return
+	}
+	nl, err = nl.LoadAttributes()
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadAttributes", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, nl.APIFormat())
+}
+
+// readNotifications changes the status of the notifications of the signed in user
+// updated before the `last_read_at` query parameter, restricted to the repository
+// `repoID` if it is not 0. Writes to `ctx` accordingly
+func readNotifications(ctx *context.APIContext, repoID int64) {
+	opts := models.FindNotificationOptions{
+		UserID: ctx.User.ID,
+		RepoID: repoID,
+	}
+
+	lastRead, err := parseQueryTime(ctx, "last_read_at")
+	if err != nil {
+		return
+	}
+	if lastRead == 0 {
+		lastRead = time.Now().Unix()
+	}
+	opts.UpdatedBeforeUnix = lastRead
+
+	if !ctx.QueryBool("all") {
+		statuses := ctx.QueryStrings("status-types")
+		if len(statuses) == 0 {
+			statuses = []string{"unread"}
+		}
+		if opts.Status, err = toNotificationStatuses(statuses); err != nil {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+			return
+		}
+	}
+
+	targetStatus, err := toNotificationStatus(ctx.QueryTrim("to-status"), models.NotificationStatusRead)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "", err)
+		return
+	}
+
+	if err := models.UpdateNotificationStatusesByOptions(opts, targetStatus); err != nil {
+		ctx.Error(http.StatusInternalServerError, "UpdateNotificationStatusesByOptions", err)
+		return
+	}
+	ctx.Status(http.StatusResetContent)
+}
+
+// parseQueryTime parses the RFC 3339 time of the query parameter `name`, 0 is
+// returned if it is not given. If the time is invalid, write to `ctx` accordingly
+func parseQueryTime(ctx *context.APIContext, name string) (int64, error) {
+	value := ctx.QueryTrim(name)
+	if len(value) == 0 {
+		return 0, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "", "Invalid "+name+": "+err.Error())
+		return 0, err
+	}
+	return t.Unix(), nil
+}
+
+func toNotificationStatuses(names []string) ([]models.NotificationStatus, error) {
+	statuses := make([]models.NotificationStatus, 0, len(names))
+	for _, name := range names {
+		status, err := toNotificationStatus(name, 0)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// toNotificationStatus converts the name of a status, `defaultStatus` is returned
+// if the name is empty
+func toNotificationStatus(name string, defaultStatus models.NotificationStatus) (models.NotificationStatus, error) {
+	switch strings.ToLower(name) {
+	case "":
+		if defaultStatus != 0 {
+			return defaultStatus, nil
+		}
+	case "unread":
+		return models.NotificationStatusUnread, nil
+	case "read":
+		return models.NotificationStatusRead, nil
+	case "pinned":
+		return models.NotificationStatusPinned, nil
+	}
+	return 0, fmt.Errorf("Invalid notification status: %s", name)
+}
@@ -6,11 +6,14 @@
 package repo
 
 import (
+	"fmt"
 	"net/http"
 
+	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
 	api "code.gitea.io/gitea/modules/structs"
 )
 
@@ -131,3 +134,477 @@ func ListBranches(ctx *context.APIContext) {
 
 	ctx.JSON(http.StatusOK, &apiBranches)
 }
+
+// GetBranchProtection gets a branch protection
+func GetBranchProtection(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/branch_protections/{name} repository repoGetBranchProtection
+	// ---
+	// summary: Get a specific branch protection for the repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of protected branch
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/BranchProtection"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	repo := ctx.Repo.Repository
+	bpName := ctx.Params("*")
+	bp, err := models.GetProtectedBranchBy(repo.ID, bpName)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProtectedBranchByID", err)
+		return
+	}
+	if bp == nil || bp.RepoID != repo.ID {
+		ctx.NotFound()
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToBranchProtection(bp))
+}
+
+// ListBranchProtections list branch protections for a repo
+func ListBranchProtections(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/branch_protections repository repoListBranchProtection
+	// ---
+	// summary: List branch protections for a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/BranchProtectionList"
+
+	repo := ctx.Repo.Repository
+	bps, err := repo.GetProtectedBranches()
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProtectedBranches", err)
+		return
+	}
+	apiBps := make([]*api.BranchProtection, len(bps))
+	for i := range bps {
+		apiBps[i] = convert.ToBranchProtection(bps[i])
+	}
+
+	ctx.JSON(http.StatusOK, apiBps)
+}
+
+// CreateBranchProtection creates a branch protection for a repo
+func CreateBranchProtection(ctx *context.APIContext, form api.CreateBranchProtectionOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/branch_protections repository repoCreateBranchProtection
+	// ---
+	// summary: Create a branch protections for a repository
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateBranchProtectionOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/BranchProtection"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	repo := ctx.Repo.Repository
+
+	// Protection must match an actual branch, unless it is a pattern
+	if models.IsBranchProtectionPattern(form.BranchName) {
+		if !models.IsValidBranchProtectionPattern(form.BranchName) {
+			ctx.Error(http.StatusUnprocessableEntity, "", "Invalid branch pattern")
+			return
+		}
+	} else if !git.IsBranchExist(ctx.Repo.Repository.RepoPath(), form.BranchName) {
+		ctx.NotFound()
+		return
+	}
+
+	protectBranch, err := models.GetProtectedBranchBy(repo.ID, form.BranchName)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProtectBranchOfRepoByName", err)
+		return
+	} else if protectBranch != nil {
+		ctx.Error(http.StatusForbidden, "Create branch protection", "Branch protection already exist")
+		return
+	}
+
+	if form.RequiredApprovals < 0 {
+		ctx.Error(http.StatusUnprocessableEntity, "", "Required approvals must not be negative")
+		return
+	}
+
+	protectBranch = &models.ProtectedBranch{
+		RepoID:                   ctx.Repo.Repository.ID,
+		BranchName:               form.BranchName,
+		CanPush:                  form.EnablePush,
+		EnableWhitelist:          form.EnablePush && form.EnablePushWhitelist,
+		EnableMergeWhitelist:     form.EnableMergeWhitelist,
+		WhitelistDeployKeys:      form.EnablePush && form.EnablePushWhitelist && form.PushWhitelistDeployKeys,
+		EnableStatusCheck:        form.EnableStatusCheck,
+		StatusCheckContexts:      form.StatusCheckContexts,
+		EnableApprovalsWhitelist: form.EnableApprovalsWhitelist,
+		RequiredApprovals:        form.RequiredApprovals,
+		BlockOnRejectedReviews:   form.BlockOnRejectedReviews,
+		RequireCodeOwnerApproval: form.RequireCodeOwnerApproval,
+		RequireSignedCommits:     form.RequireSignedCommits,
+		ProtectedFilePatterns:    form.ProtectedFilePatterns,
+	}
+	if !protectBranch.EnableStatusCheck {
+		protectBranch.StatusCheckContexts = nil
+	}
+
+	for _, pattern := range protectBranch.ProtectedFilePatterns {
+		if !models.IsValidBranchProtectionPattern(pattern) {
+			ctx.Error(http.StatusUnprocessableEntity, "", fmt.Sprintf("Invalid protected file pattern: %s", pattern))
+			return
+		}
+	}
+
+	whitelistOpts, ok := getBranchProtectionWhitelistOptions(ctx, protectBranch,
+		form.PushWhitelistUsernames, form.PushWhitelistTeams,
+		form.MergeWhitelistUsernames, form.MergeWhitelistTeams,
+		form.ApprovalsWhitelistUsernames, form.ApprovalsWhitelistTeams)
+	if !ok {
+		return
+	}
+
+	if err := models.UpdateProtectBranch(ctx.Repo.Repository, protectBranch, whitelistOpts); err != nil {
+		ctx.Error(http.StatusInternalServerError, "UpdateProtectBranch", err)
+		return
+	}
+
+	// Reload from db to get all whitelists
+	bp, err := models.GetProtectedBranchBy(ctx.Repo.Repository.ID, form.BranchName)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProtectedBranchByID", err)
+		return
+	}
+	if bp == nil || bp.RepoID != ctx.Repo.Repository.ID {
+		ctx.Error(http.StatusInternalServerError, "New branch protection not found", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, convert.ToBranchProtection(bp))
+}
+
+// EditBranchProtection edits a branch protection for a repo
+func EditBranchProtection(ctx *context.APIContext, form api.EditBranchProtectionOption) {
+	// swagger:operation PATCH /repos/{owner}/{repo}/branch_protections/{name} repository repoEditBranchProtection
+	// ---
+	// summary: Edit a branch protections for a repository. Only fields that are set will be changed
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of protected branch
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditBranchProtectionOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/BranchProtection"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	repo := ctx.Repo.Repository
+	bpName := ctx.Params("*")
+	protectBranch, err := models.GetProtectedBranchBy(repo.ID, bpName)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProtectedBranchByID", err)
+		return
+	}
+	if protectBranch == nil || protectBranch.RepoID != repo.ID {
+		ctx.NotFound()
+		return
+	}
+
+	if form.EnablePush != nil {
+		protectBranch.CanPush = *form.EnablePush
+	}
+	if form.EnablePushWhitelist != nil {
+		protectBranch.EnableWhitelist = *form.EnablePushWhitelist
+	}
+	if form.PushWhitelistDeployKeys != nil {
+		protectBranch.WhitelistDeployKeys = *form.PushWhitelistDeployKeys
+	}
+	// the push whitelist only applies when pushing is enabled at all
+	if !protectBranch.CanPush {
+		protectBranch.EnableWhitelist = false
+	}
+	if !protectBranch.EnableWhitelist {
+		protectBranch.WhitelistDeployKeys = false
+	}
+
+	if form.EnableMergeWhitelist != nil {
+		protectBranch.EnableMergeWhitelist = *form.EnableMergeWhitelist
+	}
+
+	if form.EnableStatusCheck != nil {
+		protectBranch.EnableStatusCheck = *form.EnableStatusCheck
+	}
+	if protectBranch.EnableStatusCheck {
+		if form.StatusCheckContexts != nil {
+			protectBranch.StatusCheckContexts = form.StatusCheckContexts
+		}
+	} else {
+		protectBranch.StatusCheckContexts = nil
+	}
+
+	if form.RequiredApprovals != nil {
+		if *form.RequiredApprovals < 0 {
+			ctx.Error(http.StatusUnprocessableEntity, "", "Required approvals must not be negative")
+			return
+		}
+		protectBranch.RequiredApprovals = *form.RequiredApprovals
+	}
+
+	if form.EnableApprovalsWhitelist != nil {
+		protectBranch.EnableApprovalsWhitelist = *form.EnableApprovalsWhitelist
+	}
+
+	if form.BlockOnRejectedReviews != nil {
+		protectBranch.BlockOnRejectedReviews = *form.BlockOnRejectedReviews
+	}
+
+	if form.RequireCodeOwnerApproval != nil {
+		protectBranch.RequireCodeOwnerApproval = *form.RequireCodeOwnerApproval
+	}
+
+	if form.RequireSignedCommits != nil {
+		protectBranch.RequireSignedCommits = *form.RequireSignedCommits
+	}
+
+	if form.ProtectedFilePatterns != nil {
+		protectBranch.ProtectedFilePatterns = form.ProtectedFilePatterns
+	}
+
+	for _, pattern := range protectBranch.ProtectedFilePatterns {
+		if !models.IsValidBranchProtectionPattern(pattern) {
+			ctx.Error(http.StatusUnprocessableEntity, "", fmt.Sprintf("Invalid protected file pattern: %s", pattern))
+			return
+		}
+	}
+
+	// the whitelists which are not given are kept
+	keepNames := func(names []string, ids []int64) []string {
+		if names != nil {
+			return names
+		}
+		current, err := models.GetUserNamesByIDs(ids)
+		if err != nil {
+			log.Error("GetUserNamesByIDs: %v", err)
+		}
+		return current
+	}
+	keepTeams := func(names []string, ids []int64) []string {
+		if names != nil {
+			return names
+		}
+		current, err := models.GetTeamNamesByID(ids)
+		if err != nil {
+			log.Error("GetTeamNamesByID: %v", err)
+		}
+		return current
+	}
+	whitelistOpts, ok := getBranchProtectionWhitelistOptions(ctx, protectBranch,
+		keepNames(form.PushWhitelistUsernames, protectBranch.WhitelistUserIDs),
+		keepTeams(form.PushWhitelistTeams, protectBranch.WhitelistTeamIDs),
+		keepNames(form.MergeWhitelistUsernames, protectBranch.MergeWhitelistUserIDs),
+		keepTeams(form.MergeWhitelistTeams, protectBranch.MergeWhitelistTeamIDs),
+		keepNames(form.ApprovalsWhitelistUsernames, protectBranch.ApprovalsWhitelistUserIDs),
+		keepTeams(form.ApprovalsWhitelistTeams, protectBranch.ApprovalsWhitelistTeamIDs))
+	if !ok {
+		return
+	}
+
+	if err := models.UpdateProtectBranch(ctx.Repo.Repository, protectBranch, whitelistOpts); err != nil {
+		ctx.Error(http.StatusInternalServerError, "UpdateProtectBranch", err)
+		return
+	}
+
+	// Reload from db to ensure get all whitelists
+	bp, err := models.GetProtectedBranchBy(repo.ID, bpName)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProtectedBranchBy", err)
+		return
+	}
+	if bp == nil || bp.RepoID != ctx.Repo.Repository.ID {
+		ctx.Error(http.StatusInternalServerError, "New branch protection not found", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToBranchProtection(bp))
+}
+
+// DeleteBranchProtection deletes a branch protection for a repo
+func DeleteBranchProtection(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/branch_protections/{name} repository repoDeleteBranchProtection
+	// ---
+	// summary: Delete a specific branch protection for the repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of protected branch
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	repo := ctx.Repo.Repository
+	bpName := ctx.Params("*")
+	bp, err := models.GetProtectedBranchBy(repo.ID, bpName)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProtectedBranchByID", err)
+		return
+	}
+	if bp == nil || bp.RepoID != repo.ID {
+		ctx.NotFound()
+		return
+	}
+
+	if err := ctx.Repo.Repository.DeleteProtectedBranch(bp.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteProtectedBranch", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// getBranchProtectionWhitelistOptions resolves the user and team names of the
+// whitelists of a branch protection, the whitelists which are disabled are left
+// empty. If a name can't be resolved, write to `ctx` accordingly. Return (options, ok)
+func getBranchProtectionWhitelistOptions(ctx *context.APIContext, protectBranch *models.ProtectedBranch,
+	pushUsers, pushTeams, mergeUsers, mergeTeams, approvalsUsers, approvalsTeams []string) (models.WhitelistOptions, bool) {
+	var opts models.WhitelistOptions
+
+	resolveUsers := func(names []string) ([]int64, bool) {
+		ids, err := models.GetUserIDsByNames(names, false)
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "User does not exist", err)
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetUserIDsByNames", err)
+			}
+			return nil, false
+		}
+		return ids, true
+	}
+	resolveTeams := func(names []string) ([]int64, bool) {
+		if !ctx.Repo.Owner.IsOrganization() {
+			return nil, true
+		}
+		ids, err := models.GetTeamIDsByNames(ctx.Repo.Owner.ID, names, false)
+		if err != nil {
+			if models.IsErrTeamNotExist(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "Team does not exist", err)
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetTeamIDsByNames", err)
+			}
+			return nil, false
+		}
+		return ids, true
+	}
+
+	var ok bool
+	if protectBranch.EnableWhitelist {
+		if opts.UserIDs, ok = resolveUsers(pushUsers); !ok {
+			return opts, false
+		}
+		if opts.TeamIDs, ok = resolveTeams(pushTeams); !ok {
+			return opts, false
+		}
+	}
+	if protectBranch.EnableMergeWhitelist {
+		if opts.MergeUserIDs, ok = resolveUsers(mergeUsers); !ok {
+			return opts, false
+		}
+		if opts.MergeTeamIDs, ok = resolveTeams(mergeTeams); !ok {
+			return opts, false
+		}
+	}
+	if protectBranch.EnableApprovalsWhitelist {
+		if opts.ApprovalsUserIDs, ok = resolveUsers(approvalsUsers); !ok {
+			return opts, false
+		}
+		if opts.ApprovalsTeamIDs, ok = resolveTeams(approvalsTeams); !ok {
+			return opts, false
+		}
+	}
+	return opts, true
+}
@@ -0,0 +1,202 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/services/gitdiff"
+)
+
+// CompareDiff compares two refs of a repository
+func CompareDiff(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/compare/{basehead} repository repoCompareDiff
+	// ---
+	// summary: Get the commits and changed files between two refs
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: basehead
+	//   in: path
+	//   description: the refs to compare in the form `base...head`
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of changed files to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of changed files
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Compare"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	infos := strings.SplitN(ctx.Params("*"), "...", 2)
+	if len(infos) != 2 || len(infos[0]) == 0 || len(infos[1]) == 0 ||
+		strings.HasPrefix(infos[0], "-") || strings.HasPrefix(infos[1], "-") {
+		ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("refs must be given in the form base...head"))
+		return
+	}
+
+	baseCommit, err := ctx.Repo.GitRepo.GetCommit(infos[0])
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetCommit", err)
+		}
+		return
+	}
+	headCommit, err := ctx.Repo.GitRepo.GetCommit(infos[1])
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetCommit", err)
+		}
+		return
+	}
+	baseCommitID := baseCommit.ID.String()
+	headCommitID := headCommit.ID.String()
+
+	compareInfo, err := ctx.Repo.GitRepo.GetCompareInfo(ctx.Repo.GitRepo.Path, baseCommitID, headCommitID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetCompareInfo", err)
+		return
+	}
+
+	changedFiles, err := gitdiff.GetChangedFiles(ctx.Repo.GitRepo.Path, compareInfo.MergeBase, headCommitID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetChangedFiles", err)
+		return
+	}
+	page := ctx.QueryInt("page")
+	limit := convert.ToCorrectPageSize(ctx.QueryInt("limit"))
+	files, err := toChangedFiles(ctx.Repo.Repository, ctx.Repo.GitRepo.Path, compareInfo.MergeBase, headCommitID, changedFiles, page, limit)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "toChangedFiles", err)
+		return
+	}
+
+	userCache := make(map[string]*models.User)
+	apiCommits := make([]*api.Commit, 0, compareInfo.Commits.Len())
+	for e := compareInfo.Commits.Front(); e != nil; e = e.Next() {
+		apiCommit, err := toCommit(ctx, ctx.Repo.Repository, e.Value.(*git.Commit), userCache)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "toCommit", err)
+			return
+		}
+		apiCommits = append(apiCommits, apiCommit)
+	}
+
+	ctx.SetLinkHeader(len(changedFiles), limit)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", len(changedFiles)))
+	ctx.JSON(http.StatusOK, &api.Compare{
+		BaseCommitID:      baseCommitID,
+		HeadCommitID:      headCommitID,
+		MergeBaseCommitID: compareInfo.MergeBase,
+		TotalCommits:      len(apiCommits),
+		Commits:           apiCommits,
+		TotalFiles:        len(changedFiles),
+		Files:             files,
+	})
+}
+
+// toChangedFiles converts one page of the changed files to their API representation,
+// the patches are limited per file and only computed for the files of the page
+func toChangedFiles(repo *models.Repository, repoPath, beforeCommitID, commitID string, changedFiles []*gitdiff.ChangedFile, page, limit int) ([]*api.ChangedFile, error) {
+	if page < 1 {
+		page = 1
+	}
+	start := (page - 1) * limit
+	if start > len(changedFiles) {
+		start = len(changedFiles)
+	}
+	end := start + limit
+	if end > len(changedFiles) {
+		end = len(changedFiles)
+	}
+	changedFiles = changedFiles[start:end]
+
+	// renames are only detected if the old name is part of the diff too
+	paths := make([]string, 0, len(changedFiles))
+	for _, changedFile := range changedFiles {
+		paths = append(paths, changedFile.Name)
+		if len(changedFile.OldName) > 0 {
+			paths = append(paths, changedFile.OldName)
+		}
+	}
+	diff, err := gitdiff.GetDiffRangeForFiles(repoPath, beforeCommitID, commitID, paths,
+		setting.Git.MaxGitDiffLines, setting.Git.MaxGitDiffLineCharacters)
+	if err != nil {
+		return nil, err
+	}
+	diffFiles := make(map[string]*gitdiff.DiffFile, len(diff.Files))
+	for _, diffFile := range diff.Files {
+		diffFiles[diffFile.Name] = diffFile
+	}
+
+	files := make([]*api.ChangedFile, 0, len(changedFiles))
+	for _, changedFile := range changedFiles {
+		file := &api.ChangedFile{
+			Filename:  changedFile.Name,
+			Status:    "modified",
+			Additions: changedFile.Additions,
+			Deletions: changedFile.Deletions,
+			Changes:   changedFile.Additions + changedFile.Deletions,
+			IsBinary:  changedFile.IsBinary,
+		}
+		if diffFile, ok := diffFiles[changedFile.Name]; ok {
+			file.IsIncomplete = diffFile.IsIncomplete
+			file.Patch = diffFile.GetPatch()
+		}
+
+		switch changedFile.Status {
+		case "A":
+			file.Status = "added"
+		case "D":
+			file.Status = "removed"
+		case "R":
+			file.Status = "renamed"
+			file.PreviousFilename = changedFile.OldName
+		}
+
+		// removed files don't exist at the head commit
+		if changedFile.Status != "D" {
+			escapedPath := util.PathEscapeSegments(changedFile.Name)
+			file.HTMLURL = repo.HTMLURL() + "/src/commit/" + commitID + "/" + escapedPath
+			file.ContentsURL = repo.APIURL() + "/contents/" + escapedPath + "?ref=" + commitID
+			file.RawURL = repo.HTMLURL() + "/raw/commit/" + commitID + "/" + escapedPath
+		}
+
+		files = append(files, file)
+	}
+	return files, nil
+}
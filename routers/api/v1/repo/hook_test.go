@@ -31,3 +31,23 @@ func TestTestHook(t *testing.T) {
 		HookID: 1,
 	}, models.Cond("is_delivered=?", false))
 }
+
+func TestRedeliverHookDelivery(t *testing.T) {
+	models.PrepareTestEnv(t)
+
+	ctx := test.MockContext(t, "user2/repo1/hooks/1/deliveries/1/redeliver")
+	ctx.SetParams(":id", "1")
+	ctx.SetParams(":delivery", "1")
+	test.LoadRepo(t, ctx, 1)
+	test.LoadUser(t, ctx, 2)
+	RedeliverHookDelivery(&context.APIContext{Context: ctx, Org: nil})
+	assert.EqualValues(t, http.StatusCreated, ctx.Resp.Status())
+
+	original := models.AssertExistsAndLoadBean(t, &models.HookTask{ID: 1}).(*models.HookTask)
+	redelivery := models.AssertExistsAndLoadBean(t, &models.HookTask{
+		RepoID: 1,
+		HookID: 1,
+	}, models.Cond("is_delivered=?", false)).(*models.HookTask)
+	assert.NotEqual(t, original.UUID, redelivery.UUID)
+	assert.Equal(t, original.PayloadContent, redelivery.PayloadContent)
+}
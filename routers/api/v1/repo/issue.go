@@ -80,6 +80,7 @@ func SearchIssues(ctx *context.APIContext) {
 		UserIsAdmin: ctx.IsUserSiteAdmin(),
 		OrderBy:     models.SearchOrderByRecentUpdated,
 	}
+	opts.AccessToken, _ = ctx.Data["ApiToken"].(*models.AccessToken)
 	if ctx.IsSigned {
 		opts.Private = true
 		opts.AllLimited = true
@@ -145,7 +146,8 @@ func SearchIssues(ctx *context.APIContext) {
 
 	// Only fetch the issues if we either don't have a keyword or the search returned issues
 	// This would otherwise return all issues if no issues were found by the search.
-	if len(keyword) == 0 || len(issueIDs) > 0 || len(labelIDs) > 0 {
+	// The same applies to an empty list of repositories.
+	if len(repoIDs) > 0 && (len(keyword) == 0 || len(issueIDs) > 0 || len(labelIDs) > 0) {
 		issues, err = models.Issues(&models.IssuesOptions{
 			RepoIDs:        repoIDs,
 			Page:           ctx.QueryInt("page"),
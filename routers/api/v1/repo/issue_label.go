@@ -179,8 +179,11 @@ func DeleteIssueLabel(ctx *context.APIContext) {
 	}
 
 	label, err := models.GetLabelInRepoByID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if models.IsErrLabelNotExist(err) && ctx.Repo.Owner.IsOrganization() {
+		label, err = models.GetLabelInOrgByID(ctx.Repo.Owner.ID, ctx.ParamsInt64(":id"))
+	}
 	if err != nil {
-		if models.IsErrLabelNotExist(err) {
+		if models.IsErrLabelNotExist(err) || models.IsErrOrgLabelNotExist(err) {
 			ctx.Error(http.StatusUnprocessableEntity, "", err)
 		} else {
 			ctx.Error(http.StatusInternalServerError, "GetLabelInRepoByID", err)
@@ -325,6 +328,17 @@ func prepareForReplaceOrAdd(ctx *context.APIContext, form api.IssueLabelsOption)
 		return
 	}
 
+	if ctx.Repo.Owner.IsOrganization() {
+		var orgLabels []*models.Label
+		orgLabels, err = models.GetLabelsInOrgByIDs(ctx.Repo.Owner.ID, form.Labels)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetLabelsInOrgByIDs", err)
+			return
+		}
+
+		labels = append(labels, orgLabels...)
+	}
+
 	if !ctx.Repo.CanWriteIssuesOrPulls(issue.IsPull) {
 		ctx.Status(http.StatusForbidden)
 		return
@@ -0,0 +1,247 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	mirror_service "code.gitea.io/gitea/services/mirror"
+)
+
+// ListPushMirrors list all the push mirrors of a repository
+func ListPushMirrors(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/push_mirrors repository repoListPushMirrors
+	// ---
+	// summary: List a repository's push mirrors
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PushMirrorList"
+
+	mirrors, err := models.GetPushMirrorsByRepoID(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetPushMirrorsByRepoID", err)
+		return
+	}
+
+	apiMirrors := make([]*api.PushMirror, len(mirrors))
+	for i := range mirrors {
+		apiMirrors[i] = convert.ToPushMirror(mirrors[i], mirror_service.PushMirrorAddress(mirrors[i]))
+	}
+
+	ctx.JSON(http.StatusOK, &apiMirrors)
+}
+
+// GetPushMirror get a push mirror by id
+func GetPushMirror(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/push_mirrors/{id} repository repoGetPushMirror
+	// ---
+	// summary: Get a repository's push mirror by id
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the push mirror to get
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PushMirror"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	m := getPushMirrorByID(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToPushMirror(m, mirror_service.PushMirrorAddress(m)))
+}
+
+// AddPushMirror add a push mirror to a repository
+func AddPushMirror(ctx *context.APIContext, form api.CreatePushMirrorOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/push_mirrors repository repoAddPushMirror
+	// ---
+	// summary: Add a push mirror to a repository
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreatePushMirrorOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/PushMirror"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	interval := setting.Mirror.DefaultInterval
+	if len(form.Interval) > 0 {
+		var err error
+		interval, err = time.ParseDuration(form.Interval)
+		if err != nil || (interval != 0 && interval < setting.Mirror.MinInterval) {
+			ctx.Error(http.StatusUnprocessableEntity, "", "invalid interval")
+			return
+		}
+	}
+
+	address, err := auth.ParsePushMirrorAddress(form.RemoteAddress, form.RemoteUsername, form.RemotePassword)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "", "invalid remote address, only http(s) URLs are supported")
+		return
+	}
+
+	m, err := mirror_service.AddPushMirror(ctx.Repo.Repository, address, interval, form.SyncOnPush)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "AddPushMirror", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, convert.ToPushMirror(m, mirror_service.PushMirrorAddress(m)))
+}
+
+// DeletePushMirror delete a push mirror of a repository
+func DeletePushMirror(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/push_mirrors/{id} repository repoDeletePushMirror
+	// ---
+	// summary: Delete a push mirror from a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the push mirror to delete
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	m := getPushMirrorByID(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := mirror_service.RemovePushMirror(m); err != nil {
+		ctx.Error(http.StatusInternalServerError, "RemovePushMirror", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// PushMirrorSync adds a push mirror to the sync queue
+func PushMirrorSync(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/push_mirrors/{id}/sync repository repoPushMirrorSync
+	// ---
+	// summary: Sync a push mirror of a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the push mirror to sync
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	m := getPushMirrorByID(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	mirror_service.StartToPushMirror(m.ID)
+
+	ctx.Status(http.StatusOK)
+}
+
+func getPushMirrorByID(ctx *context.APIContext) *models.PushMirror {
+	m, err := models.GetPushMirrorByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if err == models.ErrPushMirrorNotExist {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPushMirrorByID", err)
+		}
+		return nil
+	}
+	if m.RepoID != ctx.Repo.Repository.ID {
+		ctx.NotFound()
+		return nil
+	}
+	return m
+}
@@ -0,0 +1,610 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ListProjects list the projects of a repository
+func ListProjects(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/projects repository repoListProjects
+	// ---
+	// summary: List a repository's projects
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: state
+	//   in: query
+	//   description: Project state, Recognised values are open, closed and all. Defaults to "open"
+	//   type: string
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectList"
+
+	projects, err := models.GetProjectsByRepoID(ctx.Repo.Repository.ID, api.StateType(ctx.Query("state")))
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProjectsByRepoID", err)
+		return
+	}
+
+	apiProjects := make([]*api.Project, len(projects))
+	for i := range projects {
+		apiProjects[i] = projects[i].APIFormat()
+	}
+	ctx.JSON(http.StatusOK, &apiProjects)
+}
+
+// getProject returns the project of the repository with the id of the request parameters
+func getProject(ctx *context.APIContext) *models.Project {
+	project, err := models.GetProjectByRepoID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrProjectNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetProjectByRepoID", err)
+		}
+		return nil
+	}
+	return project
+}
+
+// GetProject get a project of a repository
+func GetProject(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/projects/{id} repository repoGetProject
+	// ---
+	// summary: Get a project
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Project"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	project := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.JSON(http.StatusOK, project.APIFormat())
+}
+
+// CreateProject create a project for a repository
+func CreateProject(ctx *context.APIContext, form api.CreateProjectOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/projects repository repoCreateProject
+	// ---
+	// summary: Create a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/Project"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	boardType, ok := models.ProjectBoardTypeFromName(form.BoardType)
+	if !ok {
+		ctx.Error(http.StatusUnprocessableEntity, "", "invalid board type")
+		return
+	}
+
+	project := &models.Project{
+		RepoID:      ctx.Repo.Repository.ID,
+		Title:       form.Title,
+		Description: form.Description,
+		CreatorID:   ctx.User.ID,
+		BoardType:   boardType,
+	}
+	if err := models.NewProject(project); err != nil {
+		ctx.Error(http.StatusInternalServerError, "NewProject", err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, project.APIFormat())
+}
+
+// EditProject modify a project of a repository
+func EditProject(ctx *context.APIContext, form api.EditProjectOption) {
+	// swagger:operation PATCH /repos/{owner}/{repo}/projects/{id} repository repoEditProject
+	// ---
+	// summary: Update a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Project"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	project := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if form.Title != nil && len(*form.Title) > 0 {
+		project.Title = *form.Title
+	}
+	if form.Description != nil {
+		project.Description = *form.Description
+	}
+	if err := models.UpdateProject(project); err != nil {
+		ctx.Error(http.StatusInternalServerError, "UpdateProject", err)
+		return
+	}
+
+	if form.State != nil {
+		isClosed := *form.State == string(api.StateClosed)
+		if isClosed != project.IsClosed {
+			if err := models.ChangeProjectStatus(project, isClosed); err != nil {
+				ctx.Error(http.StatusInternalServerError, "ChangeProjectStatus", err)
+				return
+			}
+		}
+	}
+	ctx.JSON(http.StatusOK, project.APIFormat())
+}
+
+// DeleteProject delete a project of a repository
+func DeleteProject(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/projects/{id} repository repoDeleteProject
+	// ---
+	// summary: Delete a project
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project to delete
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+
+	if err := models.DeleteProjectByRepoID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id")); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteProjectByRepoID", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListProjectColumns list the columns of a project
+func ListProjectColumns(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/projects/{id}/columns repository repoListProjectColumns
+	// ---
+	// summary: List the columns of a project
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectColumnList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	project := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	boards, err := models.GetProjectBoards(project.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProjectBoards", err)
+		return
+	}
+
+	apiColumns := make([]*api.ProjectColumn, len(boards))
+	for i := range boards {
+		apiColumns[i] = boards[i].APIFormat()
+	}
+	ctx.JSON(http.StatusOK, &apiColumns)
+}
+
+// CreateProjectColumn add a column to a project
+func CreateProjectColumn(ctx *context.APIContext, form api.CreateProjectColumnOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/projects/{id}/columns repository repoCreateProjectColumn
+	// ---
+	// summary: Add a column to a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectColumnOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ProjectColumn"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	project := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	board := &models.ProjectBoard{
+		ProjectID: project.ID,
+		Title:     form.Title,
+		Sorting:   form.Sorting,
+		CreatorID: ctx.User.ID,
+	}
+	if err := models.NewProjectBoard(board); err != nil {
+		ctx.Error(http.StatusInternalServerError, "NewProjectBoard", err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, board.APIFormat())
+}
+
+// getProjectColumn returns the column of the project with the ids of the request parameters
+func getProjectColumn(ctx *context.APIContext) (*models.Project, *models.ProjectBoard) {
+	project := getProject(ctx)
+	if ctx.Written() {
+		return nil, nil
+	}
+
+	columnID := ctx.ParamsInt64(":column_id")
+	if columnID == 0 {
+		return project, project.GetUncategorizedBoard()
+	}
+	board, err := models.GetProjectBoard(project.ID, columnID)
+	if err != nil {
+		if models.IsErrProjectBoardNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetProjectBoard", err)
+		}
+		return nil, nil
+	}
+	return project, board
+}
+
+// EditProjectColumn modify a column of a project
+func EditProjectColumn(ctx *context.APIContext, form api.EditProjectColumnOption) {
+	// swagger:operation PATCH /repos/{owner}/{repo}/projects/{id}/columns/{column_id} repository repoEditProjectColumn
+	// ---
+	// summary: Update a column of a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: column_id
+	//   in: path
+	//   description: id of the column
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectColumnOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectColumn"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	_, board := getProjectColumn(ctx)
+	if ctx.Written() {
+		return
+	}
+	if board.IsUncategorized() {
+		ctx.NotFound()
+		return
+	}
+
+	if form.Title != nil && len(*form.Title) > 0 {
+		board.Title = *form.Title
+	}
+	if form.Sorting != nil {
+		board.Sorting = *form.Sorting
+	}
+	if err := models.UpdateProjectBoard(board); err != nil {
+		ctx.Error(http.StatusInternalServerError, "UpdateProjectBoard", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, board.APIFormat())
+}
+
+// DeleteProjectColumn delete a column of a project
+func DeleteProjectColumn(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/projects/{id}/columns/{column_id} repository repoDeleteProjectColumn
+	// ---
+	// summary: Delete a column of a project, its cards become uncategorized
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: column_id
+	//   in: path
+	//   description: id of the column to delete
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	project := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteProjectBoard(project.ID, ctx.ParamsInt64(":column_id")); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteProjectBoard", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListProjectColumnCards list the issues and pull requests in a column of a project
+func ListProjectColumnCards(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/projects/{id}/columns/{column_id}/cards repository repoListProjectColumnCards
+	// ---
+	// summary: List the issues and pull requests in a column of a project
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: column_id
+	//   in: path
+	//   description: id of the column, 0 for the uncategorized column
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/IssueList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	_, board := getProjectColumn(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := board.LoadIssues(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadIssues", err)
+		return
+	}
+
+	apiIssues := make([]*api.Issue, len(board.Issues))
+	for i := range board.Issues {
+		apiIssues[i] = board.Issues[i].APIFormat()
+	}
+	ctx.JSON(http.StatusOK, &apiIssues)
+}
+
+// MoveProjectCard move an issue or pull request to a column of a project
+func MoveProjectCard(ctx *context.APIContext, form api.MoveProjectCardOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/projects/{id}/cards repository repoMoveProjectCard
+	// ---
+	// summary: Move an issue or pull request to a column of a project, it is added to the project if needed
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/MoveProjectCardOption"
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	project := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	board := project.GetUncategorizedBoard()
+	if form.ColumnID != 0 {
+		var err error
+		board, err = models.GetProjectBoard(project.ID, form.ColumnID)
+		if err != nil {
+			if models.IsErrProjectBoardNotExist(err) {
+				ctx.NotFound()
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetProjectBoard", err)
+			}
+			return
+		}
+	}
+
+	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.ID, form.Issue)
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueByIndex", err)
+		}
+		return
+	}
+
+	if err = models.MoveIssueAcrossProjectBoards(issue, ctx.User, board); err != nil {
+		ctx.Error(http.StatusInternalServerError, "MoveIssueAcrossProjectBoards", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
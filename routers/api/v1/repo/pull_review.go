@@ -0,0 +1,853 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	issue_service "code.gitea.io/gitea/services/issue"
+	pull_service "code.gitea.io/gitea/services/pull"
+)
+
+// ListPullReviews lists all reviews of a pull request
+func ListPullReviews(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/{index}/reviews repository repoListPullReviews
+	// ---
+	// summary: List all reviews for a pull request
+	// description: Requested reviews are included with the state REQUEST_REVIEW,
+	//              their user or team is the requested reviewer.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReviewList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	pr := getPullRequestForReview(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	opts := models.FindReviewOptions{
+		Type:    models.ReviewTypeUnknown,
+		IssueID: pr.IssueID,
+		// pending reviews are only visible to their author
+		ExcludePending:   true,
+		IncludePendingOf: ctx.User,
+	}
+
+	reviews, err := models.FindReviews(opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindReviews", err)
+		return
+	}
+
+	apiReviews, err := convert.ToPullReviewList(reviews, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToPullReviewList", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &apiReviews)
+}
+
+// GetPullReview gets a specific review of a pull request
+func GetPullReview(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/{index}/reviews/{id} repository repoGetPullReview
+	// ---
+	// summary: Get a specific review for a pull request
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReview"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	review, _ := getReviewFromContext(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	apiReview, err := convert.ToPullReview(review, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToPullReview", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, apiReview)
+}
+
+// GetPullReviewComments lists all comments of a pull request review
+func GetPullReviewComments(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/{index}/reviews/{id}/comments repository repoGetPullReviewComments
+	// ---
+	// summary: Get a specific review for a pull request
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReviewCommentList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	review, _ := getReviewFromContext(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	apiComments, err := convert.ToPullReviewCommentList(review, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToPullReviewCommentList", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &apiComments)
+}
+
+// CreatePullReviewComment adds a line comment to a pending review of a pull request
+func CreatePullReviewComment(ctx *context.APIContext, opts api.CreatePullReviewComment) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/reviews/{id}/comments repository repoCreatePullReviewComment
+	// ---
+	// summary: Add a comment to a pending review of a pull request
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/CreatePullReviewComment"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/PullReviewCommentList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	review, pr := getReviewFromContext(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if review.Type != models.ReviewTypePending || review.ReviewerID != ctx.User.ID {
+		ctx.Error(http.StatusForbidden, "", "comments can only be added to your own pending reviews")
+		return
+	}
+
+	if !validatePullReviewComment(ctx, opts) {
+		return
+	}
+
+	if err := createPullReviewComment(ctx.User, pr, opts); err != nil {
+		ctx.Error(http.StatusInternalServerError, "CreateCodeComment", err)
+		return
+	}
+
+	apiComments, err := convert.ToPullReviewCommentList(review, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToPullReviewCommentList", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, &apiComments)
+}
+
+// DeletePullReview delete a specific review from a pull request
+func DeletePullReview(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/pulls/{index}/reviews/{id} repository repoDeletePullReview
+	// ---
+	// summary: Delete a specific review from a pull request
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	review, _ := getReviewFromContext(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if !ctx.User.IsAdmin && ctx.User.ID != review.ReviewerID {
+		ctx.Error(http.StatusForbidden, "", "only the reviewer or a site admin can delete a review")
+		return
+	}
+
+	if err := models.DeleteReview(review); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteReview", fmt.Errorf("can not delete ReviewID: %d", review.ID))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// CreatePullReview create a review to a pull request
+func CreatePullReview(ctx *context.APIContext, opts api.CreatePullReviewOptions) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/reviews repository repoCreatePullReview
+	// ---
+	// summary: Create a review to a pull request
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/CreatePullReviewOptions"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReview"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	pr := getPullRequestForReview(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	// determine review type
+	reviewType, isWrong := preparePullReviewType(ctx, pr, opts.Event, opts.Body)
+	if isWrong {
+		return
+	}
+
+	for _, c := range opts.Comments {
+		if !validatePullReviewComment(ctx, c) {
+			return
+		}
+	}
+
+	// create review comments
+	for _, c := range opts.Comments {
+		if err := createPullReviewComment(ctx.User, pr, c); err != nil {
+			ctx.Error(http.StatusInternalServerError, "CreateCodeComment", err)
+			return
+		}
+	}
+
+	var review *models.Review
+	var err error
+	if reviewType == models.ReviewTypePending {
+		// keep the review as a pending draft
+		review, err = models.GetCurrentReview(ctx.User, pr.Issue)
+		if err != nil {
+			if !models.IsErrReviewNotExist(err) {
+				ctx.Error(http.StatusInternalServerError, "GetCurrentReview", err)
+				return
+			}
+			review, err = models.CreateReview(models.CreateReviewOptions{
+				Type:     models.ReviewTypePending,
+				Issue:    pr.Issue,
+				Reviewer: ctx.User,
+				Content:  opts.Body,
+				CommitID: opts.CommitID,
+			})
+			if err != nil {
+				ctx.Error(http.StatusInternalServerError, "CreateReview", err)
+				return
+			}
+		} else if opts.Body != "" || opts.CommitID != "" {
+			// the review was created by the comments above, or earlier
+			if opts.Body != "" {
+				review.Content = opts.Body
+			}
+			if opts.CommitID != "" {
+				review.CommitID = opts.CommitID
+			}
+			if err := models.UpdateReviewCols(review, "content", "commit_id"); err != nil {
+				ctx.Error(http.StatusInternalServerError, "UpdateReviewCols", err)
+				return
+			}
+		}
+	} else {
+		review, _, err = pull_service.SubmitReview(ctx.User, pr.Issue, reviewType, opts.Body, opts.CommitID)
+		if err != nil {
+			if models.IsContentEmptyErr(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "", "review event COMMENT and REQUEST_CHANGES require a body or a line comment")
+				return
+			}
+			ctx.Error(http.StatusInternalServerError, "SubmitReview", err)
+			return
+		}
+	}
+
+	apiReview, err := convert.ToPullReview(review, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToPullReview", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, apiReview)
+}
+
+// SubmitPullReview submit a pending review to a pull request
+func SubmitPullReview(ctx *context.APIContext, opts api.SubmitPullReviewOptions) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/reviews/{id} repository repoSubmitPullReview
+	// ---
+	// summary: Submit a pending review to a pull request
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/SubmitPullReviewOptions"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReview"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	review, pr := getReviewFromContext(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if review.Type != models.ReviewTypePending || review.ReviewerID != ctx.User.ID {
+		ctx.Error(http.StatusUnprocessableEntity, "", "only your own pending reviews can be submitted")
+		return
+	}
+
+	// determine review type
+	reviewType, isWrong := preparePullReviewType(ctx, pr, opts.Event, opts.Body)
+	if isWrong {
+		return
+	}
+	if reviewType == models.ReviewTypePending {
+		ctx.Error(http.StatusUnprocessableEntity, "", "review event must be one of APPROVED, REQUEST_CHANGES or COMMENT")
+		return
+	}
+
+	review, _, err := pull_service.SubmitReview(ctx.User, pr.Issue, reviewType, opts.Body, "")
+	if err != nil {
+		if models.IsContentEmptyErr(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", "review event COMMENT and REQUEST_CHANGES require a body or a line comment")
+			return
+		}
+		ctx.Error(http.StatusInternalServerError, "SubmitReview", err)
+		return
+	}
+
+	apiReview, err := convert.ToPullReview(review, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToPullReview", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, apiReview)
+}
+
+// DismissPullReview dismiss a review of a pull request
+func DismissPullReview(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/reviews/{id}/dismissals repository repoDismissPullReview
+	// ---
+	// summary: Dismiss a review for a pull request
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReview"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	review, _ := getReviewFromContext(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if review.Type != models.ReviewTypeApprove && review.Type != models.ReviewTypeReject {
+		ctx.Error(http.StatusUnprocessableEntity, "", "only approvals and change requests can be dismissed")
+		return
+	}
+
+	if err := models.DismissReview(review, true); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DismissReview", err)
+		return
+	}
+
+	apiReview, err := convert.ToPullReview(review, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToPullReview", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, apiReview)
+}
+
+// preparePullReviewType return ReviewType and false or nil and true if an error happen
+func preparePullReviewType(ctx *context.APIContext, pr *models.PullRequest, event api.ReviewStateType, body string) (models.ReviewType, bool) {
+	var reviewType models.ReviewType
+	switch event {
+	case api.ReviewStateApproved:
+		// can not approve your own PR
+		if pr.Issue.IsPoster(ctx.User.ID) {
+			ctx.Error(http.StatusUnprocessableEntity, "", "approve your own pull is not allowed")
+			return -1, true
+		}
+		reviewType = models.ReviewTypeApprove
+
+	case api.ReviewStateRequestChanges:
+		// can not reject your own PR
+		if pr.Issue.IsPoster(ctx.User.ID) {
+			ctx.Error(http.StatusUnprocessableEntity, "", "reject your own pull is not allowed")
+			return -1, true
+		}
+		reviewType = models.ReviewTypeReject
+
+	case api.ReviewStateComment:
+		reviewType = models.ReviewTypeComment
+
+	case api.ReviewStatePending, api.ReviewStateUnknown:
+		reviewType = models.ReviewTypePending
+
+	default:
+		ctx.Error(http.StatusUnprocessableEntity, "", fmt.Sprintf("unknown review event: %s", event))
+		return -1, true
+	}
+
+	return reviewType, false
+}
+
+// validatePullReviewComment returns false and writes an error if the review comment is invalid
+func validatePullReviewComment(ctx *context.APIContext, c api.CreatePullReviewComment) bool {
+	if len(strings.TrimSpace(c.Body)) == 0 || len(c.Path) == 0 {
+		ctx.Error(http.StatusUnprocessableEntity, "", "review comments require a path and a body")
+		return false
+	}
+	if (c.OldLineNum == 0) == (c.NewLineNum == 0) || c.OldLineNum < 0 || c.NewLineNum < 0 {
+		ctx.Error(http.StatusUnprocessableEntity, "", "review comments require either an old_position or a new_position")
+		return false
+	}
+	return true
+}
+
+// createPullReviewComment adds a line comment to the pending review of the doer
+func createPullReviewComment(doer *models.User, pr *models.PullRequest, c api.CreatePullReviewComment) error {
+	line := c.NewLineNum
+	if c.OldLineNum > 0 {
+		line = c.OldLineNum * -1
+	}
+
+	_, err := pull_service.CreateCodeComment(doer, pr.Issue, line, c.Body, c.Path, true, 0)
+	return err
+}
+
+// getPullRequestForReview loads the pull request and its issue from the context
+func getPullRequestForReview(ctx *context.APIContext) *models.PullRequest {
+	pr, err := models.GetPullRequestByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if models.IsErrPullRequestNotExist(err) {
+			ctx.NotFound("GetPullRequestByIndex", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPullRequestByIndex", err)
+		}
+		return nil
+	}
+
+	if err := pr.LoadIssue(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadIssue", err)
+		return nil
+	}
+	pr.Issue.Repo = ctx.Repo.Repository
+	return pr
+}
+
+// getReviewFromContext return the review and its pull request, or nil if an error happen
+func getReviewFromContext(ctx *context.APIContext) (*models.Review, *models.PullRequest) {
+	pr := getPullRequestForReview(ctx)
+	if ctx.Written() {
+		return nil, nil
+	}
+
+	review, err := models.GetReviewByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrReviewNotExist(err) {
+			ctx.NotFound("GetReviewByID", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetReviewByID", err)
+		}
+		return nil, nil
+	}
+
+	// validate the review is for the given PR and pending reviews are only visible to their author
+	if review.IssueID != pr.IssueID ||
+		(review.Type == models.ReviewTypePending && (ctx.User == nil || ctx.User.ID != review.ReviewerID)) {
+		ctx.NotFound("ReviewNotInPR")
+		return nil, nil
+	}
+
+	review.Issue = pr.Issue
+	return review, pr
+}
+
+// CreateReviewRequests create review requests to an pull request
+func CreateReviewRequests(ctx *context.APIContext, opts api.PullReviewRequestOptions) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/requested_reviewers repository repoCreatePullReviewRequests
+	// ---
+	// summary: create review requests for a pull request
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/PullReviewRequestOptions"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/PullReviewList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	apiReviewRequest(ctx, opts, true)
+}
+
+// DeleteReviewRequests delete review requests to an pull request
+func DeleteReviewRequests(ctx *context.APIContext, opts api.PullReviewRequestOptions) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/pulls/{index}/requested_reviewers repository repoDeletePullReviewRequests
+	// ---
+	// summary: cancel review requests for a pull request
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/PullReviewRequestOptions"
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	apiReviewRequest(ctx, opts, false)
+}
+
+func apiReviewRequest(ctx *context.APIContext, opts api.PullReviewRequestOptions, isAdd bool) {
+	pr := getPullRequestForReview(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	reviewers := make([]*models.User, 0, len(opts.Reviewers))
+	for _, r := range opts.Reviewers {
+		reviewer, err := models.GetUserByName(r)
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "", fmt.Sprintf("reviewer %s does not exist", r))
+				return
+			}
+			ctx.Error(http.StatusInternalServerError, "GetUserByName", err)
+			return
+		}
+
+		if err = issue_service.IsValidReviewRequest(reviewer, ctx.User, isAdd, pr.Issue); err != nil {
+			if models.IsErrNotValidReviewRequest(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "NotValidReviewRequest", err)
+				return
+			}
+			ctx.Error(http.StatusInternalServerError, "IsValidReviewRequest", err)
+			return
+		}
+		reviewers = append(reviewers, reviewer)
+	}
+
+	teamReviewers := make([]*models.Team, 0, len(opts.TeamReviewers))
+	if len(opts.TeamReviewers) > 0 {
+		if err := pr.Issue.Repo.GetOwner(); err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetOwner", err)
+			return
+		}
+		if !pr.Issue.Repo.Owner.IsOrganization() {
+			ctx.Error(http.StatusUnprocessableEntity, "", "teams can only be requested to review pull requests of organization repositories")
+			return
+		}
+		for _, t := range opts.TeamReviewers {
+			team, err := models.GetTeam(pr.Issue.Repo.OwnerID, t)
+			if err != nil {
+				if models.IsErrTeamNotExist(err) {
+					ctx.Error(http.StatusUnprocessableEntity, "", fmt.Sprintf("team %s does not exist", t))
+					return
+				}
+				ctx.Error(http.StatusInternalServerError, "GetTeam", err)
+				return
+			}
+
+			if err = issue_service.IsValidTeamReviewRequest(team, ctx.User, isAdd, pr.Issue); err != nil {
+				if models.IsErrNotValidReviewRequest(err) {
+					ctx.Error(http.StatusUnprocessableEntity, "NotValidReviewRequest", err)
+					return
+				}
+				ctx.Error(http.StatusInternalServerError, "IsValidTeamReviewRequest", err)
+				return
+			}
+			teamReviewers = append(teamReviewers, team)
+		}
+	}
+
+	for _, reviewer := range reviewers {
+		if _, err := issue_service.ReviewRequest(pr.Issue, ctx.User, reviewer, isAdd); err != nil {
+			ctx.Error(http.StatusInternalServerError, "ReviewRequest", err)
+			return
+		}
+	}
+	for _, team := range teamReviewers {
+		if _, err := issue_service.TeamReviewRequest(pr.Issue, ctx.User, team, isAdd); err != nil {
+			ctx.Error(http.StatusInternalServerError, "TeamReviewRequest", err)
+			return
+		}
+	}
+
+	if !isAdd {
+		ctx.Status(http.StatusNoContent)
+		return
+	}
+
+	requests, err := models.GetReviewRequestsByIssueID(pr.IssueID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetReviewRequestsByIssueID", err)
+		return
+	}
+
+	apiReviews, err := convert.ToPullReviewList(requests, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToPullReviewList", err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, apiReviews)
+}
@@ -140,6 +140,7 @@ func Search(ctx *context.APIContext) {
 		StarredByID:        ctx.QueryInt64("starredBy"),
 		IncludeDescription: ctx.QueryBool("includeDesc"),
 	}
+	opts.AccessToken, _ = ctx.Data["ApiToken"].(*models.AccessToken)
 
 	if ctx.Query("template") != "" {
 		opts.Template = util.OptionalBoolOf(ctx.QueryBool("template"))
@@ -420,10 +421,17 @@ func Migrate(ctx *context.APIContext, form auth.MigrateRepoForm) {
 		return
 	}
 
-	var gitServiceType = structs.PlainGitService
-	u, err := url.Parse(remoteAddr)
-	if err == nil && strings.EqualFold(u.Host, "github.com") {
-		gitServiceType = structs.GithubService
+	var gitServiceType = form.Service
+	if gitServiceType == structs.NotMigrated {
+		gitServiceType = structs.PlainGitService
+		u, err := url.Parse(remoteAddr)
+		if err == nil {
+			if strings.EqualFold(u.Host, "github.com") {
+				gitServiceType = structs.GithubService
+			} else if strings.EqualFold(u.Host, "gitlab.com") {
+				gitServiceType = structs.GitlabService
+			}
+		}
 	}
 
 	var opts = migrations.MigrateOptions{
@@ -581,6 +589,10 @@ func GetByID(ctx *context.APIContext) {
 		}
 		return
 	}
+	if token, ok := ctx.Data["ApiToken"].(*models.AccessToken); ok && !token.CanAccessRepo(repo) {
+		ctx.NotFound()
+		return
+	}
 
 	perm, err := models.GetUserRepoPermission(repo, ctx.User)
 	if err != nil {
@@ -995,3 +1007,33 @@ func MirrorSync(ctx *context.APIContext) {
 
 	ctx.Status(http.StatusOK)
 }
+
+// GetIssueTemplates returns the issue templates of a repository
+func GetIssueTemplates(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/issue_templates repository repoGetIssueTemplates
+	// ---
+	// summary: Get available issue templates for a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/IssueTemplates"
+
+	issueTemplates, err := ctx.Repo.GetIssueTemplates()
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetIssueTemplates", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, issueTemplates)
+}
@@ -112,3 +112,10 @@ type swaggerReactionList struct {
 	// in:body
 	Body []api.Reaction `json:"body"`
 }
+
+// IssueTemplates
+// swagger:response IssueTemplates
+type swaggerResponseIssueTemplates struct {
+	// in:body
+	Body []api.IssueTemplate `json:"body"`
+}
@@ -7,12 +7,34 @@ package user
 
 import (
 	"net/http"
+	"time"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
 	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
 )
 
+func toAccessToken(t *models.AccessToken) (*api.AccessToken, error) {
+	repoNames, orgNames, err := t.LoadRestrictions()
+	if err != nil {
+		return nil, err
+	}
+	apiToken := &api.AccessToken{
+		ID:             t.ID,
+		Name:           t.Name,
+		Token:          t.Token,
+		TokenLastEight: t.TokenLastEight,
+		Scopes:         t.Scope.StringSlice(),
+		Repositories:   repoNames,
+		Organizations:  orgNames,
+	}
+	if t.ExpiresUnix > 0 {
+		apiToken.ExpiresAt = t.ExpiresUnix.AsTimePtr()
+	}
+	return apiToken, nil
+}
+
 // ListAccessTokens list all the access tokens
 func ListAccessTokens(ctx *context.APIContext) {
 	// swagger:operation GET /users/{username}/tokens user userGetTokens
@@ -38,10 +60,9 @@ func ListAccessTokens(ctx *context.APIContext) {
 
 	apiTokens := make([]*api.AccessToken, len(tokens))
 	for i := range tokens {
-		apiTokens[i] = &api.AccessToken{
-			ID:             tokens[i].ID,
-			Name:           tokens[i].Name,
-			TokenLastEight: tokens[i].TokenLastEight,
+		if apiTokens[i], err = toAccessToken(tokens[i]); err != nil {
+			ctx.Error(http.StatusInternalServerError, "LoadRestrictions", err)
+			return
 		}
 	}
 	ctx.JSON(http.StatusOK, &apiTokens)
@@ -65,30 +86,57 @@ func CreateAccessToken(ctx *context.APIContext, form api.CreateAccessTokenOption
 	// - name: accessToken
 	//   in: body
 	//   schema:
-	//     type: object
-	//     required:
-	//       - name
-	//     properties:
-	//       name:
-	//         type: string
+	//     "$ref": "#/definitions/CreateAccessTokenOption"
 	// responses:
-	//   "200":
+	//   "201":
 	//     "$ref": "#/responses/AccessToken"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	// a token must not be able to create a token with more privileges than its own
+	if token, ok := ctx.Data["ApiToken"].(*models.AccessToken); ok &&
+		(token.Scope != models.AccessTokenScopeAll || token.IsRestricted()) {
+		ctx.Error(http.StatusForbidden, "", "access tokens with a limited scope can't create access tokens")
+		return
+	}
 
 	t := &models.AccessToken{
-		UID:  ctx.User.ID,
-		Name: form.Name,
+		UID:   ctx.User.ID,
+		Name:  form.Name,
+		Scope: models.AccessTokenScopeFromList(form.Scopes),
+	}
+	if form.ExpiresAt != nil {
+		if !form.ExpiresAt.After(time.Now()) {
+			ctx.Error(http.StatusUnprocessableEntity, "", "expires_at must be in the future")
+			return
+		}
+		t.ExpiresUnix = timeutil.TimeStamp(form.ExpiresAt.Unix())
+	}
+	if err := t.SetRestrictions(ctx.User, form.Repositories, form.Organizations); err != nil {
+		if models.IsErrRepoNotExist(err) || models.IsErrOrgNotExist(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "SetRestrictions", err)
+		}
+		return
 	}
 	if err := models.NewAccessToken(t); err != nil {
-		ctx.Error(http.StatusInternalServerError, "NewAccessToken", err)
+		if models.IsErrInvalidAccessTokenScope(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "NewAccessToken", err)
+		}
 		return
 	}
-	ctx.JSON(http.StatusCreated, &api.AccessToken{
-		Name:           t.Name,
-		Token:          t.Token,
-		ID:             t.ID,
-		TokenLastEight: t.TokenLastEight,
-	})
+
+	apiToken, err := toAccessToken(t)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadRestrictions", err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, apiToken)
 }
 
 // DeleteAccessToken delete access tokens
@@ -20,8 +20,12 @@ func listUserRepos(ctx *context.APIContext, u *models.User, private bool) {
 		return
 	}
 
+	token, _ := ctx.Data["ApiToken"].(*models.AccessToken)
 	apiRepos := make([]*api.Repository, 0, len(repos))
 	for i := range repos {
+		if token != nil && !token.CanAccessRepo(repos[i]) {
+			continue
+		}
 		access, err := models.AccessLevel(ctx.User, repos[i])
 		if err != nil {
 			ctx.Error(http.StatusInternalServerError, "AccessLevel", err)
@@ -81,14 +85,17 @@ func ListMyRepos(ctx *context.APIContext) {
 		return
 	}
 
-	apiRepos := make([]*api.Repository, len(ownRepos)+len(accessibleReposMap))
+	token, _ := ctx.Data["ApiToken"].(*models.AccessToken)
+	apiRepos := make([]*api.Repository, 0, len(ownRepos)+len(accessibleReposMap))
 	for i := range ownRepos {
-		apiRepos[i] = ownRepos[i].APIFormat(models.AccessModeOwner)
+		if token == nil || token.CanAccessRepo(ownRepos[i]) {
+			apiRepos = append(apiRepos, ownRepos[i].APIFormat(models.AccessModeOwner))
+		}
 	}
-	i := len(ownRepos)
 	for repo, access := range accessibleReposMap {
-		apiRepos[i] = repo.APIFormat(access)
-		i++
+		if token == nil || token.CanAccessRepo(repo) {
+			apiRepos = append(apiRepos, repo.APIFormat(access))
+		}
 	}
 	ctx.JSON(http.StatusOK, &apiRepos)
 }
@@ -7,11 +7,13 @@ package utils
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/webhook"
 	"code.gitea.io/gitea/routers/utils"
@@ -34,6 +36,21 @@ func GetOrgHook(ctx *context.APIContext, orgID, hookID int64) (*models.Webhook,
 	return w, nil
 }
 
+// GetAdminHook get an admin default or system webhook. If there is an error,
+// write to `ctx` accordingly and return the error
+func GetAdminHook(ctx *context.APIContext, hookID int64) (*models.Webhook, error) {
+	w, err := models.GetSystemOrDefaultWebhook(hookID)
+	if err != nil {
+		if models.IsErrWebhookNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetSystemOrDefaultWebhook", err)
+		}
+		return nil, err
+	}
+	return w, nil
+}
+
 // GetRepoHook get a repo's webhook. If there is an error, write to `ctx`
 // accordingly and return the error
 func GetRepoHook(ctx *context.APIContext, repoID, hookID int64) (*models.Webhook, error) {
@@ -49,6 +66,52 @@ func GetRepoHook(ctx *context.APIContext, repoID, hookID int64) (*models.Webhook
 	return w, nil
 }
 
+// ListHookDeliveries lists the deliveries of a webhook. Writes to `ctx` accordingly
+func ListHookDeliveries(ctx *context.APIContext, w *models.Webhook) {
+	tasks, err := models.FindHookTasks(w.ID, ctx.QueryInt("page"), convert.ToCorrectPageSize(ctx.QueryInt("limit")))
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindHookTasks", err)
+		return
+	}
+
+	apiDeliveries := make([]*api.HookDelivery, len(tasks))
+	for i := range tasks {
+		apiDeliveries[i] = convert.ToHookDelivery(tasks[i])
+	}
+	ctx.JSON(http.StatusOK, &apiDeliveries)
+}
+
+// GetHookDelivery get a delivery of a webhook. If there is an error, write to
+// `ctx` accordingly and return the error
+func GetHookDelivery(ctx *context.APIContext, w *models.Webhook, id int64) (*models.HookTask, error) {
+	t, err := models.GetHookTaskByHookID(w.ID, id)
+	if err != nil {
+		if models.IsErrHookTaskNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetHookTaskByHookID", err)
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+// RedeliverHookDelivery delivers the payload of a delivery of a webhook again.
+// Writes to `ctx` accordingly
+func RedeliverHookDelivery(ctx *context.APIContext, w *models.Webhook, id int64) {
+	t, err := GetHookDelivery(ctx, w, id)
+	if err != nil {
+		return
+	}
+
+	newTask, err := webhook.Redeliver(t)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "Redeliver", err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, convert.ToHookDelivery(newTask))
+}
+
 // CheckCreateHookOption check if a CreateHookOption form is valid. If invalid,
 // write the appropriate error to `ctx`. Return whether the form is valid
 func CheckCreateHookOption(ctx *context.APIContext, form *api.CreateHookOption) bool {
@@ -56,6 +119,9 @@ func CheckCreateHookOption(ctx *context.APIContext, form *api.CreateHookOption)
 		ctx.Error(http.StatusUnprocessableEntity, "", "Invalid hook type")
 		return false
 	}
+	if models.ToHookTaskType(form.Type) == models.MATRIX {
+		return checkMatrixHookConfig(ctx, form.Config)
+	}
 	for _, name := range []string{"url", "content_type"} {
 		if _, ok := form.Config[name]; !ok {
 			ctx.Error(http.StatusUnprocessableEntity, "", "Missing config option: "+name)
@@ -69,50 +135,125 @@ func CheckCreateHookOption(ctx *context.APIContext, form *api.CreateHookOption)
 	return true
 }
 
+// checkMatrixHookConfig check if the config of a matrix hook is valid. If invalid,
+// write the appropriate error to `ctx`. Return whether the config is valid
+func checkMatrixHookConfig(ctx *context.APIContext, config map[string]string) bool {
+	for _, name := range []string{"homeserver_url", "room_id", "access_token"} {
+		if len(config[name]) == 0 {
+			ctx.Error(http.StatusUnprocessableEntity, "", "Missing config option: "+name)
+			return false
+		}
+	}
+	if u, err := url.Parse(config["homeserver_url"]); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		ctx.Error(http.StatusUnprocessableEntity, "", "Invalid homeserver url")
+		return false
+	}
+	if msgType, ok := config["message_type"]; ok && !webhook.IsValidMatrixMessageType(msgType) {
+		ctx.Error(http.StatusUnprocessableEntity, "", "Invalid message type")
+		return false
+	}
+	return true
+}
+
+// matrixHookMeta returns the metadata of a matrix hook with the given config
+func matrixHookMeta(config map[string]string) ([]byte, error) {
+	msgType := config["message_type"]
+	if msgType == "" {
+		msgType = webhook.MatrixMessageTypeNotice
+	}
+	return json.Marshal(&webhook.MatrixMeta{
+		HomeserverURL: config["homeserver_url"],
+		Room:          config["room_id"],
+		AccessToken:   config["access_token"],
+		MessageType:   msgType,
+	})
+}
+
 // AddOrgHook add a hook to an organization. Writes to `ctx` accordingly
 func AddOrgHook(ctx *context.APIContext, form *api.CreateHookOption) {
 	org := ctx.Org.Organization
-	hook, ok := addHook(ctx, form, org.ID, 0)
+	hook, ok := addHook(ctx, form, org.ID, 0, false)
 	if ok {
 		ctx.JSON(http.StatusCreated, convert.ToHook(org.HomeLink(), hook))
 	}
 }
 
+// broadHookEvents maps the granular events of issues and pull requests to the
+// event they were sent as before they could be chosen on their own.
+var broadHookEvents = map[models.HookEventType]models.HookEventType{
+	models.HookEventIssueAssign:          models.HookEventIssues,
+	models.HookEventIssueLabel:           models.HookEventIssues,
+	models.HookEventIssueMilestone:       models.HookEventIssues,
+	models.HookEventPullRequestAssign:    models.HookEventPullRequest,
+	models.HookEventPullRequestLabel:     models.HookEventPullRequest,
+	models.HookEventPullRequestMilestone: models.HookEventPullRequest,
+	models.HookEventPullRequestSync:      models.HookEventPullRequest,
+}
+
+// toHookEvents returns the events chosen by the names of a hook option, the issues
+// and pull_request events include their granular events.
+func toHookEvents(events []string) models.HookEvents {
+	has := func(event models.HookEventType) bool {
+		if com.IsSliceContainsStr(events, string(event)) {
+			return true
+		}
+		broad, ok := broadHookEvents[event]
+		return ok && com.IsSliceContainsStr(events, string(broad))
+	}
+	return models.HookEvents{
+		Create:               has(models.HookEventCreate),
+		Delete:               has(models.HookEventDelete),
+		Fork:                 has(models.HookEventFork),
+		Issues:               has(models.HookEventIssues),
+		IssueAssign:          has(models.HookEventIssueAssign),
+		IssueLabel:           has(models.HookEventIssueLabel),
+		IssueMilestone:       has(models.HookEventIssueMilestone),
+		IssueComment:         has(models.HookEventIssueComment),
+		Push:                 has(models.HookEventPush),
+		PullRequest:          has(models.HookEventPullRequest),
+		PullRequestAssign:    has(models.HookEventPullRequestAssign),
+		PullRequestLabel:     has(models.HookEventPullRequestLabel),
+		PullRequestMilestone: has(models.HookEventPullRequestMilestone),
+		PullRequestSync:      has(models.HookEventPullRequestSync),
+		Repository:           has(models.HookEventRepository),
+		Release:              has(models.HookEventRelease),
+	}
+}
+
 // AddRepoHook add a hook to a repo. Writes to `ctx` accordingly
 func AddRepoHook(ctx *context.APIContext, form *api.CreateHookOption) {
 	repo := ctx.Repo
-	hook, ok := addHook(ctx, form, 0, repo.Repository.ID)
+	hook, ok := addHook(ctx, form, 0, repo.Repository.ID, false)
 	if ok {
 		ctx.JSON(http.StatusCreated, convert.ToHook(repo.RepoLink, hook))
 	}
 }
 
-// addHook add the hook specified by `form`, `orgID` and `repoID`. If there is
-// an error, write to `ctx` accordingly. Return (webhook, ok)
-func addHook(ctx *context.APIContext, form *api.CreateHookOption, orgID, repoID int64) (*models.Webhook, bool) {
+// AddAdminHook add an admin default or system hook. Writes to `ctx` accordingly
+func AddAdminHook(ctx *context.APIContext, form *api.CreateHookOption) {
+	hook, ok := addHook(ctx, form, 0, 0, form.IsSystemWebhook)
+	if ok {
+		ctx.JSON(http.StatusCreated, convert.ToHook(setting.AppURL+"admin", hook))
+	}
+}
+
+// addHook add the hook specified by `form`, `orgID`, `repoID` and `isSystemWebhook`.
+// If there is an error, write to `ctx` accordingly. Return (webhook, ok)
+func addHook(ctx *context.APIContext, form *api.CreateHookOption, orgID, repoID int64, isSystemWebhook bool) (*models.Webhook, bool) {
 	if len(form.Events) == 0 {
 		form.Events = []string{"push"}
 	}
 	w := &models.Webhook{
-		OrgID:       orgID,
-		RepoID:      repoID,
-		URL:         form.Config["url"],
-		ContentType: models.ToHookContentType(form.Config["content_type"]),
-		Secret:      form.Config["secret"],
-		HTTPMethod:  "POST",
+		OrgID:           orgID,
+		RepoID:          repoID,
+		IsSystemWebhook: isSystemWebhook,
+		URL:             form.Config["url"],
+		ContentType:     models.ToHookContentType(form.Config["content_type"]),
+		Secret:          form.Config["secret"],
+		HTTPMethod:      "POST",
 		HookEvent: &models.HookEvent{
 			ChooseEvents: true,
-			HookEvents: models.HookEvents{
-				Create:       com.IsSliceContainsStr(form.Events, string(models.HookEventCreate)),
-				Delete:       com.IsSliceContainsStr(form.Events, string(models.HookEventDelete)),
-				Fork:         com.IsSliceContainsStr(form.Events, string(models.HookEventFork)),
-				Issues:       com.IsSliceContainsStr(form.Events, string(models.HookEventIssues)),
-				IssueComment: com.IsSliceContainsStr(form.Events, string(models.HookEventIssueComment)),
-				Push:         com.IsSliceContainsStr(form.Events, string(models.HookEventPush)),
-				PullRequest:  com.IsSliceContainsStr(form.Events, string(models.HookEventPullRequest)),
-				Repository:   com.IsSliceContainsStr(form.Events, string(models.HookEventRepository)),
-				Release:      com.IsSliceContainsStr(form.Events, string(models.HookEventRelease)),
-			},
+			HookEvents:   toHookEvents(form.Events),
 			BranchFilter: form.BranchFilter,
 		},
 		IsActive:     form.Active,
@@ -142,6 +283,17 @@ func addHook(ctx *context.APIContext, form *api.CreateHookOption, orgID, repoID
 		}
 		w.Meta = string(meta)
 	}
+	if w.HookTaskType == models.MATRIX {
+		meta, err := matrixHookMeta(form.Config)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "matrix: JSON marshal failed", err)
+			return nil, false
+		}
+		w.Meta = string(meta)
+		w.URL = webhook.MatrixSendURL(form.Config["homeserver_url"], form.Config["room_id"])
+		w.ContentType = models.ContentTypeJSON
+		w.HTTPMethod = "PUT"
+	}
 
 	if err := w.UpdateEvent(); err != nil {
 		ctx.Error(http.StatusInternalServerError, "UpdateEvent", err)
@@ -170,6 +322,23 @@ func EditOrgHook(ctx *context.APIContext, form *api.EditHookOption, hookID int64
 	ctx.JSON(http.StatusOK, convert.ToHook(org.HomeLink(), updated))
 }
 
+// EditAdminHook edit an admin default or system webhook according to `form`.
+// Writes to `ctx` accordingly
+func EditAdminHook(ctx *context.APIContext, form *api.EditHookOption, hookID int64) {
+	hook, err := GetAdminHook(ctx, hookID)
+	if err != nil {
+		return
+	}
+	if !editHook(ctx, form, hook) {
+		return
+	}
+	updated, err := GetAdminHook(ctx, hookID)
+	if err != nil {
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToHook(setting.AppURL+"admin", updated))
+}
+
 // EditRepoHook edit webhook `w` according to `form`. Writes to `ctx` accordingly
 func EditRepoHook(ctx *context.APIContext, form *api.EditHookOption, hookID int64) {
 	repo := ctx.Repo
@@ -217,6 +386,32 @@ func editHook(ctx *context.APIContext, form *api.EditHookOption, w *models.Webho
 				w.Meta = string(meta)
 			}
 		}
+
+		if w.HookTaskType == models.MATRIX {
+			// the options which are not given are kept
+			config := make(map[string]string)
+			meta := webhook.GetMatrixHook(w)
+			config["homeserver_url"] = meta.HomeserverURL
+			config["room_id"] = meta.Room
+			config["access_token"] = meta.AccessToken
+			config["message_type"] = meta.MessageType
+			for _, name := range []string{"homeserver_url", "room_id", "access_token", "message_type"} {
+				if value, ok := form.Config[name]; ok {
+					config[name] = value
+				}
+			}
+			if !checkMatrixHookConfig(ctx, config) {
+				return false
+			}
+			data, err := matrixHookMeta(config)
+			if err != nil {
+				ctx.Error(http.StatusInternalServerError, "matrix: JSON marshal failed", err)
+				return false
+			}
+			w.Meta = string(data)
+			w.URL = webhook.MatrixSendURL(config["homeserver_url"], config["room_id"])
+			w.ContentType = models.ContentTypeJSON
+		}
 	}
 
 	// Update events
@@ -226,18 +421,7 @@ func editHook(ctx *context.APIContext, form *api.EditHookOption, w *models.Webho
 	w.PushOnly = false
 	w.SendEverything = false
 	w.ChooseEvents = true
-	w.Create = com.IsSliceContainsStr(form.Events, string(models.HookEventCreate))
-	w.Push = com.IsSliceContainsStr(form.Events, string(models.HookEventPush))
-	w.PullRequest = com.IsSliceContainsStr(form.Events, string(models.HookEventPullRequest))
-	w.Create = com.IsSliceContainsStr(form.Events, string(models.HookEventCreate))
-	w.Delete = com.IsSliceContainsStr(form.Events, string(models.HookEventDelete))
-	w.Fork = com.IsSliceContainsStr(form.Events, string(models.HookEventFork))
-	w.Issues = com.IsSliceContainsStr(form.Events, string(models.HookEventIssues))
-	w.IssueComment = com.IsSliceContainsStr(form.Events, string(models.HookEventIssueComment))
-	w.Push = com.IsSliceContainsStr(form.Events, string(models.HookEventPush))
-	w.PullRequest = com.IsSliceContainsStr(form.Events, string(models.HookEventPullRequest))
-	w.Repository = com.IsSliceContainsStr(form.Events, string(models.HookEventRepository))
-	w.Release = com.IsSliceContainsStr(form.Events, string(models.HookEventRelease))
+	w.HookEvents = toHookEvents(form.Events)
 	w.BranchFilter = form.BranchFilter
 
 	if err := w.UpdateEvent(); err != nil {
@@ -12,7 +12,9 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/models/migrations"
+	"code.gitea.io/gitea/modules/auth/oauth2"
 	"code.gitea.io/gitea/modules/auth/sso"
+	"code.gitea.io/gitea/modules/auth/webauthn"
 	"code.gitea.io/gitea/modules/cache"
 	"code.gitea.io/gitea/modules/cron"
 	"code.gitea.io/gitea/modules/git"
@@ -25,6 +27,7 @@ import (
 	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/ssh"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/task"
 	"code.gitea.io/gitea/modules/webhook"
 	"code.gitea.io/gitea/services/mailer"
@@ -95,6 +98,9 @@ func GlobalInit(ctx context.Context) {
 		highlight.NewContext()
 		external.RegisterParsers()
 		markup.Init()
+		if err := storage.Init(); err != nil {
+			log.Fatal("Failed to initialize storages: %v", err)
+		}
 		if err := initDBEngine(ctx); err == nil {
 			log.Info("ORM engine initialization successful!")
 		} else {
@@ -104,6 +110,15 @@ func GlobalInit(ctx context.Context) {
 		if err := models.InitOAuth2(); err != nil {
 			log.Fatal("Failed to initialize OAuth2 support: %v", err)
 		}
+		if setting.OAuth2.Enable {
+			if err := oauth2.InitSigningKey(); err != nil {
+				log.Fatal("Failed to initialize OAuth2 signing key: %v", err)
+			}
+		}
+
+		if err := webauthn.Init(); err != nil {
+			log.Fatal("Failed to initialize WebAuthn: %v", err)
+		}
 
 		models.NewRepoContext()
 
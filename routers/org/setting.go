@@ -77,6 +77,7 @@ func SettingsPost(ctx *context.Context, form auth.UpdateOrgSettingForm) {
 
 	if ctx.User.IsAdmin {
 		org.MaxRepoCreation = form.MaxRepoCreation
+		org.RequireTwoFactorAuth = form.RequireTwoFactorAuth
 	}
 
 	org.FullName = form.FullName
@@ -17,6 +17,7 @@ import (
 	"code.gitea.io/gitea/modules/private"
 	"code.gitea.io/gitea/modules/repofiles"
 	"code.gitea.io/gitea/modules/util"
+	mirror_service "code.gitea.io/gitea/services/mirror"
 
 	"gitea.com/macaron/macaron"
 )
@@ -40,8 +41,13 @@ func HookPreReceive(ctx *macaron.Context, opts private.HookOptions) {
 		newCommitID := opts.NewCommitIDs[i]
 		refFullName := opts.RefFullNames[i]
 
+		// only branches can be protected, patterns must not match other refs
+		if !strings.HasPrefix(refFullName, git.BranchPrefix) {
+			continue
+		}
+
 		branchName := strings.TrimPrefix(refFullName, git.BranchPrefix)
-		protectBranch, err := models.GetProtectedBranchBy(repo.ID, branchName)
+		protectBranch, err := models.GetMatchingProtectedBranch(repo.ID, branchName)
 		if err != nil {
 			log.Error("Unable to get protected branch: %s in %-v Error: %v", branchName, repo, err)
 			ctx.JSON(500, map[string]interface{}{
@@ -59,22 +65,23 @@ func HookPreReceive(ctx *macaron.Context, opts private.HookOptions) {
 				return
 			}
 
+			// the environment to access the quarantined objects of the push
+			env := os.Environ()
+			if opts.GitAlternativeObjectDirectories != "" {
+				env = append(env,
+					private.GitAlternativeObjectDirectories+"="+opts.GitAlternativeObjectDirectories)
+			}
+			if opts.GitObjectDirectory != "" {
+				env = append(env,
+					private.GitObjectDirectory+"="+opts.GitObjectDirectory)
+			}
+			if opts.GitQuarantinePath != "" {
+				env = append(env,
+					private.GitQuarantinePath+"="+opts.GitQuarantinePath)
+			}
+
 			// detect force push
 			if git.EmptySHA != oldCommitID {
-				env := os.Environ()
-				if opts.GitAlternativeObjectDirectories != "" {
-					env = append(env,
-						private.GitAlternativeObjectDirectories+"="+opts.GitAlternativeObjectDirectories)
-				}
-				if opts.GitObjectDirectory != "" {
-					env = append(env,
-						private.GitObjectDirectory+"="+opts.GitObjectDirectory)
-				}
-				if opts.GitQuarantinePath != "" {
-					env = append(env,
-						private.GitQuarantinePath+"="+opts.GitQuarantinePath)
-				}
-
 				output, err := git.NewCommand("rev-list", "--max-count=1", oldCommitID, "^"+newCommitID).RunInDirWithEnv(repo.RepoPath(), env)
 				if err != nil {
 					log.Error("Unable to detect force push between: %s and %s in %-v Error: %v", oldCommitID, newCommitID, repo, err)
@@ -91,6 +98,69 @@ func HookPreReceive(ctx *macaron.Context, opts private.HookOptions) {
 
 				}
 			}
+			// the commits which are new to the branch and the changes they make, the commits
+			// of a new branch are those not on any ref yet and its changes are compared to
+			// the default branch it is forked from
+			revRange := []string{oldCommitID + ".." + newCommitID}
+			diffRange := []string{oldCommitID, newCommitID}
+			if oldCommitID == git.EmptySHA {
+				revRange = []string{newCommitID, "--not", "--all"}
+				if branchName != repo.DefaultBranch && git.IsBranchExist(repo.RepoPath(), repo.DefaultBranch) {
+					diffRange = []string{git.BranchPrefix + repo.DefaultBranch + "..." + newCommitID}
+				} else {
+					diffRange = []string{git.EmptyTreeSHA, newCommitID}
+				}
+			}
+
+			if protectBranch.RequireSignedCommits {
+				unverifiedCommitID, err := models.FindUnverifiedCommit(repo.RepoPath(), revRange, env)
+				if err != nil {
+					log.Error("Unable to check commit signatures between: %s and %s in %-v Error: %v", oldCommitID, newCommitID, repo, err)
+					ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+						"err": fmt.Sprintf("Unable to check commit signatures: %v", err),
+					})
+					return
+				} else if unverifiedCommitID != "" {
+					log.Warn("Forbidden: Branch: %s in %-v requires signed commits but commit %s is not verified", branchName, repo, unverifiedCommitID)
+					ctx.JSON(http.StatusForbidden, map[string]interface{}{
+						"err": fmt.Sprintf("branch %s requires signed commits but commit %s is not verified", branchName, unverifiedCommitID),
+					})
+					return
+				}
+			}
+
+			if len(protectBranch.ProtectedFilePatterns) > 0 {
+				// admins may change protected files, deploy keys never may
+				isAdmin := false
+				if !opts.IsDeployKey {
+					isAdmin, err = isUserRepoAdmin(repo, opts.UserID)
+					if err != nil {
+						log.Error("Unable to get permission of user %d in %-v Error: %v", opts.UserID, repo, err)
+						ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+							"err": fmt.Sprintf("Unable to get permission of user %d: %v", opts.UserID, err),
+						})
+						return
+					}
+				}
+
+				if !isAdmin {
+					protectedFile, err := getChangedProtectedFile(protectBranch, repo, diffRange, env)
+					if err != nil {
+						log.Error("Unable to check protected files between: %s and %s in %-v Error: %v", oldCommitID, newCommitID, repo, err)
+						ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+							"err": fmt.Sprintf("Unable to check protected files: %v", err),
+						})
+						return
+					} else if protectedFile != "" {
+						log.Warn("Forbidden: User %d cannot change protected file %s of branch %s in %-v", opts.UserID, protectedFile, branchName, repo)
+						ctx.JSON(http.StatusForbidden, map[string]interface{}{
+							"err": fmt.Sprintf("branch %s is protected from changing file %s", branchName, protectedFile),
+						})
+						return
+					}
+				}
+			}
+
 			canPush := false
 			if opts.IsDeployKey {
 				canPush = protectBranch.CanPush && (!protectBranch.EnableWhitelist || protectBranch.WhitelistDeployKeys)
@@ -120,6 +190,13 @@ func HookPreReceive(ctx *macaron.Context, opts private.HookOptions) {
 					})
 					return
 				}
+				if protectBranch.MergeBlockedByCodeOwners(pr) {
+					log.Warn("Forbidden: User %d cannot push to protected branch: %s in %-v and pr #%d is not approved by its code owners", opts.UserID, branchName, repo, pr.Index)
+					ctx.JSON(http.StatusForbidden, map[string]interface{}{
+						"err": fmt.Sprintf("protected branch %s can not be pushed to and pr #%d is not approved by its code owners", branchName, opts.ProtectedBranchID),
+					})
+					return
+				}
 			} else if !canPush {
 				log.Warn("Forbidden: User %d cannot push to protected branch: %s in %-v", opts.UserID, branchName, repo)
 				ctx.JSON(http.StatusForbidden, map[string]interface{}{
@@ -133,6 +210,30 @@ func HookPreReceive(ctx *macaron.Context, opts private.HookOptions) {
 	ctx.PlainText(http.StatusOK, []byte("ok"))
 }
 
+// isUserRepoAdmin returns if the user with the given id is an admin of the repository
+func isUserRepoAdmin(repo *models.Repository, userID int64) (bool, error) {
+	user, err := models.GetUserByID(userID)
+	if err != nil {
+		return false, err
+	}
+	perm, err := models.GetUserRepoPermission(repo, user)
+	if err != nil {
+		return false, err
+	}
+	return perm.IsAdmin(), nil
+}
+
+// getChangedProtectedFile returns the first protected file changed between the
+// two sides of diffRange, or an empty string if no protected file is changed
+func getChangedProtectedFile(protectBranch *models.ProtectedBranch, repo *models.Repository, diffRange []string, env []string) (string, error) {
+	args := append([]string{"diff", "--name-only", "-z", "--no-renames"}, diffRange...)
+	stdout, err := git.NewCommand(args...).RunInDirWithEnv(repo.RepoPath(), env)
+	if err != nil || len(stdout) == 0 {
+		return "", err
+	}
+	return protectBranch.MatchProtectedFile(strings.Split(strings.TrimRight(stdout, "\x00"), "\x00")), nil
+}
+
 // HookPostReceive updates services and users
 func HookPostReceive(ctx *macaron.Context, opts private.HookOptions) {
 	ownerName := ctx.Params(":owner")
@@ -204,6 +305,8 @@ func HookPostReceive(ctx *macaron.Context, opts private.HookOptions) {
 			})
 			return
 		}
+
+		mirror_service.SyncPushMirrorsOnPush(repo.ID)
 	}
 
 	results := make([]private.HookPostReceiveBranchResult, 0, len(opts.OldCommitIDs))
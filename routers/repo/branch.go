@@ -203,7 +203,7 @@ func loadBranches(ctx *context.Context) []*Branch {
 		var isProtected bool
 		branchName := rawBranches[i].Name
 		for _, b := range protectedBranches {
-			if b.BranchName == branchName {
+			if b.Match(branchName) {
 				isProtected = true
 				break
 			}
@@ -434,7 +434,8 @@ func CompareDiff(ctx *context.Context) {
 	ctx.Data["RequireTribute"] = true
 	ctx.Data["RequireSimpleMDE"] = true
 	ctx.Data["PullRequestWorkInProgressPrefixes"] = setting.Repository.PullRequest.WorkInProgressPrefixes
-	setTemplateIfExists(ctx, pullRequestTemplateKey, pullRequestTemplateCandidates)
+	it, found := getTemplateFromFiles(ctx, pullRequestTemplateCandidates)
+	setTemplateIfExists(ctx, pullRequestTemplateKey, it, found)
 	renderAttachmentSettings(ctx)
 
 	ctx.HTML(200, tplCompare)
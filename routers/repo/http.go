@@ -131,6 +131,7 @@ func HTTP(ctx *context.Context) {
 	var (
 		askAuth      = !isPublicPull || setting.Service.RequireSignInView
 		authUser     *models.User
+		accessToken  *models.AccessToken
 		authUsername string
 		authPasswd   string
 		environ      []string
@@ -186,11 +187,11 @@ func HTTP(ctx *context.Context) {
 				// Assume password is token
 				authToken = authPasswd
 			}
-			uid := sso.CheckOAuthAccessToken(authToken)
-			if uid != 0 {
+			if grant := sso.CheckOAuthAccessToken(authToken); grant != nil {
 				ctx.Data["IsApiToken"] = true
+				accessToken = grant.ToAccessToken()
 
-				authUser, err = models.GetUserByID(uid)
+				authUser, err = models.GetUserByID(grant.UserID)
 				if err != nil {
 					ctx.ServerError("GetUserByID", err)
 					return
@@ -224,6 +225,7 @@ func HTTP(ctx *context.Context) {
 				if err = models.UpdateAccessToken(token); err != nil {
 					ctx.ServerError("UpdateAccessToken", err)
 				}
+				accessToken = token
 			} else if !models.IsErrAccessTokenNotExist(err) && !models.IsErrAccessTokenEmpty(err) {
 				log.Error("GetAccessTokenBySha: %v", err)
 			}
@@ -255,11 +257,41 @@ func HTTP(ctx *context.Context) {
 					ctx.ServerError("IsErrTwoFactorNotEnrolled", err)
 					return
 				}
+
+				if pending, err := models.IsTwoFactorSetUpPending(authUser.ID); err != nil {
+					ctx.ServerError("IsTwoFactorSetUpPending", err)
+					return
+				} else if pending {
+					ctx.HandleText(http.StatusUnauthorized, "Users who are required to use two-factor authentication cannot perform HTTP/HTTPS operations via plain username and password until they have set it up. Please set it up and use a personal access token")
+					return
+				}
+			}
+		}
+
+		permUser := authUser
+		if accessToken != nil {
+			level := models.AccessTokenScopeLevelWrite
+			if isPull {
+				level = models.AccessTokenScopeLevelRead
+			}
+			if !accessToken.Scope.HasScope(models.AccessTokenScopeCategoryRepository, level) {
+				ctx.HandleText(http.StatusForbidden, fmt.Sprintf("Token does not have the required scope %s:%s", level, models.AccessTokenScopeCategoryRepository))
+				return
+			}
+			if (repoExist && !accessToken.CanAccessRepo(repo)) || (!repoExist && accessToken.IsRestricted()) {
+				ctx.HandleText(http.StatusForbidden, "Token is not allowed to access this repository")
+				return
+			}
+			if authUser.IsAdmin && !accessToken.Scope.HasScope(models.AccessTokenScopeCategoryAdmin, models.AccessTokenScopeLevelRead) {
+				// site admin privileges require the admin scope
+				u := *authUser
+				u.IsAdmin = false
+				permUser = &u
 			}
 		}
 
 		if repoExist {
-			perm, err := models.GetUserRepoPermission(repo, authUser)
+			perm, err := models.GetUserRepoPermission(repo, permUser)
 			if err != nil {
 				ctx.ServerError("GetUserRepoPermission", err)
 				return
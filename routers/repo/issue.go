@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"path"
 	"strconv"
 	"strings"
 
@@ -36,9 +37,10 @@ import (
 const (
 	tplAttachment base.TplName = "repo/issue/view_content/attachments"
 
-	tplIssues    base.TplName = "repo/issue/list"
-	tplIssueNew  base.TplName = "repo/issue/new"
-	tplIssueView base.TplName = "repo/issue/view"
+	tplIssues      base.TplName = "repo/issue/list"
+	tplIssueNew    base.TplName = "repo/issue/new"
+	tplIssueChoose base.TplName = "repo/issue/choose"
+	tplIssueView   base.TplName = "repo/issue/view"
 
 	tplReactions base.TplName = "repo/issue/view_content/reactions"
 
@@ -246,9 +248,9 @@ func issues(ctx *context.Context, milestoneID int64, isPullOption util.OptionalB
 		return
 	}
 
-	labels, err := models.GetLabelsByRepoID(repo.ID, "")
+	labels, err := getRepoAndOrgLabels(repo)
 	if err != nil {
-		ctx.ServerError("GetLabelsByRepoID", err)
+		ctx.ServerError("getRepoAndOrgLabels", err)
 		return
 	}
 	for _, l := range labels {
@@ -303,6 +305,7 @@ func Issues(ctx *context.Context) {
 		}
 		ctx.Data["Title"] = ctx.Tr("repo.issues")
 		ctx.Data["PageIsIssueList"] = true
+		ctx.Data["NewIssueChooseTemplate"] = mustChooseIssueTemplate(ctx)
 	}
 
 	issues(ctx, ctx.QueryInt64("milestone"), util.OptionalBoolOf(isPullList))
@@ -325,6 +328,21 @@ func Issues(ctx *context.Context) {
 	ctx.HTML(200, tplIssues)
 }
 
+// retrieveProjects find all the projects of a repository
+func retrieveProjects(ctx *context.Context, repo *models.Repository) {
+	var err error
+	ctx.Data["OpenProjects"], err = models.GetProjects(repo.ID, -1, false, "")
+	if err != nil {
+		ctx.ServerError("GetProjects", err)
+		return
+	}
+	ctx.Data["ClosedProjects"], err = models.GetProjects(repo.ID, -1, true, "")
+	if err != nil {
+		ctx.ServerError("GetProjects", err)
+		return
+	}
+}
+
 // RetrieveRepoMilestonesAndAssignees find all the milestones and assignees of a repository
 func RetrieveRepoMilestonesAndAssignees(ctx *context.Context, repo *models.Repository) {
 	var err error
@@ -346,15 +364,35 @@ func RetrieveRepoMilestonesAndAssignees(ctx *context.Context, repo *models.Repos
 	}
 }
 
+// getRepoAndOrgLabels returns the labels of the repository followed by the labels
+// of the organization owning it
+func getRepoAndOrgLabels(repo *models.Repository) ([]*models.Label, error) {
+	labels, err := models.GetLabelsByRepoID(repo.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	if err = repo.GetOwner(); err != nil {
+		return nil, err
+	} else if !repo.Owner.IsOrganization() {
+		return labels, nil
+	}
+
+	orgLabels, err := models.GetLabelsByOrgID(repo.OwnerID, "")
+	if err != nil {
+		return nil, err
+	}
+	return append(labels, orgLabels...), nil
+}
+
 // RetrieveRepoMetas find all the meta information of a repository
 func RetrieveRepoMetas(ctx *context.Context, repo *models.Repository, isPull bool) []*models.Label {
 	if !ctx.Repo.CanWriteIssuesOrPulls(isPull) {
 		return nil
 	}
 
-	labels, err := models.GetLabelsByRepoID(repo.ID, "")
+	labels, err := getRepoAndOrgLabels(repo)
 	if err != nil {
-		ctx.ServerError("GetLabelsByRepoID", err)
+		ctx.ServerError("getRepoAndOrgLabels", err)
 		return nil
 	}
 	ctx.Data["Labels"] = labels
@@ -407,14 +445,121 @@ func getFileContentFromDefaultBranch(ctx *context.Context, filename string) (str
 	return string(bytes), true
 }
 
-func setTemplateIfExists(ctx *context.Context, ctxDataKey string, possibleFiles []string) {
+// getTemplateFromFiles returns the first of possibleFiles found in the default branch,
+// the YAML front matter of the file is used as metadata of the template if present
+func getTemplateFromFiles(ctx *context.Context, possibleFiles []string) (*api.IssueTemplate, bool) {
 	for _, filename := range possibleFiles {
 		content, found := getFileContentFromDefaultBranch(ctx, filename)
-		if found {
-			ctx.Data[ctxDataKey] = content
-			return
+		if !found {
+			continue
+		}
+
+		it := new(api.IssueTemplate)
+		body, err := markdown.ExtractMetadata(content, it)
+		if err != nil {
+			it = &api.IssueTemplate{Content: content}
+		} else {
+			it.Content = body
 		}
+		it.FileName = path.Base(filename)
+		return it, true
 	}
+	return nil, false
+}
+
+// getIssueTemplate returns the template a new issue is created from: the template
+// chosen by its file name, the only template of the templates directory or the
+// single template file of the repository, an unknown file name means a blank issue
+func getIssueTemplate(ctx *context.Context, fileName string) (*api.IssueTemplate, bool) {
+	templates, err := ctx.Repo.GetIssueTemplates()
+	if err != nil {
+		log.Error("GetIssueTemplates: %v", err)
+	}
+	if len(fileName) > 0 {
+		for i := range templates {
+			if templates[i].FileName == fileName {
+				return &templates[i], true
+			}
+		}
+		return nil, false
+	} else if len(templates) == 1 {
+		return &templates[0], true
+	}
+	return getTemplateFromFiles(ctx, IssueTemplateCandidates)
+}
+
+// setTemplateIfExists presets the content, title, labels and assignees of the
+// new issue or pull request form from the template
+func setTemplateIfExists(ctx *context.Context, ctxDataKey string, it *api.IssueTemplate, found bool) {
+	if !found {
+		return
+	}
+	ctx.Data[ctxDataKey] = it.Content
+	if _, ok := ctx.Data["title"]; !ok && len(it.Title) > 0 {
+		ctx.Data["title"] = it.Title
+	}
+
+	if labels, ok := ctx.Data["Labels"].([]*models.Label); ok && len(it.Labels) > 0 {
+		labelIDs := make([]string, 0, len(it.Labels))
+		for _, label := range labels {
+			if com.IsSliceContainsStr(it.Labels, label.Name) {
+				label.IsChecked = true
+				labelIDs = append(labelIDs, com.ToStr(label.ID))
+			}
+		}
+		ctx.Data["HasSelectedLabel"] = len(labelIDs) > 0
+		ctx.Data["label_ids"] = strings.Join(labelIDs, ",")
+	}
+
+	if assignees, ok := ctx.Data["Assignees"].([]*models.User); ok && len(it.Assignees) > 0 {
+		assigneeIDs := make([]string, 0, len(it.Assignees))
+		selectedAssignees := make(map[int64]bool, len(it.Assignees))
+		for _, assignee := range assignees {
+			if com.IsSliceContainsStr(it.Assignees, assignee.Name) {
+				selectedAssignees[assignee.ID] = true
+				assigneeIDs = append(assigneeIDs, com.ToStr(assignee.ID))
+			}
+		}
+		ctx.Data["SelectedAssignees"] = selectedAssignees
+		ctx.Data["HasSelectedAssignee"] = len(assigneeIDs) > 0
+		ctx.Data["assignee_ids"] = strings.Join(assigneeIDs, ",")
+	}
+}
+
+// mustChooseIssueTemplate returns true if the repository has several issue
+// templates, a new issue then starts from the page choosing one of them
+func mustChooseIssueTemplate(ctx *context.Context) bool {
+	issueTemplates, err := ctx.Repo.GetIssueTemplates()
+	if err != nil {
+		log.Error("GetIssueTemplates: %v", err)
+		return false
+	}
+	return len(issueTemplates) > 1
+}
+
+// NewIssueChooseTemplate render the page to choose the template of a new issue
+func NewIssueChooseTemplate(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.issues.new")
+	ctx.Data["PageIsIssueList"] = true
+
+	issueTemplates, err := ctx.Repo.GetIssueTemplates()
+	if err != nil {
+		ctx.ServerError("GetIssueTemplates", err)
+		return
+	}
+	milestoneID := ctx.QueryInt64("milestone")
+	if len(issueTemplates) < 2 {
+		link := ctx.Repo.RepoLink + "/issues/new"
+		if milestoneID > 0 {
+			link += "?milestone=" + com.ToStr(milestoneID)
+		}
+		ctx.Redirect(link)
+		return
+	}
+	ctx.Data["IssueTemplates"] = issueTemplates
+	ctx.Data["milestone"] = milestoneID
+
+	ctx.HTML(200, tplIssueChoose)
 }
 
 // NewIssue render creating issue page
@@ -439,7 +584,6 @@ func NewIssue(ctx *context.Context) {
 		}
 	}
 
-	setTemplateIfExists(ctx, issueTemplateKey, IssueTemplateCandidates)
 	renderAttachmentSettings(ctx)
 
 	RetrieveRepoMetas(ctx, ctx.Repo.Repository, false)
@@ -447,6 +591,9 @@ func NewIssue(ctx *context.Context) {
 		return
 	}
 
+	it, found := getIssueTemplate(ctx, ctx.Query("template"))
+	setTemplateIfExists(ctx, issueTemplateKey, it, found)
+
 	ctx.HTML(200, tplIssueNew)
 }
 
@@ -667,6 +814,7 @@ func ViewIssue(ctx *context.Context) {
 			return
 		}
 		ctx.Data["PageIsIssueList"] = true
+		ctx.Data["NewIssueChooseTemplate"] = mustChooseIssueTemplate(ctx)
 	}
 
 	if issue.IsPull && !ctx.Repo.CanRead(models.UnitTypeIssues) {
@@ -738,9 +886,9 @@ func ViewIssue(ctx *context.Context) {
 	for i := range issue.Labels {
 		labelIDMark[issue.Labels[i].ID] = true
 	}
-	labels, err := models.GetLabelsByRepoID(repo.ID, "")
+	labels, err := getRepoAndOrgLabels(repo)
 	if err != nil {
-		ctx.ServerError("GetLabelsByRepoID", err)
+		ctx.ServerError("getRepoAndOrgLabels", err)
 		return
 	}
 	hasSelected := false
@@ -759,6 +907,13 @@ func ViewIssue(ctx *context.Context) {
 		if ctx.Written() {
 			return
 		}
+
+		if ctx.Repo.CanRead(models.UnitTypeProjects) {
+			retrieveProjects(ctx, repo)
+			if ctx.Written() {
+				return
+			}
+		}
 	}
 
 	if ctx.IsSigned {
@@ -872,11 +1027,30 @@ func ViewIssue(ctx *context.Context) {
 			if comment.MilestoneID > 0 && comment.Milestone == nil {
 				comment.Milestone = ghostMilestone
 			}
-		} else if comment.Type == models.CommentTypeAssignees {
+		} else if comment.Type == models.CommentTypeProject {
+			if err = comment.LoadProject(); err != nil {
+				ctx.ServerError("LoadProject", err)
+				return
+			}
+			ghostProject := &models.Project{
+				ID:    -1,
+				Title: ctx.Tr("repo.issues.deleted_project"),
+			}
+			if comment.OldProjectID > 0 && comment.OldProject == nil {
+				comment.OldProject = ghostProject
+			}
+			if comment.ProjectID > 0 && comment.Project == nil {
+				comment.Project = ghostProject
+			}
+		} else if comment.Type == models.CommentTypeAssignees || comment.Type == models.CommentTypeReviewRequest {
 			if err = comment.LoadAssigneeUser(); err != nil {
 				ctx.ServerError("LoadAssigneeUser", err)
 				return
 			}
+			if err = comment.LoadAssigneeTeam(); err != nil {
+				ctx.ServerError("LoadAssigneeTeam", err)
+				return
+			}
 		} else if comment.Type == models.CommentTypeRemoveDependency || comment.Type == models.CommentTypeAddDependency {
 			if err = comment.LoadDepIssueDetails(); err != nil {
 				ctx.ServerError("LoadDepIssueDetails", err)
@@ -937,7 +1111,18 @@ func ViewIssue(ctx *context.Context) {
 			ctx.ServerError("GetUnit", err)
 			return
 		}
-		prConfig := prUnit.PullRequestsConfig()
+		prConfig := *prUnit.PullRequestsConfig()
+
+		if err = pull.LoadProtectedBranch(); err != nil {
+			ctx.ServerError("LoadProtectedBranch", err)
+			return
+		}
+		if pull.ProtectedBranch != nil {
+			// hide the merge styles the protected branch does not allow
+			prConfig.AllowRebase = prConfig.AllowRebase && pull.ProtectedBranch.IsMergeStyleAllowed(models.MergeStyleRebase)
+			prConfig.AllowRebaseMerge = prConfig.AllowRebaseMerge && pull.ProtectedBranch.IsMergeStyleAllowed(models.MergeStyleRebaseMerge)
+		}
+		ctx.Data["PullRequestsConfig"] = &prConfig
 
 		ctx.Data["AllowMerge"] = ctx.Repo.CanWrite(models.UnitTypeCode)
 		if err := pull.CheckUserAllowedToMerge(ctx.User); err != nil {
@@ -963,14 +1148,12 @@ func ViewIssue(ctx *context.Context) {
 				ctx.Data["MergeStyle"] = ""
 			}
 		}
-		if err = pull.LoadProtectedBranch(); err != nil {
-			ctx.ServerError("LoadProtectedBranch", err)
-			return
-		}
 		if pull.ProtectedBranch != nil {
 			cnt := pull.ProtectedBranch.GetGrantedApprovalsCount(pull)
 			ctx.Data["IsBlockedByApprovals"] = !pull.ProtectedBranch.HasEnoughApprovals(pull)
 			ctx.Data["IsBlockedByRejection"] = pull.ProtectedBranch.MergeBlockedByRejectedReview(pull)
+			ctx.Data["IsBlockedByCodeOwners"] = pull.ProtectedBranch.MergeBlockedByCodeOwners(pull)
+			ctx.Data["IsBlockedByUnsignedCommits"] = pull.ProtectedBranch.MergeBlockedByUnsignedCommits(pull, ctx.User)
 			ctx.Data["GrantedApprovals"] = cnt
 		}
 		ctx.Data["IsPullBranchDeletable"] = canDelete &&
@@ -983,6 +1166,30 @@ func ViewIssue(ctx *context.Context) {
 			ctx.ServerError("GetReviewersByIssueID", err)
 			return
 		}
+
+		ctx.Data["ReviewRequests"], err = models.GetReviewRequestsByIssueID(issue.ID)
+		if err != nil {
+			ctx.ServerError("GetReviewRequestsByIssueID", err)
+			return
+		}
+
+		if ctx.IsSigned && (issue.IsPoster(ctx.User.ID) || ctx.Repo.CanWrite(models.UnitTypePullRequests)) {
+			ctx.Data["CanChooseReviewer"] = true
+			if ctx.Data["Reviewers"], err = repo.GetReviewers(ctx.User.ID, issue.PosterID); err != nil {
+				ctx.ServerError("GetReviewers", err)
+				return
+			}
+			if err = repo.GetOwner(); err != nil {
+				ctx.ServerError("GetOwner", err)
+				return
+			}
+			if repo.Owner.IsOrganization() {
+				if ctx.Data["TeamReviewers"], err = models.GetTeamsWithAccessToRepo(repo.OwnerID, repo.ID, models.AccessModeRead); err != nil {
+					ctx.ServerError("GetTeamsWithAccessToRepo", err)
+					return
+				}
+			}
+		}
 	}
 
 	// Get Dependencies
@@ -1198,6 +1405,110 @@ func UpdateIssueAssignee(ctx *context.Context) {
 	})
 }
 
+// UpdatePullReviewRequest add or remove review request
+func UpdatePullReviewRequest(ctx *context.Context) {
+	issues := getActionIssues(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	// negative ids are the ids of teams
+	reviewID := ctx.QueryInt64("id")
+	action := ctx.Query("action")
+
+	if action != "attach" && action != "detach" {
+		ctx.Status(403)
+		return
+	}
+
+	for _, issue := range issues {
+		if err := issue.LoadRepo(); err != nil {
+			ctx.ServerError("issue.LoadRepo", err)
+			return
+		}
+
+		if !issue.IsPull || issue.RepoID != ctx.Repo.Repository.ID {
+			log.Warn(
+				"UpdatePullReviewRequest: refusing to add review request for non-PR issue %-v#%d",
+				issue.Repo, issue.Index,
+			)
+			ctx.Status(403)
+			return
+		}
+
+		if reviewID < 0 {
+			team, err := models.GetTeamByID(-reviewID)
+			if err != nil {
+				if models.IsErrTeamNotExist(err) {
+					log.Warn(
+						"UpdatePullReviewRequest: requested reviewer team [%d] for %-v#%d does not exist",
+						-reviewID, issue.Repo, issue.Index,
+					)
+					ctx.NotFound("GetTeamByID", err)
+					return
+				}
+				ctx.ServerError("GetTeamByID", err)
+				return
+			}
+
+			if err = issue_service.IsValidTeamReviewRequest(team, ctx.User, action == "attach", issue); err != nil {
+				if models.IsErrNotValidReviewRequest(err) {
+					log.Warn(
+						"UpdatePullReviewRequest: refusing to add invalid team review request for team %d to PR %-v#%d: %v",
+						team.ID, issue.Repo, issue.Index, err,
+					)
+					ctx.Status(403)
+					return
+				}
+				ctx.ServerError("IsValidTeamReviewRequest", err)
+				return
+			}
+
+			if _, err = issue_service.TeamReviewRequest(issue, ctx.User, team, action == "attach"); err != nil {
+				ctx.ServerError("TeamReviewRequest", err)
+				return
+			}
+			continue
+		}
+
+		reviewer, err := models.GetUserByID(reviewID)
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				log.Warn(
+					"UpdatePullReviewRequest: requested reviewer [%d] for %-v#%d does not exist",
+					reviewID, issue.Repo, issue.Index,
+				)
+				ctx.NotFound("GetUserByID", err)
+				return
+			}
+			ctx.ServerError("GetUserByID", err)
+			return
+		}
+
+		if err = issue_service.IsValidReviewRequest(reviewer, ctx.User, action == "attach", issue); err != nil {
+			if models.IsErrNotValidReviewRequest(err) {
+				log.Warn(
+					"UpdatePullReviewRequest: refusing to add invalid review request for %-v to PR %-v#%d: %v",
+					reviewer, issue.Repo, issue.Index, err,
+				)
+				ctx.Status(403)
+				return
+			}
+			ctx.ServerError("IsValidReviewRequest", err)
+			return
+		}
+
+		if _, err = issue_service.ReviewRequest(issue, ctx.User, reviewer, action == "attach"); err != nil {
+			ctx.ServerError("ReviewRequest", err)
+			return
+		}
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"ok": true,
+	})
+}
+
 // UpdateIssueStatus change issue's status
 func UpdateIssueStatus(ctx *context.Context) {
 	issues := getActionIssues(ctx)
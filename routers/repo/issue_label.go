@@ -10,6 +10,7 @@ import (
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
 	issue_service "code.gitea.io/gitea/services/issue"
 )
 
@@ -35,7 +36,7 @@ func InitializeLabels(ctx *context.Context, form auth.InitializeLabelsForm) {
 		return
 	}
 
-	if err := models.InitalizeLabels(ctx.Repo.Repository.ID, form.TemplateName); err != nil {
+	if err := models.InitalizeLabels(ctx.Repo.Repository.ID, form.TemplateName, false); err != nil {
 		if models.IsErrIssueLabelTemplateLoad(err) {
 			originalErr := err.(models.ErrIssueLabelTemplateLoad).OriginalError
 			ctx.Flash.Error(ctx.Tr("repo.issues.label_templates.fail_to_load_file", form.TemplateName, originalErr))
@@ -59,6 +60,33 @@ func RetrieveLabels(ctx *context.Context) {
 		l.CalOpenIssues()
 	}
 	ctx.Data["Labels"] = labels
+
+	repo := ctx.Repo.Repository
+	if err := repo.GetOwner(); err != nil {
+		ctx.ServerError("GetOwner", err)
+		return
+	}
+	if repo.Owner.IsOrganization() {
+		orgLabels, err := models.GetLabelsByOrgID(repo.Owner.ID, ctx.Query("sort"))
+		if err != nil {
+			ctx.ServerError("GetLabelsByOrgID", err)
+			return
+		}
+		for _, l := range orgLabels {
+			l.CalOpenIssues()
+		}
+		ctx.Data["OrgLabels"] = orgLabels
+
+		if ctx.User != nil {
+			isOwner, err := repo.Owner.IsOwnedBy(ctx.User.ID)
+			if err != nil {
+				ctx.ServerError("IsOwnedBy", err)
+				return
+			}
+			ctx.Data["IsOrganizationOwner"] = isOwner
+			ctx.Data["OrganizationLink"] = setting.AppSubURL + "/org/" + repo.Owner.Name
+		}
+	}
 	ctx.Data["NumLabels"] = len(labels)
 	ctx.Data["SortType"] = ctx.Query("sort")
 }
@@ -89,7 +117,7 @@ func NewLabel(ctx *context.Context, form auth.CreateLabelForm) {
 
 // UpdateLabel update a label's name and color
 func UpdateLabel(ctx *context.Context, form auth.CreateLabelForm) {
-	l, err := models.GetLabelByID(form.ID)
+	l, err := models.GetLabelInRepoByID(ctx.Repo.Repository.ID, form.ID)
 	if err != nil {
 		switch {
 		case models.IsErrLabelNotExist(err):
@@ -148,6 +176,10 @@ func UpdateIssueLabel(ctx *context.Context) {
 			}
 			return
 		}
+		if label.RepoID != ctx.Repo.Repository.ID && label.OrgID != ctx.Repo.Repository.OwnerID {
+			ctx.Error(404, "GetLabelByID")
+			return
+		}
 
 		if action == "toggle" {
 			// detach if any issues already have label, otherwise attach
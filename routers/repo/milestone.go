@@ -275,6 +275,7 @@ func MilestoneIssuesAndPulls(ctx *context.Context) {
 	}
 	ctx.Data["CanWriteIssues"] = perm.CanWriteIssuesOrPulls(false)
 	ctx.Data["CanWritePulls"] = perm.CanWriteIssuesOrPulls(true)
+	ctx.Data["NewIssueChooseTemplate"] = mustChooseIssueTemplate(ctx)
 
 	ctx.HTML(200, tplMilestoneIssues)
 }
@@ -0,0 +1,394 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	tplProjects     base.TplName = "repo/projects/list"
+	tplProjectsNew  base.TplName = "repo/projects/new"
+	tplProjectsView base.TplName = "repo/projects/view"
+)
+
+// Projects renders the projects page of a repository
+func Projects(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects")
+	ctx.Data["PageIsProjects"] = true
+
+	repo := ctx.Repo.Repository
+	isShowClosed := ctx.Query("state") == "closed"
+	ctx.Data["OpenCount"] = repo.NumOpenProjects
+	ctx.Data["ClosedCount"] = repo.NumClosedProjects
+
+	sortType := ctx.Query("sort")
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	var total int
+	if !isShowClosed {
+		total = repo.NumOpenProjects
+	} else {
+		total = repo.NumClosedProjects
+	}
+
+	projects, err := models.GetProjects(repo.ID, page, isShowClosed, sortType)
+	if err != nil {
+		ctx.ServerError("GetProjects", err)
+		return
+	}
+	for _, p := range projects {
+		p.RenderedContent = string(markdown.Render([]byte(p.Description), ctx.Repo.RepoLink, repo.ComposeMetas()))
+	}
+	ctx.Data["Projects"] = projects
+
+	if isShowClosed {
+		ctx.Data["State"] = "closed"
+	} else {
+		ctx.Data["State"] = "open"
+	}
+
+	ctx.Data["SortType"] = sortType
+	ctx.Data["IsShowClosed"] = isShowClosed
+	ctx.Data["CanWriteProjects"] = ctx.Repo.CanWrite(models.UnitTypeProjects)
+
+	pager := context.NewPagination(total, setting.UI.IssuePagingNum, page, 5)
+	pager.AddParam(ctx, "state", "State")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, tplProjects)
+}
+
+// NewProject renders the page for creating a project
+func NewProject(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["ProjectBoardConfigs"] = models.ProjectBoardConfigs
+	ctx.HTML(200, tplProjectsNew)
+}
+
+// NewProjectPost creates a new project
+func NewProjectPost(ctx *context.Context, form auth.CreateProjectForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["ProjectBoardConfigs"] = models.ProjectBoardConfigs
+
+	if ctx.HasError() {
+		ctx.HTML(200, tplProjectsNew)
+		return
+	}
+
+	if !models.IsProjectBoardTypeValid(form.BoardType) {
+		ctx.Data["Err_BoardType"] = true
+		ctx.RenderWithErr(ctx.Tr("repo.projects.type.invalid"), tplProjectsNew, &form)
+		return
+	}
+
+	if err := models.NewProject(&models.Project{
+		RepoID:      ctx.Repo.Repository.ID,
+		Title:       form.Title,
+		Description: form.Content,
+		CreatorID:   ctx.User.ID,
+		BoardType:   form.BoardType,
+	}); err != nil {
+		ctx.ServerError("NewProject", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.create_success", form.Title))
+	ctx.Redirect(ctx.Repo.RepoLink + "/projects")
+}
+
+// getProject returns the project of the current repository with the id of the request parameters
+func getProject(ctx *context.Context) *models.Project {
+	p, err := models.GetProjectByRepoID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrProjectNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("GetProjectByRepoID", err)
+		}
+		return nil
+	}
+	return p
+}
+
+// EditProject renders the page for editing a project
+func EditProject(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["PageIsEditProjects"] = true
+
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["title"] = p.Title
+	ctx.Data["content"] = p.Description
+	ctx.HTML(200, tplProjectsNew)
+}
+
+// EditProjectPost updates the title and description of a project
+func EditProjectPost(ctx *context.Context, form auth.CreateProjectForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["PageIsEditProjects"] = true
+
+	if ctx.HasError() {
+		ctx.HTML(200, tplProjectsNew)
+		return
+	}
+
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	p.Title = form.Title
+	p.Description = form.Content
+	if err := models.UpdateProject(p); err != nil {
+		ctx.ServerError("UpdateProject", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.edit_success", p.Title))
+	ctx.Redirect(ctx.Repo.RepoLink + "/projects")
+}
+
+// ChangeProjectStatus opens or closes a project
+func ChangeProjectStatus(ctx *context.Context) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	switch ctx.Params(":action") {
+	case "open":
+		if p.IsClosed {
+			if err := models.ChangeProjectStatus(p, false); err != nil {
+				ctx.ServerError("ChangeProjectStatus", err)
+				return
+			}
+		}
+		ctx.Redirect(ctx.Repo.RepoLink + "/projects?state=open")
+	case "close":
+		if !p.IsClosed {
+			if err := models.ChangeProjectStatus(p, true); err != nil {
+				ctx.ServerError("ChangeProjectStatus", err)
+				return
+			}
+		}
+		ctx.Redirect(ctx.Repo.RepoLink + "/projects?state=closed")
+	default:
+		ctx.Redirect(ctx.Repo.RepoLink + "/projects")
+	}
+}
+
+// DeleteProject deletes a project and its columns
+func DeleteProject(ctx *context.Context) {
+	if err := models.DeleteProjectByRepoID(ctx.Repo.Repository.ID, ctx.QueryInt64("id")); err != nil {
+		ctx.Flash.Error("DeleteProjectByRepoID: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.projects.deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": ctx.Repo.RepoLink + "/projects",
+	})
+}
+
+// ViewProject renders the board of a project with its columns and cards
+func ViewProject(ctx *context.Context) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	boards, err := models.GetProjectBoards(p.ID)
+	if err != nil {
+		ctx.ServerError("GetProjectBoards", err)
+		return
+	}
+	boards = append(models.ProjectBoardList{p.GetUncategorizedBoard()}, boards...)
+	if err = boards.LoadIssues(); err != nil {
+		ctx.ServerError("LoadIssues", err)
+		return
+	}
+
+	p.RenderedContent = string(markdown.Render([]byte(p.Description), ctx.Repo.RepoLink, ctx.Repo.Repository.ComposeMetas()))
+	ctx.Data["Title"] = p.Title
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["Project"] = p
+	ctx.Data["Boards"] = boards
+	ctx.Data["CanWriteProjects"] = ctx.Repo.CanWrite(models.UnitTypeProjects)
+
+	ctx.HTML(200, tplProjectsView)
+}
+
+// AddBoardToProjectPost adds a new column to a project
+func AddBoardToProjectPost(ctx *context.Context, form auth.EditProjectBoardForm) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	projectLink := fmt.Sprintf("%s/projects/%d", ctx.Repo.RepoLink, p.ID)
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+		ctx.Redirect(projectLink)
+		return
+	}
+
+	if err := models.NewProjectBoard(&models.ProjectBoard{
+		ProjectID: p.ID,
+		Title:     form.Title,
+		Sorting:   form.Sorting,
+		CreatorID: ctx.User.ID,
+	}); err != nil {
+		ctx.ServerError("NewProjectBoard", err)
+		return
+	}
+	ctx.Redirect(projectLink)
+}
+
+// getProjectBoard returns the column of the project with the ids of the request parameters
+func getProjectBoard(ctx *context.Context) (*models.Project, *models.ProjectBoard) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return nil, nil
+	}
+
+	boardID := ctx.ParamsInt64(":boardID")
+	if boardID == 0 {
+		return p, p.GetUncategorizedBoard()
+	}
+	board, err := models.GetProjectBoard(p.ID, boardID)
+	if err != nil {
+		if models.IsErrProjectBoardNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("GetProjectBoard", err)
+		}
+		return nil, nil
+	}
+	return p, board
+}
+
+// EditProjectBoardPost updates the title and sorting of a project column
+func EditProjectBoardPost(ctx *context.Context, form auth.EditProjectBoardForm) {
+	p, board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+	projectLink := fmt.Sprintf("%s/projects/%d", ctx.Repo.RepoLink, p.ID)
+
+	if board.IsUncategorized() {
+		ctx.NotFound("", nil)
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+		ctx.Redirect(projectLink)
+		return
+	}
+
+	board.Title = form.Title
+	board.Sorting = form.Sorting
+	if err := models.UpdateProjectBoard(board); err != nil {
+		ctx.ServerError("UpdateProjectBoard", err)
+		return
+	}
+	ctx.Redirect(projectLink)
+}
+
+// DeleteProjectBoard deletes a project column, its cards become uncategorized
+func DeleteProjectBoard(ctx *context.Context) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteProjectBoard(p.ID, ctx.QueryInt64("id")); err != nil {
+		ctx.Flash.Error("DeleteProjectBoard: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.projects.column.deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": fmt.Sprintf("%s/projects/%d", ctx.Repo.RepoLink, p.ID),
+	})
+}
+
+// MoveIssueAcrossBoards moves an issue or pull request to another column of a project
+func MoveIssueAcrossBoards(ctx *context.Context) {
+	_, board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	issue, err := models.GetIssueByID(ctx.QueryInt64("issue"))
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("GetIssueByID", err)
+		}
+		return
+	}
+	if issue.RepoID != ctx.Repo.Repository.ID {
+		ctx.NotFound("", nil)
+		return
+	}
+
+	if err = models.MoveIssueAcrossProjectBoards(issue, ctx.User, board); err != nil {
+		ctx.ServerError("MoveIssueAcrossProjectBoards", err)
+		return
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+// UpdateIssueProject changes the project issues and pull requests are assigned to
+func UpdateIssueProject(ctx *context.Context) {
+	issues := getActionIssues(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	projectID := ctx.QueryInt64("id")
+	for _, issue := range issues {
+		if issue.RepoID != ctx.Repo.Repository.ID {
+			ctx.NotFound("", nil)
+			return
+		}
+		if issue.ProjectID() == projectID {
+			continue
+		}
+		if err := models.ChangeProjectAssign(issue, ctx.User, projectID); err != nil {
+			if models.IsErrProjectNotExist(err) {
+				ctx.NotFound("", nil)
+			} else {
+				ctx.ServerError("ChangeProjectAssign", err)
+			}
+			return
+		}
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"ok": true,
+	})
+}
@@ -18,6 +18,7 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/migrations"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/task"
 	"code.gitea.io/gitea/modules/util"
 	repo_service "code.gitea.io/gitea/services/repository"
@@ -347,6 +348,9 @@ func MigratePost(ctx *context.Context, form auth.MigrateRepoForm) {
 		PullRequests: form.PullRequests,
 		Releases:     form.Releases,
 	}
+	if form.Service != structs.NotMigrated {
+		opts.GitServiceType = form.Service
+	}
 	if opts.Mirror {
 		opts.Issues = false
 		opts.Milestones = false
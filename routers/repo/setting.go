@@ -22,6 +22,7 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/repository"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/validation"
 	"code.gitea.io/gitea/routers/utils"
@@ -29,7 +30,6 @@ import (
 	mirror_service "code.gitea.io/gitea/services/mirror"
 	repo_service "code.gitea.io/gitea/services/repository"
 
-	"github.com/unknwon/com"
 	"mvdan.cc/xurls/v2"
 )
 
@@ -50,6 +50,15 @@ func Settings(ctx *context.Context) {
 	ctx.Data["Title"] = ctx.Tr("repo.settings")
 	ctx.Data["PageIsSettingsOptions"] = true
 	ctx.Data["ForcePrivate"] = setting.Repository.ForcePrivate
+
+	pushMirrors, err := models.GetPushMirrorsByRepoID(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.ServerError("GetPushMirrorsByRepoID", err)
+		return
+	}
+	ctx.Data["PushMirrors"] = pushMirrors
+	ctx.Data["push_mirror_interval"] = setting.Mirror.DefaultInterval.String()
+
 	ctx.HTML(200, tplSettingsOptions)
 }
 
@@ -60,6 +69,13 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 
 	repo := ctx.Repo.Repository
 
+	pushMirrors, err := models.GetPushMirrorsByRepoID(repo.ID)
+	if err != nil {
+		ctx.ServerError("GetPushMirrorsByRepoID", err)
+		return
+	}
+	ctx.Data["PushMirrors"] = pushMirrors
+
 	switch ctx.Query("action") {
 	case "update":
 		if ctx.HasError() {
@@ -203,6 +219,58 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 		ctx.Flash.Info(ctx.Tr("repo.settings.mirror_sync_in_progress"))
 		ctx.Redirect(repo.Link() + "/settings")
 
+	case "push-mirror-add":
+		// This section doesn't require repo_name/RepoName to be set in the form, don't show it
+		// as an error on the UI for this action
+		ctx.Data["Err_RepoName"] = nil
+
+		interval, err := time.ParseDuration(form.PushMirrorInterval)
+		if err != nil || (interval != 0 && interval < setting.Mirror.MinInterval) {
+			ctx.Data["Err_PushMirrorInterval"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.mirror_interval_invalid"), tplSettingsOptions, &form)
+			return
+		}
+
+		address, err := auth.ParsePushMirrorAddress(form.PushMirrorAddress, form.PushMirrorUsername, form.PushMirrorPassword)
+		if err != nil {
+			ctx.Data["Err_PushMirrorAddress"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.settings.push_mirror_address_invalid"), tplSettingsOptions, &form)
+			return
+		}
+
+		if _, err := mirror_service.AddPushMirror(repo, address, interval, form.PushMirrorSyncOnPush); err != nil {
+			ctx.ServerError("AddPushMirror", err)
+			return
+		}
+
+		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
+		ctx.Redirect(repo.Link() + "/settings")
+
+	case "push-mirror-remove":
+		m := getPushMirrorByIDAndRepo(ctx, form.PushMirrorID)
+		if ctx.Written() {
+			return
+		}
+
+		if err := mirror_service.RemovePushMirror(m); err != nil {
+			ctx.ServerError("RemovePushMirror", err)
+			return
+		}
+
+		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
+		ctx.Redirect(repo.Link() + "/settings")
+
+	case "push-mirror-sync":
+		m := getPushMirrorByIDAndRepo(ctx, form.PushMirrorID)
+		if ctx.Written() {
+			return
+		}
+
+		mirror_service.StartToPushMirror(m.ID)
+
+		ctx.Flash.Info(ctx.Tr("repo.settings.mirror_sync_in_progress"))
+		ctx.Redirect(repo.Link() + "/settings")
+
 	case "advanced":
 		var units []models.RepoUnit
 
@@ -276,6 +344,14 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 			}
 		}
 
+		if form.EnableProjects {
+			units = append(units, models.RepoUnit{
+				RepoID: repo.ID,
+				Type:   models.UnitTypeProjects,
+				Config: new(models.UnitConfig),
+			})
+		}
+
 		if form.EnablePulls {
 			units = append(units, models.RepoUnit{
 				RepoID: repo.ID,
@@ -472,6 +548,23 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 	}
 }
 
+func getPushMirrorByIDAndRepo(ctx *context.Context, id int64) *models.PushMirror {
+	m, err := models.GetPushMirrorByID(id)
+	if err != nil {
+		if err == models.ErrPushMirrorNotExist {
+			ctx.NotFound("GetPushMirrorByID", err)
+		} else {
+			ctx.ServerError("GetPushMirrorByID", err)
+		}
+		return nil
+	}
+	if m.RepoID != ctx.Repo.Repository.ID {
+		ctx.NotFound("GetPushMirrorByID", nil)
+		return nil
+	}
+	return m
+}
+
 // Collaboration render a repository's collaboration page
 func Collaboration(ctx *context.Context) {
 	ctx.Data["Title"] = ctx.Tr("repo.settings")
@@ -824,7 +917,7 @@ func UpdateAvatarSetting(ctx *context.Context, form auth.AvatarForm) error {
 		// No avatar is uploaded and we not removing it here.
 		// No random avatar generated here.
 		// Just exit, no action.
-		if !com.IsFile(ctxRepo.CustomAvatarPath()) {
+		if _, err := storage.RepoAvatars.Stat(ctxRepo.CustomAvatarRelativePath()); err != nil {
 			log.Trace("No avatar was uploaded for repo: %d. Default icon will appear instead.", ctxRepo.ID)
 		}
 		return nil
@@ -16,6 +16,7 @@ import (
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
 )
 
 // ProtectedBranch render the page to protect the repository
@@ -86,15 +87,32 @@ func ProtectedBranchPost(ctx *context.Context) {
 
 		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
 		ctx.Redirect(setting.AppSubURL + ctx.Req.URL.Path)
+	case "protected_branch_pattern":
+		pattern := strings.TrimSpace(ctx.Query("pattern"))
+		if !models.IsBranchProtectionPattern(pattern) || !models.IsValidBranchProtectionPattern(pattern) {
+			ctx.Flash.Error(ctx.Tr("repo.settings.protected_branch_invalid_pattern", pattern))
+			ctx.Redirect(setting.AppSubURL + ctx.Req.URL.Path)
+			return
+		}
+
+		ctx.Redirect(fmt.Sprintf("%s/settings/branches/%s", ctx.Repo.RepoLink, util.PathEscapeSegments(pattern)))
 	default:
 		ctx.NotFound("", nil)
 	}
 }
 
+// isValidProtectedBranchRule returns if the rule is an existing branch or a valid pattern
+func isValidProtectedBranchRule(ctx *context.Context, rule string) bool {
+	if models.IsBranchProtectionPattern(rule) && models.IsValidBranchProtectionPattern(rule) {
+		return true
+	}
+	return ctx.Repo.GitRepo.IsBranchExist(rule)
+}
+
 // SettingsProtectedBranch renders the protected branch setting page
 func SettingsProtectedBranch(c *context.Context) {
 	branch := c.Params("*")
-	if !c.Repo.GitRepo.IsBranchExist(branch) {
+	if !isValidProtectedBranchRule(c, branch) {
 		c.NotFound("IsBranchExist", nil)
 		return
 	}
@@ -140,6 +158,7 @@ func SettingsProtectedBranch(c *context.Context) {
 		}
 	}
 
+	c.Data["protected_file_patterns"] = strings.Join(protectBranch.ProtectedFilePatterns, ";")
 	c.Data["branch_status_check_contexts"] = contexts
 	c.Data["is_context_required"] = func(context string) bool {
 		for _, c := range protectBranch.StatusCheckContexts {
@@ -162,6 +181,17 @@ func SettingsProtectedBranch(c *context.Context) {
 		c.Data["approvals_whitelist_teams"] = strings.Join(base.Int64sToStrings(protectBranch.ApprovalsWhitelistTeamIDs), ",")
 	}
 
+	if protectBranch.IsPattern() {
+		branches := c.Data["Branches"].([]string)
+		matchingBranches := make([]string, 0, len(branches))
+		for _, b := range branches {
+			if protectBranch.Match(b) {
+				matchingBranches = append(matchingBranches, b)
+			}
+		}
+		c.Data["MatchingBranches"] = strings.Join(matchingBranches, ", ")
+	}
+
 	c.Data["Branch"] = protectBranch
 	c.HTML(200, tplProtectedBranch)
 }
@@ -169,7 +199,7 @@ func SettingsProtectedBranch(c *context.Context) {
 // SettingsProtectedBranchPost updates the protected branch settings
 func SettingsProtectedBranchPost(ctx *context.Context, f auth.ProtectBranchForm) {
 	branch := ctx.Params("*")
-	if !ctx.Repo.GitRepo.IsBranchExist(branch) {
+	if !isValidProtectedBranchRule(ctx, branch) {
 		ctx.NotFound("IsBranchExist", nil)
 		return
 	}
@@ -192,7 +222,7 @@ func SettingsProtectedBranchPost(ctx *context.Context, f auth.ProtectBranchForm)
 		}
 		if f.RequiredApprovals < 0 {
 			ctx.Flash.Error(ctx.Tr("repo.settings.protected_branch_required_approvals_min"))
-			ctx.Redirect(fmt.Sprintf("%s/settings/branches/%s", ctx.Repo.RepoLink, branch))
+			ctx.Redirect(fmt.Sprintf("%s/settings/branches/%s", ctx.Repo.RepoLink, util.PathEscapeSegments(branch)))
 		}
 
 		var whitelistUsers, whitelistTeams, mergeWhitelistUsers, mergeWhitelistTeams, approvalsWhitelistUsers, approvalsWhitelistTeams []int64
@@ -245,6 +275,22 @@ func SettingsProtectedBranchPost(ctx *context.Context, f auth.ProtectBranchForm)
 			}
 		}
 		protectBranch.BlockOnRejectedReviews = f.BlockOnRejectedReviews
+		protectBranch.RequireCodeOwnerApproval = f.RequireCodeOwnerApproval
+		protectBranch.RequireSignedCommits = f.RequireSignedCommits
+
+		protectBranch.ProtectedFilePatterns = nil
+		for _, pattern := range strings.Split(f.ProtectedFilePatterns, ";") {
+			pattern = strings.TrimSpace(pattern)
+			if pattern == "" {
+				continue
+			}
+			if !models.IsValidBranchProtectionPattern(pattern) {
+				ctx.Flash.Error(ctx.Tr("repo.settings.protect_invalid_file_pattern", pattern))
+				ctx.Redirect(fmt.Sprintf("%s/settings/branches/%s", ctx.Repo.RepoLink, util.PathEscapeSegments(branch)))
+				return
+			}
+			protectBranch.ProtectedFilePatterns = append(protectBranch.ProtectedFilePatterns, pattern)
+		}
 
 		err = models.UpdateProtectBranch(ctx.Repo.Repository, protectBranch, models.WhitelistOptions{
 			UserIDs:          whitelistUsers,
@@ -259,7 +305,7 @@ func SettingsProtectedBranchPost(ctx *context.Context, f auth.ProtectBranchForm)
 			return
 		}
 		ctx.Flash.Success(ctx.Tr("repo.settings.update_protect_branch_success", branch))
-		ctx.Redirect(fmt.Sprintf("%s/settings/branches/%s", ctx.Repo.RepoLink, branch))
+		ctx.Redirect(fmt.Sprintf("%s/settings/branches/%s", ctx.Repo.RepoLink, util.PathEscapeSegments(branch)))
 	} else {
 		if protectBranch != nil {
 			if err := ctx.Repo.Repository.DeleteProtectedBranch(protectBranch.ID); err != nil {
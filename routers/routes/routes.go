@@ -8,7 +8,9 @@ import (
 	"bytes"
 	"encoding/gob"
 	"net/http"
+	"os"
 	"path"
+	"strings"
 	"text/template"
 	"time"
 
@@ -21,6 +23,7 @@ import (
 	"code.gitea.io/gitea/modules/options"
 	"code.gitea.io/gitea/modules/public"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/templates"
 	"code.gitea.io/gitea/modules/validation"
 	"code.gitea.io/gitea/routers"
@@ -47,8 +50,8 @@ import (
 	"gitea.com/macaron/macaron"
 	"gitea.com/macaron/session"
 	"gitea.com/macaron/toolbox"
+	"github.com/duo-labs/webauthn/webauthn"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/tstranex/u2f"
 )
 
 type routerLoggerOptions struct {
@@ -106,9 +109,66 @@ func RouterHandler(level log.Level) func(ctx *macaron.Context) {
 	}
 }
 
+// storageHandler serves the files of a storage below prefix, local files are
+// served as static files while other storages either redirect to the object
+// or stream it
+func storageHandler(storageSetting setting.Storage, prefix string, objStore storage.ObjectStorage) macaron.Handler {
+	if storageSetting.IsLocal() {
+		return public.StaticHandler(
+			storageSetting.Path,
+			&public.Options{
+				Prefix:       prefix,
+				SkipLogging:  setting.DisableRouterLog,
+				ExpiresAfter: setting.StaticCacheTime,
+			},
+		)
+	}
+
+	prefix = "/" + prefix + "/"
+	return func(ctx *macaron.Context) {
+		if ctx.Req.Method != "GET" && ctx.Req.Method != "HEAD" {
+			return
+		}
+		if !strings.HasPrefix(ctx.Req.URL.Path, prefix) {
+			return
+		}
+
+		rPath := strings.TrimPrefix(ctx.Req.URL.Path, prefix)
+		if rPath == "" {
+			ctx.Error(http.StatusNotFound)
+			return
+		}
+
+		if storageSetting.ServeDirect {
+			u, err := objStore.URL(rPath, path.Base(rPath))
+			if err != nil {
+				log.Error("Error whilst getting URL for %s %s. Error: %v", prefix, rPath, err)
+				ctx.Error(http.StatusInternalServerError)
+				return
+			}
+			ctx.Redirect(u.String(), http.StatusTemporaryRedirect)
+			return
+		}
+
+		obj, err := objStore.Open(rPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				ctx.Error(http.StatusNotFound)
+				return
+			}
+			log.Error("Error whilst opening %s %s. Error: %v", prefix, rPath, err)
+			ctx.Error(http.StatusInternalServerError)
+			return
+		}
+		defer obj.Close()
+
+		http.ServeContent(ctx.Resp, ctx.Req.Request, path.Base(rPath), time.Time{}, obj)
+	}
+}
+
 // NewMacaron initializes Macaron instance.
 func NewMacaron() *macaron.Macaron {
-	gob.Register(&u2f.Challenge{})
+	gob.Register(&webauthn.SessionData{})
 	var m *macaron.Macaron
 	if setting.RedirectMacaronLog {
 		loggerAsWriter := log.NewLoggerAsWriter("INFO", log.GetLogger("macaron"))
@@ -148,22 +208,8 @@ func NewMacaron() *macaron.Macaron {
 			ExpiresAfter: setting.StaticCacheTime,
 		},
 	))
-	m.Use(public.StaticHandler(
-		setting.AvatarUploadPath,
-		&public.Options{
-			Prefix:       "avatars",
-			SkipLogging:  setting.DisableRouterLog,
-			ExpiresAfter: setting.StaticCacheTime,
-		},
-	))
-	m.Use(public.StaticHandler(
-		setting.RepositoryAvatarUploadPath,
-		&public.Options{
-			Prefix:       "repo-avatars",
-			SkipLogging:  setting.DisableRouterLog,
-			ExpiresAfter: setting.StaticCacheTime,
-		},
-	))
+	m.Use(storageHandler(setting.AvatarStorage, "avatars", storage.Avatars))
+	m.Use(storageHandler(setting.RepoAvatarStorage, "repo-avatars", storage.RepoAvatars))
 
 	m.Use(templates.HTMLRenderer())
 	mailer.InitMailRender(templates.Mailer())
@@ -273,6 +319,17 @@ func RegisterRoutes(m *macaron.Macaron) {
 	// ***** START: User *****
 	m.Group("/user", func() {
 		m.Get("/login", user.TrapSignIn)
+		m.Group("/two_factor", func() {
+			m.Get("", user.TwoFactor)
+			m.Post("", bindIgnErr(auth.TwoFactorAuthForm{}), user.TwoFactorPost)
+			m.Get("/scratch", user.TwoFactorScratch)
+			m.Post("/scratch", bindIgnErr(auth.TwoFactorScratchAuthForm{}), user.TwoFactorScratchPost)
+		})
+		m.Group("/webauthn", func() {
+			m.Get("", user.WebAuthn)
+			m.Get("/assertion", user.WebAuthnLoginAssertion)
+			m.Post("/assertion", user.WebAuthnLoginAssertionPost)
+		})
 	}, reqSignOut)
 
 	m.Group("/login/oauth", func() {
@@ -282,6 +339,10 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Post("/authorize", bindIgnErr(auth.AuthorizationForm{}), user.AuthorizeOAuth)
 	}, ignSignInAndCsrf, reqSignIn)
 	m.Post("/login/oauth/access_token", bindIgnErr(auth.AccessTokenForm{}), ignSignInAndCsrf, user.AccessTokenOAuth)
+	m.Post("/login/oauth/revoke", bindIgnErr(auth.RevokeOAuth2TokenForm{}), ignSignInAndCsrf, user.RevokeOAuth2Token)
+	m.Combo("/login/oauth/userinfo", ignSignInAndCsrf).Get(user.InfoOAuth).Post(user.InfoOAuth)
+	m.Get("/login/oauth/keys", ignSignInAndCsrf, user.OIDCKeys)
+	m.Get("/.well-known/openid-configuration", ignSignInAndCsrf, user.OIDCWellKnown)
 
 	m.Group("/user/settings", func() {
 		m.Get("", userSetting.Profile)
@@ -304,6 +365,21 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Combo("/keys").Get(userSetting.Keys).
 			Post(bindIgnErr(auth.AddKeyForm{}), userSetting.KeysPost)
 		m.Post("/keys/delete", userSetting.DeleteKey)
+		m.Group("/security", func() {
+			m.Get("", userSetting.Security)
+			m.Group("/two_factor", func() {
+				m.Post("/regenerate_scratch", userSetting.RegenerateScratchTwoFactor)
+				m.Post("/disable", userSetting.DisableTwoFactor)
+				m.Get("/enroll", userSetting.EnrollTwoFactor)
+				m.Post("/enroll", bindIgnErr(auth.TwoFactorAuthForm{}), userSetting.EnrollTwoFactorPost)
+			})
+			m.Group("/webauthn", func() {
+				m.Post("/request_register", bindIgnErr(auth.WebAuthnRegistrationForm{}), userSetting.WebAuthnRegister)
+				m.Post("/register", userSetting.WebAuthnRegisterPost)
+				m.Post("/delete", bindIgnErr(auth.WebAuthnDeleteForm{}), userSetting.WebAuthnDelete)
+			})
+			m.Post("/account_link", userSetting.DeleteAccountLink)
+		})
 		m.Get("/organization", userSetting.Organization)
 		m.Get("/repos", userSetting.Repos)
 	}, reqSignIn, func(ctx *context.Context) {
@@ -319,6 +395,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Get("/email2user", user.Email2User)
 		m.Get("/recover_account", user.ResetPasswd)
 		m.Post("/recover_account", user.ResetPasswdPost)
+		m.Post("/logout", user.SignOut)
 	})
 	// ***** END: User *****
 
@@ -362,9 +439,9 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Post("/delete", admin.DeleteRepo)
 		})
 
-		m.Group("/hooks", func() {
-			m.Get("", admin.DefaultWebhooks)
-			m.Post("/delete", admin.DeleteDefaultWebhook)
+		m.Group("/^:configType(hooks|system-hooks)$", func() {
+			m.Get("", admin.DefaultOrSystemWebhooks)
+			m.Post("/delete", admin.DeleteDefaultOrSystemWebhook)
 			m.Get("/:type/new", repo.WebhooksNew)
 			m.Post("/gitea/new", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksNewPost)
 			m.Post("/gogs/new", bindIgnErr(auth.NewGogshookForm{}), repo.GogsHooksNewPost)
@@ -373,6 +450,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Post("/dingtalk/new", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksNewPost)
 			m.Post("/telegram/new", bindIgnErr(auth.NewTelegramHookForm{}), repo.TelegramHooksNewPost)
 			m.Post("/msteams/new", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksNewPost)
+			m.Post("/matrix/new", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksNewPost)
 			m.Get("/:id", repo.WebHooksEdit)
 			m.Post("/gitea/:id", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksEditPost)
 			m.Post("/gogs/:id", bindIgnErr(auth.NewGogshookForm{}), repo.GogsHooksEditPost)
@@ -381,6 +459,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Post("/dingtalk/:id", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksEditPost)
 			m.Post("/telegram/:id", bindIgnErr(auth.NewTelegramHookForm{}), repo.TelegramHooksEditPost)
 			m.Post("/msteams/:id", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksEditPost)
+			m.Post("/matrix/:id", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksEditPost)
 		})
 
 		m.Group("/auths", func() {
@@ -429,6 +508,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 	reqRepoPullsReader := context.RequireRepoReader(models.UnitTypePullRequests)
 	reqRepoIssuesOrPullsWriter := context.RequireRepoWriterOr(models.UnitTypeIssues, models.UnitTypePullRequests)
 	reqRepoIssuesOrPullsReader := context.RequireRepoReaderOr(models.UnitTypeIssues, models.UnitTypePullRequests)
+	reqRepoProjectsReader := context.RequireRepoReader(models.UnitTypeProjects)
+	reqRepoProjectsWriter := context.RequireRepoWriter(models.UnitTypeProjects)
 
 	// ***** START: Organization *****
 	m.Group("/org", func() {
@@ -478,6 +559,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Post("/dingtalk/new", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksNewPost)
 					m.Post("/telegram/new", bindIgnErr(auth.NewTelegramHookForm{}), repo.TelegramHooksNewPost)
 					m.Post("/msteams/new", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksNewPost)
+					m.Post("/matrix/new", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksNewPost)
 					m.Get("/:id", repo.WebHooksEdit)
 					m.Post("/gitea/:id", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksEditPost)
 					m.Post("/gogs/:id", bindIgnErr(auth.NewGogshookForm{}), repo.GogsHooksEditPost)
@@ -486,6 +568,15 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Post("/dingtalk/:id", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksEditPost)
 					m.Post("/telegram/:id", bindIgnErr(auth.NewTelegramHookForm{}), repo.TelegramHooksEditPost)
 					m.Post("/msteams/:id", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksEditPost)
+					m.Post("/matrix/:id", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksEditPost)
+				})
+
+				m.Group("/labels", func() {
+					m.Get("", org.RetrieveLabels, org.Labels)
+					m.Post("/new", bindIgnErr(auth.CreateLabelForm{}), org.NewLabel)
+					m.Post("/edit", bindIgnErr(auth.CreateLabelForm{}), org.UpdateLabel)
+					m.Post("/delete", org.DeleteLabel)
+					m.Post("/initialize", bindIgnErr(auth.InitializeLabelsForm{}), org.InitializeLabels)
 				})
 
 				m.Route("/delete", "GET,POST", org.SettingsDelete)
@@ -542,6 +633,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Post("/dingtalk/new", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksNewPost)
 				m.Post("/telegram/new", bindIgnErr(auth.NewTelegramHookForm{}), repo.TelegramHooksNewPost)
 				m.Post("/msteams/new", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksNewPost)
+				m.Post("/matrix/new", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksNewPost)
 				m.Get("/:id", repo.WebHooksEdit)
 				m.Post("/:id/test", repo.TestWebhook)
 				m.Post("/gitea/:id", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksEditPost)
@@ -551,6 +643,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Post("/dingtalk/:id", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksEditPost)
 				m.Post("/telegram/:id", bindIgnErr(auth.NewTelegramHookForm{}), repo.TelegramHooksEditPost)
 				m.Post("/msteams/:id", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksEditPost)
+				m.Post("/matrix/:id", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksEditPost)
 
 				m.Group("/git", func() {
 					m.Get("", repo.GitHooks)
@@ -599,6 +692,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Group("/issues", func() {
 			m.Combo("/new").Get(context.RepoRef(), repo.NewIssue).
 				Post(bindIgnErr(auth.CreateIssueForm{}), repo.NewIssuePost)
+			m.Get("/new/choose", context.RepoRef(), repo.NewIssueChooseTemplate)
 		}, context.RepoMustNotBeArchived(), reqRepoIssueReader)
 		// FIXME: should use different URLs but mostly same logic for comments of issue and pull reuqest.
 		// So they can apply their own enable/disable logic on routers.
@@ -627,8 +721,10 @@ func RegisterRoutes(m *macaron.Macaron) {
 
 			m.Post("/labels", reqRepoIssuesOrPullsWriter, repo.UpdateIssueLabel)
 			m.Post("/milestone", reqRepoIssuesOrPullsWriter, repo.UpdateIssueMilestone)
+			m.Post("/projects", reqRepoIssuesOrPullsWriter, reqRepoProjectsReader, repo.UpdateIssueProject)
 			m.Post("/assignee", reqRepoIssuesOrPullsWriter, repo.UpdateIssueAssignee)
 			m.Post("/status", reqRepoIssuesOrPullsWriter, repo.UpdateIssueStatus)
+			m.Post("/request_review", reqRepoIssuesOrPullsReader, repo.UpdatePullReviewRequest)
 		}, context.RepoMustNotBeArchived())
 		m.Group("/comments/:id", func() {
 			m.Post("", repo.UpdateCommentContent)
@@ -747,6 +843,25 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get("/raw/*", repo.WikiRaw)
 		}, repo.MustEnableWiki)
 
+		m.Group("/projects", func() {
+			m.Get("", repo.Projects)
+			m.Get("/:id", repo.ViewProject)
+			m.Group("", func() {
+				m.Combo("/new").Get(repo.NewProject).
+					Post(bindIgnErr(auth.CreateProjectForm{}), repo.NewProjectPost)
+				m.Post("/delete", repo.DeleteProject)
+				m.Group("/:id", func() {
+					m.Combo("/edit").Get(repo.EditProject).
+						Post(bindIgnErr(auth.CreateProjectForm{}), repo.EditProjectPost)
+					m.Post("/^:action(open|close)$", repo.ChangeProjectStatus)
+					m.Post("/columns/new", bindIgnErr(auth.EditProjectBoardForm{}), repo.AddBoardToProjectPost)
+					m.Post("/columns/delete", repo.DeleteProjectBoard)
+					m.Post("/columns/:boardID/edit", bindIgnErr(auth.EditProjectBoardForm{}), repo.EditProjectBoardPost)
+					m.Post("/columns/:boardID/move", repo.MoveIssueAcrossBoards)
+				})
+			}, context.RepoMustNotBeArchived(), reqSignIn, reqRepoProjectsWriter)
+		}, reqRepoProjectsReader, context.RepoRef())
+
 		m.Group("/activity", func() {
 			m.Get("", repo.Activity)
 			m.Get("/:period", repo.Activity)
@@ -14,6 +14,7 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/auth/oauth2"
+	"code.gitea.io/gitea/modules/auth/webauthn"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
@@ -26,8 +27,8 @@ import (
 	"code.gitea.io/gitea/services/mailer"
 
 	"gitea.com/macaron/captcha"
+	gowebauthn "github.com/duo-labs/webauthn/webauthn"
 	"github.com/markbates/goth"
-	"github.com/tstranex/u2f"
 )
 
 const (
@@ -44,7 +45,7 @@ const (
 	tplTwofa          base.TplName = "user/auth/twofa"
 	tplTwofaScratch   base.TplName = "user/auth/twofa_scratch"
 	tplLinkAccount    base.TplName = "user/auth/link_account"
-	tplU2F            base.TplName = "user/auth/u2f"
+	tplWebAuthn       base.TplName = "user/auth/webauthn"
 )
 
 // AutoSignIn reads cookie and try to auto-login.
@@ -194,15 +195,19 @@ func SignInPost(ctx *context.Context, form auth.SignInForm) {
 		}
 		return
 	}
-	// If this user is enrolled in 2FA, we can't sign the user in just yet.
-	// Instead, redirect them to the 2FA authentication page.
-	_, err = models.GetTwoFactorByUID(u.ID)
+	handleSignInTwoFactor(ctx, u, form.Remember)
+}
+
+// handleSignInTwoFactor signs the user in if they are not enrolled in 2FA,
+// otherwise it redirects them to the 2FA authentication page.
+func handleSignInTwoFactor(ctx *context.Context, u *models.User, remember bool) {
+	hasTwoFactor, err := models.HasTwoFactorByUID(u.ID)
 	if err != nil {
-		if models.IsErrTwoFactorNotEnrolled(err) {
-			handleSignIn(ctx, u, form.Remember)
-		} else {
-			ctx.ServerError("UserSignIn", err)
-		}
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+	if !hasTwoFactor {
+		handleSignIn(ctx, u, remember)
 		return
 	}
 
@@ -211,7 +216,7 @@ func SignInPost(ctx *context.Context, form auth.SignInForm) {
 		ctx.ServerError("UserSignIn: Unable to set twofaUid in session", err)
 		return
 	}
-	if err := ctx.Session.Set("twofaRemember", form.Remember); err != nil {
+	if err := ctx.Session.Set("twofaRemember", remember); err != nil {
 		ctx.ServerError("UserSignIn: Unable to set twofaRemember in session", err)
 		return
 	}
@@ -220,9 +225,15 @@ func SignInPost(ctx *context.Context, form auth.SignInForm) {
 		return
 	}
 
-	regs, err := models.GetU2FRegistrationsByUID(u.ID)
-	if err == nil && len(regs) > 0 {
-		ctx.Redirect(setting.AppSubURL + "/user/u2f")
+	redirectToTwoFactor(ctx, u.ID)
+}
+
+// redirectToTwoFactor redirects to the WebAuthn page if the user has registered
+// a security key, to the TOTP page otherwise.
+func redirectToTwoFactor(ctx *context.Context, uid int64) {
+	hasWebAuthn, err := models.ExistsWebAuthnCredentialsForUID(uid)
+	if err == nil && hasWebAuthn {
+		ctx.Redirect(setting.AppSubURL + "/user/webauthn")
 		return
 	}
 
@@ -372,115 +383,127 @@ func TwoFactorScratchPost(ctx *context.Context, form auth.TwoFactorScratchAuthFo
 	ctx.RenderWithErr(ctx.Tr("auth.twofa_scratch_token_incorrect"), tplTwofaScratch, auth.TwoFactorScratchAuthForm{})
 }
 
-// U2F shows the U2F login page
-func U2F(ctx *context.Context) {
+// WebAuthn shows the WebAuthn login page
+func WebAuthn(ctx *context.Context) {
 	ctx.Data["Title"] = ctx.Tr("twofa")
-	ctx.Data["RequireU2F"] = true
+
 	// Check auto-login.
 	if checkAutoLogin(ctx) {
 		return
 	}
 
 	// Ensure user is in a 2FA session.
-	if ctx.Session.Get("twofaUid") == nil {
-		ctx.ServerError("UserSignIn", errors.New("not in U2F session"))
+	idSess := ctx.Session.Get("twofaUid")
+	if idSess == nil {
+		ctx.ServerError("UserSignIn", errors.New("not in WebAuthn session"))
+		return
+	}
+
+	_, err := models.GetTwoFactorByUID(idSess.(int64))
+	if err != nil && !models.IsErrTwoFactorNotEnrolled(err) {
+		ctx.ServerError("UserSignIn", err)
 		return
 	}
+	ctx.Data["TwofaEnrolled"] = err == nil
 
-	ctx.HTML(200, tplU2F)
+	ctx.HTML(200, tplWebAuthn)
 }
 
-// U2FChallenge submits a sign challenge to the browser
-func U2FChallenge(ctx *context.Context) {
-	// Ensure user is in a U2F session.
+// WebAuthnLoginAssertion submits a WebAuthn challenge to the browser
+func WebAuthnLoginAssertion(ctx *context.Context) {
+	// Ensure user is in a WebAuthn session.
 	idSess := ctx.Session.Get("twofaUid")
 	if idSess == nil {
-		ctx.ServerError("UserSignIn", errors.New("not in U2F session"))
+		ctx.ServerError("UserSignIn", errors.New("not in WebAuthn session"))
 		return
 	}
-	id := idSess.(int64)
-	regs, err := models.GetU2FRegistrationsByUID(id)
+
+	user, err := models.GetUserByID(idSess.(int64))
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+
+	exists, err := models.ExistsWebAuthnCredentialsForUID(user.ID)
 	if err != nil {
 		ctx.ServerError("UserSignIn", err)
 		return
 	}
-	if len(regs) == 0 {
+	if !exists {
 		ctx.ServerError("UserSignIn", errors.New("no device registered"))
 		return
 	}
-	challenge, err := u2f.NewChallenge(setting.U2F.AppID, setting.U2F.TrustedFacets)
+
+	assertion, sessionData, err := webauthn.BeginLogin(user)
 	if err != nil {
-		ctx.ServerError("u2f.NewChallenge", err)
+		ctx.ServerError("webauthn.BeginLogin", err)
 		return
 	}
-	if err := ctx.Session.Set("u2fChallenge", challenge); err != nil {
-		ctx.ServerError("UserSignIn: unable to set u2fChallenge in session", err)
+
+	if err := ctx.Session.Set("webauthnAssertion", sessionData); err != nil {
+		ctx.ServerError("UserSignIn: unable to set webauthnAssertion in session", err)
 		return
 	}
 	if err := ctx.Session.Release(); err != nil {
 		ctx.ServerError("UserSignIn: unable to store session", err)
+		return
 	}
 
-	ctx.JSON(200, challenge.SignRequest(regs.ToRegistrations()))
+	ctx.JSON(200, assertion)
 }
 
-// U2FSign authenticates the user by signResp
-func U2FSign(ctx *context.Context, signResp u2f.SignResponse) {
-	challSess := ctx.Session.Get("u2fChallenge")
+// WebAuthnLoginAssertionPost validates the signature and logs the user in
+func WebAuthnLoginAssertionPost(ctx *context.Context) {
 	idSess := ctx.Session.Get("twofaUid")
-	if challSess == nil || idSess == nil {
-		ctx.ServerError("UserSignIn", errors.New("not in U2F session"))
+	sessionData, okData := ctx.Session.Get("webauthnAssertion").(*gowebauthn.SessionData)
+	if idSess == nil || !okData || sessionData == nil {
+		ctx.ServerError("UserSignIn", errors.New("not in WebAuthn session"))
 		return
 	}
-	challenge := challSess.(*u2f.Challenge)
-	id := idSess.(int64)
-	regs, err := models.GetU2FRegistrationsByUID(id)
+	defer func() {
+		_ = ctx.Session.Delete("webauthnAssertion")
+	}()
+
+	user, err := models.GetUserByID(idSess.(int64))
 	if err != nil {
 		ctx.ServerError("UserSignIn", err)
 		return
 	}
-	for _, reg := range regs {
-		r, err := reg.Parse()
-		if err != nil {
-			log.Fatal("parsing u2f registration: %v", err)
-			continue
-		}
-		newCounter, authErr := r.Authenticate(signResp, *challenge, reg.Counter)
-		if authErr == nil {
-			reg.Counter = newCounter
-			user, err := models.GetUserByID(id)
-			if err != nil {
-				ctx.ServerError("UserSignIn", err)
-				return
-			}
-			remember := ctx.Session.Get("twofaRemember").(bool)
-			if err := reg.UpdateCounter(); err != nil {
-				ctx.ServerError("UserSignIn", err)
-				return
-			}
 
-			if ctx.Session.Get("linkAccount") != nil {
-				gothUser := ctx.Session.Get("linkAccountGothUser")
-				if gothUser == nil {
-					ctx.ServerError("UserSignIn", errors.New("not in LinkAccount session"))
-					return
-				}
+	body, err := ctx.Req.Body().Bytes()
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
 
-				err = externalaccount.LinkAccountToUser(user, gothUser.(goth.User))
-				if err != nil {
-					ctx.ServerError("UserSignIn", err)
-					return
-				}
-			}
-			redirect := handleSignInFull(ctx, user, remember, false)
-			if redirect == "" {
-				redirect = setting.AppSubURL + "/"
-			}
-			ctx.PlainText(200, []byte(redirect))
+	cred, err := webauthn.FinishLogin(user, *sessionData, body)
+	if err != nil {
+		log.Info("Failed WebAuthn authentication attempt for %s from %s: %v", user.Name, ctx.RemoteAddr(), err)
+		ctx.Error(401)
+		return
+	}
+	log.Trace("User %s signed in with WebAuthn credential %s", user.Name, cred.Name)
+
+	if ctx.Session.Get("linkAccount") != nil {
+		gothUser := ctx.Session.Get("linkAccountGothUser")
+		if gothUser == nil {
+			ctx.ServerError("UserSignIn", errors.New("not in LinkAccount session"))
+			return
+		}
+
+		err = externalaccount.LinkAccountToUser(user, gothUser.(goth.User))
+		if err != nil {
+			ctx.ServerError("UserSignIn", err)
 			return
 		}
 	}
-	ctx.Error(401)
+
+	remember := ctx.Session.Get("twofaRemember").(bool)
+	redirect := handleSignInFull(ctx, user, remember, false)
+	if redirect == "" {
+		redirect = setting.AppSubURL + "/"
+	}
+	ctx.PlainText(200, []byte(redirect))
 }
 
 // This handles the final part of the sign-in process of the user.
@@ -502,7 +525,7 @@ func handleSignInFull(ctx *context.Context, u *models.User, remember bool, obeyR
 	_ = ctx.Session.Delete("openid_determined_username")
 	_ = ctx.Session.Delete("twofaUid")
 	_ = ctx.Session.Delete("twofaRemember")
-	_ = ctx.Session.Delete("u2fChallenge")
+	_ = ctx.Session.Delete("webauthnAssertion")
 	_ = ctx.Session.Delete("linkAccount")
 	if err := ctx.Session.Set("uid", u.ID); err != nil {
 		log.Error("Error setting uid %d in session: %v", u.ID, err)
@@ -616,12 +639,12 @@ func handleOAuth2SignIn(u *models.User, gothUser goth.User, ctx *context.Context
 
 	// If this user is enrolled in 2FA, we can't sign the user in just yet.
 	// Instead, redirect them to the 2FA authentication page.
-	_, err = models.GetTwoFactorByUID(u.ID)
+	hasTwoFactor, err := models.HasTwoFactorByUID(u.ID)
 	if err != nil {
-		if !models.IsErrTwoFactorNotEnrolled(err) {
-			ctx.ServerError("UserSignIn", err)
-			return
-		}
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+	if !hasTwoFactor {
 
 		if err := ctx.Session.Set("uid", u.ID); err != nil {
 			log.Error("Error setting uid in session: %v", err)
@@ -669,14 +692,7 @@ func handleOAuth2SignIn(u *models.User, gothUser goth.User, ctx *context.Context
 		log.Error("Error storing session: %v", err)
 	}
 
-	// If U2F is enrolled -> Redirect to U2F instead
-	regs, err := models.GetU2FRegistrationsByUID(u.ID)
-	if err == nil && len(regs) > 0 {
-		ctx.Redirect(setting.AppSubURL + "/user/u2f")
-		return
-	}
-
-	ctx.Redirect(setting.AppSubURL + "/user/two_factor")
+	redirectToTwoFactor(ctx, u.ID)
 }
 
 // OAuth2UserLoginCallback attempts to handle the callback from the OAuth2 provider and if successful
@@ -844,14 +860,7 @@ func LinkAccountPostSignIn(ctx *context.Context, signInForm auth.SignInForm) {
 		log.Error("Error storing session: %v", err)
 	}
 
-	// If U2F is enrolled -> Redirect to U2F instead
-	regs, err := models.GetU2FRegistrationsByUID(u.ID)
-	if err == nil && len(regs) > 0 {
-		ctx.Redirect(setting.AppSubURL + "/user/u2f")
-		return
-	}
-
-	ctx.Redirect(setting.AppSubURL + "/user/two_factor")
+	redirectToTwoFactor(ctx, u.ID)
 }
 
 // LinkAccountPostRegister handle the creation of a new account for an external account using signUp
@@ -12,6 +12,8 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/auth/oauth2"
+	"code.gitea.io/gitea/modules/auth/sso"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
@@ -106,9 +108,39 @@ type AccessTokenResponse struct {
 	TokenType    TokenType `json:"token_type"`
 	ExpiresIn    int64     `json:"expires_in"`
 	RefreshToken string    `json:"refresh_token"`
+	Scope        string    `json:"scope"`
+	IDToken      string    `json:"id_token,omitempty"`
 }
 
-func newAccessTokenResponse(grant *models.OAuth2Grant) (*AccessTokenResponse, *AccessTokenError) {
+// grantsOIDCScope returns true if the grant includes the given OpenID Connect scope,
+// grants with full access include all of them
+func grantsOIDCScope(grant *models.OAuth2Grant, scope string) bool {
+	return grant.ScopeContains(scope) || grant.ScopeContains(string(models.AccessTokenScopeAll))
+}
+
+// setOIDCUserClaims sets the claims about the user the scopes of the grant allow
+func setOIDCUserClaims(token *models.OIDCToken, user *models.User, grant *models.OAuth2Grant) {
+	token.Subject = fmt.Sprint(user.ID)
+	if grantsOIDCScope(grant, models.OAuth2ScopeProfile) {
+		token.Name = user.FullName
+		token.PreferredUsername = user.Name
+		token.Profile = user.HTMLURL()
+		token.Picture = user.AvatarLink()
+		token.Website = user.Website
+		token.Locale = user.Language
+		token.UpdatedAt = user.UpdatedUnix
+	}
+	if grantsOIDCScope(grant, models.OAuth2ScopeEmail) {
+		token.Email = user.Email
+		token.EmailVerified = user.IsActive
+	}
+}
+
+func oidcIssuer() string {
+	return strings.TrimSuffix(setting.AppURL, "/")
+}
+
+func newAccessTokenResponse(app *models.OAuth2Application, grant *models.OAuth2Grant, nonce string) (*AccessTokenResponse, *AccessTokenError) {
 	if setting.OAuth2.InvalidateRefreshTokens {
 		if err := grant.IncreaseCounter(); err != nil {
 			return nil, &AccessTokenError{
@@ -152,11 +184,45 @@ func newAccessTokenResponse(grant *models.OAuth2Grant) (*AccessTokenResponse, *A
 		}
 	}
 
+	// generate the OpenID Connect ID token if the client requested it
+	var signedIDToken string
+	if grant.ScopeContains(models.OAuth2ScopeOpenID) {
+		user, err := models.GetUserByID(grant.UserID)
+		if err != nil {
+			return nil, &AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+				ErrorDescription: "cannot find user",
+			}
+		}
+		idToken := &models.OIDCToken{
+			StandardClaims: jwt.StandardClaims{
+				Issuer:    oidcIssuer(),
+				Audience:  app.ClientID,
+				ExpiresAt: expirationDate.AsTime().Unix(),
+			},
+			Nonce: nonce,
+		}
+		setOIDCUserClaims(idToken, user, grant)
+		if oauth2.DefaultSigningKey == nil {
+			err = fmt.Errorf("no signing key")
+		} else {
+			signedIDToken, err = idToken.SignToken(oauth2.DefaultSigningKey)
+		}
+		if err != nil {
+			return nil, &AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+				ErrorDescription: "cannot sign token",
+			}
+		}
+	}
+
 	return &AccessTokenResponse{
 		AccessToken:  signedAccessToken,
 		TokenType:    TokenTypeBearer,
 		ExpiresIn:    setting.OAuth2.AccessTokenExpirationTime,
 		RefreshToken: signedRefreshToken,
+		Scope:        grant.Scope,
+		IDToken:      signedIDToken,
 	}, nil
 }
 
@@ -209,10 +275,19 @@ func AuthorizeOAuth(ctx *context.Context, form auth.AuthorizationForm) {
 		return
 	}
 
+	scope, err := models.NormalizeOAuth2Scope(form.Scope)
+	if err != nil {
+		handleAuthorizeError(ctx, AuthorizeError{
+			ErrorCode:        ErrorCodeInvalidScope,
+			ErrorDescription: err.Error(),
+			State:            form.State,
+		}, form.RedirectURI)
+		return
+	}
+
 	// pkce support
 	switch form.CodeChallengeMethod {
-	case "S256":
-	case "plain":
+	case "S256", "plain":
 		if err := ctx.Session.Set("CodeChallengeMethod", form.CodeChallengeMethod); err != nil {
 			handleAuthorizeError(ctx, AuthorizeError{
 				ErrorCode:        ErrorCodeServerError,
@@ -221,7 +296,7 @@ func AuthorizeOAuth(ctx *context.Context, form auth.AuthorizationForm) {
 			}, form.RedirectURI)
 			return
 		}
-		if err := ctx.Session.Set("CodeChallengeMethod", form.CodeChallenge); err != nil {
+		if err := ctx.Session.Set("CodeChallenge", form.CodeChallenge); err != nil {
 			handleAuthorizeError(ctx, AuthorizeError{
 				ErrorCode:        ErrorCodeServerError,
 				ErrorDescription: "cannot set code challenge",
@@ -251,9 +326,9 @@ func AuthorizeOAuth(ctx *context.Context, form auth.AuthorizationForm) {
 		return
 	}
 
-	// Redirect if user already granted access
-	if grant != nil {
-		code, err := grant.GenerateNewAuthorizationCode(form.RedirectURI, form.CodeChallenge, form.CodeChallengeMethod)
+	// Redirect if user already granted the requested access
+	if grant != nil && grant.HasScope(scope) {
+		code, err := grant.GenerateNewAuthorizationCode(form.RedirectURI, form.CodeChallenge, form.CodeChallengeMethod, form.Nonce)
 		if err != nil {
 			handleServerError(ctx, form.State, form.RedirectURI)
 			return
@@ -271,6 +346,9 @@ func AuthorizeOAuth(ctx *context.Context, form auth.AuthorizationForm) {
 	ctx.Data["Application"] = app
 	ctx.Data["RedirectURI"] = form.RedirectURI
 	ctx.Data["State"] = form.State
+	ctx.Data["Scope"] = scope
+	ctx.Data["Nonce"] = form.Nonce
+	ctx.Data["ScopeDescriptions"] = scopeDescriptions(ctx, scope)
 	ctx.Data["ApplicationUserLink"] = "<a href=\"" + setting.AppURL + app.User.LowerName + "\">@" + app.User.Name + "</a>"
 	ctx.Data["ApplicationRedirectDomainHTML"] = "<strong>" + form.RedirectURI + "</strong>"
 	// TODO document SESSION <=> FORM
@@ -292,6 +370,18 @@ func AuthorizeOAuth(ctx *context.Context, form auth.AuthorizationForm) {
 		log.Error(err.Error())
 		return
 	}
+	err = ctx.Session.Set("scope", scope)
+	if err != nil {
+		handleServerError(ctx, form.State, form.RedirectURI)
+		log.Error(err.Error())
+		return
+	}
+	err = ctx.Session.Set("nonce", form.Nonce)
+	if err != nil {
+		handleServerError(ctx, form.State, form.RedirectURI)
+		log.Error(err.Error())
+		return
+	}
 	// Here we're just going to try to release the session early
 	if err := ctx.Session.Release(); err != nil {
 		// we'll tolerate errors here as they *should* get saved elsewhere
@@ -300,10 +390,26 @@ func AuthorizeOAuth(ctx *context.Context, form auth.AuthorizationForm) {
 	ctx.HTML(200, tplGrantAccess)
 }
 
+// scopeDescriptions returns the descriptions of the permissions the scopes grant
+func scopeDescriptions(ctx *context.Context, scope string) []string {
+	descriptions := make([]string, 0, 4)
+	for _, s := range strings.Fields(scope) {
+		fields := strings.SplitN(s, ":", 2)
+		if len(fields) == 2 {
+			descriptions = append(descriptions, ctx.Tr("auth.authorize_scope_api",
+				ctx.Tr("settings.token_scope_"+fields[1]), ctx.Tr("settings.token_scope_"+fields[0])))
+		} else {
+			descriptions = append(descriptions, ctx.Tr("auth.authorize_scope_"+s))
+		}
+	}
+	return descriptions
+}
+
 // GrantApplicationOAuth manages the post request submitted when a user grants access to an application
 func GrantApplicationOAuth(ctx *context.Context, form auth.GrantApplicationForm) {
 	if ctx.Session.Get("client_id") != form.ClientID || ctx.Session.Get("state") != form.State ||
-		ctx.Session.Get("redirect_uri") != form.RedirectURI {
+		ctx.Session.Get("redirect_uri") != form.RedirectURI || ctx.Session.Get("scope") != form.Scope ||
+		ctx.Session.Get("nonce") != form.Nonce {
 		ctx.Error(400)
 		return
 	}
@@ -312,7 +418,14 @@ func GrantApplicationOAuth(ctx *context.Context, form auth.GrantApplicationForm)
 		ctx.ServerError("GetOAuth2ApplicationByClientID", err)
 		return
 	}
-	grant, err := app.CreateGrant(ctx.User.ID)
+	grant, err := app.GetGrantByUserID(ctx.User.ID)
+	if err == nil {
+		if grant == nil {
+			grant, err = app.CreateGrant(ctx.User.ID, form.Scope)
+		} else {
+			err = grant.AddScope(form.Scope)
+		}
+	}
 	if err != nil {
 		handleAuthorizeError(ctx, AuthorizeError{
 			State:            form.State,
@@ -326,7 +439,7 @@ func GrantApplicationOAuth(ctx *context.Context, form auth.GrantApplicationForm)
 	codeChallenge, _ = ctx.Session.Get("CodeChallenge").(string)
 	codeChallengeMethod, _ = ctx.Session.Get("CodeChallengeMethod").(string)
 
-	code, err := grant.GenerateNewAuthorizationCode(form.RedirectURI, codeChallenge, codeChallengeMethod)
+	code, err := grant.GenerateNewAuthorizationCode(form.RedirectURI, codeChallenge, codeChallengeMethod, form.Nonce)
 	if err != nil {
 		handleServerError(ctx, form.State, form.RedirectURI)
 		return
@@ -339,31 +452,59 @@ func GrantApplicationOAuth(ctx *context.Context, form auth.GrantApplicationForm)
 	ctx.Redirect(redirect.String(), 302)
 }
 
+// parseClientCredentials reads the client credentials from the basic auth header
+// if the client did not send them as part of the form
+func parseClientCredentials(ctx *context.Context, clientID, clientSecret *string) *AccessTokenError {
+	if *clientID != "" {
+		return nil
+	}
+	authHeader := ctx.Req.Header.Get("Authorization")
+	authContent := strings.SplitN(authHeader, " ", 2)
+	if len(authContent) != 2 || authContent[0] != "Basic" {
+		return nil
+	}
+	payload, err := base64.StdEncoding.DecodeString(authContent[1])
+	if err != nil {
+		return &AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "cannot parse basic auth header",
+		}
+	}
+	pair := strings.SplitN(string(payload), ":", 2)
+	if len(pair) != 2 {
+		return &AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "cannot parse basic auth header",
+		}
+	}
+	*clientID = pair[0]
+	*clientSecret = pair[1]
+	return nil
+}
+
+// authenticateClient returns the application with the given client credentials
+func authenticateClient(clientID, clientSecret string) (*models.OAuth2Application, *AccessTokenError) {
+	app, err := models.GetOAuth2ApplicationByClientID(clientID)
+	if err != nil {
+		return nil, &AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidClient,
+			ErrorDescription: fmt.Sprintf("cannot load client with client id: '%s'", clientID),
+		}
+	}
+	if !app.ValidateClientSecret([]byte(clientSecret)) {
+		return nil, &AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeUnauthorizedClient,
+			ErrorDescription: "client is not authorized",
+		}
+	}
+	return app, nil
+}
+
 // AccessTokenOAuth manages all access token requests by the client
 func AccessTokenOAuth(ctx *context.Context, form auth.AccessTokenForm) {
-	if form.ClientID == "" {
-		authHeader := ctx.Req.Header.Get("Authorization")
-		authContent := strings.SplitN(authHeader, " ", 2)
-		if len(authContent) == 2 && authContent[0] == "Basic" {
-			payload, err := base64.StdEncoding.DecodeString(authContent[1])
-			if err != nil {
-				handleAccessTokenError(ctx, AccessTokenError{
-					ErrorCode:        AccessTokenErrorCodeInvalidRequest,
-					ErrorDescription: "cannot parse basic auth header",
-				})
-				return
-			}
-			pair := strings.SplitN(string(payload), ":", 2)
-			if len(pair) != 2 {
-				handleAccessTokenError(ctx, AccessTokenError{
-					ErrorCode:        AccessTokenErrorCodeInvalidRequest,
-					ErrorDescription: "cannot parse basic auth header",
-				})
-				return
-			}
-			form.ClientID = pair[0]
-			form.ClientSecret = pair[1]
-		}
+	if err := parseClientCredentials(ctx, &form.ClientID, &form.ClientSecret); err != nil {
+		handleAccessTokenError(ctx, *err)
+		return
 	}
 	switch form.GrantType {
 	case "refresh_token":
@@ -381,8 +522,13 @@ func AccessTokenOAuth(ctx *context.Context, form auth.AccessTokenForm) {
 }
 
 func handleRefreshToken(ctx *context.Context, form auth.AccessTokenForm) {
+	app, tokenErr := authenticateClient(form.ClientID, form.ClientSecret)
+	if tokenErr != nil {
+		handleAccessTokenError(ctx, *tokenErr)
+		return
+	}
 	token, err := models.ParseOAuth2Token(form.RefreshToken)
-	if err != nil {
+	if err != nil || token.Type != models.TypeRefreshToken {
 		handleAccessTokenError(ctx, AccessTokenError{
 			ErrorCode:        AccessTokenErrorCodeUnauthorizedClient,
 			ErrorDescription: "client is not authorized",
@@ -391,7 +537,7 @@ func handleRefreshToken(ctx *context.Context, form auth.AccessTokenForm) {
 	}
 	// get grant before increasing counter
 	grant, err := models.GetOAuth2GrantByID(token.GrantID)
-	if err != nil || grant == nil {
+	if err != nil || grant == nil || grant.ApplicationID != app.ID {
 		handleAccessTokenError(ctx, AccessTokenError{
 			ErrorCode:        AccessTokenErrorCodeInvalidGrant,
 			ErrorDescription: "grant does not exist",
@@ -405,10 +551,15 @@ func handleRefreshToken(ctx *context.Context, form auth.AccessTokenForm) {
 			ErrorCode:        AccessTokenErrorCodeUnauthorizedClient,
 			ErrorDescription: "token was already used",
 		})
-		log.Warn("A client tried to use a refresh token for grant_id = %d was used twice!", grant.ID)
+		// a rotated refresh token is only used twice if it got stolen,
+		// revoke the grant so neither the client nor the thief can use it anymore
+		log.Warn("A client tried to use a refresh token for grant_id = %d was used twice! Revoking the grant.", grant.ID)
+		if err := models.RevokeOAuth2Grant(grant.ID, grant.UserID); err != nil {
+			log.Error("RevokeOAuth2Grant: %v", err)
+		}
 		return
 	}
-	accessToken, tokenErr := newAccessTokenResponse(grant)
+	accessToken, tokenErr := newAccessTokenResponse(app, grant, "")
 	if tokenErr != nil {
 		handleAccessTokenError(ctx, *tokenErr)
 		return
@@ -470,7 +621,7 @@ func handleAuthorizationCode(ctx *context.Context, form auth.AccessTokenForm) {
 			ErrorDescription: "cannot proceed your request",
 		})
 	}
-	resp, tokenErr := newAccessTokenResponse(authorizationCode.Grant)
+	resp, tokenErr := newAccessTokenResponse(app, authorizationCode.Grant, authorizationCode.Nonce)
 	if tokenErr != nil {
 		handleAccessTokenError(ctx, *tokenErr)
 		return
@@ -479,6 +630,140 @@ func handleAuthorizationCode(ctx *context.Context, form auth.AccessTokenForm) {
 	ctx.JSON(200, resp)
 }
 
+// RevokeOAuth2Token revokes an access token or a refresh token (RFC 7009). As all tokens
+// are bound to the grant of the user, this revokes the grant and all tokens issued for it.
+func RevokeOAuth2Token(ctx *context.Context, form auth.RevokeOAuth2TokenForm) {
+	if err := parseClientCredentials(ctx, &form.ClientID, &form.ClientSecret); err != nil {
+		handleAccessTokenError(ctx, *err)
+		return
+	}
+	app, tokenErr := authenticateClient(form.ClientID, form.ClientSecret)
+	if tokenErr != nil {
+		handleAccessTokenError(ctx, *tokenErr)
+		return
+	}
+
+	// invalid tokens do not cause an error response, the client can't do anything about them
+	token, err := models.ParseOAuth2Token(form.Token)
+	if err != nil {
+		ctx.Status(200)
+		return
+	}
+	grant, err := models.GetOAuth2GrantByID(token.GrantID)
+	if err != nil {
+		ctx.ServerError("GetOAuth2GrantByID", err)
+		return
+	} else if grant == nil {
+		ctx.Status(200)
+		return
+	}
+	if grant.ApplicationID != app.ID {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeUnauthorizedClient,
+			ErrorDescription: "token was not issued to the client",
+		})
+		return
+	}
+	if err := models.RevokeOAuth2Grant(grant.ID, grant.UserID); err != nil {
+		ctx.ServerError("RevokeOAuth2Grant", err)
+		return
+	}
+	ctx.Status(200)
+}
+
+// InfoOAuth returns the claims about the user of an access token (OpenID Connect userinfo endpoint)
+func InfoOAuth(ctx *context.Context) {
+	var grant *models.OAuth2Grant
+	auths := strings.Fields(ctx.Req.Header.Get("Authorization"))
+	if len(auths) == 2 && strings.ToLower(auths[0]) == "bearer" {
+		grant = sso.CheckOAuthAccessToken(auths[1])
+	}
+	if grant == nil {
+		ctx.Resp.Header().Set("WWW-Authenticate", `Bearer realm="", error="invalid_token"`)
+		ctx.PlainText(401, []byte("no valid access token provided"))
+		return
+	}
+	if !grantsOIDCScope(grant, models.OAuth2ScopeOpenID) {
+		ctx.Resp.Header().Set("WWW-Authenticate", `Bearer realm="", error="insufficient_scope", scope="openid"`)
+		ctx.PlainText(403, []byte("the access token was not granted the openid scope"))
+		return
+	}
+
+	user, err := models.GetUserByID(grant.UserID)
+	if err != nil {
+		ctx.ServerError("GetUserByID", err)
+		return
+	}
+	info := new(models.OIDCToken)
+	setOIDCUserClaims(info, user, grant)
+	ctx.JSON(200, info)
+}
+
+// OIDCDiscovery represents the OpenID Connect discovery document
+type OIDCDiscovery struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	JwksURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// OIDCWellKnown returns the OpenID Connect discovery document
+func OIDCWellKnown(ctx *context.Context) {
+	if oauth2.DefaultSigningKey == nil {
+		ctx.NotFound("OIDCWellKnown", nil)
+		return
+	}
+
+	scopes := []string{models.OAuth2ScopeOpenID, models.OAuth2ScopeProfile, models.OAuth2ScopeEmail, string(models.AccessTokenScopeAll)}
+	for _, category := range models.AccessTokenScopeCategories {
+		scopes = append(scopes,
+			models.AccessTokenScopeLevelRead.String()+":"+string(category),
+			models.AccessTokenScopeLevelWrite.String()+":"+string(category))
+	}
+
+	ctx.JSON(200, &OIDCDiscovery{
+		Issuer:                            oidcIssuer(),
+		AuthorizationEndpoint:             setting.AppURL + "login/oauth/authorize",
+		TokenEndpoint:                     setting.AppURL + "login/oauth/access_token",
+		UserinfoEndpoint:                  setting.AppURL + "login/oauth/userinfo",
+		RevocationEndpoint:                setting.AppURL + "login/oauth/revoke",
+		JwksURI:                           setting.AppURL + "login/oauth/keys",
+		ScopesSupported:                   scopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "refresh_token"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{oauth2.DefaultSigningKey.SigningMethod().Alg()},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post"},
+		CodeChallengeMethodsSupported:     []string{"plain", "S256"},
+		ClaimsSupported: []string{
+			"iss", "sub", "aud", "exp", "iat", "nonce",
+			"name", "preferred_username", "profile", "picture", "website", "locale", "updated_at",
+			"email", "email_verified",
+		},
+	})
+}
+
+// OIDCKeys returns the public keys to verify ID tokens as JSON Web Key Set
+func OIDCKeys(ctx *context.Context) {
+	if oauth2.DefaultSigningKey == nil {
+		ctx.NotFound("OIDCKeys", nil)
+		return
+	}
+	ctx.JSON(200, map[string][]map[string]string{
+		"keys": {oauth2.DefaultSigningKey.ToJWK()},
+	})
+}
+
 func handleAccessTokenError(ctx *context.Context, acErr AccessTokenError) {
 	ctx.JSON(400, acErr)
 }
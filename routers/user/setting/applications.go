@@ -6,11 +6,15 @@
 package setting
 
 import (
+	"strings"
+	"time"
+
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
 )
 
 const (
@@ -40,11 +44,50 @@ func ApplicationsPost(ctx *context.Context, form auth.NewAccessTokenForm) {
 	}
 
 	t := &models.AccessToken{
-		UID:  ctx.User.ID,
-		Name: form.Name,
+		UID:   ctx.User.ID,
+		Name:  form.Name,
+		Scope: models.AccessTokenScopeFromList(form.Scope),
+	}
+	if len(form.ExpiresAt) > 0 {
+		expiresAt, err := time.ParseInLocation("2006-01-02", form.ExpiresAt, setting.DefaultUILocation)
+		// the token stays valid until the end of the given day
+		if err == nil {
+			expiresAt = expiresAt.AddDate(0, 0, 1)
+		}
+		if err != nil || !expiresAt.After(time.Now()) {
+			loadApplicationsData(ctx)
+
+			ctx.Data["Err_ExpiresAt"] = true
+			ctx.RenderWithErr(ctx.Tr("settings.token_invalid_expiry"), tplSettingsApplications, &form)
+			return
+		}
+		t.ExpiresUnix = timeutil.TimeStamp(expiresAt.Unix())
+	}
+	if err := t.SetRestrictions(ctx.User, strings.Split(form.Repositories, ","), strings.Split(form.Organizations, ",")); err != nil {
+		switch {
+		case models.IsErrRepoNotExist(err):
+			loadApplicationsData(ctx)
+
+			ctx.Data["Err_Repositories"] = true
+			ctx.RenderWithErr(ctx.Tr("settings.token_repository_not_exist"), tplSettingsApplications, &form)
+		case models.IsErrOrgNotExist(err):
+			loadApplicationsData(ctx)
+
+			ctx.Data["Err_Organizations"] = true
+			ctx.RenderWithErr(ctx.Tr("settings.token_organization_not_exist"), tplSettingsApplications, &form)
+		default:
+			ctx.ServerError("SetRestrictions", err)
+		}
+		return
 	}
 	if err := models.NewAccessToken(t); err != nil {
-		ctx.ServerError("NewAccessToken", err)
+		if models.IsErrInvalidAccessTokenScope(err) {
+			loadApplicationsData(ctx)
+
+			ctx.RenderWithErr(ctx.Tr("settings.token_invalid_scope"), tplSettingsApplications, &form)
+		} else {
+			ctx.ServerError("NewAccessToken", err)
+		}
 		return
 	}
 
@@ -74,6 +117,17 @@ func loadApplicationsData(ctx *context.Context) {
 		return
 	}
 	ctx.Data["Tokens"] = tokens
+	tokenRestrictions := make(map[int64]string, len(tokens))
+	for _, t := range tokens {
+		repoNames, orgNames, err := t.LoadRestrictions()
+		if err != nil {
+			ctx.ServerError("LoadRestrictions", err)
+			return
+		}
+		tokenRestrictions[t.ID] = strings.Join(append(repoNames, orgNames...), ", ")
+	}
+	ctx.Data["TokenRestrictions"] = tokenRestrictions
+	ctx.Data["TokenScopeCategories"] = models.AccessTokenScopeCategories
 	ctx.Data["EnableOAuth2"] = setting.OAuth2.Enable
 	if setting.OAuth2.Enable {
 		ctx.Data["Applications"], err = models.GetOAuth2ApplicationsByUserID(ctx.User.ID)
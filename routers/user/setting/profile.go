@@ -17,8 +17,8 @@ import (
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 
-	"github.com/unknwon/com"
 	"github.com/unknwon/i18n"
 )
 
@@ -150,11 +150,13 @@ func UpdateAvatarSetting(ctx *context.Context, form auth.AvatarForm, ctxUser *mo
 		if err = ctxUser.UploadAvatar(data); err != nil {
 			return fmt.Errorf("UploadAvatar: %v", err)
 		}
-	} else if ctxUser.UseCustomAvatar && !com.IsFile(ctxUser.CustomAvatarPath()) {
+	} else if ctxUser.UseCustomAvatar {
 		// No avatar is uploaded but setting has been changed to enable,
 		// generate a random one when needed.
-		if err := ctxUser.GenerateRandomAvatar(); err != nil {
-			log.Error("GenerateRandomAvatar[%d]: %v", ctxUser.ID, err)
+		if _, err := storage.Avatars.Stat(ctxUser.CustomAvatarRelativePath()); ctxUser.Avatar == "" || err != nil {
+			if err := ctxUser.GenerateRandomAvatar(); err != nil {
+				log.Error("GenerateRandomAvatar[%d]: %v", ctxUser.ID, err)
+			}
 		}
 	}
 
@@ -50,6 +50,35 @@ func DeleteAccountLink(ctx *context.Context) {
 	})
 }
 
+// isSecondFactorRemovable returns whether the user may disable TOTP or delete one of
+// their security keys, which they may not if it is the last second factor they have
+// and they are required to use one.
+func isSecondFactorRemovable(uid int64, isTOTP bool) (bool, error) {
+	required, err := models.IsTwoFactorRequired(uid)
+	if err != nil || !required {
+		return !required, err
+	}
+
+	creds, err := models.GetWebAuthnCredentialsByUID(uid)
+	if err != nil {
+		return false, err
+	}
+	if isTOTP {
+		return len(creds) > 0, nil
+	}
+	if len(creds) > 1 {
+		return true, nil
+	}
+
+	if _, err = models.GetTwoFactorByUID(uid); err != nil {
+		if models.IsErrTwoFactorNotEnrolled(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
 func loadSecurityData(ctx *context.Context) {
 	enrolled := true
 	_, err := models.GetTwoFactorByUID(ctx.User.ID)
@@ -62,13 +91,17 @@ func loadSecurityData(ctx *context.Context) {
 		}
 	}
 	ctx.Data["TwofaEnrolled"] = enrolled
-	if enrolled {
-		ctx.Data["U2FRegistrations"], err = models.GetU2FRegistrationsByUID(ctx.User.ID)
-		if err != nil {
-			ctx.ServerError("GetU2FRegistrationsByUID", err)
-			return
-		}
-		ctx.Data["RequireU2F"] = true
+
+	ctx.Data["WebAuthnCredentials"], err = models.GetWebAuthnCredentialsByUID(ctx.User.ID)
+	if err != nil {
+		ctx.ServerError("GetWebAuthnCredentialsByUID", err)
+		return
+	}
+
+	ctx.Data["TwofaRequired"], err = models.IsTwoFactorRequired(ctx.User.ID)
+	if err != nil {
+		ctx.ServerError("IsTwoFactorRequired", err)
+		return
 	}
 
 	tokens, err := models.ListAccessTokens(ctx.User.ID)
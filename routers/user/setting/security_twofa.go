@@ -67,6 +67,15 @@ func DisableTwoFactor(ctx *context.Context) {
 		return
 	}
 
+	if removable, err := isSecondFactorRemovable(ctx.User.ID, true); err != nil {
+		ctx.ServerError("SettingsTwoFactor: Failed to check whether 2FA is required", err)
+		return
+	} else if !removable {
+		ctx.Flash.Error(ctx.Tr("settings.twofa_required_remove"))
+		ctx.Redirect(setting.AppSubURL + "/user/settings/security")
+		return
+	}
+
 	if err = models.DeleteTwoFactorByID(t.ID, ctx.User.ID); err != nil {
 		if models.IsErrTwoFactorNotEnrolled(err) {
 			// There is a potential DB race here - we must have been disabled by another request in the intervening period
@@ -0,0 +1,135 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"errors"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/auth/webauthn"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	gowebauthn "github.com/duo-labs/webauthn/webauthn"
+)
+
+// WebAuthnRegister initializes the WebAuthn registration procedure
+func WebAuthnRegister(ctx *context.Context, form auth.WebAuthnRegistrationForm) {
+	if form.Name == "" {
+		ctx.Error(409)
+		return
+	}
+
+	used, err := models.IsWebAuthnCredentialNameUsed(ctx.User.ID, form.Name)
+	if err != nil {
+		ctx.ServerError("IsWebAuthnCredentialNameUsed", err)
+		return
+	}
+	if used {
+		ctx.Error(409, "Name already taken")
+		return
+	}
+
+	credentialOptions, sessionData, err := webauthn.BeginRegistration(ctx.User)
+	if err != nil {
+		ctx.ServerError("webauthn.BeginRegistration", err)
+		return
+	}
+
+	if err := ctx.Session.Set("webauthnRegistration", sessionData); err != nil {
+		ctx.ServerError("Unable to set session key for webauthnRegistration", err)
+		return
+	}
+	if err := ctx.Session.Set("webauthnName", form.Name); err != nil {
+		ctx.ServerError("Unable to set session key for webauthnName", err)
+		return
+	}
+	// Here we're just going to try to release the session early
+	if err := ctx.Session.Release(); err != nil {
+		// we'll tolerate errors here as they *should* get saved elsewhere
+		log.Error("Unable to save changes to the session: %v", err)
+	}
+
+	ctx.JSON(200, credentialOptions)
+}
+
+// WebAuthnRegisterPost receives the response of the security key
+func WebAuthnRegisterPost(ctx *context.Context) {
+	name, okName := ctx.Session.Get("webauthnName").(string)
+	sessionData, okData := ctx.Session.Get("webauthnRegistration").(*gowebauthn.SessionData)
+	if !okName || !okData || sessionData == nil {
+		ctx.ServerError("WebAuthnRegisterPost", errors.New("not in WebAuthn session"))
+		return
+	}
+	defer func() {
+		_ = ctx.Session.Delete("webauthnName")
+		_ = ctx.Session.Delete("webauthnRegistration")
+	}()
+
+	body, err := ctx.Req.Body().Bytes()
+	if err != nil {
+		ctx.ServerError("WebAuthnRegisterPost", err)
+		return
+	}
+
+	cred, err := webauthn.FinishRegistration(ctx.User, *sessionData, body)
+	if err != nil {
+		log.Info("Failed WebAuthn registration for %s: %v", ctx.User.Name, err)
+		ctx.Error(400)
+		return
+	}
+
+	// Check the name again, another credential may have been registered in the meantime
+	used, err := models.IsWebAuthnCredentialNameUsed(ctx.User.ID, name)
+	if err != nil {
+		ctx.ServerError("IsWebAuthnCredentialNameUsed", err)
+		return
+	}
+	if used {
+		ctx.Error(409, "Name already taken")
+		return
+	}
+
+	if _, err := models.CreateWebAuthnCredential(ctx.User.ID, name, cred); err != nil {
+		ctx.ServerError("CreateWebAuthnCredential", err)
+		return
+	}
+	ctx.Flash.Success(ctx.Tr("settings.webauthn_registered", name))
+	ctx.JSON(201, map[string]interface{}{
+		"redirect": setting.AppSubURL + "/user/settings/security",
+	})
+}
+
+// WebAuthnDelete deletes a security key by id
+func WebAuthnDelete(ctx *context.Context, form auth.WebAuthnDeleteForm) {
+	cred, err := models.GetWebAuthnCredentialByID(form.ID)
+	if err != nil {
+		if models.IsErrWebAuthnCredentialNotExist(err) {
+			ctx.Status(200)
+			return
+		}
+		ctx.ServerError("GetWebAuthnCredentialByID", err)
+		return
+	}
+	if cred.UserID != ctx.User.ID {
+		ctx.Status(401)
+		return
+	}
+
+	if removable, err := isSecondFactorRemovable(ctx.User.ID, false); err != nil {
+		ctx.ServerError("isSecondFactorRemovable", err)
+		return
+	} else if !removable {
+		ctx.Flash.Error(ctx.Tr("settings.twofa_required_remove"))
+	} else if _, err := models.DeleteWebAuthnCredential(cred.ID, ctx.User.ID); err != nil {
+		ctx.ServerError("DeleteWebAuthnCredential", err)
+		return
+	}
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": setting.AppSubURL + "/user/settings/security",
+	})
+}
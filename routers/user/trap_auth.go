@@ -20,7 +20,7 @@ func TrapSignIn(ctx *context.Context) {
 
 	token := ctx.GetCookie("traP_token")
 	if user := getUserFromTrapToken(token); user != nil {
-		handleSignIn(ctx, user, false)
+		handleSignInTwoFactor(ctx, user, false)
 	} else {
 		ctx.Redirect("https://q.trap.jp/pipeline?redirect=" + url.QueryEscape(setting.AppURL+"/login"))
 	}
@@ -342,6 +342,22 @@ func (diffFile *DiffFile) GetHighlightClass() string {
 	return highlight.FileNameToHighlightClass(diffFile.Name)
 }
 
+// GetPatch returns the hunks of the file as unified diff, as far as they have
+// been parsed. Sections added for display like the tail section are left out.
+func (diffFile *DiffFile) GetPatch() string {
+	var patch strings.Builder
+	for _, section := range diffFile.Sections {
+		for _, line := range section.Lines {
+			if line.Type == DiffLineSection && !strings.HasPrefix(line.Content, "@@") {
+				continue
+			}
+			patch.WriteString(line.Content)
+			patch.WriteByte('\n')
+		}
+	}
+	return patch.String()
+}
+
 // GetTailSection creates a fake DiffLineSection if the last section is not the end of the file
 func (diffFile *DiffFile) GetTailSection(gitRepo *git.Repository, leftCommitID, rightCommitID string) *DiffSection {
 	if len(diffFile.Sections) == 0 || diffFile.Type != DiffFileChange || diffFile.IsBin || diffFile.IsLFSFile {
@@ -880,6 +896,92 @@ func GetDiffRangeWithWhitespaceBehavior(repoPath, beforeCommitID, afterCommitID
 	return diff, nil
 }
 
+// ChangedFile is a file changed between two commits with the number of its changed lines
+type ChangedFile struct {
+	Name    string
+	OldName string
+	// Status is the status letter of git diff --name-status, e.g. A, D, M or R
+	Status    string
+	Additions int
+	Deletions int
+	IsBinary  bool
+}
+
+// GetChangedFiles returns all files changed between two commits, unlike a Diff the list
+// is not limited by the number of files. An empty beforeCommitID compares to the empty tree.
+func GetChangedFiles(repoPath, beforeCommitID, afterCommitID string) ([]*ChangedFile, error) {
+	if len(beforeCommitID) == 0 {
+		beforeCommitID = git.EmptyTreeSHA
+	}
+
+	stdout, err := git.NewCommand("diff", "-M", "--name-status", "-z", beforeCommitID, afterCommitID).RunInDir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Split(strings.TrimSuffix(stdout, "\x00"), "\x00")
+	files := make([]*ChangedFile, 0, len(fields)/2)
+	for i := 0; i < len(fields) && len(fields[i]) > 0; {
+		file := &ChangedFile{Status: fields[i][:1]}
+		// renames and copies are followed by the old and the new name
+		if file.Status == "R" || file.Status == "C" {
+			if i+2 >= len(fields) {
+				return nil, fmt.Errorf("Unexpected output of git diff --name-status: %q", stdout)
+			}
+			file.OldName, file.Name = fields[i+1], fields[i+2]
+			i += 3
+		} else {
+			if i+1 >= len(fields) {
+				return nil, fmt.Errorf("Unexpected output of git diff --name-status: %q", stdout)
+			}
+			file.Name = fields[i+1]
+			i += 2
+		}
+		files = append(files, file)
+	}
+
+	stdout, err = git.NewCommand("diff", "-M", "--numstat", "-z", beforeCommitID, afterCommitID).RunInDir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	fields = strings.Split(strings.TrimSuffix(stdout, "\x00"), "\x00")
+	for i, n := 0, 0; i < len(fields) && len(fields[i]) > 0; n++ {
+		stat := strings.SplitN(fields[i], "\t", 3)
+		if len(stat) != 3 || n >= len(files) {
+			return nil, fmt.Errorf("Unexpected output of git diff --numstat: %q", stdout)
+		}
+		// binary files are counted as - and -
+		files[n].IsBinary = stat[0] == "-"
+		files[n].Additions, _ = strconv.Atoi(stat[0])
+		files[n].Deletions, _ = strconv.Atoi(stat[1])
+		// the path is empty for renames, the old and the new name follow
+		if len(stat[2]) == 0 {
+			i += 3
+		} else {
+			i++
+		}
+	}
+	return files, nil
+}
+
+// GetDiffRangeForFiles builds a Diff between two commits which only contains the given
+// files, the limits of lines and line characters apply to each of them. An empty
+// beforeCommitID compares to the empty tree.
+func GetDiffRangeForFiles(repoPath, beforeCommitID, afterCommitID string, files []string, maxLines, maxLineCharacters int) (*Diff, error) {
+	if len(files) == 0 {
+		return &Diff{Files: make([]*DiffFile, 0)}, nil
+	}
+	if len(beforeCommitID) == 0 {
+		beforeCommitID = git.EmptyTreeSHA
+	}
+
+	args := append([]string{"diff", "-M", beforeCommitID, afterCommitID, "--"}, files...)
+	stdout, err := git.NewCommand(args...).RunInDirWithEnv(repoPath, append(os.Environ(), "GIT_LITERAL_PATHSPECS=1"))
+	if err != nil {
+		return nil, err
+	}
+	return ParsePatch(maxLines, maxLineCharacters, len(files), strings.NewReader(stdout))
+}
+
 // RawDiffType type of a raw diff.
 type RawDiffType string
 
@@ -156,6 +156,26 @@ func TestParsePatch(t *testing.T) {
 	println(result)
 }
 
+func TestDiffFile_GetPatch(t *testing.T) {
+	var diff = `diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1,3 +1,4 @@
+ # gitea-github-migrator
++
+ Build Status
+-Latest Release
++Latest Releases
+`
+	result, err := ParsePatch(setting.Git.MaxGitDiffLines, setting.Git.MaxGitDiffLineCharacters, setting.Git.MaxGitDiffFiles, strings.NewReader(diff))
+	assert.NoError(t, err)
+	if assert.Len(t, result.Files, 1) {
+		file := result.Files[0]
+		file.Sections = append(file.Sections, &DiffSection{Lines: []*DiffLine{{Type: DiffLineSection, Content: " "}}})
+		assert.Equal(t, diff[strings.Index(diff, "@@"):], file.GetPatch())
+	}
+}
+
 func setupDefaultDiff() *Diff {
 	return &Diff{
 		Files: []*DiffFile{
@@ -209,3 +229,38 @@ func TestGetDiffRangeWithWhitespaceBehavior(t *testing.T) {
 		}
 	}
 }
+
+func TestGetChangedFiles(t *testing.T) {
+	const (
+		repoPath = "./testdata/academic-module"
+		before   = "559c156f8e0178b71cb44355428f24001b08fc68"
+		after    = "bd7063cc7c04689c4d082183d32a604ed27a24f9"
+	)
+	diff, err := GetDiffRange(repoPath, before, after, setting.Git.MaxGitDiffLines, setting.Git.MaxGitDiffLineCharacters, 1000)
+	assert.NoError(t, err)
+	if !assert.True(t, len(diff.Files) > 1) {
+		return
+	}
+
+	// all files are listed even if a diff is limited to fewer files
+	files, err := GetChangedFiles(repoPath, before, after)
+	assert.NoError(t, err)
+	if assert.Len(t, files, len(diff.Files)) {
+		for i, file := range files {
+			assert.Equal(t, diff.Files[i].Name, file.Name)
+			assert.Equal(t, diff.Files[i].IsBin, file.IsBinary, file.Name)
+			if !file.IsBinary {
+				assert.Equal(t, diff.Files[i].Addition, file.Additions, file.Name)
+				assert.Equal(t, diff.Files[i].Deletion, file.Deletions, file.Name)
+			}
+		}
+	}
+
+	last := files[len(files)-1]
+	fileDiff, err := GetDiffRangeForFiles(repoPath, before, after, []string{last.Name}, setting.Git.MaxGitDiffLines, setting.Git.MaxGitDiffLineCharacters)
+	assert.NoError(t, err)
+	if assert.Len(t, fileDiff.Files, 1) {
+		assert.Equal(t, last.Name, fileDiff.Files[0].Name)
+		assert.Equal(t, diff.Files[len(files)-1].GetPatch(), fileDiff.Files[0].GetPatch())
+	}
+}
@@ -51,3 +51,132 @@ func ToggleAssignee(issue *models.Issue, doer *models.User, assigneeID int64) (r
 
 	return
 }
+
+// ReviewRequest add or remove a review request from a user for this PR, and make comment for it.
+func ReviewRequest(issue *models.Issue, doer *models.User, reviewer *models.User, isAdd bool) (comment *models.Comment, err error) {
+	if isAdd {
+		comment, err = models.AddReviewRequest(issue, reviewer, doer)
+	} else {
+		comment, err = models.RemoveReviewRequest(issue, reviewer, doer)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if comment != nil {
+		notification.NotifyPullReviewRequest(doer, issue, reviewer, nil, isAdd, comment)
+	}
+
+	return comment, nil
+}
+
+// TeamReviewRequest add or remove a review request from a team for this PR, and make comment for it.
+func TeamReviewRequest(issue *models.Issue, doer *models.User, reviewer *models.Team, isAdd bool) (comment *models.Comment, err error) {
+	if isAdd {
+		comment, err = models.AddTeamReviewRequest(issue, reviewer, doer)
+	} else {
+		comment, err = models.RemoveTeamReviewRequest(issue, reviewer, doer)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if comment != nil {
+		notification.NotifyPullReviewRequest(doer, issue, nil, reviewer, isAdd, comment)
+	}
+
+	return comment, nil
+}
+
+// IsValidReviewRequest checks if the doer may request a review of the pull request from the reviewer
+func IsValidReviewRequest(reviewer, doer *models.User, isAdd bool, issue *models.Issue) error {
+	if reviewer.IsOrganization() {
+		return models.ErrNotValidReviewRequest{
+			Reason: "Organization can't be added as reviewer",
+			UserID: doer.ID,
+			RepoID: issue.Repo.ID,
+		}
+	}
+	if doer.IsOrganization() {
+		return models.ErrNotValidReviewRequest{
+			Reason: "Organization can't be doer to add reviewer",
+			UserID: doer.ID,
+			RepoID: issue.Repo.ID,
+		}
+	}
+
+	permReviewer, err := models.GetUserRepoPermission(issue.Repo, reviewer)
+	if err != nil {
+		return err
+	}
+	permDoer, err := models.GetUserRepoPermission(issue.Repo, doer)
+	if err != nil {
+		return err
+	}
+
+	if isAdd {
+		if !permReviewer.CanRead(models.UnitTypePullRequests) {
+			return models.ErrNotValidReviewRequest{
+				Reason: "Reviewer can't read",
+				UserID: doer.ID,
+				RepoID: issue.Repo.ID,
+			}
+		}
+		if reviewer.ID == issue.PosterID {
+			return models.ErrNotValidReviewRequest{
+				Reason: "poster of pr can't be reviewer",
+				UserID: doer.ID,
+				RepoID: issue.Repo.ID,
+			}
+		}
+	}
+
+	// only the poster and the writers of the pull requests may change the review requests,
+	// everyone may remove a review request of themselves
+	if doer.ID == issue.PosterID || permDoer.CanWrite(models.UnitTypePullRequests) ||
+		(!isAdd && doer.ID == reviewer.ID) {
+		return nil
+	}
+
+	return models.ErrNotValidReviewRequest{
+		Reason: "Doer can't choose reviewer",
+		UserID: doer.ID,
+		RepoID: issue.Repo.ID,
+	}
+}
+
+// IsValidTeamReviewRequest checks if the doer may request a review of the pull request from the team
+func IsValidTeamReviewRequest(reviewer *models.Team, doer *models.User, isAdd bool, issue *models.Issue) error {
+	if err := issue.Repo.GetOwner(); err != nil {
+		return err
+	}
+	if !issue.Repo.Owner.IsOrganization() || reviewer.OrgID != issue.Repo.OwnerID {
+		return models.ErrNotValidReviewRequest{
+			Reason: "Team doesn't belong to the owner of the repository",
+			UserID: doer.ID,
+			RepoID: issue.Repo.ID,
+		}
+	}
+
+	if isAdd && !reviewer.HasRepository(issue.Repo.ID) && !reviewer.IncludesAllRepositories {
+		return models.ErrNotValidReviewRequest{
+			Reason: "Team has no access to the repository",
+			UserID: doer.ID,
+			RepoID: issue.Repo.ID,
+		}
+	}
+
+	permDoer, err := models.GetUserRepoPermission(issue.Repo, doer)
+	if err != nil {
+		return err
+	}
+	if doer.ID == issue.PosterID || permDoer.CanWrite(models.UnitTypePullRequests) {
+		return nil
+	}
+
+	return models.ErrNotValidReviewRequest{
+		Reason: "Doer can't choose reviewer",
+		UserID: doer.ID,
+		RepoID: issue.Repo.ID,
+	}
+}
@@ -281,6 +281,17 @@ func SendIssueAssignedMail(issue *models.Issue, doer *models.User, content strin
 	}, tos, false, "issue assigned"))
 }
 
+// SendIssueReviewRequestMail composes and sends the mail to a user whose review of a pull request was requested
+func SendIssueReviewRequestMail(issue *models.Issue, doer *models.User, content string, comment *models.Comment, tos []string) {
+	SendAsyncs(composeIssueCommentMessages(&mailCommentContext{
+		Issue:      issue,
+		Doer:       doer,
+		ActionType: models.ActionType(0),
+		Content:    content,
+		Comment:    comment,
+	}, tos, false, "review requested"))
+}
+
 // actionToTemplate returns the type and name of the action facing the user
 // (slightly different from models.ActionType) and the name of the template to use (based on availability)
 func actionToTemplate(issue *models.Issue, actionType models.ActionType,
@@ -316,6 +327,8 @@ func actionToTemplate(issue *models.Issue, actionType models.ActionType,
 			name = "code"
 		case models.CommentTypeAssignees:
 			name = "assigned"
+		case models.CommentTypeReviewRequest:
+			name = "review_request"
 		default:
 			name = "default"
 		}
@@ -36,27 +36,28 @@ func readAddress(m *models.Mirror) {
 		return
 	}
 	var err error
-	m.Address, err = remoteAddress(m.Repo.RepoPath())
+	m.Address, err = remoteAddress(m.Repo.RepoPath(), "origin")
 	if err != nil {
 		log.Error("remoteAddress: %v", err)
 	}
 }
 
-func remoteAddress(repoPath string) (string, error) {
+func remoteAddress(repoPath, remoteName string) (string, error) {
 	var cmd *git.Command
 	binVersion, err := git.BinVersion()
 	if err != nil {
 		return "", err
 	}
 	if version.Compare(binVersion, "2.7", ">=") {
-		cmd = git.NewCommand("remote", "get-url", "origin")
+		cmd = git.NewCommand("remote", "get-url", remoteName)
 	} else {
-		cmd = git.NewCommand("config", "--get", "remote.origin.url")
+		cmd = git.NewCommand("config", "--get", "remote."+remoteName+".url")
 	}
 
 	result, err := cmd.RunInDir(repoPath)
 	if err != nil {
-		if strings.HasPrefix(err.Error(), "exit status 128 - fatal: No such remote ") {
+		if strings.HasPrefix(err.Error(), "exit status 128 - fatal: No such remote ") ||
+			strings.HasPrefix(err.Error(), "exit status 2 - error: No such remote ") {
 			return "", nil
 		}
 		return "", err
@@ -70,7 +71,13 @@ func remoteAddress(repoPath string) (string, error) {
 // sanitizeOutput sanitizes output of a command, replacing occurrences of the
 // repository's remote address with a sanitized version.
 func sanitizeOutput(output, repoPath string) (string, error) {
-	remoteAddr, err := remoteAddress(repoPath)
+	return sanitizeRemoteOutput(output, repoPath, "origin")
+}
+
+// sanitizeRemoteOutput sanitizes output of a command, replacing occurrences of
+// the address of the given remote with a sanitized version.
+func sanitizeRemoteOutput(output, repoPath, remoteName string) (string, error) {
+	remoteAddr, err := remoteAddress(repoPath, remoteName)
 	if err != nil {
 		// if we're unable to load the remote address, then we're unable to
 		// sanitize.
@@ -296,7 +303,7 @@ func Password(m *models.Mirror) string {
 	return password
 }
 
-// Update checks and updates mirror repositories.
+// Update checks and updates mirror repositories and push mirrors.
 func Update(ctx context.Context) {
 	log.Trace("Doing: Update")
 	if err := models.MirrorsIterate(func(idx int, bean interface{}) error {
@@ -315,6 +322,26 @@ func Update(ctx context.Context) {
 	}); err != nil {
 		log.Error("Update: %v", err)
 	}
+
+	if err := models.PushMirrorsIterate(func(idx int, bean interface{}) error {
+		m := bean.(*models.PushMirror)
+		if m.Repo == nil {
+			log.Error("Disconnected push mirror found: %d", m.ID)
+			return nil
+		}
+		if !m.IsDue() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Aborted due to shutdown")
+		default:
+			pushMirrorQueue.Add(m.ID)
+			return nil
+		}
+	}); err != nil {
+		log.Error("Update: %v", err)
+	}
 }
 
 // SyncMirrors checks and syncs mirrors.
@@ -325,9 +352,12 @@ func SyncMirrors(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			mirrorQueue.Close()
+			pushMirrorQueue.Close()
 			return
 		case repoID := <-mirrorQueue.Queue():
 			syncMirror(repoID)
+		case mirrorID := <-pushMirrorQueue.Queue():
+			syncPushMirror(mirrorID)
 		}
 	}
 }
@@ -0,0 +1,243 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mirror
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/sync"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/unknwon/com"
+)
+
+// pushMirrorQueue holds an UniqueQueue object of the push mirrors
+var pushMirrorQueue = sync.NewUniqueQueue(setting.Repository.MirrorQueueLength)
+
+// AddPushMirror creates a new push mirror for the repository and registers
+// its remote, including any credentials, in the git configuration.
+func AddPushMirror(repo *models.Repository, addr string, interval time.Duration, syncOnPush bool) (*models.PushMirror, error) {
+	remoteSuffix, err := generate.GetRandomString(10)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &models.PushMirror{
+		RepoID:     repo.ID,
+		Repo:       repo,
+		RemoteName: "remote_mirror_" + remoteSuffix,
+		SyncOnPush: syncOnPush,
+		Interval:   interval,
+	}
+
+	if err := addPushMirrorRemote(m, addr); err != nil {
+		return nil, fmt.Errorf("addPushMirrorRemote: %v", err)
+	}
+
+	if err := models.InsertPushMirror(m); err != nil {
+		if errRemove := removePushMirrorRemote(m); errRemove != nil {
+			log.Error("removePushMirrorRemote: %v", errRemove)
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+// RemovePushMirror removes the push mirror and its remote from the repository.
+func RemovePushMirror(m *models.PushMirror) error {
+	if err := removePushMirrorRemote(m); err != nil {
+		return fmt.Errorf("removePushMirrorRemote: %v", err)
+	}
+	return models.DeletePushMirrorByID(m.ID)
+}
+
+func addPushMirrorRemote(m *models.PushMirror, addr string) error {
+	if err := addPushMirrorRemoteToPath(m, addr, m.Repo.RepoPath()); err != nil {
+		return err
+	}
+
+	if m.Repo.HasWiki() {
+		if err := addPushMirrorRemoteToPath(m, wikiRemoteAddress(addr), m.Repo.WikiPath()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addPushMirrorRemoteToPath adds the remote of the push mirror to the repository at path
+func addPushMirrorRemoteToPath(m *models.PushMirror, addr, path string) error {
+	if _, err := git.NewCommand("remote", "add", "--mirror=push", m.RemoteName, addr).RunInDir(path); err != nil {
+		return err
+	}
+	// A push mirror must never be fetched by `git remote update`
+	if _, err := git.NewCommand("config", "--add", "remote."+m.RemoteName+".skipDefaultUpdate", "true").RunInDir(path); err != nil {
+		return err
+	}
+	return nil
+}
+
+// wikiRemoteAddress returns the address of the wiki of the repository at addr
+func wikiRemoteAddress(addr string) string {
+	return strings.TrimSuffix(addr, ".git") + ".wiki.git"
+}
+
+// configuredRemoteURL returns the url of the remote in the git configuration of the
+// repository at path, or an empty string if there is no such remote
+func configuredRemoteURL(path, remoteName string) (string, error) {
+	stdout, err := git.NewCommand("config", "--get", "remote."+remoteName+".url").RunInDir(path)
+	if err != nil {
+		// git config exits with 1 if the key is not set
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+func removePushMirrorRemote(m *models.PushMirror) error {
+	removeRemote := func(path string) error {
+		_, err := git.NewCommand("remote", "rm", m.RemoteName).RunInDir(path)
+		if err != nil && !strings.HasPrefix(err.Error(), "exit status 128 - fatal: No such remote") &&
+			!strings.HasPrefix(err.Error(), "exit status 2 - error: No such remote") {
+			return err
+		}
+		return nil
+	}
+
+	if err := removeRemote(m.Repo.RepoPath()); err != nil {
+		return err
+	}
+
+	if m.Repo.HasWiki() {
+		if err := removeRemote(m.Repo.WikiPath()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PushMirrorAddress returns the push mirror address from Git repository config without credentials.
+func PushMirrorAddress(m *models.PushMirror) string {
+	addr, err := remoteAddress(m.Repo.RepoPath(), m.RemoteName)
+	if err != nil {
+		log.Error("remoteAddress: %v", err)
+		return ""
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		// this shouldn't happen but just return it sanitised
+		return util.SanitizeURLCredentials(addr, false)
+	}
+	u.User = nil
+	return u.String()
+}
+
+// runPushSync pushes all refs of the repository, and of its wiki if any, to the push mirror.
+func runPushSync(m *models.PushMirror) error {
+	timeout := time.Duration(setting.Git.Timeout.Mirror) * time.Second
+
+	performPush := func(path string) error {
+		stdoutBuilder := strings.Builder{}
+		stderrBuilder := strings.Builder{}
+		if err := git.NewCommand("push", "--mirror", m.RemoteName).
+			SetDescription(fmt.Sprintf("PushMirror.runPushSync: %s", m.Repo.FullName())).
+			RunInDirTimeoutPipeline(timeout, path, &stdoutBuilder, &stderrBuilder); err != nil {
+			// sanitize the output, since it may contain the remote address, which may
+			// contain a password
+			stderrMessage, sanitizeErr := sanitizeRemoteOutput(stderrBuilder.String(), path, m.RemoteName)
+			if sanitizeErr != nil {
+				log.Error("sanitizeRemoteOutput failed on stderr: %v", sanitizeErr)
+				return err
+			}
+			return fmt.Errorf("%v - %s", err, strings.TrimSpace(stderrMessage))
+		}
+		return nil
+	}
+
+	if err := performPush(m.Repo.RepoPath()); err != nil {
+		return err
+	}
+
+	if m.Repo.HasWiki() {
+		// the wiki may have been created after the push mirror was added
+		wikiRemote, err := configuredRemoteURL(m.Repo.WikiPath(), m.RemoteName)
+		if err != nil {
+			return err
+		}
+		if len(wikiRemote) == 0 {
+			addr, err := configuredRemoteURL(m.Repo.RepoPath(), m.RemoteName)
+			if err != nil {
+				return err
+			} else if len(addr) == 0 {
+				return fmt.Errorf("remote %s of the push mirror is missing", m.RemoteName)
+			}
+			if err := addPushMirrorRemoteToPath(m, wikiRemoteAddress(addr), m.Repo.WikiPath()); err != nil {
+				return err
+			}
+		}
+		if err := performPush(m.Repo.WikiPath()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func syncPushMirror(mirrorID string) {
+	log.Trace("SyncPushMirrors [mirror_id: %v]", mirrorID)
+	pushMirrorQueue.Remove(mirrorID)
+
+	m, err := models.GetPushMirrorByID(com.StrTo(mirrorID).MustInt64())
+	if err != nil {
+		log.Error("GetPushMirrorByID [%s]: %v", mirrorID, err)
+		return
+	}
+	if m.Repo == nil {
+		log.Error("Disconnected push mirror found: %d", m.ID)
+		return
+	}
+
+	m.LastError = ""
+	if err := runPushSync(m); err != nil {
+		log.Error("SyncPushMirror [mirror: %d][repo: %-v]: %v", m.ID, m.Repo, err)
+		m.LastError = err.Error()
+	}
+	m.LastUpdateUnix = timeutil.TimeStampNow()
+
+	if err := models.UpdatePushMirror(m); err != nil {
+		log.Error("UpdatePushMirror [%d]: %v", m.ID, err)
+	}
+}
+
+// StartToPushMirror adds the push mirror to the push mirror queue
+func StartToPushMirror(mirrorID int64) {
+	go pushMirrorQueue.Add(mirrorID)
+}
+
+// SyncPushMirrorsOnPush adds all push mirrors of the repository which
+// are configured to sync on push to the push mirror queue
+func SyncPushMirrorsOnPush(repoID int64) {
+	mirrors, err := models.GetPushMirrorsSyncedOnPush(repoID)
+	if err != nil {
+		log.Error("GetPushMirrorsSyncedOnPush [%d]: %v", repoID, err)
+		return
+	}
+	for _, m := range mirrors {
+		StartToPushMirror(m.ID)
+	}
+}
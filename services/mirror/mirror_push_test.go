@@ -0,0 +1,49 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mirror
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunPushSync_WikiCreatedLater(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	assert.True(t, repo.HasWiki())
+
+	tmpDir, err := ioutil.TempDir("", "push-mirror")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	addr := filepath.Join(tmpDir, "target.git")
+	for _, path := range []string{addr, wikiRemoteAddress(addr)} {
+		assert.NoError(t, git.InitRepository(path, true))
+	}
+
+	// the remote was added before the wiki existed
+	m := &models.PushMirror{RepoID: repo.ID, Repo: repo, RemoteName: "remote_mirror_test"}
+	assert.NoError(t, addPushMirrorRemoteToPath(m, addr, repo.RepoPath()))
+	defer func() {
+		assert.NoError(t, removePushMirrorRemote(m))
+	}()
+	wikiRemote, err := configuredRemoteURL(repo.WikiPath(), m.RemoteName)
+	assert.NoError(t, err)
+	assert.Empty(t, wikiRemote)
+
+	assert.NoError(t, runPushSync(m))
+
+	wikiRemote, err = configuredRemoteURL(repo.WikiPath(), m.RemoteName)
+	assert.NoError(t, err)
+	assert.Equal(t, wikiRemoteAddress(addr), wikiRemote)
+	assert.True(t, git.IsBranchExist(wikiRemoteAddress(addr), "master"))
+}
@@ -0,0 +1,161 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	issue_service "code.gitea.io/gitea/services/issue"
+)
+
+// maxCodeOwnersFileSize is the maximum size of a CODEOWNERS file which is read
+const maxCodeOwnersFileSize = 128 * 1024
+
+// getCodeOwnersContent returns the content of the first CODEOWNERS file found on the commit
+func getCodeOwnersContent(commit *git.Commit) (string, error) {
+	for _, path := range models.CodeOwnersFiles {
+		blob, err := commit.GetBlobByPath(path)
+		if err != nil {
+			if git.IsErrNotExist(err) {
+				continue
+			}
+			return "", err
+		}
+
+		dataRc, err := blob.DataAsync()
+		if err != nil {
+			return "", err
+		}
+		defer dataRc.Close()
+
+		content, err := ioutil.ReadAll(&io.LimitedReader{R: dataRc, N: maxCodeOwnersFileSize})
+		if err != nil {
+			return "", err
+		}
+		return string(content), nil
+	}
+	return "", nil
+}
+
+// UpdateCodeOwners updates the code owners of the paths changed by the pull request using the CODEOWNERS
+// file of the base branch, and requests reviews from the owners who have not been requested yet
+func UpdateCodeOwners(pr *models.PullRequest) error {
+	if err := pr.LoadIssue(); err != nil {
+		return fmt.Errorf("LoadIssue: %v", err)
+	}
+	if err := pr.LoadBaseRepo(); err != nil {
+		return fmt.Errorf("LoadBaseRepo: %v", err)
+	}
+	issue := pr.Issue
+	issue.Repo = pr.BaseRepo
+	if err := issue.LoadPoster(); err != nil {
+		return fmt.Errorf("LoadPoster: %v", err)
+	}
+
+	gitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	if err != nil {
+		return fmt.Errorf("OpenRepository: %v", err)
+	}
+	defer gitRepo.Close()
+
+	commit, err := gitRepo.GetBranchCommit(pr.BaseBranch)
+	if err != nil {
+		return fmt.Errorf("GetBranchCommit[%s]: %v", pr.BaseBranch, err)
+	}
+	content, err := getCodeOwnersContent(commit)
+	if err != nil {
+		return fmt.Errorf("getCodeOwnersContent: %v", err)
+	}
+
+	var owners []*models.PullRequestCodeOwner
+	if len(content) > 0 {
+		rules, err := models.ParseCodeOwners(pr.BaseRepo, content)
+		if err != nil {
+			return fmt.Errorf("ParseCodeOwners: %v", err)
+		}
+		files, err := gitRepo.GetFilesChangedSinceMergeBase(commit.ID.String(), pr.GetGitRefName())
+		if err != nil {
+			return fmt.Errorf("GetFilesChangedSinceMergeBase: %v", err)
+		}
+		owners = models.GetCodeOwnersOfFiles(rules, files)
+	}
+
+	previousOwners, err := models.GetPullRequestCodeOwners(issue.ID)
+	if err != nil {
+		return fmt.Errorf("GetPullRequestCodeOwners: %v", err)
+	}
+	if err = models.UpdatePullRequestCodeOwners(issue.ID, owners); err != nil {
+		return fmt.Errorf("UpdatePullRequestCodeOwners: %v", err)
+	}
+
+	requestedUsers := make(map[int64]bool)
+	requestedTeams := make(map[int64]bool)
+	for _, owner := range previousOwners {
+		for _, id := range owner.UserIDs {
+			requestedUsers[id] = true
+		}
+		for _, id := range owner.TeamIDs {
+			requestedTeams[id] = true
+		}
+	}
+
+	// the review requests are made in the name of the poster
+	doer := issue.Poster
+	for _, owner := range owners {
+		for _, id := range owner.UserIDs {
+			if requestedUsers[id] {
+				continue
+			}
+			requestedUsers[id] = true
+
+			reviewer, err := models.GetUserByID(id)
+			if models.IsErrUserNotExist(err) {
+				continue
+			} else if err != nil {
+				return fmt.Errorf("GetUserByID[%d]: %v", id, err)
+			}
+			if err = issue_service.IsValidReviewRequest(reviewer, doer, true, issue); err != nil {
+				if models.IsErrNotValidReviewRequest(err) {
+					log.Trace("Skipping code owner %s of pull request %d: %v", reviewer.Name, pr.ID, err)
+					continue
+				}
+				return err
+			}
+			if _, err = issue_service.ReviewRequest(issue, doer, reviewer, true); err != nil {
+				return fmt.Errorf("ReviewRequest: %v", err)
+			}
+		}
+		for _, id := range owner.TeamIDs {
+			if requestedTeams[id] {
+				continue
+			}
+			requestedTeams[id] = true
+
+			team, err := models.GetTeamByID(id)
+			if models.IsErrTeamNotExist(err) {
+				continue
+			} else if err != nil {
+				return fmt.Errorf("GetTeamByID[%d]: %v", id, err)
+			}
+			if err = issue_service.IsValidTeamReviewRequest(team, doer, true, issue); err != nil {
+				if models.IsErrNotValidReviewRequest(err) {
+					log.Trace("Skipping code owner team %s of pull request %d: %v", team.Name, pr.ID, err)
+					continue
+				}
+				return err
+			}
+			if _, err = issue_service.TeamReviewRequest(issue, doer, team, true); err != nil {
+				return fmt.Errorf("TeamReviewRequest: %v", err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -62,6 +62,12 @@ func Merge(pr *models.PullRequest, doer *models.User, baseGitRepo *git.Repositor
 	if !prConfig.IsMergeStyleAllowed(mergeStyle) {
 		return models.ErrInvalidMergeStyle{ID: pr.BaseRepo.ID, Style: mergeStyle}
 	}
+	if err = pr.LoadProtectedBranch(); err != nil {
+		log.Error("LoadProtectedBranch: %v", err)
+		return fmt.Errorf("LoadProtectedBranch: %v", err)
+	} else if pr.ProtectedBranch != nil && !pr.ProtectedBranch.IsMergeStyleAllowed(mergeStyle) {
+		return models.ErrInvalidMergeStyle{ID: pr.BaseRepo.ID, Style: mergeStyle}
+	}
 
 	defer func() {
 		go AddTestPullRequestTask(doer, pr.BaseRepo.ID, pr.BaseBranch, false)
@@ -44,6 +44,10 @@ func NewPullRequest(repo *models.Repository, pull *models.Issue, labelIDs []int6
 
 	notification.NotifyNewPullRequest(pr)
 
+	if err := UpdateCodeOwners(pr); err != nil {
+		log.Error("UpdateCodeOwners[%d]: %v", pr.ID, err)
+	}
+
 	return nil
 }
 
@@ -132,6 +136,10 @@ func ChangeTargetBranch(pr *models.PullRequest, doer *models.User, targetBranch
 		return fmt.Errorf("CreateChangeTargetBranchComment: %v", err)
 	}
 
+	if err := UpdateCodeOwners(pr); err != nil {
+		log.Error("UpdateCodeOwners[%d]: %v", pr.ID, err)
+	}
+
 	return nil
 }
 
@@ -200,6 +208,14 @@ func AddTestPullRequestTask(doer *models.User, repoID int64, branch string, isSy
 
 		addHeadRepoTasks(prs)
 
+		if isSync {
+			for _, pr := range prs {
+				if err := UpdateCodeOwners(pr); err != nil {
+					log.Error("UpdateCodeOwners[%d]: %v", pr.ID, err)
+				}
+			}
+		}
+
 		log.Trace("AddTestPullRequestTask [base_repo_id: %d, base_branch: %s]: finding pull requests", repoID, branch)
 		prs, err = models.GetUnmergedPullRequestsByBaseInfo(repoID, branch)
 		if err != nil {
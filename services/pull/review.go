@@ -94,7 +94,7 @@ func CreateCodeComment(doer *models.User, issue *models.Issue, line int64, conte
 
 	if !isReview && !existsReview {
 		// Submit the review we've just created so the comment shows up in the issue view
-		if _, _, err = SubmitReview(doer, issue, models.ReviewTypeComment, ""); err != nil {
+		if _, _, err = SubmitReview(doer, issue, models.ReviewTypeComment, "", ""); err != nil {
 			return nil, err
 		}
 	}
@@ -158,18 +158,43 @@ func createCodeComment(doer *models.User, repo *models.Repository, issue *models
 	})
 }
 
-// SubmitReview creates a review out of the existing pending review or creates a new one if no pending review exist
-func SubmitReview(doer *models.User, issue *models.Issue, reviewType models.ReviewType, content string) (*models.Review, *models.Comment, error) {
-	review, comm, err := models.SubmitReview(doer, issue, reviewType, content)
+// SubmitReview creates a review out of the existing pending review or creates a new one if no pending review exist.
+// If commitID is empty the current head commit of the pull request is recorded.
+func SubmitReview(doer *models.User, issue *models.Issue, reviewType models.ReviewType, content, commitID string) (*models.Review, *models.Comment, error) {
+	pr, err := issue.GetPullRequest()
 	if err != nil {
 		return nil, nil, err
 	}
 
-	pr, err := issue.GetPullRequest()
+	if len(commitID) == 0 {
+		if commitID, err = getHeadCommitID(pr); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	review, comm, err := models.SubmitReview(doer, issue, reviewType, content, commitID)
 	if err != nil {
 		return nil, nil, err
 	}
+
 	notification.NotifyPullRequestReview(pr, review, comm)
 
 	return review, comm, nil
 }
+
+func getHeadCommitID(pr *models.PullRequest) (string, error) {
+	if err := pr.GetBaseRepo(); err != nil {
+		return "", fmt.Errorf("GetBaseRepo: %v", err)
+	}
+	gitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	if err != nil {
+		return "", fmt.Errorf("OpenRepository: %v", err)
+	}
+	defer gitRepo.Close()
+
+	headCommitID, err := gitRepo.GetRefCommitID(pr.GetGitRefName())
+	if err != nil {
+		return "", fmt.Errorf("GetRefCommitID[%s]: %v", pr.GetGitRefName(), err)
+	}
+	return headCommitID, nil
+}
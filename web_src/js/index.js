@@ -458,6 +458,7 @@ function initCommentForm() {
   initListSubmits('select-label', 'labels');
   initListSubmits('select-assignees', 'assignees');
   initListSubmits('select-assignees-modify', 'assignees');
+  initListSubmits('select-reviewers-modify', 'reviewers');
 
   function selectItem(select_id, input_id) {
     const $menu = $(`${select_id} .menu`);
@@ -480,6 +481,7 @@ function initCommentForm() {
       }
       switch (input_id) {
         case '#milestone_id':
+        case '#project_id':
           $list.find('.selected').html(`<a class="item" href=${$(this).data('href')}>${
             htmlEncode($(this).text())}</a>`);
           break;
@@ -513,6 +515,7 @@ function initCommentForm() {
 
   // Milestone and assignee
   selectItem('.select-milestone', '#milestone_id');
+  selectItem('.select-project', '#project_id');
   selectItem('.select-assignee', '#assignee_id');
 }
 
@@ -630,6 +633,79 @@ function initIssueComments() {
   });
 }
 
+function initRepoProject() {
+  if ($('.repository.project.view').length === 0) {
+    return;
+  }
+
+  $('.edit-project-column-button').click(function () {
+    const $form = $('.edit-project-column.form');
+    $form.attr('action', $(this).data('url'));
+    $form.find('.project-column-title-input').val($(this).data('title'));
+    $form.find('.project-column-sorting-input').val($(this).data('sorting'));
+    $('.edit-project-column.modal').modal({
+      onApprove() {
+        $form.submit();
+      }
+    }).modal('show');
+    return false;
+  });
+
+  if (!$('.project-board').data('sortable')) {
+    return;
+  }
+
+  function updateCardCount($column) {
+    $column.find('.board-card-cnt').text($column.find('.board-card').length);
+  }
+
+  let $dragged = null;
+  $('.board-card').on('dragstart', function (e) {
+    $dragged = $(this);
+    e.originalEvent.dataTransfer.effectAllowed = 'move';
+    // Firefox only starts dragging when some data is set
+    e.originalEvent.dataTransfer.setData('text/plain', $dragged.data('issue'));
+    $dragged.addClass('dragging');
+  }).on('dragend', function () {
+    $(this).removeClass('dragging');
+    $('.board-column').removeClass('drag-over');
+    $dragged = null;
+  });
+
+  $('.board-column').on('dragover', function (e) {
+    if ($dragged === null) {
+      return;
+    }
+    e.preventDefault();
+    $(this).addClass('drag-over');
+  }).on('dragleave', function (e) {
+    if (!$.contains(this, e.relatedTarget)) {
+      $(this).removeClass('drag-over');
+    }
+  }).on('drop', function (e) {
+    e.preventDefault();
+    const $column = $(this);
+    $column.removeClass('drag-over');
+    if ($dragged === null) {
+      return;
+    }
+
+    const $card = $dragged;
+    const $source = $card.closest('.board-column');
+    if ($source.is($column)) {
+      return;
+    }
+    $.post($column.data('url'), {
+      _csrf: csrf,
+      issue: $card.data('issue')
+    }).done(() => {
+      $column.find('.board').append($card);
+      updateCardCount($source);
+      updateCardCount($column);
+    });
+  });
+}
+
 function initRepository() {
   if ($('.repository').length === 0) {
     return;
@@ -692,7 +768,7 @@ function initRepository() {
   }
 
   // Labels
-  if ($('.repository.labels').length > 0) {
+  if ($('.repository.labels').length > 0 || $('.organization.settings.labels').length > 0) {
     // Create label
     const $newLabelPanel = $('.new-label.segment');
     $('.new-label.button').click(() => {
@@ -1124,9 +1200,11 @@ function initRepository() {
 function initMigration() {
   const toggleMigrations = function () {
     const authUserName = $('#auth_username').val();
-    const cloneAddr = $('#clone_addr').val();
-    if (!$('#mirror').is(':checked') && (authUserName && authUserName.length > 0)
-        && (cloneAddr !== undefined && (cloneAddr.startsWith('https://github.com') || cloneAddr.startsWith('http://github.com')))) {
+    const cloneAddr = $('#clone_addr').val() || '';
+    const service = $('#service').val();
+    const isGithub = service === '2' || (service === '0' && /^https?:\/\/github\.com/.test(cloneAddr));
+    const isGitlab = service === '4' || (service === '0' && /^https?:\/\/gitlab\.com/.test(cloneAddr));
+    if (!$('#mirror').is(':checked') && ((isGithub && authUserName && authUserName.length > 0) || isGitlab)) {
       $('#migrate_items').show();
     } else {
       $('#migrate_items').hide();
@@ -1138,6 +1216,7 @@ function initMigration() {
   $('#clone_addr').on('input', toggleMigrations);
   $('#auth_username').on('input', toggleMigrations);
   $('#mirror').on('change', toggleMigrations);
+  $('#service').on('change', toggleMigrations);
 }
 
 function initPullRequestReview() {
@@ -2151,128 +2230,174 @@ function initCodeView() {
   $('.ui.blob-excerpt').on('click', (e) => { insertBlobExcerpt(e); });
 }
 
-function initU2FAuth() {
-  if ($('#wait-for-key').length === 0) {
+function decodeURLEncodedBase64(value) {
+  return Uint8Array.from(atob(value.replace(/-/g, '+').replace(/_/g, '/')), (c) => c.charCodeAt(0));
+}
+
+function encodeURLEncodedBase64(value) {
+  return btoa(String.fromCharCode(...new Uint8Array(value)))
+    .replace(/\+/g, '-')
+    .replace(/\//g, '_')
+    .replace(/=/g, '');
+}
+
+function webAuthnError(errorType) {
+  const $errors = $('#webauthn-error [data-webauthn-error-msg]');
+  $errors.addClass('hide');
+  $errors.filter(`[data-webauthn-error-msg="${errorType}"]`).removeClass('hide');
+  $('#webauthn-error').modal('show');
+}
+
+function detectWebAuthnSupport() {
+  if (!window.isSecureContext) {
+    webAuthnError('insecure');
+    return false;
+  }
+  if (typeof window.PublicKeyCredential !== 'function') {
+    webAuthnError('browser');
+    return false;
+  }
+  return true;
+}
+
+function webAuthnErrorType(err) {
+  switch (err && err.name) {
+    case 'NotAllowedError':
+    case 'AbortError':
+      return 'timeout';
+    case 'InvalidStateError':
+      return 'duplicated';
+    case 'SecurityError':
+      return 'insecure';
+    case 'NotSupportedError':
+      return 'browser';
+    default:
+      return 'unknown';
+  }
+}
+
+function initWebAuthnAuth() {
+  if ($('.user.signin.webauthn-prompt').length === 0) {
     return;
   }
-  u2fApi.ensureSupport()
-    .then(() => {
-      $.getJSON(`${suburl}/user/u2f/challenge`).success((req) => {
-        u2fApi.sign(req.appId, req.challenge, req.registeredKeys, 30)
-          .then(u2fSigned)
-          .catch((err) => {
-            if (err === undefined) {
-              u2fError(1);
-              return;
-            }
-            u2fError(err.metaData.code);
-          });
-      });
-    }).catch(() => {
-      // Fallback in case browser do not support U2F
-      window.location.href = `${suburl}/user/two_factor`;
+  if (typeof window.PublicKeyCredential !== 'function') {
+    // Fallback in case the browser does not support WebAuthn
+    window.location.href = `${suburl}/user/two_factor`;
+    return;
+  }
+  $('#webauthn-error').modal({ allowMultiple: false });
+
+  $.getJSON(`${suburl}/user/webauthn/assertion`).done((makeAssertionOptions) => {
+    makeAssertionOptions.publicKey.challenge = decodeURLEncodedBase64(makeAssertionOptions.publicKey.challenge);
+    for (const cred of makeAssertionOptions.publicKey.allowCredentials || []) {
+      cred.id = decodeURLEncodedBase64(cred.id);
+    }
+    navigator.credentials.get({
+      publicKey: makeAssertionOptions.publicKey
+    }).then(webAuthnSigned).catch((err) => {
+      webAuthnError(webAuthnErrorType(err));
     });
+  }).fail(() => {
+    webAuthnError('unknown');
+  });
 }
-function u2fSigned(resp) {
+
+function webAuthnSigned(assertion) {
+  // extensions are left out, the server does not need their results
+  const response = {
+    id: assertion.id,
+    rawId: encodeURLEncodedBase64(assertion.rawId),
+    type: assertion.type,
+    response: {
+      authenticatorData: encodeURLEncodedBase64(assertion.response.authenticatorData),
+      clientDataJSON: encodeURLEncodedBase64(assertion.response.clientDataJSON),
+      signature: encodeURLEncodedBase64(assertion.response.signature),
+      userHandle: assertion.response.userHandle ? encodeURLEncodedBase64(assertion.response.userHandle) : '',
+    },
+  };
   $.ajax({
-    url: `${suburl}/user/u2f/sign`,
+    url: `${suburl}/user/webauthn/assertion`,
     type: 'POST',
     headers: { 'X-Csrf-Token': csrf },
-    data: JSON.stringify(resp),
+    data: JSON.stringify(response),
     contentType: 'application/json; charset=utf-8',
   }).done((res) => {
     window.location.replace(res);
   }).fail(() => {
-    u2fError(1);
+    webAuthnError('unknown');
   });
 }
 
-function u2fRegistered(resp) {
-  if (checkError(resp)) {
-    return;
-  }
+function webAuthnRegistered(credential) {
+  const response = {
+    id: credential.id,
+    rawId: encodeURLEncodedBase64(credential.rawId),
+    type: credential.type,
+    response: {
+      attestationObject: encodeURLEncodedBase64(credential.response.attestationObject),
+      clientDataJSON: encodeURLEncodedBase64(credential.response.clientDataJSON),
+    },
+  };
   $.ajax({
-    url: `${suburl}/user/settings/security/u2f/register`,
+    url: `${suburl}/user/settings/security/webauthn/register`,
     type: 'POST',
     headers: { 'X-Csrf-Token': csrf },
-    data: JSON.stringify(resp),
+    data: JSON.stringify(response),
     contentType: 'application/json; charset=utf-8',
-    success() {
-      reload();
-    },
-    fail() {
-      u2fError(1);
-    }
+  }).done((res) => {
+    window.location.href = res.redirect;
+  }).fail((xhr) => {
+    $('#register-device').modal('hide');
+    webAuthnError(xhr.status === 409 ? 'duplicated' : 'unable-to-process');
   });
 }
 
-function checkError(resp) {
-  if (!('errorCode' in resp)) {
-    return false;
-  }
-  if (resp.errorCode === 0) {
-    return false;
+function initWebAuthnRegister() {
+  if ($('#register-webauthn').length === 0) {
+    return;
   }
-  u2fError(resp.errorCode);
-  return true;
-}
-
-
-function u2fError(errorType) {
-  const u2fErrors = {
-    browser: $('#unsupported-browser'),
-    1: $('#u2f-error-1'),
-    2: $('#u2f-error-2'),
-    3: $('#u2f-error-3'),
-    4: $('#u2f-error-4'),
-    5: $('.u2f-error-5')
-  };
-  u2fErrors[errorType].removeClass('hide');
-
-  Object.keys(u2fErrors).forEach((type) => {
-    if (type !== errorType) {
-      u2fErrors[type].addClass('hide');
-    }
-  });
-  $('#u2f-error').modal('show');
-}
-
-function initU2FRegister() {
   $('#register-device').modal({ allowMultiple: false });
-  $('#u2f-error').modal({ allowMultiple: false });
-  $('#register-security-key').on('click', (e) => {
+  $('#webauthn-error').modal({ allowMultiple: false });
+  $('#register-webauthn').on('click', (e) => {
     e.preventDefault();
-    u2fApi.ensureSupport()
-      .then(u2fRegisterRequest)
-      .catch(() => {
-        u2fError('browser');
-      });
+    if (!detectWebAuthnSupport()) {
+      return;
+    }
+    webAuthnRegisterRequest();
   });
 }
 
-function u2fRegisterRequest() {
-  $.post(`${suburl}/user/settings/security/u2f/request_register`, {
+function webAuthnRegisterRequest() {
+  const $nickname = $('#nickname');
+  if ($nickname.val() === '') {
+    $nickname.closest('div.field').addClass('error');
+    webAuthnError('empty');
+    return;
+  }
+  $.post(`${suburl}/user/settings/security/webauthn/request_register`, {
     _csrf: csrf,
-    name: $('#nickname').val()
-  }).success((req) => {
-    $('#nickname').closest('div.field').removeClass('error');
+    name: $nickname.val()
+  }).done((makeCredentialOptions) => {
+    $nickname.closest('div.field').removeClass('error');
     $('#register-device').modal('show');
-    if (req.registeredKeys === null) {
-      req.registeredKeys = [];
-    }
-    u2fApi.register(req.appId, req.registerRequests, req.registeredKeys, 30)
-      .then(u2fRegistered)
-      .catch((reason) => {
-        if (reason === undefined) {
-          u2fError(1);
-          return;
-        }
-        u2fError(reason.metaData.code);
-      });
+
+    makeCredentialOptions.publicKey.challenge = decodeURLEncodedBase64(makeCredentialOptions.publicKey.challenge);
+    makeCredentialOptions.publicKey.user.id = decodeURLEncodedBase64(makeCredentialOptions.publicKey.user.id);
+    for (const cred of makeCredentialOptions.publicKey.excludeCredentials || []) {
+      cred.id = decodeURLEncodedBase64(cred.id);
+    }
+    navigator.credentials.create({
+      publicKey: makeCredentialOptions.publicKey
+    }).then(webAuthnRegistered).catch((err) => {
+      $('#register-device').modal('hide');
+      webAuthnError(webAuthnErrorType(err));
+    });
   }).fail((xhr) => {
     if (xhr.status === 409) {
-      $('#nickname').closest('div.field').addClass('error');
+      $nickname.closest('div.field').addClass('error');
+      return;
     }
+    webAuthnError('unknown');
   });
 }
 
@@ -2558,6 +2683,7 @@ $(document).ready(() => {
   initCommentForm();
   initInstall();
   initRepository();
+  initRepoProject();
   initMigration();
   initWikiForm();
   initEditForm();
@@ -2572,8 +2698,8 @@ $(document).ready(() => {
   initCtrlEnterSubmit();
   initNavbarContentToggle();
   initTopicbar();
-  initU2FAuth();
-  initU2FRegister();
+  initWebAuthnAuth();
+  initWebAuthnRegister();
   initIssueList();
   initWipTitle();
   initPullRequestReview();